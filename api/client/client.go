@@ -11,6 +11,7 @@ func init() {
 	context.RegisterCustomKey(transactionHeaderKey, context.CustomHeaderKey)
 	context.RegisterCustomKey(instanceIDHeaderKey, context.CustomHeaderKey)
 	context.RegisterCustomKey(localDevicesHeaderKey, context.CustomHeaderKey)
+	context.RegisterCustomKey(traceParentHeaderKey, context.CustomHeaderKey)
 }
 
 // Client is the libStorage API client.
@@ -2,10 +2,16 @@ package client
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
 )
 
@@ -59,6 +65,7 @@ func (c *client) Services(
 	if ctx.Value(ctxInstanceForSvc) != nil {
 		url = "/services?instance"
 	}
+	url = withFilterQuery(ctx, url)
 
 	if _, err := c.httpGet(ctx, url, &reply); err != nil {
 		return nil, err
@@ -82,12 +89,24 @@ func (c *client) ServiceInspect(
 	return reply, nil
 }
 
+func (c *client) ServiceProfiles(
+	ctx types.Context, name string) (types.VolumeProfileMap, error) {
+
+	reply := types.VolumeProfileMap{}
+	url := fmt.Sprintf("/services/%s/profiles", name)
+	if _, err := c.httpGet(ctx, url, &reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
 func (c *client) Volumes(
 	ctx types.Context,
 	attachments bool) (types.ServiceVolumeMap, error) {
 
 	reply := types.ServiceVolumeMap{}
-	url := fmt.Sprintf("/volumes?attachments=%v", attachments)
+	url := fmt.Sprintf(
+		"/volumes?attachments=%v%s", attachments, filterQuery(ctx))
 	if _, err := c.httpGet(ctx, url, &reply); err != nil {
 		return nil, err
 	}
@@ -100,13 +119,47 @@ func (c *client) VolumesByService(
 	attachments bool) (types.VolumeMap, error) {
 
 	reply := types.VolumeMap{}
-	url := fmt.Sprintf("/volumes/%s?attachments=%v", service, attachments)
+	url := fmt.Sprintf(
+		"/volumes/%s?attachments=%v%s", service, attachments, filterQuery(ctx))
 	if _, err := c.httpGet(ctx, url, &reply); err != nil {
 		return nil, err
 	}
 	return reply, nil
 }
 
+func (c *client) VolumesPage(
+	ctx types.Context,
+	attachments bool,
+	page *types.PageRequest) (types.ServiceVolumeMap, string, error) {
+
+	reply := types.ServiceVolumeMap{}
+	url := fmt.Sprintf(
+		"/volumes?attachments=%v%s&%s",
+		attachments, filterQuery(ctx), pageQuery(page))
+	res, err := c.httpGet(ctx, url, &reply)
+	if err != nil {
+		return nil, "", err
+	}
+	return reply, res.Header.Get(types.NextTokenHeader), nil
+}
+
+func (c *client) VolumesByServicePage(
+	ctx types.Context,
+	service string,
+	attachments bool,
+	page *types.PageRequest) (types.VolumeMap, string, error) {
+
+	reply := types.VolumeMap{}
+	url := fmt.Sprintf(
+		"/volumes/%s?attachments=%v%s&%s",
+		service, attachments, filterQuery(ctx), pageQuery(page))
+	res, err := c.httpGet(ctx, url, &reply)
+	if err != nil {
+		return nil, "", err
+	}
+	return reply, res.Header.Get(types.NextTokenHeader), nil
+}
+
 func (c *client) VolumeInspect(
 	ctx types.Context,
 	service, volumeID string,
@@ -121,6 +174,21 @@ func (c *client) VolumeInspect(
 	return &reply, nil
 }
 
+func (c *client) VolumeInspectByName(
+	ctx types.Context,
+	service, volumeName string,
+	attachments bool) (*types.Volume, error) {
+
+	reply := types.Volume{}
+	url := fmt.Sprintf(
+		"/volumes/%s/by-name/%s?attachments=%v",
+		service, (&url.URL{Path: volumeName}).EscapedPath(), attachments)
+	if _, err := c.httpGet(ctx, url, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
 func (c *client) VolumeCreate(
 	ctx types.Context,
 	service string,
@@ -162,6 +230,20 @@ func (c *client) VolumeCopy(
 	return &reply, nil
 }
 
+func (c *client) VolumeResize(
+	ctx types.Context,
+	service, volumeID string,
+	request *types.VolumeResizeRequest) (*types.Volume, error) {
+
+	reply := types.Volume{}
+	if _, err := c.httpPost(ctx,
+		fmt.Sprintf("/volumes/%s/%s?resize", service, volumeID),
+		request, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
 func (c *client) VolumeRemove(
 	ctx types.Context,
 	service, volumeID string) error {
@@ -248,7 +330,8 @@ func (c *client) Snapshots(
 	ctx types.Context) (types.ServiceSnapshotMap, error) {
 
 	reply := types.ServiceSnapshotMap{}
-	if _, err := c.httpGet(ctx, "/snapshots", &reply); err != nil {
+	if _, err := c.httpGet(
+		ctx, withFilterQuery(ctx, "/snapshots"), &reply); err != nil {
 		return nil, err
 	}
 	return reply, nil
@@ -258,13 +341,41 @@ func (c *client) SnapshotsByService(
 	ctx types.Context, service string) (types.SnapshotMap, error) {
 
 	reply := types.SnapshotMap{}
-	if _, err := c.httpGet(ctx,
-		fmt.Sprintf("/snapshots/%s", service), &reply); err != nil {
+	if _, err := c.httpGet(ctx, withFilterQuery(
+		ctx, fmt.Sprintf("/snapshots/%s", service)), &reply); err != nil {
 		return nil, err
 	}
 	return reply, nil
 }
 
+func (c *client) SnapshotsPage(
+	ctx types.Context,
+	page *types.PageRequest) (types.ServiceSnapshotMap, string, error) {
+
+	reply := types.ServiceSnapshotMap{}
+	url := "/snapshots?" + pageQuery(page) + filterQuery(ctx)
+	res, err := c.httpGet(ctx, url, &reply)
+	if err != nil {
+		return nil, "", err
+	}
+	return reply, res.Header.Get(types.NextTokenHeader), nil
+}
+
+func (c *client) SnapshotsByServicePage(
+	ctx types.Context,
+	service string,
+	page *types.PageRequest) (types.SnapshotMap, string, error) {
+
+	reply := types.SnapshotMap{}
+	url := fmt.Sprintf(
+		"/snapshots/%s?%s%s", service, pageQuery(page), filterQuery(ctx))
+	res, err := c.httpGet(ctx, url, &reply)
+	if err != nil {
+		return nil, "", err
+	}
+	return reply, res.Header.Get(types.NextTokenHeader), nil
+}
+
 func (c *client) SnapshotInspect(
 	ctx types.Context,
 	service, snapshotID string) (*types.Snapshot, error) {
@@ -303,6 +414,97 @@ func (c *client) SnapshotCopy(
 	return &reply, nil
 }
 
+func (c *client) Tasks(
+	ctx types.Context) (map[string]*types.Task, error) {
+
+	reply := map[string]*taskReply{}
+	if _, err := c.httpGet(
+		ctx, withFilterQuery(ctx, "/tasks"), &reply); err != nil {
+		return nil, err
+	}
+	tasks := map[string]*types.Task{}
+	for k, v := range reply {
+		tasks[k] = v.task()
+	}
+	return tasks, nil
+}
+
+func (c *client) TaskInspect(
+	ctx types.Context, taskID int) (*types.Task, error) {
+
+	reply := &taskReply{}
+	if _, err := c.httpGet(ctx,
+		fmt.Sprintf("/tasks/%d", taskID), reply); err != nil {
+		return nil, err
+	}
+	return reply.task(), nil
+}
+
+// taskReply is used to decode a task since the task's error field is an
+// interface that cannot be unmarshaled directly.
+type taskReply struct {
+	types.Task
+	Error json.RawMessage `json:"error,omitempty"`
+}
+
+func (t *taskReply) task() *types.Task {
+	task := t.Task
+	if len(t.Error) == 0 || string(t.Error) == "null" {
+		return &task
+	}
+	var msg string
+	if err := json.Unmarshal(t.Error, &msg); err != nil {
+		msg = string(t.Error)
+	}
+	task.Error = goof.New(msg)
+	return &task
+}
+
+// filterQuery returns the URL-encoded filter query parameter for the filter
+// stored in the context, if any.
+func filterQuery(ctx types.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	filter, ok := ctx.Value(context.FilterKey).(string)
+	if !ok || filter == "" {
+		return ""
+	}
+	return "&filter=" + url.QueryEscape(filter)
+}
+
+// pageQuery returns the URL-encoded query parameters that request a page. The
+// parameters always request a page, even if the page request is nil, so that
+// the server returns the continuation token.
+func pageQuery(page *types.PageRequest) string {
+	if page == nil {
+		page = &types.PageRequest{}
+	}
+	q := fmt.Sprintf("limit=%d", page.Limit)
+	if page.Token != "" {
+		q += "&token=" + url.QueryEscape(page.Token)
+	} else if page.Offset > 0 {
+		q += fmt.Sprintf("&offset=%d", page.Offset)
+	}
+	if page.Sort != "" {
+		q += "&sort=" + url.QueryEscape(page.Sort)
+	}
+	return q
+}
+
+// withFilterQuery returns the path with the filter query parameter for the
+// filter stored in the context, if any.
+func withFilterQuery(ctx types.Context, path string) string {
+	q := filterQuery(ctx)
+	if q == "" {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + q
+	}
+	return path + "?" + q[1:]
+}
+
 func (c *client) Executors(
 	ctx types.Context) (map[string]*types.ExecutorInfo, error) {
 
@@ -13,6 +13,7 @@ import (
 
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/tracing"
 )
 
 type headerKey int
@@ -21,6 +22,7 @@ const (
 	transactionHeaderKey headerKey = iota
 	instanceIDHeaderKey
 	localDevicesHeaderKey
+	traceParentHeaderKey
 )
 
 func (k headerKey) String() string {
@@ -31,6 +33,8 @@ func (k headerKey) String() string {
 		return types.InstanceIDHeader
 	case localDevicesHeaderKey:
 		return types.LocalDevicesHeader
+	case traceParentHeaderKey:
+		return types.TraceParentHeader
 	}
 	panic("invalid header key")
 }
@@ -38,7 +42,12 @@ func (k headerKey) String() string {
 func (c *client) httpDo(
 	ctx types.Context,
 	method, path string,
-	payload, reply interface{}) (*http.Response, error) {
+	payload, reply interface{}) (res *http.Response, err error) {
+
+	ctx, span := tracing.StartSpan(ctx, "client."+method)
+	span.SetAttribute("path", path)
+	defer func() { span.Finish(err) }()
+	ctx = ctx.WithValue(traceParentHeaderKey, span)
 
 	reqBody, err := encPayload(payload)
 	if err != nil {
@@ -121,11 +130,12 @@ func (c *client) httpDo(
 
 	c.logRequest(req)
 
-	res, err := ctxhttp.Do(ctx, &c.Client, req)
+	res, err = ctxhttp.Do(ctx, &c.Client, req)
 	if err != nil {
 		return nil, err
 	}
 	defer c.setServerName(res)
+	span.SetAttribute("statusCode", fmt.Sprintf("%d", res.StatusCode))
 
 	c.logResponse(res)
 
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/paging"
+)
+
+// DefaultPageLimit is the number of objects in the pages that an iterator
+// requests if the iterator's page request does not specify a limit.
+const DefaultPageLimit = 100
+
+// VolumeIterator walks a sorted listing of volumes one page at a time.
+type VolumeIterator struct {
+	ctx         types.Context
+	c           types.APIClient
+	service     string
+	attachments bool
+	page        types.PageRequest
+	keys        []*types.SortKey
+	services    []string
+	vols        []*types.Volume
+	i           int
+	done        bool
+	err         error
+}
+
+// NewVolumeIterator returns an iterator for the volumes of a service, or of
+// all services if the service is empty. The page request specifies the page
+// size, the first page, and the sort order.
+func NewVolumeIterator(
+	ctx types.Context,
+	c types.APIClient,
+	service string,
+	attachments bool,
+	page *types.PageRequest) *VolumeIterator {
+
+	it := &VolumeIterator{
+		ctx:         ctx,
+		c:           c,
+		service:     service,
+		attachments: attachments,
+		i:           -1,
+	}
+	it.page, it.keys, it.err = newIteratorPage(page, paging.VolumeSortFields)
+	return it
+}
+
+// Next advances the iterator to the next volume and returns a flag
+// indicating whether there is such a volume. Next requests the next page of
+// the listing as needed.
+func (it *VolumeIterator) Next() bool {
+	it.i++
+	for it.i >= len(it.vols) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	return true
+}
+
+// Service returns the name of the current volume's service.
+func (it *VolumeIterator) Service() string {
+	return it.services[it.i]
+}
+
+// Volume returns the current volume.
+func (it *VolumeIterator) Volume() *types.Volume {
+	return it.vols[it.i]
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *VolumeIterator) Err() error {
+	return it.err
+}
+
+func (it *VolumeIterator) fetch() {
+	var (
+		svm  types.ServiceVolumeMap
+		next string
+	)
+	if it.service == "" {
+		svm, next, it.err = it.c.VolumesPage(it.ctx, it.attachments, &it.page)
+	} else {
+		var vm types.VolumeMap
+		vm, next, it.err = it.c.VolumesByServicePage(
+			it.ctx, it.service, it.attachments, &it.page)
+		svm = types.ServiceVolumeMap{it.service: vm}
+	}
+	if it.err != nil {
+		return
+	}
+	it.services, it.vols = paging.SortedVolumes(svm, it.keys)
+	it.i = 0
+	it.page.Token = next
+	it.done = next == ""
+}
+
+// SnapshotIterator walks a sorted listing of snapshots one page at a time.
+type SnapshotIterator struct {
+	ctx      types.Context
+	c        types.APIClient
+	service  string
+	page     types.PageRequest
+	keys     []*types.SortKey
+	services []string
+	snaps    []*types.Snapshot
+	i        int
+	done     bool
+	err      error
+}
+
+// NewSnapshotIterator returns an iterator for the snapshots of a service, or
+// of all services if the service is empty. The page request specifies the
+// page size, the first page, and the sort order.
+func NewSnapshotIterator(
+	ctx types.Context,
+	c types.APIClient,
+	service string,
+	page *types.PageRequest) *SnapshotIterator {
+
+	it := &SnapshotIterator{
+		ctx:     ctx,
+		c:       c,
+		service: service,
+		i:       -1,
+	}
+	it.page, it.keys, it.err = newIteratorPage(
+		page, paging.SnapshotSortFields)
+	return it
+}
+
+// Next advances the iterator to the next snapshot and returns a flag
+// indicating whether there is such a snapshot. Next requests the next page
+// of the listing as needed.
+func (it *SnapshotIterator) Next() bool {
+	it.i++
+	for it.i >= len(it.snaps) {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.fetch()
+	}
+	return true
+}
+
+// Service returns the name of the current snapshot's service.
+func (it *SnapshotIterator) Service() string {
+	return it.services[it.i]
+}
+
+// Snapshot returns the current snapshot.
+func (it *SnapshotIterator) Snapshot() *types.Snapshot {
+	return it.snaps[it.i]
+}
+
+// Err returns the error, if any, that stopped the iteration.
+func (it *SnapshotIterator) Err() error {
+	return it.err
+}
+
+func (it *SnapshotIterator) fetch() {
+	var (
+		ssm  types.ServiceSnapshotMap
+		next string
+	)
+	if it.service == "" {
+		ssm, next, it.err = it.c.SnapshotsPage(it.ctx, &it.page)
+	} else {
+		var sm types.SnapshotMap
+		sm, next, it.err = it.c.SnapshotsByServicePage(
+			it.ctx, it.service, &it.page)
+		ssm = types.ServiceSnapshotMap{it.service: sm}
+	}
+	if it.err != nil {
+		return
+	}
+	it.services, it.snaps = paging.SortedSnapshots(ssm, it.keys)
+	it.i = 0
+	it.page.Token = next
+	it.done = next == ""
+}
+
+// newIteratorPage returns a copy of an iterator's page request with the
+// default limit, and the request's sort keys. The pages of a listing are
+// returned as maps, so an iterator sorts each page again by the sort keys.
+func newIteratorPage(
+	page *types.PageRequest,
+	fields []string) (types.PageRequest, []*types.SortKey, error) {
+
+	p := types.PageRequest{}
+	if page != nil {
+		p = *page
+	}
+	if p.Limit == 0 {
+		p.Limit = DefaultPageLimit
+	}
+	keys, err := paging.ParseSort(p.Sort, fields)
+	return p, keys, err
+}
@@ -33,6 +33,17 @@ const (
 	// AdminTokenKey is the key for the server's admin token.
 	AdminTokenKey
 
+	// SpanKey is the key for the current trace span.
+	SpanKey
+
+	// FilterKey is the key for an LDAP-style filter string that the API
+	// client sends along with list requests.
+	FilterKey
+
+	// ReloadKey is the key for the server's reload function, a func() error
+	// that re-reads the server's configuration and applies it.
+	ReloadKey
+
 	// keyLoggable is the minimum value from which the succeeding keys should
 	// be checked when logging.
 	keyLoggable
@@ -41,6 +41,16 @@ func (d *sdm) Type(
 	return d.StorageDriver.Type(ctx.Join(d.Context))
 }
 
+func (d *sdm) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	return sd.Capabilities(ctx.Join(d.Context))
+}
+
 func (d *sdm) InstanceInspect(
 	ctx types.Context,
 	opts types.Store) (*types.Instance, error) {
@@ -91,6 +101,18 @@ func (d *sdm) VolumeCopy(
 		ctx.Join(d.Context), volumeID, volumeName, opts)
 }
 
+func (d *sdm) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverVolumeResizer)
+	if !ok {
+		return nil, types.ErrNotImplemented
+	}
+	return sd.VolumeResize(ctx.Join(d.Context), volumeID, opts)
+}
+
 func (d *sdm) VolumeSnapshot(
 	ctx types.Context,
 	volumeID,
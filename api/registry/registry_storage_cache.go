@@ -0,0 +1,387 @@
+package registry
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+type sdc struct {
+	types.StorageDriver
+	volumesTTL    time.Duration
+	snapshotsTTL  time.Duration
+	entries       map[string]*sdcEntry
+	entriesRWL    *sync.RWMutex
+	generation    int64
+	hits          int64
+	misses        int64
+	invalidations int64
+}
+
+type sdcEntry struct {
+	val     interface{}
+	expires time.Time
+}
+
+// NewStorageDriverCache returns a new storage driver cache. The results of
+// the Volumes and VolumeInspect calls are cached for volumesTTL and the
+// results of the Snapshots and SnapshotInspect calls are cached for
+// snapshotsTTL. A TTL of zero disables caching for that type of resource.
+//
+// Any operation that mutates a volume or snapshot through the cache
+// invalidates all of the cache's entries. A lookup bypasses the cache and
+// refreshes it when the "nocache" option is set.
+func NewStorageDriverCache(
+	d types.StorageDriver,
+	volumesTTL, snapshotsTTL time.Duration) types.StorageDriverCache {
+
+	return &sdc{
+		StorageDriver: d,
+		volumesTTL:    volumesTTL,
+		snapshotsTTL:  snapshotsTTL,
+		entries:       map[string]*sdcEntry{},
+		entriesRWL:    &sync.RWMutex{},
+	}
+}
+
+func (d *sdc) CacheStats() *types.CacheStats {
+	d.entriesRWL.RLock()
+	defer d.entriesRWL.RUnlock()
+	return &types.CacheStats{
+		VolumesTTL:    d.volumesTTL.String(),
+		SnapshotsTTL:  d.snapshotsTTL.String(),
+		Entries:       len(d.entries),
+		Hits:          atomic.LoadInt64(&d.hits),
+		Misses:        atomic.LoadInt64(&d.misses),
+		Invalidations: atomic.LoadInt64(&d.invalidations),
+	}
+}
+
+func (d *sdc) CacheInvalidate() {
+	d.entriesRWL.Lock()
+	defer d.entriesRWL.Unlock()
+	d.entries = map[string]*sdcEntry{}
+	d.generation++
+	atomic.AddInt64(&d.invalidations, 1)
+}
+
+// get returns the cached value for the key as well as the cache generation
+// that must be provided to set when storing a value after a miss.
+func (d *sdc) get(
+	key string, opts types.Store) (interface{}, int64, bool) {
+
+	d.entriesRWL.RLock()
+	defer d.entriesRWL.RUnlock()
+	if opts != nil && opts.GetBool("nocache") {
+		atomic.AddInt64(&d.misses, 1)
+		return nil, d.generation, false
+	}
+	e, ok := d.entries[key]
+	if !ok || time.Now().After(e.expires) {
+		atomic.AddInt64(&d.misses, 1)
+		return nil, d.generation, false
+	}
+	atomic.AddInt64(&d.hits, 1)
+	return e.val, d.generation, true
+}
+
+// set stores a value in the cache unless the cache was invalidated since
+// the provided generation was obtained, as the value may be stale.
+func (d *sdc) set(
+	key string, val interface{}, ttl time.Duration, generation int64) {
+
+	d.entriesRWL.Lock()
+	defer d.entriesRWL.Unlock()
+	if generation != d.generation {
+		return
+	}
+	now := time.Now()
+	for k, e := range d.entries {
+		if now.After(e.expires) {
+			delete(d.entries, k)
+		}
+	}
+	d.entries[key] = &sdcEntry{val: val, expires: now.Add(ttl)}
+}
+
+func volumesCacheKey(ctx types.Context, attachments bool) string {
+	if !attachments {
+		return "volumes"
+	}
+	iid, ok := context.InstanceID(ctx)
+	if !ok {
+		return "volumes|attachments"
+	}
+	return fmt.Sprintf("volumes|attachments|%s|%s", iid.Driver, iid.ID)
+}
+
+func (d *sdc) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	return sd.Capabilities(ctx)
+}
+
+func (d *sdc) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	if d.volumesTTL <= 0 || opts == nil {
+		return d.StorageDriver.Volumes(ctx, opts)
+	}
+
+	// the cache holds complete listings, so the driver is not asked for a
+	// page or a filtered listing and the server pages and filters the cached
+	// volumes instead
+	if opts.Page != nil || opts.Filter != nil {
+		opts = &types.VolumesOpts{Attachments: opts.Attachments, Opts: opts.Opts}
+	}
+
+	key := volumesCacheKey(ctx, opts.Attachments)
+	val, gen, ok := d.get(key, opts.Opts)
+	if ok {
+		ctx.WithField("key", key).Debug("cache hit")
+		return copyVolumes(val.([]*types.Volume)), nil
+	}
+
+	vols, err := d.StorageDriver.Volumes(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.set(key, copyVolumes(vols), d.volumesTTL, gen)
+	return vols, nil
+}
+
+func (d *sdc) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	if d.volumesTTL <= 0 || opts == nil {
+		return d.StorageDriver.VolumeInspect(ctx, volumeID, opts)
+	}
+
+	key := fmt.Sprintf(
+		"%s|%s", volumesCacheKey(ctx, opts.Attachments), volumeID)
+	val, gen, ok := d.get(key, opts.Opts)
+	if ok {
+		ctx.WithField("key", key).Debug("cache hit")
+		return copyVolume(val.(*types.Volume)), nil
+	}
+
+	vol, err := d.StorageDriver.VolumeInspect(ctx, volumeID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.set(key, copyVolume(vol), d.volumesTTL, gen)
+	return vol, nil
+}
+
+func (d *sdc) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	if d.snapshotsTTL <= 0 {
+		return d.StorageDriver.Snapshots(ctx, opts)
+	}
+
+	key := "snapshots"
+	val, gen, ok := d.get(key, opts)
+	if ok {
+		ctx.WithField("key", key).Debug("cache hit")
+		return copySnapshots(val.([]*types.Snapshot)), nil
+	}
+
+	snaps, err := d.StorageDriver.Snapshots(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.set(key, copySnapshots(snaps), d.snapshotsTTL, gen)
+	return snaps, nil
+}
+
+func (d *sdc) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if d.snapshotsTTL <= 0 {
+		return d.StorageDriver.SnapshotInspect(ctx, snapshotID, opts)
+	}
+
+	key := fmt.Sprintf("snapshots|%s", snapshotID)
+	val, gen, ok := d.get(key, opts)
+	if ok {
+		ctx.WithField("key", key).Debug("cache hit")
+		return copySnapshot(val.(*types.Snapshot)), nil
+	}
+
+	snap, err := d.StorageDriver.SnapshotInspect(ctx, snapshotID, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	d.set(key, copySnapshot(snap), d.snapshotsTTL, gen)
+	return snap, nil
+}
+
+func (d *sdc) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeCreate(ctx, name, opts)
+}
+
+func (d *sdc) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID,
+	volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeCreateFromSnapshot(
+		ctx, snapshotID, volumeName, opts)
+}
+
+func (d *sdc) VolumeCopy(
+	ctx types.Context,
+	volumeID,
+	volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeCopy(ctx, volumeID, volumeName, opts)
+}
+
+func (d *sdc) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverVolumeResizer)
+	if !ok {
+		return nil, types.ErrNotImplemented
+	}
+	defer d.CacheInvalidate()
+	return sd.VolumeResize(ctx, volumeID, opts)
+}
+
+func (d *sdc) VolumeSnapshot(
+	ctx types.Context,
+	volumeID,
+	snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeSnapshot(ctx, volumeID, snapshotName, opts)
+}
+
+func (d *sdc) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeRemove(ctx, volumeID, opts)
+}
+
+func (d *sdc) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeAttach(ctx, volumeID, opts)
+}
+
+func (d *sdc) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.VolumeDetach(ctx, volumeID, opts)
+}
+
+func (d *sdc) SnapshotCopy(
+	ctx types.Context,
+	snapshotID,
+	snapshotName,
+	destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.SnapshotCopy(
+		ctx, snapshotID, snapshotName, destinationID, opts)
+}
+
+func (d *sdc) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	defer d.CacheInvalidate()
+	return d.StorageDriver.SnapshotRemove(ctx, snapshotID, opts)
+}
+
+// copyVolume returns a copy of a volume so that callers, such as the volume
+// router when filtering attachments, cannot modify the cached object.
+func copyVolume(v *types.Volume) *types.Volume {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	if v.Attachments != nil {
+		c.Attachments = make([]*types.VolumeAttachment, len(v.Attachments))
+		for i, a := range v.Attachments {
+			if a == nil {
+				continue
+			}
+			ca := *a
+			c.Attachments[i] = &ca
+		}
+	}
+	return &c
+}
+
+func copyVolumes(vols []*types.Volume) []*types.Volume {
+	if vols == nil {
+		return nil
+	}
+	c := make([]*types.Volume, len(vols))
+	for i, v := range vols {
+		c[i] = copyVolume(v)
+	}
+	return c
+}
+
+func copySnapshot(s *types.Snapshot) *types.Snapshot {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func copySnapshots(snaps []*types.Snapshot) []*types.Snapshot {
+	if snaps == nil {
+		return nil
+	}
+	c := make([]*types.Snapshot, len(snaps))
+	for i, s := range snaps {
+		c[i] = copySnapshot(s)
+	}
+	return c
+}
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+type countingDriver struct {
+	types.StorageDriver
+	volumesCalls int
+	vols         []*types.Volume
+}
+
+func (d *countingDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	d.volumesCalls++
+	return d.vols, nil
+}
+
+func (d *countingDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.vols = d.vols[1:]
+	return nil
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{
+		vols: []*types.Volume{
+			{ID: "vol-000", Name: "v0"},
+			{ID: "vol-001", Name: "v1"},
+		},
+	}
+}
+
+func TestStorageDriverCacheVolumes(t *testing.T) {
+	ctx := context.Background()
+	d := newCountingDriver()
+	c := NewStorageDriverCache(d, time.Minute, 0)
+	opts := &types.VolumesOpts{Opts: utils.NewStore()}
+
+	vols, err := c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	assert.Len(t, vols, 2)
+
+	// modifying the result must not modify the cached volumes
+	vols[0].Name = "modified"
+
+	vols, err = c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	assert.Len(t, vols, 2)
+	assert.Equal(t, "v0", vols[0].Name)
+	assert.Equal(t, 1, d.volumesCalls)
+
+	stats := c.CacheStats()
+	assert.EqualValues(t, 1, stats.Hits)
+	assert.EqualValues(t, 1, stats.Misses)
+	assert.Equal(t, 1, stats.Entries)
+}
+
+func TestStorageDriverCacheNoCache(t *testing.T) {
+	ctx := context.Background()
+	d := newCountingDriver()
+	c := NewStorageDriverCache(d, time.Minute, 0)
+
+	store := utils.NewStore()
+	opts := &types.VolumesOpts{Opts: store}
+	_, err := c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+
+	store.Set("nocache", true)
+	_, err = c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, d.volumesCalls)
+}
+
+func TestStorageDriverCacheInvalidate(t *testing.T) {
+	ctx := context.Background()
+	d := newCountingDriver()
+	c := NewStorageDriverCache(d, time.Minute, 0)
+	opts := &types.VolumesOpts{Opts: utils.NewStore()}
+
+	_, err := c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, c.VolumeRemove(ctx, "vol-000", utils.NewStore()))
+
+	vols, err := c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	assert.Len(t, vols, 1)
+	assert.Equal(t, 2, d.volumesCalls)
+	assert.EqualValues(t, 1, c.CacheStats().Invalidations)
+}
+
+func TestStorageDriverCacheExpires(t *testing.T) {
+	ctx := context.Background()
+	d := newCountingDriver()
+	c := NewStorageDriverCache(d, 10*time.Millisecond, 0)
+	opts := &types.VolumesOpts{Opts: utils.NewStore()}
+
+	_, err := c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = c.Volumes(ctx, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, d.volumesCalls)
+}
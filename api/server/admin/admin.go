@@ -0,0 +1,474 @@
+// Package admin provides the libStorage server's identity, the server's name
+// and the named admin tokens that authorize requests to its admin routes.
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// DefaultTokenName is the name of the admin token that is set with the
+	// libstorage.server.adminToken property or that is generated when no
+	// admin tokens exist.
+	DefaultTokenName = "default"
+
+	// StateFileName is the name of the file in the state directory in which
+	// the server's identity is saved.
+	StateFileName = "server.json"
+)
+
+func init() {
+	apiconfig.Constrain(
+		types.ConfigServerAdminTokens+".*.expires",
+		func(v interface{}) error {
+			_, err := ParseExpires(fmt.Sprintf("%v", v))
+			return err
+		})
+}
+
+var (
+	identitiesByServer    = map[string]*Identity{}
+	identitiesByServerRWL = &sync.RWMutex{}
+)
+
+// Identity is a server's name and admin tokens.
+type Identity struct {
+	name       string
+	tokens     map[string]*types.AdminToken
+	configured map[string]bool
+	stateFile  string
+	rwl        sync.RWMutex
+}
+
+// state is the persisted form of an identity. Tokens that are set in the
+// config are not persisted.
+type state struct {
+	Name   string              `json:"name"`
+	Tokens []*types.AdminToken `json:"tokens,omitempty"`
+}
+
+// New returns the server identity described by the config. When the state is
+// enabled the name and tokens are first read from the state file. The name
+// and tokens set in the config are applied next. If the server still has no
+// name then newName is used to create one, and a default token is generated
+// if the server has no tokens.
+func New(config gofig.Config, newName func() string) (*Identity, error) {
+
+	i := &Identity{
+		tokens:     map[string]*types.AdminToken{},
+		configured: map[string]bool{},
+	}
+
+	if config.GetBool(types.ConfigServerStateEnabled) {
+		stateDir := config.GetString(types.ConfigServerStateDir)
+		if stateDir == "" {
+			stateDir = types.Lib.String()
+		}
+		i.stateFile = path.Join(stateDir, StateFileName)
+		if err := i.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if name := config.GetString(types.ConfigServerName); name != "" {
+		i.name = name
+	}
+	if i.name == "" {
+		i.name = newName()
+	}
+
+	if err := i.Apply(config); err != nil {
+		return nil, err
+	}
+
+	return i, nil
+}
+
+// Apply applies the tokens set in the config. Tokens that were previously
+// set in the config but are no longer are removed.
+func (i *Identity) Apply(config gofig.Config) error {
+
+	configured, err := parseTokens(config)
+	if err != nil {
+		return err
+	}
+
+	i.rwl.Lock()
+	defer i.rwl.Unlock()
+
+	for name := range i.configured {
+		if _, ok := configured[name]; !ok {
+			delete(i.tokens, name)
+		}
+	}
+
+	i.configured = map[string]bool{}
+	for name, t := range configured {
+		i.tokens[name] = t
+		i.configured[name] = true
+	}
+
+	if len(i.tokens) == 0 {
+		t, err := newToken(DefaultTokenName, 0)
+		if err != nil {
+			return err
+		}
+		i.tokens[t.Name] = t
+	}
+
+	return i.save()
+}
+
+// Name returns the server's name.
+func (i *Identity) Name() string {
+	return i.name
+}
+
+// Token returns the value of the token with the specified name. An empty
+// string is returned if there is no such token or if it has expired.
+func (i *Identity) Token(name string) string {
+	i.rwl.RLock()
+	defer i.rwl.RUnlock()
+	t, ok := i.tokens[name]
+	if !ok || expired(t) {
+		return ""
+	}
+	return t.Token
+}
+
+// DisplayToken returns the default token for the server's startup banner.
+// A placeholder is returned if the default token is set in the config.
+func (i *Identity) DisplayToken() string {
+	i.rwl.RLock()
+	configured := i.configured[DefaultTokenName]
+	i.rwl.RUnlock()
+	if configured {
+		return "(configured)"
+	}
+	return i.Token(DefaultTokenName)
+}
+
+// Valid returns a flag indicating whether the token matches one of the
+// server's unexpired tokens.
+func (i *Identity) Valid(token string) bool {
+	if token == "" {
+		return false
+	}
+	i.rwl.RLock()
+	defer i.rwl.RUnlock()
+	for _, t := range i.tokens {
+		if expired(t) {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// Tokens returns the names and expiration times of the server's unexpired
+// tokens, sorted by name. The tokens' values are omitted.
+func (i *Identity) Tokens() []*types.AdminToken {
+	i.rwl.RLock()
+	defer i.rwl.RUnlock()
+	var tokens []*types.AdminToken
+	for _, t := range i.tokens {
+		if expired(t) {
+			continue
+		}
+		tokens = append(tokens, &types.AdminToken{
+			Name:    t.Name,
+			Expires: t.Expires,
+		})
+	}
+	sort.Sort(byName(tokens))
+	return tokens
+}
+
+// Rotate replaces the value of the token with the specified name, creating
+// the token if it does not exist. A zero expires value means the token never
+// expires.
+func (i *Identity) Rotate(name string, expires int64) (*types.AdminToken, error) {
+
+	t, err := newToken(strings.ToLower(name), expires)
+	if err != nil {
+		return nil, err
+	}
+
+	i.rwl.Lock()
+	defer i.rwl.Unlock()
+
+	i.tokens[t.Name] = t
+	delete(i.configured, t.Name)
+	if err := i.save(); err != nil {
+		return nil, err
+	}
+
+	tc := *t
+	return &tc, nil
+}
+
+// Remove removes the token with the specified name. The server's last
+// unexpired token cannot be removed.
+func (i *Identity) Remove(name string) error {
+
+	name = strings.ToLower(name)
+
+	i.rwl.Lock()
+	defer i.rwl.Unlock()
+
+	t, ok := i.tokens[name]
+	if !ok || expired(t) {
+		return utils.NewNotFoundError(name)
+	}
+
+	valid := 0
+	for _, t := range i.tokens {
+		if !expired(t) {
+			valid++
+		}
+	}
+	if valid == 1 {
+		return goof.WithField("name", name, "cannot remove last admin token")
+	}
+
+	delete(i.tokens, name)
+	delete(i.configured, name)
+	return i.save()
+}
+
+func (i *Identity) load() error {
+
+	buf, err := ioutil.ReadFile(i.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s := &state{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return goof.WithFieldE("path", i.stateFile, "invalid state file", err)
+	}
+
+	i.name = s.Name
+	for _, t := range s.Tokens {
+		if t.Name == "" || t.Token == "" || expired(t) {
+			continue
+		}
+		i.tokens[t.Name] = t
+	}
+
+	return nil
+}
+
+// save writes the identity to the state file if the state is enabled. The
+// caller must hold the identity's write lock.
+func (i *Identity) save() error {
+
+	if i.stateFile == "" {
+		return nil
+	}
+
+	s := &state{Name: i.name}
+	for name, t := range i.tokens {
+		if i.configured[name] || expired(t) {
+			continue
+		}
+		s.Tokens = append(s.Tokens, t)
+	}
+	sort.Sort(byName(s.Tokens))
+
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(i.stateFile), 0755); err != nil {
+		return err
+	}
+
+	// write the state to a temporary file first so that a partial write
+	// never replaces a valid state file
+	tmp := i.stateFile + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, i.stateFile)
+}
+
+func parseTokens(config gofig.Config) (map[string]*types.AdminToken, error) {
+
+	tokens := map[string]*types.AdminToken{}
+
+	token, err := readToken(
+		config.GetString(types.ConfigServerAdminToken),
+		config.GetString(types.ConfigServerAdminTokenFile))
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		tokens[DefaultTokenName] = &types.AdminToken{
+			Name:  DefaultTokenName,
+			Token: token,
+		}
+	}
+
+	cfgTokens, ok := config.Get(
+		types.ConfigServerAdminTokens).(map[string]interface{})
+	if !ok {
+		return tokens, nil
+	}
+
+	for name := range cfgTokens {
+		name = strings.ToLower(name)
+		scope := fmt.Sprintf("%s.%s", types.ConfigServerAdminTokens, name)
+
+		token, err := readToken(
+			config.GetString(scope+".token"),
+			config.GetString(scope+".tokenFile"))
+		if err != nil {
+			return nil, err
+		}
+		if token == "" {
+			return nil, goof.WithField("name", name, "missing admin token")
+		}
+
+		// a duration is not accepted here since it would be relative to
+		// each start or reload and so the token would never expire
+		t := &types.AdminToken{Name: name, Token: token}
+		if v := config.GetString(scope + ".expires"); v != "" {
+			exp, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, goof.WithFields(goof.Fields{
+					"name":    name,
+					"expires": v,
+				}, "admin token expiration time must be in RFC3339 format")
+			}
+			t.Expires = exp.Unix()
+		}
+		tokens[name] = t
+	}
+
+	return tokens, nil
+}
+
+// readToken returns the token, or the trimmed contents of the token file if
+// one is specified.
+func readToken(token, tokenFile string) (string, error) {
+	if tokenFile == "" {
+		return token, nil
+	}
+	buf, err := ioutil.ReadFile(tokenFile)
+	if err != nil {
+		return "", goof.WithFieldE(
+			"path", tokenFile, "error reading admin token file", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// ParseExpires parses an expiration time, either an RFC3339 timestamp or a
+// duration from now such as 24h, and returns it as an epoch time.
+func ParseExpires(v string) (int64, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return time.Now().Add(d).Unix(), nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return 0, goof.WithField("expires", v, "invalid expiration time")
+	}
+	return t.Unix(), nil
+}
+
+func newToken(name string, expires int64) (*types.AdminToken, error) {
+	uuid, err := types.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+	return &types.AdminToken{
+		Name:    name,
+		Token:   uuid.String(),
+		Expires: expires,
+	}, nil
+}
+
+func expired(t *types.AdminToken) bool {
+	return t.Expires > 0 && time.Now().Unix() >= t.Expires
+}
+
+type byName []*types.AdminToken
+
+func (s byName) Len() int           { return len(s) }
+func (s byName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// Init registers the identity with the server specified by the context.
+func Init(ctx types.Context, i *Identity) {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
+	}
+
+	identitiesByServerRWL.Lock()
+	defer identitiesByServerRWL.Unlock()
+	identitiesByServer[serverName] = i
+}
+
+// Get returns the identity of the server specified by the context.
+func Get(ctx types.Context) *Identity {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		return nil
+	}
+
+	identitiesByServerRWL.RLock()
+	defer identitiesByServerRWL.RUnlock()
+	return identitiesByServer[serverName]
+}
+
+// Close removes the identity of the server specified by the context.
+func Close(ctx types.Context) {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		return
+	}
+
+	identitiesByServerRWL.Lock()
+	defer identitiesByServerRWL.Unlock()
+	delete(identitiesByServer, serverName)
+}
+
+// Validate returns an error if the token does not match one of the unexpired
+// tokens of the server specified by the context.
+func Validate(ctx types.Context, token string) error {
+	i := Get(ctx)
+	if i == nil {
+		return utils.NewBadAdminTokenError("missing")
+	}
+	if !i.Valid(token) {
+		return utils.NewBadAdminTokenError(token)
+	}
+	return nil
+}
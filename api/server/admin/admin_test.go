@@ -0,0 +1,171 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+func newTestConfig(t *testing.T, yaml string) gofig.Config {
+	config := gofig.New()
+	if err := config.ReadConfig(bytes.NewReader([]byte(yaml))); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func newTestName() string {
+	return "test-server"
+}
+
+func TestNewGenerated(t *testing.T) {
+	i, err := New(newTestConfig(t, ""), newTestName)
+	assert.NoError(t, err)
+	assert.Equal(t, "test-server", i.Name())
+
+	token := i.Token(DefaultTokenName)
+	assert.NotEmpty(t, token)
+	assert.Equal(t, token, i.DisplayToken())
+	assert.True(t, i.Valid(token))
+	assert.False(t, i.Valid(""))
+	assert.False(t, i.Valid("invalid"))
+}
+
+func TestNewConfigured(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libstorage-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokenFile := path.Join(dir, "ops.token")
+	assert.NoError(t, ioutil.WriteFile(tokenFile, []byte("ops-token\n"), 0600))
+
+	i, err := New(newTestConfig(t, fmt.Sprintf(`
+libstorage:
+  server:
+    name: lss-01
+    adminToken: default-token
+    adminTokens:
+      ops:
+        tokenFile: %s
+      ci:
+        token: ci-token
+        expires: 2000-01-01T00:00:00Z
+`, tokenFile)), newTestName)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "lss-01", i.Name())
+	assert.Equal(t, "(configured)", i.DisplayToken())
+	assert.True(t, i.Valid("default-token"))
+	assert.True(t, i.Valid("ops-token"))
+	assert.False(t, i.Valid("ci-token"))
+
+	tokens := i.Tokens()
+	if assert.Len(t, tokens, 2) {
+		assert.Equal(t, "default", tokens[0].Name)
+		assert.Equal(t, "ops", tokens[1].Name)
+		assert.Empty(t, tokens[0].Token)
+	}
+
+	// applying a config without the ops token removes it
+	assert.NoError(t, i.Apply(newTestConfig(t, `
+libstorage:
+  server:
+    adminToken: default-token
+`)))
+	assert.False(t, i.Valid("ops-token"))
+	assert.True(t, i.Valid("default-token"))
+
+	// a configured token cannot expire after a duration
+	assert.Error(t, i.Apply(newTestConfig(t, `
+libstorage:
+  server:
+    adminTokens:
+      ci:
+        token: ci-token
+        expires: 24h
+`)))
+	assert.True(t, i.Valid("default-token"))
+}
+
+func TestRotateAndRemove(t *testing.T) {
+	i, err := New(newTestConfig(t, ""), newTestName)
+	assert.NoError(t, err)
+	old := i.Token(DefaultTokenName)
+
+	rotated, err := i.Rotate(DefaultTokenName, 0)
+	assert.NoError(t, err)
+	assert.NotEqual(t, old, rotated.Token)
+	assert.False(t, i.Valid(old))
+	assert.True(t, i.Valid(rotated.Token))
+
+	expires := time.Now().Add(time.Hour).Unix()
+	ci, err := i.Rotate("CI", expires)
+	assert.NoError(t, err)
+	assert.Equal(t, "ci", ci.Name)
+	assert.Equal(t, expires, ci.Expires)
+	assert.True(t, i.Valid(ci.Token))
+
+	assert.NoError(t, i.Remove("ci"))
+	assert.False(t, i.Valid(ci.Token))
+	assert.IsType(t, &types.ErrNotFound{}, i.Remove("ci"))
+	assert.Error(t, i.Remove(DefaultTokenName))
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libstorage-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := newTestConfig(t, fmt.Sprintf(`
+libstorage:
+  server:
+    adminTokens:
+      ops:
+        token: ops-token
+    state:
+      enabled: true
+      dir: %s
+`, dir))
+
+	i, err := New(config, newTestName)
+	assert.NoError(t, err)
+	ci, err := i.Rotate("ci", 0)
+	assert.NoError(t, err)
+
+	buf, err := ioutil.ReadFile(path.Join(dir, StateFileName))
+	assert.NoError(t, err)
+	assert.Contains(t, string(buf), ci.Token)
+	assert.NotContains(t, string(buf), "ops-token")
+
+	i, err = New(config, func() string { return "another-name" })
+	assert.NoError(t, err)
+	assert.Equal(t, "test-server", i.Name())
+	assert.True(t, i.Valid(ci.Token))
+	assert.True(t, i.Valid("ops-token"))
+}
+
+func TestParseExpires(t *testing.T) {
+	v, err := ParseExpires("2000-01-01T00:00:00Z")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(946684800), v)
+
+	v, err = ParseExpires("1h")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), v, 5)
+
+	_, err = ParseExpires("tomorrow")
+	assert.Error(t, err)
+}
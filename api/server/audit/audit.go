@@ -0,0 +1,221 @@
+// Package audit provides the libStorage server's audit log, a record of the
+// mutating operations received by the server.
+package audit
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+const (
+	// TypeFile is the audit type that writes records to a rotating file.
+	TypeFile = "file"
+
+	// TypeSyslog is the audit type that writes records to syslog.
+	TypeSyslog = "syslog"
+
+	// DefaultFileName is the name of the audit file created in the
+	// libStorage log directory if no file is configured.
+	DefaultFileName = "libstorage-audit.log"
+)
+
+var (
+	auditorsByServer    = map[string]*Auditor{}
+	auditorsByServerRWL = &sync.RWMutex{}
+)
+
+// Query is a query for audit records. Empty fields match all records.
+type Query struct {
+
+	// Service matches records by service name.
+	Service string
+
+	// Route matches records by route name.
+	Route string
+
+	// VolumeID matches records by volume ID.
+	VolumeID string
+
+	// SnapshotID matches records by snapshot ID.
+	SnapshotID string
+
+	// InstanceID matches records by instance ID.
+	InstanceID string
+
+	// User matches records by user name.
+	User string
+
+	// Outcome matches records by outcome.
+	Outcome types.AuditOutcome
+
+	// Since matches records that occurred at or after this time.
+	Since time.Time
+
+	// Limit is the maximum number of records to return. The most recent
+	// records are returned if there are more than this number of matches.
+	Limit int
+}
+
+// Match returns a flag indicating whether the record matches the query.
+func (q *Query) Match(r *types.AuditRecord) bool {
+	return matchString(q.Service, r.Service) &&
+		matchString(q.Route, r.Route) &&
+		matchString(q.VolumeID, r.VolumeID) &&
+		matchString(q.SnapshotID, r.SnapshotID) &&
+		matchString(q.InstanceID, r.InstanceID) &&
+		matchString(q.User, r.User) &&
+		matchString(string(q.Outcome), string(r.Outcome)) &&
+		(q.Since.IsZero() || !r.Time.Before(q.Since))
+}
+
+func matchString(expected, actual string) bool {
+	return expected == "" || strings.EqualFold(expected, actual)
+}
+
+// sink is the destination to which audit records are written.
+type sink interface {
+	write(r *types.AuditRecord) error
+	query(q *Query) ([]*types.AuditRecord, error)
+	close() error
+}
+
+// Auditor writes audit records to the configured destination.
+type Auditor struct {
+	sink sink
+}
+
+// New returns a new Auditor configured with the provided config. A nil
+// Auditor is returned if the audit log is not enabled.
+func New(ctx types.Context, config gofig.Config) (*Auditor, error) {
+
+	if !config.GetBool(types.ConfigServerAuditEnabled) {
+		return nil, nil
+	}
+
+	var (
+		s   sink
+		err error
+	)
+
+	switch typ := strings.ToLower(
+		config.GetString(types.ConfigServerAuditType)); typ {
+	case "", TypeFile:
+		fileName := config.GetString(types.ConfigServerAuditFile)
+		if fileName == "" {
+			fileName = types.Log.Join(DefaultFileName)
+		}
+		s, err = newFileSink(
+			fileName,
+			int64(config.GetInt(types.ConfigServerAuditMaxSize))*1024*1024,
+			config.GetInt(types.ConfigServerAuditMaxBackups))
+		ctx.WithField("file", fileName).Info("enabled audit log")
+	case TypeSyslog:
+		s, err = newSyslogSink(
+			config.GetString(types.ConfigServerAuditSyslogNetwork),
+			config.GetString(types.ConfigServerAuditSyslogAddress),
+			config.GetString(types.ConfigServerAuditSyslogTag))
+		ctx.Info("enabled syslog audit log")
+	default:
+		return nil, goof.WithField("type", typ, "invalid audit type")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Auditor{sink: s}, nil
+}
+
+// Init initializes the Auditor for the server specified by the context.
+func Init(ctx types.Context, config gofig.Config) (*Auditor, error) {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
+	}
+
+	a, err := New(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	auditorsByServerRWL.Lock()
+	defer auditorsByServerRWL.Unlock()
+	if a == nil {
+		delete(auditorsByServer, serverName)
+	} else {
+		auditorsByServer[serverName] = a
+	}
+
+	return a, nil
+}
+
+// Get returns the Auditor for the server specified by the context. A nil
+// value is returned if the server's audit log is not enabled.
+func Get(ctx types.Context) *Auditor {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		return nil
+	}
+
+	auditorsByServerRWL.RLock()
+	defer auditorsByServerRWL.RUnlock()
+	return auditorsByServer[serverName]
+}
+
+// Close closes the Auditor for the server specified by the context.
+func Close(ctx types.Context) error {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		return nil
+	}
+
+	auditorsByServerRWL.Lock()
+	a, ok := auditorsByServer[serverName]
+	delete(auditorsByServer, serverName)
+	auditorsByServerRWL.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return a.Close()
+}
+
+// Write writes an audit record.
+func (a *Auditor) Write(r *types.AuditRecord) error {
+	return a.sink.write(r)
+}
+
+// Query returns the audit records that match the query, ordered from oldest
+// to newest.
+func (a *Auditor) Query(q *Query) ([]*types.AuditRecord, error) {
+	return a.sink.query(q)
+}
+
+// Close closes the Auditor.
+func (a *Auditor) Close() error {
+	return a.sink.close()
+}
+
+// appendLimited appends a record to a slice of records, dropping the oldest
+// record if the slice would exceed the limit.
+func appendLimited(
+	records []*types.AuditRecord,
+	r *types.AuditRecord,
+	limit int) []*types.AuditRecord {
+
+	records = append(records, r)
+	if limit > 0 && len(records) > limit {
+		records = records[len(records)-limit:]
+	}
+	return records
+}
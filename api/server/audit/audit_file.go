@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+// fileSink writes audit records as lines of JSON to a file that is rotated
+// once it exceeds a maximum size.
+type fileSink struct {
+	sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	f          *os.File
+	size       int64
+}
+
+func newFileSink(
+	filePath string, maxSize int64, maxBackups int) (*fileSink, error) {
+
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return nil, err
+	}
+	s := &fileSink{path: filePath, maxSize: maxSize, maxBackups: maxBackups}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *fileSink) open() error {
+	f, err := os.OpenFile(
+		s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f = f
+	s.size = fi.Size()
+	return nil
+}
+
+func (s *fileSink) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+// rotate renames the current file to the first backup, shifting the existing
+// backups and removing the oldest, and opens a new file.
+func (s *fileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups > 0 {
+		os.Remove(s.backupPath(s.maxBackups))
+		for i := s.maxBackups - 1; i > 0; i-- {
+			os.Rename(s.backupPath(i), s.backupPath(i+1))
+		}
+		if err := os.Rename(s.path, s.backupPath(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *fileSink) write(r *types.AuditRecord) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(buf)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.f.Write(buf)
+	s.size += int64(n)
+	return err
+}
+
+func (s *fileSink) query(q *Query) ([]*types.AuditRecord, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var records []*types.AuditRecord
+
+	// read the backups from oldest to newest followed by the current file
+	paths := []string{}
+	for i := s.maxBackups; i > 0; i-- {
+		paths = append(paths, s.backupPath(i))
+	}
+	paths = append(paths, s.path)
+
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			r := &types.AuditRecord{}
+			if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+				continue
+			}
+			if q.Match(r) {
+				records = appendLimited(records, r, q.Limit)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
+
+func (s *fileSink) close() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.f.Close()
+}
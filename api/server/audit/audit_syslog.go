@@ -0,0 +1,63 @@
+// +build !windows,!plan9,!nacl
+
+package audit
+
+import (
+	"encoding/json"
+	"log/syslog"
+	"sync"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+// syslogRecordsLen is the number of the most recent records a syslog sink
+// retains in order to answer queries.
+const syslogRecordsLen = 1000
+
+// syslogSink writes audit records as JSON to syslog. Since syslog cannot be
+// queried, the most recent records are also kept in memory.
+type syslogSink struct {
+	sync.RWMutex
+	w       *syslog.Writer
+	records []*types.AuditRecord
+}
+
+func newSyslogSink(network, raddr, tag string) (sink, error) {
+	w, err := syslog.Dial(
+		network, raddr, syslog.LOG_NOTICE|syslog.LOG_AUTH, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{w: w}, nil
+}
+
+func (s *syslogSink) write(r *types.AuditRecord) error {
+	buf, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.records = appendLimited(s.records, r, syslogRecordsLen)
+	s.Unlock()
+
+	_, err = s.w.Write(buf)
+	return err
+}
+
+func (s *syslogSink) query(q *Query) ([]*types.AuditRecord, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	var records []*types.AuditRecord
+	for _, r := range s.records {
+		if q.Match(r) {
+			records = appendLimited(records, r, q.Limit)
+		}
+	}
+	return records, nil
+}
+
+func (s *syslogSink) close() error {
+	return s.w.Close()
+}
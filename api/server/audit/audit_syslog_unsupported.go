@@ -0,0 +1,9 @@
+// +build windows plan9 nacl
+
+package audit
+
+import "github.com/akutz/goof"
+
+func newSyslogSink(network, raddr, tag string) (sink, error) {
+	return nil, goof.New("syslog audit type unsupported")
+}
@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+func newTestRecord(i int) *types.AuditRecord {
+	outcome := types.AuditOutcomeSuccess
+	if i%2 == 1 {
+		outcome = types.AuditOutcomeFailure
+	}
+	return &types.AuditRecord{
+		Time:     time.Now().UTC(),
+		Service:  "vfs",
+		Route:    "volumeCreate",
+		Method:   "POST",
+		VolumeID: fmt.Sprintf("vfs-%03d", i),
+		Outcome:  outcome,
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libstorage-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newFileSink(path.Join(dir, DefaultFileName), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Auditor{sink: s}
+	defer a.Close()
+
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, a.Write(newTestRecord(i)))
+	}
+
+	records, err := a.Query(&Query{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 4)
+	assert.Equal(t, "vfs-000", records[0].VolumeID)
+
+	records, err = a.Query(&Query{Outcome: types.AuditOutcomeFailure})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "vfs-001", records[0].VolumeID)
+	assert.Equal(t, "vfs-003", records[1].VolumeID)
+
+	records, err = a.Query(&Query{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "vfs-003", records[0].VolumeID)
+
+	records, err = a.Query(&Query{VolumeID: "vfs-002"})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	records, err = a.Query(&Query{Since: time.Now().Add(time.Hour)})
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+}
+
+func TestFileSinkRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "libstorage-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a maximum size of one byte rotates the file on every write
+	filePath := path.Join(dir, DefaultFileName)
+	s, err := newFileSink(filePath, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Auditor{sink: s}
+	defer a.Close()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, a.Write(newTestRecord(i)))
+	}
+
+	assert.True(t, fileExists(filePath))
+	assert.True(t, fileExists(filePath+".1"))
+	assert.True(t, fileExists(filePath+".2"))
+	assert.False(t, fileExists(filePath+".3"))
+
+	records, err := a.Query(&Query{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "vfs-002", records[0].VolumeID)
+	assert.Equal(t, "vfs-004", records[2].VolumeID)
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server/audit"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/server/services"
+	"github.com/emccode/libstorage/api/types"
+)
+
+// auditHandler is a global HTTP filter for writing an audit record for each
+// request that mutates a resource.
+type auditHandler struct {
+	handler types.APIFunc
+	auditor *audit.Auditor
+}
+
+// NewAuditHandler returns a new global HTTP filter for writing an audit record
+// for each request that mutates a resource.
+func NewAuditHandler(auditor *audit.Auditor) types.Middleware {
+	return &auditHandler{auditor: auditor}
+}
+
+func (h *auditHandler) Name() string {
+	return "audit-handler"
+}
+
+func (h *auditHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&auditHandler{m, h.auditor}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *auditHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return h.handler(ctx, w, req, store)
+	}
+
+	rec := &types.AuditRecord{
+		Time:       time.Now().UTC(),
+		Method:     req.Method,
+		Service:    store.GetString("service"),
+		InstanceID: auditInstanceID(ctx, req, store.GetString("service")),
+	}
+	if route, ok := context.Route(ctx); ok {
+		rec.Route = route.GetName()
+	}
+	if tx, ok := context.Transaction(ctx); ok {
+		rec.TransactionID = tx.ID.String()
+	}
+	if user, ok := ctx.Value(context.UserKey).(string); ok {
+		rec.User = user
+	}
+
+	sw := &statusResponseWriter{ResponseWriter: w, code: http.StatusOK}
+	err := h.handler(ctx, sw, req, store)
+
+	rec.StatusCode = sw.code
+	if err != nil {
+		rec.StatusCode = http.StatusInternalServerError
+		rec.Error = err.Error()
+	}
+
+	// the record of an asynchronous operation is written once its task
+	// completes so that the record has the task's outcome and result
+	task, _ := store.Get(httputils.TaskKey).(*types.Task)
+	if task != nil && rec.StatusCode == http.StatusAccepted {
+		go func() {
+			<-services.TaskWaitC(ctx, task.ID)
+			h.write(ctx, rec, store, task)
+		}()
+		return err
+	}
+
+	h.write(ctx, rec, store, task)
+	return err
+}
+
+// write completes an audit record and writes it. The volume and snapshot IDs
+// are read from the store after the handler returns, since routes that
+// address volumes by name store the resolved volume ID, and the ID of a volume
+// or snapshot that the operation created is read from the task's result.
+func (h *auditHandler) write(
+	ctx types.Context,
+	rec *types.AuditRecord,
+	store types.Store,
+	task *types.Task) {
+
+	rec.Duration = int64(time.Since(rec.Time) / time.Millisecond)
+	rec.VolumeID = store.GetString("volumeID")
+	rec.SnapshotID = store.GetString("snapshotID")
+
+	if task != nil {
+		switch r := task.Result.(type) {
+		case *types.Volume:
+			rec.VolumeID = r.ID
+		case *types.Snapshot:
+			rec.SnapshotID = r.ID
+		}
+		if task.Error != nil && rec.Error == "" {
+			rec.Error = task.Error.Error()
+		}
+	}
+
+	if rec.StatusCode < http.StatusBadRequest && rec.Error == "" {
+		rec.Outcome = types.AuditOutcomeSuccess
+	} else {
+		rec.Outcome = types.AuditOutcomeFailure
+	}
+
+	if err := h.auditor.Write(rec); err != nil {
+		ctx.WithError(err).Error("error writing audit record")
+	}
+}
+
+// auditInstanceID returns the instance ID from the request's headers that
+// belongs to the service's driver. If the request does not target a service
+// the instance ID is returned only if the request includes exactly one.
+func auditInstanceID(
+	ctx types.Context, req *http.Request, serviceName string) string {
+
+	iids := types.InstanceIDMap{}
+	for _, h := range req.Header[types.InstanceIDHeader] {
+		iid := &types.InstanceID{}
+		if err := iid.UnmarshalText([]byte(h)); err != nil {
+			continue
+		}
+		iids[strings.ToLower(iid.Driver)] = iid
+	}
+
+	if serviceName != "" {
+		if svc := services.GetStorageService(ctx, serviceName); svc != nil {
+			if iid, ok := iids[strings.ToLower(svc.Driver().Name())]; ok {
+				return iid.ID
+			}
+		}
+		return ""
+	}
+
+	if len(iids) == 1 {
+		for _, iid := range iids {
+			return iid.ID
+		}
+	}
+	return ""
+}
@@ -52,6 +52,18 @@ func getStatus(err error) int {
 		return http.StatusUnauthorized
 	case *types.ErrNotFound:
 		return http.StatusNotFound
+	case *types.ErrBadPage:
+		return http.StatusBadRequest
+	case *types.ErrBadFilter:
+		return http.StatusBadRequest
+	case *types.ErrBadProfile:
+		return http.StatusBadRequest
+	case *types.ErrBadRequest:
+		return http.StatusBadRequest
+	case *types.ErrDuplicateName:
+		return http.StatusConflict
+	case *types.ErrUnsupportedCapability:
+		return http.StatusNotImplemented
 	default:
 		return http.StatusInternalServerError
 	}
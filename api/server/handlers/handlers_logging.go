@@ -17,10 +17,13 @@ import (
 	"github.com/akutz/gotil"
 
 	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const (
 	lowerhex = "0123456789abcdef"
+
+	adminQueryParam = "admin"
 )
 
 // loggingHandler is an HTTP logging handler for the libStorage service
@@ -67,7 +70,7 @@ func (h *loggingHandler) Handle(
 	var err error
 	var reqDump []byte
 	if h.logRequests {
-		if reqDump, err = httputil.DumpRequest(req, true); err != nil {
+		if reqDump, err = dumpRequest(req); err != nil {
 			return err
 		}
 	}
@@ -105,8 +108,9 @@ func logRequest(
 	req *http.Request,
 	reqDump []byte) {
 
+	rreq, rurl := redactRequest(req)
 	cll := buildCommonLogLine(
-		req, *req.URL, time.Now(), rec.Code, rec.Body.Len())
+		rreq, *rurl, time.Now(), rec.Code, rec.Body.Len())
 	fmt.Fprintln(w, string(cll))
 
 	if !l || len(reqDump) == 0 {
@@ -120,6 +124,41 @@ func logRequest(
 	gotil.WriteIndented(w, reqDump)
 }
 
+// dumpRequest dumps the request with its secrets redacted.
+func dumpRequest(req *http.Request) ([]byte, error) {
+	rreq, rurl := redactRequest(req)
+	rreq.URL = rurl
+	rreq.Header = http.Header{}
+	for k, v := range req.Header {
+		if strings.EqualFold(k, "Authorization") {
+			v = []string{apiconfig.Redacted}
+		}
+		rreq.Header[k] = v
+	}
+	buf, err := httputil.DumpRequest(rreq, true)
+	req.Body = rreq.Body
+	return buf, err
+}
+
+// redactRequest returns a shallow copy of the request and a copy of its URL
+// with the value of the admin token query parameter redacted.
+func redactRequest(req *http.Request) (*http.Request, *url.URL) {
+	rreq := *req
+	rurl := *req.URL
+	q := rurl.Query()
+	if _, ok := q[adminQueryParam]; !ok {
+		return &rreq, &rurl
+	}
+	q.Del(adminQueryParam)
+	rurl.RawQuery = q.Encode()
+	if rurl.RawQuery != "" {
+		rurl.RawQuery += "&"
+	}
+	rurl.RawQuery += adminQueryParam + "=" + apiconfig.Redacted
+	rreq.RequestURI = rurl.RequestURI()
+	return &rreq, &rurl
+}
+
 func logResponse(
 	w io.Writer,
 	rec *httptest.ResponseRecorder,
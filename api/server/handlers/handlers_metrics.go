@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server/metrics"
+	"github.com/emccode/libstorage/api/types"
+)
+
+// metricsHandler is a global HTTP filter for recording the number and latency
+// of requests by route.
+type metricsHandler struct {
+	handler types.APIFunc
+}
+
+// NewMetricsHandler returns a new global HTTP filter for recording the number
+// and latency of requests by route.
+func NewMetricsHandler() types.Middleware {
+	return &metricsHandler{}
+}
+
+func (h *metricsHandler) Name() string {
+	return "metrics-handler"
+}
+
+func (h *metricsHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&metricsHandler{m}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *metricsHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	routeName := ""
+	if route, ok := context.Route(ctx); ok {
+		routeName = route.GetName()
+	}
+
+	start := time.Now()
+	sw := &statusResponseWriter{ResponseWriter: w, code: http.StatusOK}
+	err := h.handler(ctx, sw, req, store)
+
+	code := sw.code
+	if err != nil {
+		code = http.StatusInternalServerError
+	}
+	metrics.HTTPRequests.Inc(routeName, strconv.Itoa(code))
+	metrics.HTTPRequestDuration.Observe(metrics.Since(start), routeName)
+
+	return err
+}
+
+// statusResponseWriter records the status code written to a response.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusResponseWriter) WriteHeader(code int) {
+	w.code = code
+	w.ResponseWriter.WriteHeader(code)
+}
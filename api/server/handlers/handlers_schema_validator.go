@@ -44,6 +44,16 @@ func (h *schemaValidator) Name() string {
 	return "schema-validator"
 }
 
+// RequestSchema returns the JSON schema of the request payload.
+func (h *schemaValidator) RequestSchema() []byte {
+	return h.reqSchema
+}
+
+// ResponseSchema returns the JSON schema of the response payload.
+func (h *schemaValidator) ResponseSchema() []byte {
+	return h.resSchema
+}
+
 func (h *schemaValidator) Handler(m types.APIFunc) types.APIFunc {
 	return (&schemaValidator{
 		m, h.reqSchema, h.resSchema, h.newReqObjFunc}).Handle
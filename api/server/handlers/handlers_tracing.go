@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/tracing"
+)
+
+// tracingHandler is a global HTTP filter for starting a span for each request
+// that is a child of the client span described by the traceparent header.
+type tracingHandler struct {
+	handler types.APIFunc
+}
+
+// NewTracingHandler returns a new global HTTP filter for starting a span for
+// each request that is a child of the client span described by the
+// traceparent header.
+func NewTracingHandler() types.Middleware {
+	return &tracingHandler{}
+}
+
+func (h *tracingHandler) Name() string {
+	return "tracing-handler"
+}
+
+func (h *tracingHandler) Handler(m types.APIFunc) types.APIFunc {
+	return (&tracingHandler{m}).Handle
+}
+
+// Handle is the type's Handler function.
+func (h *tracingHandler) Handle(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	ctx = tracing.WithRemoteParent(
+		ctx, req.Header.Get(types.TraceParentHeader))
+
+	routeName := ""
+	if route, ok := context.Route(ctx); ok {
+		routeName = route.GetName()
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "server."+routeName)
+	span.SetAttribute("method", req.Method)
+	span.SetAttribute("path", req.URL.Path)
+	if tx, ok := context.Transaction(ctx); ok {
+		span.SetAttribute("txID", tx.ID.String())
+	}
+
+	sw := &statusResponseWriter{ResponseWriter: w, code: http.StatusOK}
+	err := h.handler(ctx, sw, req, store)
+
+	span.SetAttribute("statusCode", strconv.Itoa(sw.code))
+	span.Finish(err)
+
+	return err
+}
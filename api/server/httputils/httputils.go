@@ -46,7 +46,13 @@ func WriteResponse(w http.ResponseWriter, rec *httptest.ResponseRecorder) {
 	w.Write(rec.Body.Bytes())
 }
 
-// WriteTask writes a task to a ResponseWriter.
+// TaskKey is the store key at which WriteTask saves the task it writes, so
+// that global filters may inspect the task once the handler returns.
+const TaskKey = "task"
+
+// WriteTask writes a task to a ResponseWriter. The continuation token of a
+// task's paged result is written to the NextTokenHeader header of a
+// synchronous response.
 func WriteTask(
 	ctx types.Context,
 	config gofig.Config,
@@ -55,6 +61,8 @@ func WriteTask(
 	task *types.Task,
 	okStatus int) error {
 
+	store.Set(TaskKey, task)
+
 	if store.GetBool("async") {
 		WriteJSON(w, http.StatusAccepted, task)
 		return nil
@@ -72,6 +80,9 @@ func WriteTask(
 		if task.Error != nil {
 			return task.Error
 		}
+		if pr, ok := task.Result.(*types.PagedResult); ok && pr.NextToken != "" {
+			w.Header().Set(types.NextTokenHeader, pr.NextToken)
+		}
 		WriteJSON(w, okStatus, task.Result)
 	case <-exeTimeout.C:
 		WriteJSON(w, http.StatusRequestTimeout, task)
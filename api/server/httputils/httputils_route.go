@@ -4,6 +4,73 @@ import (
 	"github.com/emccode/libstorage/api/types"
 )
 
+var (
+	// AdminParam documents the query parameter with the admin token that
+	// privileged routes require.
+	AdminParam = &types.RouteParam{
+		Name:        "admin",
+		Type:        "string",
+		Description: "An admin token",
+	}
+
+	// AttachmentsParam documents the query parameter that requests volume
+	// attachment information.
+	AttachmentsParam = &types.RouteParam{
+		Name:        "attachments",
+		Type:        "boolean",
+		Description: "Include the volumes' attachments",
+	}
+
+	// InstanceParam documents the query parameter that requests the
+	// information about the instance that makes the request.
+	InstanceParam = &types.RouteParam{
+		Name:        "instance",
+		Type:        "boolean",
+		Description: "Include the instance that makes the request",
+	}
+
+	// FilterParam documents the query parameter with an LDAP filter.
+	FilterParam = &types.RouteParam{
+		Name:        "filter",
+		Type:        "string",
+		Description: "An LDAP filter, such as (name=vol-01) or (size>=1024)",
+	}
+
+	// ForceParam documents the query parameter that forces an operation.
+	ForceParam = &types.RouteParam{
+		Name:        "force",
+		Type:        "boolean",
+		Description: "Force the operation",
+	}
+
+	// PageParams document the query parameters that request a page of a
+	// sorted listing.
+	PageParams = []*types.RouteParam{
+		&types.RouteParam{
+			Name:        "limit",
+			Type:        "integer",
+			Description: "The maximum number of objects in the page",
+		},
+		&types.RouteParam{
+			Name:        "offset",
+			Type:        "integer",
+			Description: "The number of objects that precede the page",
+		},
+		&types.RouteParam{
+			Name: "token",
+			Type: "string",
+			Description: "The continuation token from the " +
+				"Libstorage-Nexttoken header of the previous page",
+		},
+		&types.RouteParam{
+			Name: "sort",
+			Type: "string",
+			Description: "The fields by which objects are sorted, " +
+				"such as name,-size",
+		},
+	}
+)
+
 // route defines an individual API route.
 type route struct {
 	name        string
@@ -12,6 +79,7 @@ type route struct {
 	queries     []string
 	handler     types.APIFunc
 	middlewares []types.Middleware
+	doc         *types.RouteDoc
 }
 
 func (r *route) ContextLoggerField() (string, interface{}) {
@@ -82,6 +150,17 @@ func (r *route) GetMiddlewares() []types.Middleware {
 	return r.middlewares
 }
 
+// Doc documents the route.
+func (r *route) Doc(doc *types.RouteDoc) types.Route {
+	r.doc = doc
+	return r
+}
+
+// GetDoc returns the route's documentation.
+func (r *route) GetDoc() *types.RouteDoc {
+	return r.doc
+}
+
 // NewRoute initialies a new local route for the reouter
 func NewRoute(
 	name, method, path string,
@@ -0,0 +1,362 @@
+// Package metrics provides the collectors for the libStorage server's
+// metrics and writes them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ContentType is the content type of the Prometheus text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default histogram buckets, in seconds.
+var DefaultBuckets = []float64{
+	.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60,
+}
+
+var (
+	// HTTPRequests is the number of HTTP requests by route name and status
+	// code.
+	HTTPRequests = NewCounterVec(
+		"libstorage_http_requests_total",
+		"The number of HTTP requests by route and status code.",
+		"route", "code")
+
+	// HTTPRequestDuration is the latency of HTTP requests by route name.
+	HTTPRequestDuration = NewHistogramVec(
+		"libstorage_http_request_duration_seconds",
+		"The latency of HTTP requests by route.",
+		DefaultBuckets,
+		"route")
+
+	// DriverCalls is the number of storage driver calls by service, driver,
+	// and operation.
+	DriverCalls = NewCounterVec(
+		"libstorage_driver_calls_total",
+		"The number of storage driver calls by service and operation.",
+		"service", "driver", "op")
+
+	// DriverCallErrors is the number of storage driver calls that returned
+	// an error by service, driver, and operation.
+	DriverCallErrors = NewCounterVec(
+		"libstorage_driver_call_errors_total",
+		"The number of failed storage driver calls by service and operation.",
+		"service", "driver", "op")
+
+	// DriverCallDuration is the latency of storage driver calls by service,
+	// driver, and operation.
+	DriverCallDuration = NewHistogramVec(
+		"libstorage_driver_call_duration_seconds",
+		"The latency of storage driver calls by service and operation.",
+		DefaultBuckets,
+		"service", "driver", "op")
+
+	// ExecutorDownloads is the number of executor downloads by executor name.
+	ExecutorDownloads = NewCounterVec(
+		"libstorage_executor_downloads_total",
+		"The number of executor downloads by executor.",
+		"executor")
+
+	collectors = []Collector{
+		HTTPRequests,
+		HTTPRequestDuration,
+		DriverCalls,
+		DriverCallErrors,
+		DriverCallDuration,
+		ExecutorDownloads,
+	}
+)
+
+// Collector is a metric that can be written in the Prometheus text
+// exposition format.
+type Collector interface {
+
+	// Name returns the name of the metric.
+	Name() string
+
+	// WriteTo writes the metric to the provided writer.
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// WriteTo writes the server's metrics followed by the optional, additional
+// collectors to the provided writer.
+func WriteTo(w io.Writer, extra ...Collector) (int64, error) {
+	var total int64
+	for _, c := range append(collectors, extra...) {
+		n, err := c.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Since returns the number of seconds elapsed since the provided time.
+func Since(t time.Time) float64 {
+	return time.Since(t).Seconds()
+}
+
+type vec struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+	series map[string]*series
+	rwl    *sync.RWMutex
+}
+
+type series struct {
+	labels  string
+	value   float64
+	count   uint64
+	buckets []uint64
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{
+		name:   name,
+		help:   help,
+		typ:    typ,
+		labels: labels,
+		series: map[string]*series{},
+		rwl:    &sync.RWMutex{},
+	}
+}
+
+func (v *vec) Name() string {
+	return v.name
+}
+
+// get returns the series for the label values and must be called while
+// holding the vector's write lock.
+func (v *vec) get(labelValues []string, nbuckets int) *series {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf(
+			"metrics: %s: expected %d label values, got %d",
+			v.name, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := v.series[key]
+	if !ok {
+		s = &series{
+			labels:  formatLabels(v.labels, labelValues),
+			buckets: make([]uint64, nbuckets),
+		}
+		v.series[key] = s
+	}
+	return s
+}
+
+func (v *vec) sorted() []*series {
+	all := make([]*series, 0, len(v.series))
+	for _, s := range v.series {
+		all = append(all, s)
+	}
+	sort.Sort(byLabels(all))
+	return all
+}
+
+func (v *vec) writeHeader(w io.Writer) (int, error) {
+	return fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n",
+		v.name, escapeHelp(v.help), v.name, v.typ)
+}
+
+// CounterVec is a collection of counters partitioned by label values.
+type CounterVec struct {
+	*vec
+}
+
+// NewCounterVec returns a new counter vector.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec(name, help, "counter", labels)}
+}
+
+// Inc increments the counter for the provided label values by one.
+func (v *CounterVec) Inc(labelValues ...string) {
+	v.Add(1, labelValues...)
+}
+
+// Add increments the counter for the provided label values.
+func (v *CounterVec) Add(val float64, labelValues ...string) {
+	v.rwl.Lock()
+	defer v.rwl.Unlock()
+	v.get(labelValues, 0).value += val
+}
+
+// Value returns the counter's value for the provided label values.
+func (v *CounterVec) Value(labelValues ...string) float64 {
+	v.rwl.RLock()
+	defer v.rwl.RUnlock()
+	if s, ok := v.series[strings.Join(labelValues, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// WriteTo writes the counters to the provided writer.
+func (v *CounterVec) WriteTo(w io.Writer) (int64, error) {
+	v.rwl.RLock()
+	defer v.rwl.RUnlock()
+	var (
+		total int64
+		n     int
+		err   error
+	)
+	if n, err = v.writeHeader(w); err != nil {
+		return int64(n), err
+	}
+	total += int64(n)
+	for _, s := range v.sorted() {
+		n, err = fmt.Fprintf(
+			w, "%s%s %s\n", v.name, s.labels, formatFloat(s.value))
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// GaugeVec is a collection of gauges partitioned by label values.
+type GaugeVec struct {
+	*vec
+}
+
+// NewGaugeVec returns a new gauge vector.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec(name, help, "gauge", labels)}
+}
+
+// Set sets the gauge for the provided label values.
+func (v *GaugeVec) Set(val float64, labelValues ...string) {
+	v.rwl.Lock()
+	defer v.rwl.Unlock()
+	v.get(labelValues, 0).value = val
+}
+
+// WriteTo writes the gauges to the provided writer.
+func (v *GaugeVec) WriteTo(w io.Writer) (int64, error) {
+	return (&CounterVec{v.vec}).WriteTo(w)
+}
+
+// HistogramVec is a collection of histograms partitioned by label values.
+type HistogramVec struct {
+	*vec
+	bounds []float64
+}
+
+// NewHistogramVec returns a new histogram vector. The buckets are the upper
+// bounds of the histogram's buckets and must be sorted in increasing order.
+func NewHistogramVec(
+	name, help string,
+	buckets []float64,
+	labels ...string) *HistogramVec {
+
+	return &HistogramVec{newVec(name, help, "histogram", labels), buckets}
+}
+
+// Observe adds an observation to the histogram for the provided label values.
+func (v *HistogramVec) Observe(val float64, labelValues ...string) {
+	v.rwl.Lock()
+	defer v.rwl.Unlock()
+	s := v.get(labelValues, len(v.bounds))
+	s.count++
+	s.value += val
+	for i, b := range v.bounds {
+		if val <= b {
+			s.buckets[i]++
+		}
+	}
+}
+
+// WriteTo writes the histograms to the provided writer.
+func (v *HistogramVec) WriteTo(w io.Writer) (int64, error) {
+	v.rwl.RLock()
+	defer v.rwl.RUnlock()
+	var (
+		total int64
+		n     int
+		err   error
+	)
+	if n, err = v.writeHeader(w); err != nil {
+		return int64(n), err
+	}
+	total += int64(n)
+	for _, s := range v.sorted() {
+		for i, b := range v.bounds {
+			n, err = fmt.Fprintf(w, "%s_bucket%s %d\n",
+				v.name, withLE(s.labels, formatFloat(b)), s.buckets[i])
+			total += int64(n)
+			if err != nil {
+				return total, err
+			}
+		}
+		n, err = fmt.Fprintf(w,
+			"%[1]s_bucket%[2]s %[3]d\n%[1]s_sum%[4]s %[5]s\n"+
+				"%[1]s_count%[4]s %[3]d\n",
+			v.name, withLE(s.labels, "+Inf"), s.count,
+			s.labels, formatFloat(s.value))
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+type byLabels []*series
+
+func (s byLabels) Len() int           { return len(s) }
+func (s byLabels) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byLabels) Less(i, j int) bool { return s[i].labels < s[j].labels }
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, n := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, n, escapeLabelValue(values[i]))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(pairs, ","))
+}
+
+func withLE(labels, le string) string {
+	if labels == "" {
+		return fmt.Sprintf(`{le="%s"}`, le)
+	}
+	return fmt.Sprintf(`%s,le="%s"}`, labels[:len(labels)-1], le)
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelReplacer.Replace(s)
+}
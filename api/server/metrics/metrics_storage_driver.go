@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+type storageDriver struct {
+	types.StorageDriver
+	service string
+}
+
+// NewStorageDriver returns a storage driver that records the number, errors,
+// and latency of the calls made to the provided driver on behalf of the
+// named service.
+func NewStorageDriver(
+	service string, d types.StorageDriver) types.StorageDriver {
+
+	return &storageDriver{StorageDriver: d, service: service}
+}
+
+// observe records a call to the driver. It is meant to be deferred with the
+// time the call was started and a pointer to the call's error.
+func (d *storageDriver) observe(op string, start time.Time, err *error) {
+	driver := d.StorageDriver.Name()
+	DriverCalls.Inc(d.service, driver, op)
+	DriverCallDuration.Observe(Since(start), d.service, driver, op)
+	if *err != nil {
+		DriverCallErrors.Inc(d.service, driver, op)
+	}
+}
+
+func (d *storageDriver) NextDeviceInfo(
+	ctx types.Context) (ndi *types.NextDeviceInfo, err error) {
+
+	defer d.observe("NextDeviceInfo", time.Now(), &err)
+	return d.StorageDriver.NextDeviceInfo(ctx)
+}
+
+func (d *storageDriver) Type(
+	ctx types.Context) (st types.StorageType, err error) {
+
+	defer d.observe("Type", time.Now(), &err)
+	return d.StorageDriver.Type(ctx)
+}
+
+func (d *storageDriver) Capabilities(
+	ctx types.Context) (caps *types.Capabilities, err error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	defer d.observe("Capabilities", time.Now(), &err)
+	return sd.Capabilities(ctx)
+}
+
+func (d *storageDriver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (i *types.Instance, err error) {
+
+	defer d.observe("InstanceInspect", time.Now(), &err)
+	return d.StorageDriver.InstanceInspect(ctx, opts)
+}
+
+func (d *storageDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) (vols []*types.Volume, err error) {
+
+	defer d.observe("Volumes", time.Now(), &err)
+	return d.StorageDriver.Volumes(ctx, opts)
+}
+
+func (d *storageDriver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (vol *types.Volume, err error) {
+
+	defer d.observe("VolumeInspect", time.Now(), &err)
+	return d.StorageDriver.VolumeInspect(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	defer d.observe("VolumeCreate", time.Now(), &err)
+	return d.StorageDriver.VolumeCreate(ctx, name, opts)
+}
+
+func (d *storageDriver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID,
+	volumeName string,
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	defer d.observe("VolumeCreateFromSnapshot", time.Now(), &err)
+	return d.StorageDriver.VolumeCreateFromSnapshot(
+		ctx, snapshotID, volumeName, opts)
+}
+
+func (d *storageDriver) VolumeCopy(
+	ctx types.Context,
+	volumeID,
+	volumeName string,
+	opts types.Store) (vol *types.Volume, err error) {
+
+	defer d.observe("VolumeCopy", time.Now(), &err)
+	return d.StorageDriver.VolumeCopy(ctx, volumeID, volumeName, opts)
+}
+
+func (d *storageDriver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (vol *types.Volume, err error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverVolumeResizer)
+	if !ok {
+		return nil, types.ErrNotImplemented
+	}
+	defer d.observe("VolumeResize", time.Now(), &err)
+	return sd.VolumeResize(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID,
+	snapshotName string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	defer d.observe("VolumeSnapshot", time.Now(), &err)
+	return d.StorageDriver.VolumeSnapshot(ctx, volumeID, snapshotName, opts)
+}
+
+func (d *storageDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) (err error) {
+
+	defer d.observe("VolumeRemove", time.Now(), &err)
+	return d.StorageDriver.VolumeRemove(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (vol *types.Volume, tok string, err error) {
+
+	defer d.observe("VolumeAttach", time.Now(), &err)
+	return d.StorageDriver.VolumeAttach(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (vol *types.Volume, err error) {
+
+	defer d.observe("VolumeDetach", time.Now(), &err)
+	return d.StorageDriver.VolumeDetach(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) Snapshots(
+	ctx types.Context,
+	opts types.Store) (snaps []*types.Snapshot, err error) {
+
+	defer d.observe("Snapshots", time.Now(), &err)
+	return d.StorageDriver.Snapshots(ctx, opts)
+}
+
+func (d *storageDriver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	defer d.observe("SnapshotInspect", time.Now(), &err)
+	return d.StorageDriver.SnapshotInspect(ctx, snapshotID, opts)
+}
+
+func (d *storageDriver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID,
+	snapshotName,
+	destinationID string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	defer d.observe("SnapshotCopy", time.Now(), &err)
+	return d.StorageDriver.SnapshotCopy(
+		ctx, snapshotID, snapshotName, destinationID, opts)
+}
+
+func (d *storageDriver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (err error) {
+
+	defer d.observe("SnapshotRemove", time.Now(), &err)
+	return d.StorageDriver.SnapshotRemove(ctx, snapshotID, opts)
+}
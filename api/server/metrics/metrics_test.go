@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := NewCounterVec("test_total", "A test\ncounter.", "route", "code")
+	c.Inc("volumeCreate", "200")
+	c.Inc("volumeCreate", "200")
+	c.Inc("volume\"Attach", "500")
+
+	assert.EqualValues(t, 2, c.Value("volumeCreate", "200"))
+	assert.EqualValues(t, 0, c.Value("volumeCreate", "404"))
+
+	buf := &bytes.Buffer{}
+	_, err := c.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"# HELP test_total A test\\ncounter.\n"+
+			"# TYPE test_total counter\n"+
+			"test_total{route=\"volumeCreate\",code=\"200\"} 2\n"+
+			"test_total{route=\"volume\\\"Attach\",code=\"500\"} 1\n",
+		buf.String())
+}
+
+func TestHistogramVec(t *testing.T) {
+	h := NewHistogramVec("test_seconds", "A test histogram.",
+		[]float64{0.1, 1}, "route")
+	h.Observe(0.05, "volumes")
+	h.Observe(0.5, "volumes")
+	h.Observe(5, "volumes")
+
+	buf := &bytes.Buffer{}
+	_, err := h.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"# HELP test_seconds A test histogram.\n"+
+			"# TYPE test_seconds histogram\n"+
+			"test_seconds_bucket{route=\"volumes\",le=\"0.1\"} 1\n"+
+			"test_seconds_bucket{route=\"volumes\",le=\"1\"} 2\n"+
+			"test_seconds_bucket{route=\"volumes\",le=\"+Inf\"} 3\n"+
+			"test_seconds_sum{route=\"volumes\"} 5.55\n"+
+			"test_seconds_count{route=\"volumes\"} 3\n",
+		buf.String())
+}
+
+func TestGaugeVecNoLabels(t *testing.T) {
+	g := NewGaugeVec("test_depth", "A test gauge.")
+	g.Set(3)
+
+	buf := &bytes.Buffer{}
+	_, err := g.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		"# HELP test_depth A test gauge.\n"+
+			"# TYPE test_depth gauge\n"+
+			"test_depth 3\n",
+		buf.String())
+}
+
+type testDriver struct {
+	types.StorageDriver
+}
+
+func (d *testDriver) Name() string {
+	return "testDriver"
+}
+
+func (d *testDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if volumeID == "" {
+		return errors.New("missing volume ID")
+	}
+	return nil
+}
+
+func TestStorageDriver(t *testing.T) {
+	ctx := context.Background()
+	d := NewStorageDriver("testService", &testDriver{})
+
+	assert.NoError(t, d.VolumeRemove(ctx, "vol-000", nil))
+	assert.Error(t, d.VolumeRemove(ctx, "", nil))
+
+	assert.EqualValues(t, 2,
+		DriverCalls.Value("testService", "testDriver", "VolumeRemove"))
+	assert.EqualValues(t, 1,
+		DriverCallErrors.Value("testService", "testDriver", "VolumeRemove"))
+
+	buf := &bytes.Buffer{}
+	_, err := WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(),
+		"libstorage_driver_call_duration_seconds_count{"+
+			"service=\"testService\",driver=\"testDriver\","+
+			"op=\"VolumeRemove\"} 2\n")
+}
@@ -0,0 +1,490 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/schema"
+)
+
+const (
+	// Version is the version of the OpenAPI specification to which the
+	// document conforms.
+	Version = "3.0.0"
+
+	// Title is the title of the document.
+	Title = "libStorage API"
+
+	definitionsRef = "#/definitions/"
+	componentsRef  = "#/components/schemas/"
+	jsonType       = "application/json"
+)
+
+var pathParamRX = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       *Info                            `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components *Components                      `json:"components"`
+}
+
+// Info describes the API.
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Operation describes the routes that share a method and path. Most
+// operations describe a single route. The routes of an operation that
+// describes several routes differ by the query strings that they must match,
+// and the names of the routes are the operation's XRoutes.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Description string               `json:"description,omitempty"`
+	XRoutes     []string             `json:"x-routes,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter.
+type Parameter struct {
+	Name            string      `json:"name"`
+	In              string      `json:"in"`
+	Description     string      `json:"description,omitempty"`
+	Required        bool        `json:"required,omitempty"`
+	AllowEmptyValue bool        `json:"allowEmptyValue,omitempty"`
+	Schema          interface{} `json:"schema"`
+}
+
+// RequestBody describes a request payload.
+type RequestBody struct {
+	Content map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the payload of a request or response.
+type MediaType struct {
+	Schema interface{} `json:"schema"`
+}
+
+// Components are the schemas to which the operations refer.
+type Components struct {
+	Schemas map[string]interface{} `json:"schemas"`
+}
+
+// New returns an OpenAPI document that describes the routes of the routers.
+// The schemas of the document's components are the definitions of the
+// libStorage JSON schema.
+func New(routers ...types.Router) (*Document, error) {
+
+	schemas, err := components()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       &Info{Title: Title, Version: "unknown"},
+		Paths:      map[string]map[string]*Operation{},
+		Components: &Components{Schemas: schemas},
+	}
+	if api.Version != nil && api.Version.SemVer != "" {
+		doc.Info.Version = api.Version.SemVer
+	}
+
+	for _, r := range routers {
+		tag := strings.TrimSuffix(r.Name(), "-router")
+		for _, route := range r.Routes() {
+			op, err := newOperation(tag, route)
+			if err != nil {
+				return nil, err
+			}
+			path := Path(route)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*Operation{}
+			}
+			method := strings.ToLower(route.GetMethod())
+			if prev, ok := doc.Paths[path][method]; ok {
+				op = merge(prev, op)
+			}
+			doc.Paths[path][method] = op
+		}
+	}
+
+	return doc, nil
+}
+
+// Path returns the route's path in the document. The query strings that the
+// route must match are not part of the path, since OpenAPI does not allow
+// query strings in paths. They are the operation's query parameters instead.
+func Path(route types.Route) string {
+	return pathParamRX.ReplaceAllString(route.GetPath(), "{$1}")
+}
+
+func newOperation(tag string, route types.Route) (*Operation, error) {
+
+	doc := route.GetDoc()
+	if doc == nil {
+		doc = &types.RouteDoc{}
+	}
+
+	op := &Operation{
+		OperationID: route.GetName(),
+		Summary:     doc.Summary,
+		XRoutes:     []string{route.GetName()},
+		Tags:        []string{tag},
+		Responses:   map[string]*Response{},
+	}
+
+	for _, m := range pathParamRX.FindAllStringSubmatch(route.GetPath(), -1) {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:     m[1],
+			In:       "path",
+			Required: true,
+			Schema:   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	queries := route.GetQueries()
+	for i := 0; i+1 < len(queries); i += 2 {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:            queries[i],
+			In:              "query",
+			Description:     "Selects the " + route.GetName() + " route",
+			Required:        true,
+			AllowEmptyValue: queries[i+1] == "",
+			Schema:          map[string]interface{}{"type": "string"},
+		})
+	}
+
+	params := append([]*types.RouteParam{}, doc.Params...)
+	if doc.Async {
+		params = append(params, &types.RouteParam{
+			Name: "async",
+			Type: "boolean",
+			Description: "Respond with the route's task instead of " +
+				"waiting for the task to complete",
+		})
+	}
+	for _, p := range params {
+		op.Parameters = append(op.Parameters, &Parameter{
+			Name:        p.Name,
+			In:          "query",
+			Description: p.Description,
+			Schema:      map[string]interface{}{"type": p.Type},
+		})
+	}
+
+	var reqSchema, resSchema []byte
+	for _, m := range route.GetMiddlewares() {
+		if sm, ok := m.(types.SchemaMiddleware); ok {
+			reqSchema = sm.RequestSchema()
+			resSchema = sm.ResponseSchema()
+		}
+	}
+	if resSchema == nil {
+		resSchema = doc.ResponseSchema
+	}
+
+	if reqSchema != nil {
+		s, err := schemaRef(reqSchema)
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &RequestBody{
+			Content: map[string]*MediaType{jsonType: &MediaType{Schema: s}},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	res := &Response{Description: http.StatusText(status)}
+	if status != http.StatusNoContent && route.GetMethod() != http.MethodHead {
+
+		contentType := doc.ContentType
+		if contentType == "" {
+			contentType = jsonType
+		}
+		var s interface{} = map[string]interface{}{}
+		if resSchema != nil {
+			var err error
+			if s, err = schemaRef(resSchema); err != nil {
+				return nil, err
+			}
+		}
+		res.Content = map[string]*MediaType{contentType: &MediaType{Schema: s}}
+	}
+	op.Responses[strconv.Itoa(status)] = res
+
+	if doc.Async {
+		s, err := schemaRef(schema.TaskSchema)
+		if err != nil {
+			return nil, err
+		}
+		op.Responses[strconv.Itoa(http.StatusAccepted)] = &Response{
+			Description: http.StatusText(http.StatusAccepted),
+			Content: map[string]*MediaType{
+				jsonType: &MediaType{Schema: s},
+			},
+		}
+	}
+
+	s, err := schemaRef(schema.ErrorSchema)
+	if err != nil {
+		return nil, err
+	}
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{jsonType: &MediaType{Schema: s}},
+	}
+
+	return op, nil
+}
+
+// merge returns an operation that describes the routes of two operations that
+// share a method and path. The routes differ by the query strings that they
+// must match, so the query parameters of the merged operation are optional and
+// select the route that handles a request. The request and response schemas
+// of the merged operation are the alternatives of the routes' schemas.
+func merge(a, b *Operation) *Operation {
+
+	op := &Operation{
+		OperationID: a.OperationID + "Or" + strings.Title(b.OperationID),
+		XRoutes:     append(append([]string{}, a.XRoutes...), b.XRoutes...),
+		Tags:        a.Tags,
+		Responses:   map[string]*Response{},
+	}
+
+	desc := []string{}
+	for _, o := range []*Operation{a, b} {
+		if o.Description != "" {
+			desc = append(desc, o.Description)
+		} else {
+			desc = append(desc, fmt.Sprintf("%s: %s", o.OperationID, o.Summary))
+		}
+	}
+	op.Description = strings.Join(desc, "\n")
+
+	for _, o := range []*Operation{a, b} {
+		for _, p := range o.Parameters {
+			if hasParameter(op.Parameters, p) {
+				continue
+			}
+			mp := *p
+			if mp.In == "query" {
+				mp.Required = false
+			}
+			op.Parameters = append(op.Parameters, &mp)
+		}
+	}
+
+	switch {
+	case a.RequestBody == nil:
+		op.RequestBody = b.RequestBody
+	case b.RequestBody == nil:
+		op.RequestBody = a.RequestBody
+	default:
+		op.RequestBody = &RequestBody{
+			Content: mergeContent(a.RequestBody.Content, b.RequestBody.Content),
+		}
+	}
+
+	for _, o := range []*Operation{a, b} {
+		for status, res := range o.Responses {
+			prev, ok := op.Responses[status]
+			if !ok {
+				op.Responses[status] = res
+				continue
+			}
+			op.Responses[status] = &Response{
+				Description: prev.Description,
+				Content:     mergeContent(prev.Content, res.Content),
+			}
+		}
+	}
+
+	return op
+}
+
+func hasParameter(params []*Parameter, p *Parameter) bool {
+	for _, pp := range params {
+		if pp.Name == p.Name && pp.In == p.In {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeContent returns the content whose schema for each media type is the
+// alternatives of the schemas of two contents.
+func mergeContent(a, b map[string]*MediaType) map[string]*MediaType {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	c := map[string]*MediaType{}
+	for k, v := range a {
+		c[k] = v
+	}
+	for k, v := range b {
+		if prev, ok := c[k]; ok {
+			c[k] = &MediaType{Schema: oneOf(prev.Schema, v.Schema)}
+		} else {
+			c[k] = v
+		}
+	}
+	return c
+}
+
+// oneOf returns a schema that matches either of two schemas. Equal schemas
+// are not repeated.
+func oneOf(a, b interface{}) interface{} {
+	if reflect.DeepEqual(a, b) {
+		return a
+	}
+	alts := []interface{}{}
+	for _, s := range []interface{}{a, b} {
+		if m, ok := s.(map[string]interface{}); ok && len(m) == 1 {
+			if v, ok := m["oneOf"].([]interface{}); ok {
+				alts = append(alts, v...)
+				continue
+			}
+		}
+		alts = append(alts, s)
+	}
+	uniq := []interface{}{}
+	for _, s := range alts {
+		dup := false
+		for _, u := range uniq {
+			if reflect.DeepEqual(s, u) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			uniq = append(uniq, s)
+		}
+	}
+	if len(uniq) == 1 {
+		return uniq[0]
+	}
+	return map[string]interface{}{"oneOf": uniq}
+}
+
+// components returns the definitions of the libStorage JSON schema as
+// OpenAPI schemas.
+func components() (map[string]interface{}, error) {
+	var root struct {
+		Definitions map[string]interface{} `json:"definitions"`
+	}
+	if err := json.Unmarshal([]byte(schema.JSONSchema), &root); err != nil {
+		return nil, goof.WithError("error parsing json schema", err)
+	}
+	schemas := map[string]interface{}{}
+	for name, s := range root.Definitions {
+		schemas[name] = convert(s)
+	}
+	return schemas, nil
+}
+
+// schemaRef returns the OpenAPI schema for one of the JSON schemas in the
+// schema package, which refer to the definitions of the libStorage JSON
+// schema.
+func schemaRef(s []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(s, &v); err != nil {
+		return nil, goof.WithError("error parsing json schema", err)
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		delete(m, "$schema")
+	}
+	return convert(v), nil
+}
+
+// convert converts a JSON schema to an OpenAPI schema. References to the
+// libStorage JSON schema's definitions become references to the document's
+// components, and the keywords that OpenAPI does not support are replaced
+// by their OpenAPI equivalents.
+func convert(v interface{}) interface{} {
+
+	s, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+
+	out := map[string]interface{}{}
+	for k, v := range s {
+		switch k {
+		case "$ref":
+			ref, _ := v.(string)
+			if i := strings.Index(ref, definitionsRef); i >= 0 {
+				ref = componentsRef + ref[i+len(definitionsRef):]
+			}
+			out[k] = ref
+		case "properties":
+			props := map[string]interface{}{}
+			if m, ok := v.(map[string]interface{}); ok {
+				for name, p := range m {
+					props[name] = convert(p)
+				}
+			}
+			out[k] = props
+		case "patternProperties":
+			if m, ok := v.(map[string]interface{}); ok {
+				for _, p := range m {
+					out["additionalProperties"] = convert(p)
+				}
+			}
+		case "additionalProperties":
+			if _, ok := s["patternProperties"]; !ok {
+				out[k] = convert(v)
+			}
+		case "items", "not":
+			out[k] = convert(v)
+		case "anyOf", "allOf", "oneOf":
+			items := []interface{}{}
+			if a, ok := v.([]interface{}); ok {
+				for _, i := range a {
+					if m, ok := i.(map[string]interface{}); ok &&
+						m["type"] == "null" {
+						out["nullable"] = true
+						continue
+					}
+					items = append(items, convert(i))
+				}
+			}
+			out[k] = items
+		default:
+			out[k] = v
+		}
+	}
+
+	if out["type"] == "array" && out["items"] == nil {
+		out["items"] = map[string]interface{}{}
+	}
+
+	return out
+}
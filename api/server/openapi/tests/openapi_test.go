@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/openapi"
+	"github.com/emccode/libstorage/api/types"
+
+	// load the routers
+	_ "github.com/emccode/libstorage/imports/routers"
+)
+
+var refRX = regexp.MustCompile(`"\$ref":\s*"#/components/schemas/([^"]+)"`)
+
+func newRouters() []types.Router {
+	config := gofig.New()
+	routers := []types.Router{}
+	for r := range registry.Routers() {
+		r.Init(config)
+		routers = append(routers, r)
+	}
+	return routers
+}
+
+func TestEveryRouteDocumented(t *testing.T) {
+	routers := newRouters()
+	if !assert.NotEmpty(t, routers) {
+		t.FailNow()
+	}
+
+	doc, err := openapi.New(routers...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	operationIDs := map[string]bool{}
+	for _, r := range routers {
+		for _, route := range r.Routes() {
+			name := route.GetName()
+
+			rd := route.GetDoc()
+			if assert.NotNil(t, rd, name) {
+				assert.NotEmpty(t, rd.Summary, name)
+			}
+
+			path := openapi.Path(route)
+			op := doc.Paths[path][strings.ToLower(route.GetMethod())]
+			if !assert.NotNil(t, op, "%s %s", route.GetMethod(), path) {
+				continue
+			}
+			assert.Contains(t, op.XRoutes, name)
+			assert.False(t, operationIDs[name], "duplicate route %s", name)
+			operationIDs[name] = true
+
+			for _, p := range op.Parameters {
+				if p.In == "path" {
+					assert.Contains(t, path, "{"+p.Name+"}", name)
+				}
+			}
+		}
+	}
+}
+
+func TestSchemaRefs(t *testing.T) {
+	doc, err := openapi.New(newRouters()...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	buf, err := json.Marshal(doc)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotContains(t, string(buf), "#/definitions/")
+	assert.NotContains(t, string(buf), "patternProperties")
+
+	refs := refRX.FindAllStringSubmatch(string(buf), -1)
+	assert.NotEmpty(t, refs)
+	for _, m := range refs {
+		assert.Contains(t, doc.Components.Schemas, m[1])
+	}
+
+	vols := doc.Paths["/volumes/{service}"]["get"]
+	if assert.NotNil(t, vols) {
+		assert.Contains(t, vols.Responses, "200")
+		assert.Contains(t, vols.Responses, "202")
+		assert.Contains(t, vols.Responses, "default")
+	}
+
+	for path := range doc.Paths {
+		assert.NotContains(t, path, "?")
+	}
+
+	attach := doc.Paths["/volumes/{service}/{volumeID}"]["post"]
+	if assert.NotNil(t, attach) {
+		assert.Contains(t, attach.XRoutes, "volumeAttach")
+		assert.Contains(t, attach.XRoutes, "volumeDetach")
+		assert.NotNil(t, attach.RequestBody)
+
+		queries := map[string]bool{}
+		for _, p := range attach.Parameters {
+			if p.In == "query" {
+				queries[p.Name] = p.Required
+			}
+		}
+		required, ok := queries["attach"]
+		assert.True(t, ok)
+		assert.False(t, required)
+	}
+
+	inspect := doc.Paths["/volumes/{service}/{volumeID}"]["get"]
+	if assert.NotNil(t, inspect) {
+		assert.Equal(t, "volumeInspect", inspect.OperationID)
+	}
+}
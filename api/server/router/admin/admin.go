@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/types"
+)
+
+func init() {
+	registry.RegisterRouter(&router{})
+}
+
+type router struct {
+	routes []types.Route
+}
+
+func (r *router) Name() string {
+	return "admin-router"
+}
+
+func (r *router) Init(config gofig.Config) {
+	r.initRoutes()
+}
+
+// Routes returns the available routes.
+func (r *router) Routes() []types.Route {
+	return r.routes
+}
+
+func (r *router) initRoutes() {
+
+	r.routes = []types.Route{
+
+		// GET
+		httputils.NewGetRoute(
+			"adminTokens",
+			"/admin/tokens",
+			r.adminTokens).Doc(&types.RouteDoc{
+			Summary: "List the admin tokens",
+			Params:  []*types.RouteParam{httputils.AdminParam},
+		}),
+
+		// POST
+		httputils.NewPostRoute(
+			"adminTokenRotate",
+			"/admin/tokens/{name}",
+			r.adminTokenRotate).Doc(&types.RouteDoc{
+			Summary: "Create or replace an admin token",
+			Params: []*types.RouteParam{
+				httputils.AdminParam,
+				&types.RouteParam{
+					Name: "expires",
+					Type: "string",
+					Description: "When the token expires, an RFC3339 " +
+						"timestamp or a duration from now such as 24h",
+				},
+			},
+		}),
+
+		// DELETE
+		httputils.NewDeleteRoute(
+			"adminTokenRemove",
+			"/admin/tokens/{name}",
+			r.adminTokenRemove).Doc(&types.RouteDoc{
+			Summary: "Remove an admin token",
+			Params:  []*types.RouteParam{httputils.AdminParam},
+			Status:  http.StatusNoContent,
+		}),
+	}
+}
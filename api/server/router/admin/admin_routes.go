@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/server/admin"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+// adminTokens returns the names and expiration times of the server's admin
+// tokens.
+func (r *router) adminTokens(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	identity, err := validate(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, identity.Tokens())
+	return nil
+}
+
+// adminTokenRotate creates or replaces the named admin token. The optional
+// expires query parameter is an RFC3339 timestamp or a duration from now.
+func (r *router) adminTokenRotate(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	identity, err := validate(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	var expires int64
+	if v := req.URL.Query().Get("expires"); v != "" {
+		if expires, err = admin.ParseExpires(v); err != nil {
+			return utils.NewBadRequestErr(
+				fmt.Sprintf("invalid expiration time: %s", v),
+				goof.Fields{"expires": v}, err)
+		}
+	}
+
+	token, err := identity.Rotate(store.GetString("name"), expires)
+	if err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, token)
+	return nil
+}
+
+// adminTokenRemove removes the named admin token.
+func (r *router) adminTokenRemove(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	identity, err := validate(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	if err := identity.Remove(store.GetString("name")); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func validate(ctx types.Context, store types.Store) (*admin.Identity, error) {
+	if err := admin.Validate(ctx, store.GetString("admin")); err != nil {
+		return nil, err
+	}
+	return admin.Get(ctx), nil
+}
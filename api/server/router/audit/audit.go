@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/types"
+)
+
+func init() {
+	registry.RegisterRouter(&router{})
+}
+
+type router struct {
+	routes []types.Route
+}
+
+func (r *router) Name() string {
+	return "audit-router"
+}
+
+func (r *router) Init(config gofig.Config) {
+	r.initRoutes()
+}
+
+// Routes returns the available routes.
+func (r *router) Routes() []types.Route {
+	return r.routes
+}
+
+func (r *router) initRoutes() {
+
+	r.routes = []types.Route{
+
+		// GET
+		httputils.NewGetRoute(
+			"audit",
+			"/audit",
+			r.audit).Doc(&types.RouteDoc{
+			Summary: "Query the audit log",
+			Params: []*types.RouteParam{
+				httputils.AdminParam,
+				auditParam("service", "string", "The name of a service"),
+				auditParam("route", "string", "The name of a route"),
+				auditParam("volumeID", "string", "The ID of a volume"),
+				auditParam("snapshotID", "string", "The ID of a snapshot"),
+				auditParam("instanceID", "string", "The ID of an instance"),
+				auditParam("user", "string", "The name of a user"),
+				auditParam("outcome", "string", "success or failure"),
+				auditParam("since", "string",
+					"An RFC3339 timestamp or a duration before now, "+
+						"such as 1h"),
+				auditParam("limit", "integer",
+					"The maximum number of records"),
+			},
+		}),
+	}
+}
+
+func auditParam(name, paramType, description string) *types.RouteParam {
+	return &types.RouteParam{
+		Name:        name,
+		Type:        paramType,
+		Description: description,
+	}
+}
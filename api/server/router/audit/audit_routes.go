@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/server/admin"
+	"github.com/emccode/libstorage/api/server/audit"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+// audit returns the audit records that match the query parameters.
+func (r *router) audit(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := admin.Validate(ctx, store.GetString("admin")); err != nil {
+		return err
+	}
+
+	auditor := audit.Get(ctx)
+	if auditor == nil {
+		return utils.NewNotFoundError("audit")
+	}
+
+	q, err := parseQuery(req)
+	if err != nil {
+		return err
+	}
+
+	records, err := auditor.Query(q)
+	if err != nil {
+		return err
+	}
+	if records == nil {
+		records = []*types.AuditRecord{}
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, records)
+	return nil
+}
+
+// parseQuery parses the audit query from the request's query string. The
+// since parameter may be an RFC3339 timestamp or a duration relative to the
+// current time, such as 1h.
+func parseQuery(req *http.Request) (*audit.Query, error) {
+
+	v := req.URL.Query()
+	q := &audit.Query{
+		Service:    v.Get("service"),
+		Route:      v.Get("route"),
+		VolumeID:   v.Get("volumeID"),
+		SnapshotID: v.Get("snapshotID"),
+		InstanceID: v.Get("instanceID"),
+		User:       v.Get("user"),
+		Outcome:    types.AuditOutcome(v.Get("outcome")),
+	}
+
+	if since := v.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			q.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil {
+			q.Since = time.Now().Add(-d)
+		} else {
+			return nil, goof.WithField("since", since, "invalid since")
+		}
+	}
+
+	if limit := v.Get("limit"); limit != "" {
+		i, err := strconv.Atoi(limit)
+		if err != nil || i < 0 {
+			return nil, goof.WithField("limit", limit, "invalid limit")
+		}
+		q.Limit = i
+	}
+
+	return q, nil
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/emccode/libstorage/api/registry"
 	"github.com/emccode/libstorage/api/server/httputils"
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/schema"
 )
 
 func init() {
@@ -37,18 +38,26 @@ func (r *router) initRoutes() {
 		httputils.NewGetRoute(
 			"executors",
 			"/executors",
-			r.executors),
+			r.executors).Doc(&types.RouteDoc{
+			Summary:        "List the executors",
+			ResponseSchema: schema.ExecutorInfoMapSchema,
+		}),
 
 		// GET
 		httputils.NewGetRoute(
 			"executorInspect",
 			"/executors/{executor}",
-			r.executorInspect),
+			r.executorInspect).Doc(&types.RouteDoc{
+			Summary:     "Download an executor",
+			ContentType: "application/octet-stream",
+		}),
 
 		// HEAD
 		httputils.NewHeadRoute(
 			"executorHead",
 			"/executors/{executor}",
-			r.executorHead),
+			r.executorHead).Doc(&types.RouteDoc{
+			Summary: "Get an executor's size, checksum, and modification time",
+		}),
 	}
 }
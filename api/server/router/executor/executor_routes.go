@@ -11,6 +11,7 @@ import (
 
 	"github.com/emccode/libstorage/api/server/executors"
 	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/server/metrics"
 	"github.com/emccode/libstorage/api/types"
 )
 
@@ -38,6 +39,7 @@ func (r *router) executorInspect(
 	if err != nil {
 		return err
 	}
+	metrics.ExecutorDownloads.Inc(ei.Name)
 
 	return writeFile(w, ei)
 }
@@ -1,6 +1,8 @@
 package help
 
 import (
+	"net/http"
+
 	"github.com/akutz/gofig"
 
 	"github.com/emccode/libstorage/api/registry"
@@ -34,9 +36,41 @@ func (r *router) Routes() []types.Route {
 func (r *router) initRoutes() {
 	r.routes = []types.Route{
 		// GET
-		httputils.NewGetRoute("version", "/help", r.helpInspect),
-		httputils.NewGetRoute("version", "/help/config", r.configInspect),
-		httputils.NewGetRoute("version", "/help/env", r.envInspect),
-		httputils.NewGetRoute("version", "/help/version", r.versionInspect),
+		httputils.NewGetRoute("help", "/help", r.helpInspect).Doc(
+			&types.RouteDoc{
+				Summary: "List the help routes",
+			}),
+		httputils.NewGetRoute("config", "/help/config", r.configInspect).Doc(
+			&types.RouteDoc{
+				Summary: "Get the server's config with its secrets redacted",
+				Params:  []*types.RouteParam{httputils.AdminParam},
+			}),
+		httputils.NewGetRoute("env", "/help/env", r.envInspect).Doc(
+			&types.RouteDoc{
+				Summary: "Get the server's environment with its secrets " +
+					"redacted",
+				Params: []*types.RouteParam{httputils.AdminParam},
+			}),
+		httputils.NewGetRoute("version", "/help/version", r.versionInspect).Doc(
+			&types.RouteDoc{
+				Summary: "Get the server's version",
+			}),
+		httputils.NewGetRoute("cacheStats", "/help/cache", r.cacheInspect).Doc(
+			&types.RouteDoc{
+				Summary: "Get the cache statistics of the services",
+			}),
+		httputils.NewGetRoute(
+			"openapi", "/help/openapi.json", r.openAPIInspect).Doc(
+			&types.RouteDoc{
+				Summary: "Get the OpenAPI document that describes the API",
+			}),
+
+		// POST
+		httputils.NewPostRoute("reload", "/help/reload", r.reload).Doc(
+			&types.RouteDoc{
+				Summary: "Reload the server's config",
+				Params:  []*types.RouteParam{httputils.AdminParam},
+				Status:  http.StatusNoContent,
+			}),
 	}
 }
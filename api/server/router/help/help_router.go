@@ -7,9 +7,14 @@ import (
 
 	"github.com/emccode/libstorage/api"
 	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/admin"
 	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/server/openapi"
+	"github.com/emccode/libstorage/api/server/services"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 func (r *router) helpInspect(
@@ -28,6 +33,9 @@ func (r *router) helpInspect(
 		fmt.Sprintf("%s/help/config", rootURL),
 		fmt.Sprintf("%s/help/env", rootURL),
 		fmt.Sprintf("%s/help/version", rootURL),
+		fmt.Sprintf("%s/help/cache", rootURL),
+		fmt.Sprintf("%s/help/reload", rootURL),
+		fmt.Sprintf("%s/help/openapi.json", rootURL),
 	}
 
 	httputils.WriteJSON(w, http.StatusOK, reply)
@@ -44,42 +52,94 @@ func (r *router) versionInspect(
 	return nil
 }
 
-func (r *router) configInspect(
+// openAPIInspect writes the OpenAPI document that describes the routes of
+// the registered routers.
+func (r *router) openAPIInspect(
 	ctx types.Context,
 	w http.ResponseWriter,
 	req *http.Request,
 	store types.Store) error {
 
-	expectedToken, ok := ctx.Value(context.AdminTokenKey).(string)
-	if !ok {
-		return utils.NewBadAdminTokenError("missing")
+	routers := []types.Router{}
+	for rr := range registry.Routers() {
+		routers = append(routers, rr)
 	}
 
-	actualToken := store.GetString("admin")
-	if expectedToken != actualToken {
-		return utils.NewBadAdminTokenError(actualToken)
+	doc, err := openapi.New(routers...)
+	if err != nil {
+		return err
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, r.config.AllSettings())
+	httputils.WriteJSON(w, http.StatusOK, doc)
 	return nil
 }
 
-func (r *router) envInspect(
+func (r *router) cacheInspect(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	reply := map[string]*types.CacheStats{}
+	for svc := range services.StorageServices(ctx) {
+		if c, ok := svc.Driver().(types.StorageDriverCache); ok {
+			reply[svc.Name()] = c.CacheStats()
+		}
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, reply)
+	return nil
+}
+
+func (r *router) configInspect(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := admin.Validate(ctx, store.GetString("admin")); err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(
+		w, http.StatusOK, apiconfig.Redact(services.Config(ctx)))
+	return nil
+}
+
+func (r *router) reload(
 	ctx types.Context,
 	w http.ResponseWriter,
 	req *http.Request,
 	store types.Store) error {
 
-	expectedToken, ok := ctx.Value(context.AdminTokenKey).(string)
+	if err := admin.Validate(ctx, store.GetString("admin")); err != nil {
+		return err
+	}
+
+	reload, ok := ctx.Value(context.ReloadKey).(func() error)
 	if !ok {
-		return utils.NewBadAdminTokenError("missing")
+		return utils.NewNotFoundError("reload")
+	}
+
+	if err := reload(); err != nil {
+		return err
 	}
 
-	actualToken := store.GetString("admin")
-	if expectedToken != actualToken {
-		return utils.NewBadAdminTokenError(actualToken)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (r *router) envInspect(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	if err := admin.Validate(ctx, store.GetString("admin")); err != nil {
+		return err
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, os.Environ())
+	httputils.WriteJSON(
+		w, http.StatusOK, apiconfig.RedactEnv(os.Environ()))
 	return nil
 }
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/emccode/libstorage/api/server/metrics"
+	"github.com/emccode/libstorage/api/server/services"
+	"github.com/emccode/libstorage/api/types"
+)
+
+var taskStates = []types.TaskState{
+	types.TaskStateQueued,
+	types.TaskStateRunning,
+	types.TaskStateSuccess,
+	types.TaskStateError,
+}
+
+// metrics writes the server's metrics in the Prometheus text exposition
+// format.
+func (r *router) metrics(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	counts := map[types.TaskState]int{}
+	for t := range services.Tasks(ctx) {
+		state := t.State
+		if state == "" {
+			state = types.TaskStateQueued
+		}
+		counts[state]++
+	}
+
+	tasks := metrics.NewGaugeVec(
+		"libstorage_tasks",
+		"The number of tracked tasks by state.",
+		"state")
+	for _, s := range taskStates {
+		tasks.Set(float64(counts[s]), string(s))
+	}
+
+	queueDepth := metrics.NewGaugeVec(
+		"libstorage_task_queue_depth",
+		"The number of tasks that are queued or running.")
+	queueDepth.Set(float64(
+		counts[types.TaskStateQueued] + counts[types.TaskStateRunning]))
+
+	buf := &bytes.Buffer{}
+	if _, err := metrics.WriteTo(buf, tasks, queueDepth); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -32,6 +32,8 @@ func (r *router) Routes() []types.Route {
 func (r *router) initRoutes() {
 	r.routes = []types.Route{
 		// GET
-		httputils.NewGetRoute("root", "/", r.root),
+		httputils.NewGetRoute("root", "/", r.root).Doc(&types.RouteDoc{
+			Summary: "List the resource routes",
+		}),
 	}
 }
@@ -40,13 +40,33 @@ func (r *router) initRoutes() {
 			"services",
 			"/services",
 			r.servicesList,
-			handlers.NewSchemaValidator(nil, schema.ServiceInfoMapSchema, nil)),
+			handlers.NewSchemaValidator(nil, schema.ServiceInfoMapSchema, nil)).Doc(&types.RouteDoc{
+			Summary: "List the services",
+			Params: []*types.RouteParam{
+				httputils.InstanceParam,
+				httputils.FilterParam,
+			},
+		}),
 
 		httputils.NewGetRoute(
 			"serviceInspect",
 			"/services/{service}",
 			r.serviceInspect,
 			handlers.NewServiceValidator(),
-			handlers.NewSchemaValidator(nil, schema.ServiceInfoSchema, nil)),
+			handlers.NewSchemaValidator(nil, schema.ServiceInfoSchema, nil)).Doc(&types.RouteDoc{
+			Summary: "Get a service",
+			Params: []*types.RouteParam{
+				httputils.InstanceParam,
+			},
+		}),
+
+		httputils.NewGetRoute(
+			"serviceProfiles",
+			"/services/{service}/profiles",
+			r.serviceProfiles,
+			handlers.NewServiceValidator(),
+			handlers.NewSchemaValidator(nil, schema.VolumeProfileMapSchema, nil)).Doc(&types.RouteDoc{
+			Summary: "List the volume profiles of a service",
+		}),
 	}
 }
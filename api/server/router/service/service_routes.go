@@ -8,6 +8,7 @@ import (
 	"github.com/emccode/libstorage/api/server/services"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
 )
 
 func (r *router) servicesList(
@@ -16,6 +17,11 @@ func (r *router) servicesList(
 	req *http.Request,
 	store types.Store) error {
 
+	filter, err := filters.FromStore(store)
+	if err != nil {
+		return err
+	}
+
 	reply := map[string]*types.ServiceInfo{}
 	for service := range services.StorageServices(ctx) {
 		ctx := context.WithStorageService(ctx, service)
@@ -23,6 +29,15 @@ func (r *router) servicesList(
 		if err != nil {
 			return err
 		}
+		if filter != nil {
+			ok, err := filters.Match(filter, si)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+		}
 		reply[si.Name] = si
 	}
 
@@ -45,6 +60,21 @@ func (r *router) serviceInspect(
 	return nil
 }
 
+func (r *router) serviceProfiles(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	service := context.MustService(ctx)
+	profiles, err := utils.VolumeProfiles(service.Config())
+	if err != nil {
+		return err
+	}
+	httputils.WriteJSON(w, http.StatusOK, profiles)
+	return nil
+}
+
 func toServiceInfo(
 	ctx types.Context,
 	service types.StorageService,
@@ -74,14 +104,19 @@ func toServiceInfo(
 	if err != nil {
 		return nil, err
 	}
+	caps, err := utils.DriverCapabilities(ctx, d)
+	if err != nil {
+		return nil, err
+	}
 
 	return &types.ServiceInfo{
 		Name:     service.Name(),
 		Instance: instance,
 		Driver: &types.DriverInfo{
-			Name:       d.Name(),
-			Type:       st,
-			NextDevice: nd,
+			Name:         d.Name(),
+			Type:         st,
+			NextDevice:   nd,
+			Capabilities: caps,
 		},
 	}, nil
 }
@@ -1,6 +1,8 @@
 package snapshot
 
 import (
+	"net/http"
+
 	"github.com/akutz/gofig"
 
 	"github.com/emccode/libstorage/api/registry"
@@ -45,7 +47,13 @@ func (r *router) initRoutes() {
 			r.snapshots,
 			handlers.NewSchemaValidator(
 				nil, schema.ServiceSnapshotMapSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "List the snapshots of all services",
+			Params: append([]*types.RouteParam{
+				httputils.FilterParam,
+			}, httputils.PageParams...),
+			Async: true,
+		}),
 
 		// get all snapshots from a specific service
 		httputils.NewGetRoute(
@@ -55,7 +63,13 @@ func (r *router) initRoutes() {
 			handlers.NewServiceValidator(),
 			handlers.NewSchemaValidator(
 				nil, schema.SnapshotMapSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "List a service's snapshots",
+			Params: append([]*types.RouteParam{
+				httputils.FilterParam,
+			}, httputils.PageParams...),
+			Async: true,
+		}),
 
 		// get a specific snapshot from a specific service
 		httputils.NewGetRoute(
@@ -64,7 +78,10 @@ func (r *router) initRoutes() {
 			r.snapshotInspect,
 			handlers.NewServiceValidator(),
 			handlers.NewSchemaValidator(nil, schema.SnapshotSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "Get a snapshot",
+			Async:   true,
+		}),
 
 		// POST
 
@@ -79,7 +96,11 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCreateRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("create"),
+		).Queries("create").Doc(&types.RouteDoc{
+			Summary: "Create a volume from a snapshot",
+			Status:  http.StatusCreated,
+			Async:   true,
+		}),
 
 		// copy snapshot
 		httputils.NewPostRoute(
@@ -94,7 +115,11 @@ func (r *router) initRoutes() {
 					return &types.SnapshotCopyRequest{}
 				}),
 			handlers.NewPostArgsHandler(),
-		).Queries("copy"),
+		).Queries("copy").Doc(&types.RouteDoc{
+			Summary: "Copy a snapshot",
+			Status:  http.StatusCreated,
+			Async:   true,
+		}),
 
 		// DELETE
 		httputils.NewDeleteRoute(
@@ -102,6 +127,10 @@ func (r *router) initRoutes() {
 			"/snapshots/{service}/{snapshotID}",
 			r.snapshotRemove,
 			handlers.NewServiceValidator(),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "Remove a snapshot",
+			Status:  http.StatusResetContent,
+			Async:   true,
+		}),
 	}
 }
@@ -10,6 +10,8 @@ import (
 	"github.com/emccode/libstorage/api/server/services"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
+	"github.com/emccode/libstorage/api/utils/paging"
 	"github.com/emccode/libstorage/api/utils/schema"
 )
 
@@ -19,6 +21,16 @@ func (r *router) snapshots(
 	req *http.Request,
 	store types.Store) error {
 
+	filter, err := filters.FromStore(store)
+	if err != nil {
+		return err
+	}
+
+	page, err := paging.ParsePage(req.URL.Query(), paging.SnapshotSortFields)
+	if err != nil {
+		return err
+	}
+
 	var (
 		tasks   = map[string]*types.Task{}
 		taskIDs []int
@@ -32,17 +44,7 @@ func (r *router) snapshots(
 			svc types.StorageService) (interface{}, error) {
 
 			ctx = context.WithStorageService(ctx, svc)
-
-			objs, err := svc.Driver().Snapshots(ctx, store)
-			if err != nil {
-				return nil, err
-			}
-
-			objMap := map[string]*types.Snapshot{}
-			for _, obj := range objs {
-				objMap[obj.ID] = obj
-			}
-			return objMap, nil
+			return getFilteredSnapshots(ctx, store, svc, filter)
 		}
 
 		task := service.TaskExecute(ctx, run, schema.SnapshotMapSchema)
@@ -59,14 +61,19 @@ func (r *router) snapshots(
 				return nil, utils.NewBatchProcessErr(reply, v.Error)
 			}
 
-			objMap, ok := v.Result.(map[string]*types.Snapshot)
+			objMap, ok := v.Result.(types.SnapshotMap)
 			if !ok {
 				return nil, utils.NewBatchProcessErr(
-					reply, goof.New("error casting to []*types.Snapshot"))
+					reply, goof.New("error casting to types.SnapshotMap"))
 			}
 			reply[k] = objMap
 		}
 
+		if page != nil {
+			objs, next := paging.PageSnapshots(page, reply)
+			return &types.PagedResult{Result: objs, NextToken: next}, nil
+		}
+
 		return reply, nil
 	}
 
@@ -85,22 +92,34 @@ func (r *router) snapshotsForService(
 	req *http.Request,
 	store types.Store) error {
 
+	filter, err := filters.FromStore(store)
+	if err != nil {
+		return err
+	}
+
+	page, err := paging.ParsePage(req.URL.Query(), paging.SnapshotSortFields)
+	if err != nil {
+		return err
+	}
+
 	service := context.MustService(ctx)
 
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		var reply types.SnapshotMap = map[string]*types.Snapshot{}
-
-		objs, err := svc.Driver().Snapshots(ctx, store)
+		reply, err := getFilteredSnapshots(ctx, store, svc, filter)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, obj := range objs {
-			reply[obj.ID] = obj
+		if page != nil {
+			ssm, next := paging.PageSnapshots(
+				page, types.ServiceSnapshotMap{svc.Name(): reply})
+			return &types.PagedResult{
+				Result: ssm[svc.Name()], NextToken: next}, nil
 		}
+
 		return reply, nil
 	}
 
@@ -113,6 +132,33 @@ func (r *router) snapshotsForService(
 		http.StatusOK)
 }
 
+func getFilteredSnapshots(
+	ctx types.Context,
+	store types.Store,
+	storSvc types.StorageService,
+	filter *types.Filter) (types.SnapshotMap, error) {
+
+	objs, err := storSvc.Driver().Snapshots(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+
+	objMap := types.SnapshotMap{}
+	for _, obj := range objs {
+		if filter != nil {
+			ok, err := filters.Match(filter, obj)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		objMap[obj.ID] = obj
+	}
+	return objMap, nil
+}
+
 func (r *router) snapshotInspect(
 	ctx types.Context,
 	w http.ResponseWriter,
@@ -174,21 +220,36 @@ func (r *router) volumeCreate(
 	store types.Store) error {
 
 	service := context.MustService(ctx)
+	if err := utils.CheckCapabilities(
+		ctx, service, types.CapabilitySnapshots); err != nil {
+		return err
+	}
+	if err := utils.CheckVolumeCapabilities(
+		ctx,
+		service,
+		store.GetInt64Ptr("size"),
+		store.GetInt64Ptr("iops"),
+		store.GetStringPtr("type")); err != nil {
+		return err
+	}
 
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		v, err := svc.Driver().VolumeCreateFromSnapshot(
-			ctx,
-			store.GetString("snapshotID"),
-			store.GetString("name"),
-			&types.VolumeCreateOpts{
-				AvailabilityZone: store.GetStringPtr("availabilityZone"),
-				IOPS:             store.GetInt64Ptr("iops"),
-				Size:             store.GetInt64Ptr("size"),
-				Type:             store.GetStringPtr("type"),
-				Opts:             store,
+		name := store.GetString("name")
+		opts := &types.VolumeCreateOpts{
+			AvailabilityZone: store.GetStringPtr("availabilityZone"),
+			IOPS:             store.GetInt64Ptr("iops"),
+			Size:             store.GetInt64Ptr("size"),
+			Type:             store.GetStringPtr("type"),
+			Opts:             store,
+		}
+
+		v, err := volume.CreateUniqueVolume(ctx, svc, name, store,
+			func() (*types.Volume, error) {
+				return svc.Driver().VolumeCreateFromSnapshot(
+					ctx, store.GetString("snapshotID"), name, opts)
 			})
 
 		if err != nil {
@@ -224,6 +285,10 @@ func (r *router) snapshotCopy(
 	store types.Store) error {
 
 	service := context.MustService(ctx)
+	if err := utils.CheckCapabilities(
+		ctx, service, types.CapabilitySnapshots); err != nil {
+		return err
+	}
 
 	run := func(
 		ctx types.Context,
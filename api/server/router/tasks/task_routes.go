@@ -8,6 +8,7 @@ import (
 	"github.com/emccode/libstorage/api/server/services"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
 )
 
 func (r *router) tasks(
@@ -16,10 +17,34 @@ func (r *router) tasks(
 	req *http.Request,
 	store types.Store) error {
 
+	filter, err := filters.FromStore(store)
+	if err != nil {
+		return err
+	}
+
+	// drain the channel even if a task cannot be matched so that the
+	// goroutine sending the tasks does not block forever
+	var matchErr error
 	tasks := map[string]*types.Task{}
 	for t := range services.Tasks(ctx) {
+		if matchErr != nil {
+			continue
+		}
+		if filter != nil {
+			ok, err := filters.Match(filter, t)
+			if err != nil {
+				matchErr = err
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
 		tasks[fmt.Sprintf("%d", t.ID)] = t
 	}
+	if matchErr != nil {
+		return matchErr
+	}
 	httputils.WriteJSON(w, http.StatusOK, tasks)
 	return nil
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/emccode/libstorage/api/registry"
 	"github.com/emccode/libstorage/api/server/httputils"
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils/schema"
 )
 
 func init() {
@@ -36,12 +37,19 @@ func (r *router) initRoutes() {
 		httputils.NewGetRoute(
 			"tasks",
 			"/tasks",
-			r.tasks),
+			r.tasks).Doc(&types.RouteDoc{
+			Summary:        "List the tasks",
+			Params:         []*types.RouteParam{httputils.FilterParam},
+			ResponseSchema: schema.TaskMapSchema,
+		}),
 
 		// GET
 		httputils.NewGetRoute(
 			"taskInspect",
 			"/tasks/{taskID}",
-			r.taskInspect),
+			r.taskInspect).Doc(&types.RouteDoc{
+			Summary:        "Get a task",
+			ResponseSchema: schema.TaskSchema,
+		}),
 	}
 }
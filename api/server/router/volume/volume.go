@@ -57,7 +57,14 @@ func (r *router) initRoutes() {
 			"/volumes",
 			r.volumes,
 			handlers.NewSchemaValidator(nil, schema.ServiceVolumeMapSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "List the volumes of all services",
+			Params: append([]*types.RouteParam{
+				httputils.AttachmentsParam,
+				httputils.FilterParam,
+			}, httputils.PageParams...),
+			Async: true,
+		}),
 
 		// get all volumes from a specific service
 		httputils.NewGetRoute(
@@ -66,7 +73,14 @@ func (r *router) initRoutes() {
 			r.volumesForService,
 			handlers.NewServiceValidator(),
 			handlers.NewSchemaValidator(nil, schema.VolumeMapSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "List a service's volumes",
+			Params: append([]*types.RouteParam{
+				httputils.AttachmentsParam,
+				httputils.FilterParam,
+			}, httputils.PageParams...),
+			Async: true,
+		}),
 
 		// get a specific volume from a specific service
 		httputils.NewGetRoute(
@@ -75,7 +89,28 @@ func (r *router) initRoutes() {
 			r.volumeInspect,
 			handlers.NewServiceValidator(),
 			handlers.NewSchemaValidator(nil, schema.VolumeSchema, nil),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "Get a volume",
+			Params: []*types.RouteParam{
+				httputils.AttachmentsParam,
+			},
+			Async: true,
+		}),
+
+		// get a specific volume from a specific service by the volume's name
+		httputils.NewGetRoute(
+			"volumeInspectByName",
+			"/volumes/{service}/by-name/{name}",
+			byName(r.volumeInspect),
+			handlers.NewServiceValidator(),
+			handlers.NewSchemaValidator(nil, schema.VolumeSchema, nil),
+		).Doc(&types.RouteDoc{
+			Summary: "Get a volume by its name",
+			Params: []*types.RouteParam{
+				httputils.AttachmentsParam,
+			},
+			Async: true,
+		}),
 
 		// POST
 
@@ -90,7 +125,11 @@ func (r *router) initRoutes() {
 				schema.VolumeMapSchema,
 				func() interface{} { return &types.VolumeDetachRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("detach"),
+		).Queries("detach").Doc(&types.RouteDoc{
+			Summary: "Detach all of a service's volumes",
+			Status:  http.StatusResetContent,
+			Async:   true,
+		}),
 
 		// create a new volume
 		httputils.NewPostRoute(
@@ -103,7 +142,11 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCreateRequest{} }),
 			handlers.NewPostArgsHandler(),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "Create a volume",
+			Status:  http.StatusCreated,
+			Async:   true,
+		}),
 
 		// create a new volume using an existing volume as the baseline
 		httputils.NewPostRoute(
@@ -116,7 +159,27 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeCopyRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("copy"),
+		).Queries("copy").Doc(&types.RouteDoc{
+			Summary: "Copy a volume",
+			Status:  http.StatusCreated,
+			Async:   true,
+		}),
+
+		// resize an existing volume
+		httputils.NewPostRoute(
+			"volumeResize",
+			"/volumes/{service}/{volumeID}",
+			r.volumeResize,
+			handlers.NewServiceValidator(),
+			handlers.NewSchemaValidator(
+				schema.VolumeResizeRequestSchema,
+				schema.VolumeSchema,
+				func() interface{} { return &types.VolumeResizeRequest{} }),
+			handlers.NewPostArgsHandler(),
+		).Queries("resize").Doc(&types.RouteDoc{
+			Summary: "Resize a volume",
+			Async:   true,
+		}),
 
 		// snapshot an existing volume
 		httputils.NewPostRoute(
@@ -129,7 +192,11 @@ func (r *router) initRoutes() {
 				schema.SnapshotSchema,
 				func() interface{} { return &types.VolumeSnapshotRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("snapshot"),
+		).Queries("snapshot").Doc(&types.RouteDoc{
+			Summary: "Snapshot a volume",
+			Status:  http.StatusCreated,
+			Async:   true,
+		}),
 
 		// attach an existing volume
 		httputils.NewPostRoute(
@@ -142,7 +209,26 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeAttachRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("attach"),
+		).Queries("attach").Doc(&types.RouteDoc{
+			Summary: "Attach a volume",
+			Async:   true,
+		}),
+
+		// attach an existing volume by the volume's name
+		httputils.NewPostRoute(
+			"volumeAttachByName",
+			"/volumes/{service}/by-name/{name}",
+			byName(r.volumeAttach),
+			handlers.NewServiceValidator(),
+			handlers.NewSchemaValidator(
+				schema.VolumeAttachRequestSchema,
+				schema.VolumeSchema,
+				func() interface{} { return &types.VolumeAttachRequest{} }),
+			handlers.NewPostArgsHandler(),
+		).Queries("attach").Doc(&types.RouteDoc{
+			Summary: "Attach a volume by its name",
+			Async:   true,
+		}),
 
 		// detach all volumes for all services
 		httputils.NewPostRoute(
@@ -154,7 +240,11 @@ func (r *router) initRoutes() {
 				schema.ServiceVolumeMapSchema,
 				func() interface{} { return &types.VolumeDetachRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("detach"),
+		).Queries("detach").Doc(&types.RouteDoc{
+			Summary: "Detach the volumes of all services",
+			Status:  http.StatusResetContent,
+			Async:   true,
+		}),
 
 		// detach an individual volume
 		httputils.NewPostRoute(
@@ -167,7 +257,28 @@ func (r *router) initRoutes() {
 				schema.VolumeSchema,
 				func() interface{} { return &types.VolumeDetachRequest{} }),
 			handlers.NewPostArgsHandler(),
-		).Queries("detach"),
+		).Queries("detach").Doc(&types.RouteDoc{
+			Summary: "Detach a volume",
+			Status:  http.StatusResetContent,
+			Async:   true,
+		}),
+
+		// detach an individual volume by the volume's name
+		httputils.NewPostRoute(
+			"volumeDetachByName",
+			"/volumes/{service}/by-name/{name}",
+			byName(r.volumeDetach),
+			handlers.NewServiceValidator(),
+			handlers.NewSchemaValidator(
+				schema.VolumeDetachRequestSchema,
+				schema.VolumeSchema,
+				func() interface{} { return &types.VolumeDetachRequest{} }),
+			handlers.NewPostArgsHandler(),
+		).Queries("detach").Doc(&types.RouteDoc{
+			Summary: "Detach a volume by its name",
+			Status:  http.StatusResetContent,
+			Async:   true,
+		}),
 
 		// DELETE
 		httputils.NewDeleteRoute(
@@ -175,6 +286,22 @@ func (r *router) initRoutes() {
 			"/volumes/{service}/{volumeID}",
 			r.volumeRemove,
 			handlers.NewServiceValidator(),
-		),
+		).Doc(&types.RouteDoc{
+			Summary: "Remove a volume",
+			Status:  http.StatusNoContent,
+			Async:   true,
+		}),
+
+		// remove a volume by the volume's name
+		httputils.NewDeleteRoute(
+			"volumeRemoveByName",
+			"/volumes/{service}/by-name/{name}",
+			byName(r.volumeRemove),
+			handlers.NewServiceValidator(),
+		).Doc(&types.RouteDoc{
+			Summary: "Remove a volume by its name",
+			Status:  http.StatusNoContent,
+			Async:   true,
+		}),
 	}
 }
@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"fmt"
 	"net/http"
 	"strings"
 	"sync"
@@ -13,6 +14,7 @@ import (
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
 	"github.com/emccode/libstorage/api/utils/filters"
+	"github.com/emccode/libstorage/api/utils/paging"
 	"github.com/emccode/libstorage/api/utils/schema"
 )
 
@@ -22,12 +24,14 @@ func (r *router) volumes(
 	req *http.Request,
 	store types.Store) error {
 
-	filter, err := parseFilter(store)
+	filter, err := filters.FromStore(store)
 	if err != nil {
 		return err
 	}
-	if filter != nil {
-		store.Set("filter", filter)
+
+	page, err := paging.ParsePage(req.URL.Query(), paging.VolumeSortFields)
+	if err != nil {
+		return err
 	}
 
 	var (
@@ -35,6 +39,7 @@ func (r *router) volumes(
 		taskIDs []int
 		opts    = &types.VolumesOpts{
 			Attachments: store.GetBool("attachments"),
+			Filter:      filter,
 			Opts:        store,
 		}
 		reply = types.ServiceVolumeMap{}
@@ -72,6 +77,11 @@ func (r *router) volumes(
 			reply[k] = objMap
 		}
 
+		if page != nil {
+			objs, next := paging.PageVolumes(page, reply)
+			return &types.PagedResult{Result: objs, NextToken: next}, nil
+		}
+
 		return reply, nil
 	}
 
@@ -90,26 +100,55 @@ func (r *router) volumesForService(
 	req *http.Request,
 	store types.Store) error {
 
-	filter, err := parseFilter(store)
+	filter, err := filters.FromStore(store)
 	if err != nil {
 		return err
 	}
-	if filter != nil {
-		store.Set("filter", filter)
+
+	page, err := paging.ParsePage(req.URL.Query(), paging.VolumeSortFields)
+	if err != nil {
+		return err
 	}
 
 	service := context.MustService(ctx)
 
 	opts := &types.VolumesOpts{
 		Attachments: store.GetBool("attachments"),
+		Filter:      filter,
 		Opts:        store,
 	}
 
+	// a driver may page the volumes only if every volume it lists is part
+	// of the reply
+	if page != nil && filter == nil && !opts.Attachments && OnVolume == nil {
+		opts.Page = page
+	}
+
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		return getFilteredVolumes(ctx, req, store, svc, opts, filter)
+		objs, err := getFilteredVolumes(ctx, req, store, svc, opts, filter)
+		if err != nil || page == nil {
+			return objs, err
+		}
+
+		if opts.Page != nil && opts.Page.Paged {
+			return &types.PagedResult{
+				Result:    objs,
+				NextToken: paging.EncodeDriverToken(opts.Page.NextToken),
+			}, nil
+		}
+
+		// only the driver that created a token can continue from it
+		if page.Token != "" {
+			return nil, utils.NewBadPageErr(
+				paging.TokenParam, req.URL.Query().Get(paging.TokenParam))
+		}
+
+		svm, next := paging.PageVolumes(
+			page, types.ServiceVolumeMap{svc.Name(): objs})
+		return &types.PagedResult{Result: svm[svc.Name()], NextToken: next}, nil
 	}
 
 	return httputils.WriteTask(
@@ -129,12 +168,7 @@ func getFilteredVolumes(
 	opts *types.VolumesOpts,
 	filter *types.Filter) (types.VolumeMap, error) {
 
-	var (
-		filterOp    types.FilterOperator
-		filterLeft  string
-		filterRight string
-		objMap      = types.VolumeMap{}
-	)
+	objMap := types.VolumeMap{}
 
 	iid, iidOK := context.InstanceID(ctx)
 	if opts.Attachments && !iidOK {
@@ -151,16 +185,16 @@ func getFilteredVolumes(
 		lcaseIID = strings.ToLower(iid.ID)
 	}
 
-	if filter != nil {
-		filterOp = filter.Op
-		filterLeft = strings.ToLower(filter.Left)
-		filterRight = strings.ToLower(filter.Right)
-	}
-
 	for _, obj := range objs {
 
-		if filterOp == types.FilterEqualityMatch && filterLeft == "name" {
-			if strings.ToLower(obj.Name) != filterRight {
+		// the filter is applied before the attachments are reduced to the
+		// instance's attachments so that it may match any attachment
+		if filter != nil {
+			ok, err := filters.Match(filter, obj)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
 				continue
 			}
 		}
@@ -219,36 +253,23 @@ func (r *router) volumeInspect(
 			ctx types.Context,
 			svc types.StorageService) (interface{}, error) {
 
-			vols, err := svc.Driver().Volumes(
-				ctx,
-				&types.VolumesOpts{
-					Attachments: attachments,
-					Opts:        store,
-				})
-
+			name := getVolumeName(store)
+			v, err := getVolumeByName(ctx, svc, name, attachments, store)
 			if err != nil {
 				return nil, err
 			}
 
-			volID := strings.ToLower(store.GetString("volumeID"))
-			for _, v := range vols {
-				if strings.ToLower(v.Name) == volID {
-
-					if OnVolume != nil {
-						ok, err := OnVolume(ctx, req, store, v)
-						if err != nil {
-							return nil, err
-						}
-						if !ok {
-							return nil, utils.NewNotFoundError(volID)
-						}
-					}
-
-					return v, nil
+			if OnVolume != nil {
+				ok, err := OnVolume(ctx, req, store, v)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					return nil, utils.NewNotFoundError(name)
 				}
 			}
 
-			return nil, utils.NewNotFoundError(volID)
+			return v, nil
 		}
 
 	} else {
@@ -294,20 +315,35 @@ func (r *router) volumeCreate(
 	store types.Store) error {
 
 	service := context.MustService(ctx)
+	if err := utils.ApplyVolumeProfile(service, store); err != nil {
+		return err
+	}
+	if err := utils.CheckVolumeCapabilities(
+		ctx,
+		service,
+		store.GetInt64Ptr("size"),
+		store.GetInt64Ptr("iops"),
+		store.GetStringPtr("type")); err != nil {
+		return err
+	}
 
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		v, err := svc.Driver().VolumeCreate(
-			ctx,
-			store.GetString("name"),
-			&types.VolumeCreateOpts{
-				AvailabilityZone: store.GetStringPtr("availabilityZone"),
-				IOPS:             store.GetInt64Ptr("iops"),
-				Size:             store.GetInt64Ptr("size"),
-				Type:             store.GetStringPtr("type"),
-				Opts:             store,
+		name := store.GetString("name")
+		opts := &types.VolumeCreateOpts{
+			AvailabilityZone: store.GetStringPtr("availabilityZone"),
+			IOPS:             store.GetInt64Ptr("iops"),
+			Size:             store.GetInt64Ptr("size"),
+			Type:             store.GetStringPtr("type"),
+			Profile:          store.GetStringPtr("profile"),
+			Opts:             store,
+		}
+
+		v, err := CreateUniqueVolume(ctx, svc, name, store,
+			func() (*types.Volume, error) {
+				return svc.Driver().VolumeCreate(ctx, name, opts)
 			})
 
 		if err != nil {
@@ -343,16 +379,80 @@ func (r *router) volumeCopy(
 	store types.Store) error {
 
 	service := context.MustService(ctx)
+	if err := utils.CheckCapabilities(
+		ctx, service, types.CapabilityCopy); err != nil {
+		return err
+	}
+
+	run := func(
+		ctx types.Context,
+		svc types.StorageService) (interface{}, error) {
+
+		name := store.GetString("volumeName")
+		v, err := CreateUniqueVolume(ctx, svc, name, store,
+			func() (*types.Volume, error) {
+				return svc.Driver().VolumeCopy(
+					ctx, store.GetString("volumeID"), name, store)
+			})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if OnVolume != nil {
+			ok, err := OnVolume(ctx, req, store, v)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, utils.NewNotFoundError(v.ID)
+			}
+		}
+
+		return v, nil
+	}
+
+	return httputils.WriteTask(
+		ctx,
+		r.config,
+		w,
+		store,
+		service.TaskExecute(ctx, run, schema.VolumeSchema),
+		http.StatusCreated)
+}
+
+func (r *router) volumeResize(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	service := context.MustService(ctx)
+	if err := utils.CheckCapabilities(
+		ctx, service, types.CapabilityResize); err != nil {
+		return err
+	}
+	if err := utils.CheckVolumeCapabilities(
+		ctx, service, store.GetInt64Ptr("size"), nil, nil); err != nil {
+		return err
+	}
 
 	run := func(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		v, err := svc.Driver().VolumeCopy(
+		d, ok := svc.Driver().(types.StorageDriverVolumeResizer)
+		if !ok {
+			return nil, types.ErrNotImplemented
+		}
+
+		v, err := d.VolumeResize(
 			ctx,
 			store.GetString("volumeID"),
-			store.GetString("volumeName"),
-			store)
+			&types.VolumeResizeOpts{
+				Size: store.GetInt64("size"),
+				Opts: store,
+			})
 
 		if err != nil {
 			return nil, err
@@ -377,7 +477,7 @@ func (r *router) volumeCopy(
 		w,
 		store,
 		service.TaskExecute(ctx, run, schema.VolumeSchema),
-		http.StatusCreated)
+		http.StatusOK)
 }
 
 func (r *router) volumeSnapshot(
@@ -387,6 +487,10 @@ func (r *router) volumeSnapshot(
 	store types.Store) error {
 
 	service := context.MustService(ctx)
+	if err := utils.CheckCapabilities(
+		ctx, service, types.CapabilitySnapshots); err != nil {
+		return err
+	}
 
 	run := func(
 		ctx types.Context,
@@ -423,9 +527,23 @@ func (r *router) volumeAttach(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
+		volumeID, err := getVolumeID(ctx, svc, store)
+		if err != nil {
+			return nil, err
+		}
+
+		// a forced attach detaches the volume from the other instances
+		if !store.GetBool("force") {
+			iid := context.MustInstanceID(ctx)
+			if err := utils.CheckAttachCapabilities(
+				ctx, svc, volumeID, iid); err != nil {
+				return nil, err
+			}
+		}
+
 		v, attTokn, err := svc.Driver().VolumeAttach(
 			ctx,
-			store.GetString("volumeID"),
+			volumeID,
 			&types.VolumeAttachOpts{
 				NextDevice: store.GetStringPtr("nextDeviceName"),
 				Force:      store.GetBool("force"),
@@ -476,9 +594,14 @@ func (r *router) volumeDetach(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
+		volumeID, err := getVolumeID(ctx, svc, store)
+		if err != nil {
+			return nil, err
+		}
+
 		v, err := svc.Driver().VolumeDetach(
 			ctx,
-			store.GetString("volumeID"),
+			volumeID,
 			&types.VolumeDetachOpts{
 				Force: store.GetBool("force"),
 				Opts:  store,
@@ -685,10 +808,12 @@ func (r *router) volumeRemove(
 		ctx types.Context,
 		svc types.StorageService) (interface{}, error) {
 
-		return nil, svc.Driver().VolumeRemove(
-			ctx,
-			store.GetString("volumeID"),
-			store)
+		volumeID, err := getVolumeID(ctx, svc, store)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, svc.Driver().VolumeRemove(ctx, volumeID, store)
 	}
 
 	return httputils.WriteTask(
@@ -700,14 +825,159 @@ func (r *router) volumeRemove(
 		http.StatusNoContent)
 }
 
-func parseFilter(store types.Store) (*types.Filter, error) {
-	if !store.IsSet("filter") {
-		return nil, nil
+// byName returns a handler for a route that addresses a volume by the name
+// in the route's path instead of by the volume's ID.
+func byName(f types.APIFunc) types.APIFunc {
+	return func(
+		ctx types.Context,
+		w http.ResponseWriter,
+		req *http.Request,
+		store types.Store) error {
+
+		store.Set("byName", true)
+		return f(ctx, w, req, store)
+	}
+}
+
+// getVolumeName returns the name of the volume that a request addresses by
+// name. The by-name routes have the name in their path. A request to inspect
+// a volume with the byName query parameter has the name in place of the
+// volume's ID.
+func getVolumeName(store types.Store) string {
+	if store.IsSet("name") {
+		return store.GetString("name")
 	}
-	fsz := store.GetString("filter")
-	filter, err := filters.CompileFilter(fsz)
+	return store.GetString("volumeID")
+}
+
+// getVolumeID returns the ID of the volume that a request addresses, looking
+// the volume up by name if the request addresses it by name.
+func getVolumeID(
+	ctx types.Context,
+	svc types.StorageService,
+	store types.Store) (string, error) {
+
+	if !store.IsSet("byName") {
+		return store.GetString("volumeID"), nil
+	}
+	v, err := getVolumeByName(ctx, svc, getVolumeName(store), false, store)
 	if err != nil {
-		return nil, utils.NewBadFilterErr(fsz, err)
+		return "", err
+	}
+	// the resolved ID is stored for the global filters, such as the audit
+	// filter, that read the ID of a request's volume from the store
+	store.Set("volumeID", v.ID)
+	return v.ID, nil
+}
+
+// getVolumeByName returns the service's volume with the specified name. Names
+// are not case sensitive. The storage driver receives a filter for the name
+// so that it may look the volume up on the storage platform instead of
+// listing every volume.
+func getVolumeByName(
+	ctx types.Context,
+	svc types.StorageService,
+	name string,
+	attachments bool,
+	store types.Store) (*types.Volume, error) {
+
+	vols, err := svc.Driver().Volumes(ctx, &types.VolumesOpts{
+		Attachments: attachments,
+		Filter: &types.Filter{
+			Op:    types.FilterEqualityMatch,
+			Left:  "name",
+			Right: name,
+		},
+		Opts: store,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var vol *types.Volume
+	for _, v := range vols {
+		if !strings.EqualFold(v.Name, name) {
+			continue
+		}
+		if vol != nil {
+			return nil, utils.NewDuplicateNameErr(name)
+		}
+		vol = v
+	}
+	if vol == nil {
+		return nil, utils.NewNotFoundError(name)
+	}
+	return vol, nil
+}
+
+// uniqueVolumeNames returns a flag indicating whether the names of the
+// service's volumes must be unique. The flag is read from the service's
+// scope first and then from libstorage.server.volumes.
+func uniqueVolumeNames(svc types.StorageService) bool {
+	return svc.Config().GetBool(strings.TrimPrefix(
+		types.ConfigServerVolumesUniqueNames, types.ConfigServer+"."))
+}
+
+// CreateUniqueVolume invokes a function that creates a volume with the
+// specified name. If the names of the service's volumes must be unique the
+// function is invoked only if the service has no volume with the name, and
+// the lookup and the function are serialized with those of other requests to
+// create a volume with the same name so that both cannot pass the check.
+func CreateUniqueVolume(
+	ctx types.Context,
+	svc types.StorageService,
+	name string,
+	store types.Store,
+	create func() (*types.Volume, error)) (*types.Volume, error) {
+
+	if !uniqueVolumeNames(svc) {
+		return create()
+	}
+
+	unlock := lockVolumeName(svc.Name(), name)
+	defer unlock()
+
+	_, err := getVolumeByName(ctx, svc, name, false, store)
+	if err == nil {
+		return nil, utils.NewDuplicateNameErr(name)
+	}
+	if _, ok := err.(*types.ErrNotFound); !ok {
+		return nil, err
+	}
+	return create()
+}
+
+type volumeNameLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	volumeNameLocks     = map[string]*volumeNameLock{}
+	volumeNameLocksLock = &sync.Mutex{}
+)
+
+// lockVolumeName locks a volume name of a service and returns the function
+// that unlocks it. Names are not case sensitive.
+func lockVolumeName(service, name string) func() {
+	key := fmt.Sprintf("%s/%s", service, strings.ToLower(name))
+
+	volumeNameLocksLock.Lock()
+	l, ok := volumeNameLocks[key]
+	if !ok {
+		l = &volumeNameLock{}
+		volumeNameLocks[key] = l
+	}
+	l.refs++
+	volumeNameLocksLock.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		volumeNameLocksLock.Lock()
+		defer volumeNameLocksLock.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(volumeNameLocks, key)
+		}
 	}
-	return filter, nil
 }
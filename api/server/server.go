@@ -16,10 +16,13 @@ import (
 	"github.com/akutz/gofig"
 
 	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server/admin"
+	"github.com/emccode/libstorage/api/server/audit"
 	"github.com/emccode/libstorage/api/server/services"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
 	apicnfg "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/api/utils/tracing"
 
 	// imported to load routers
 	_ "github.com/emccode/libstorage/imports/routers"
@@ -42,6 +45,8 @@ type server struct {
 	closeSignal  chan int
 	closedSignal chan int
 	closeOnce    *sync.Once
+	reloadLock   sync.Mutex
+	auditor      *audit.Auditor
 
 	routers        []types.Router
 	routeHandlers  map[string][]types.Middleware
@@ -57,17 +62,6 @@ type server struct {
 
 func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
 
-	adminTokenUUID, err := types.NewUUID()
-	if err != nil {
-		return nil, err
-	}
-	adminToken := adminTokenUUID.String()
-	serverName := randomServerName()
-
-	ctx := context.New(goCtx)
-	ctx = ctx.WithValue(context.ServerKey, serverName)
-	ctx = ctx.WithValue(context.AdminTokenKey, adminToken)
-
 	if config == nil {
 		var err error
 		if config, err = apicnfg.NewConfig(); err != nil {
@@ -75,16 +69,36 @@ func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
 		}
 	}
 	config = config.Scope(types.ConfigServer)
+	if err := apicnfg.Validate(config); err != nil {
+		return nil, err
+	}
+	if err := apicnfg.ResolveSecrets(config); err != nil {
+		return nil, err
+	}
+	tracing.Init(config)
+
+	identity, err := admin.New(config, randomServerName)
+	if err != nil {
+		return nil, err
+	}
+	serverName := identity.Name()
+
+	ctx := context.New(goCtx)
+	ctx = ctx.WithValue(context.ServerKey, serverName)
+	ctx = ctx.WithValue(
+		context.AdminTokenKey, identity.Token(admin.DefaultTokenName))
+	admin.Init(ctx, identity)
 
 	s := &server{
 		ctx:          ctx,
 		name:         serverName,
-		adminToken:   adminToken,
+		adminToken:   identity.DisplayToken(),
 		config:       config,
 		closeSignal:  make(chan int),
 		closedSignal: make(chan int),
 		closeOnce:    &sync.Once{},
 	}
+	s.ctx = s.ctx.WithValue(context.ReloadKey, s.Reload)
 
 	if logger, ok := s.ctx.Value(context.LoggerKey).(*log.Logger); ok {
 		s.PrintServerStartupHeader(logger.Out)
@@ -120,6 +134,10 @@ func newServer(goCtx gocontext.Context, config gofig.Config) (*server, error) {
 	}
 	s.ctx.Info("initialized services")
 
+	if s.auditor, err = audit.Init(s.ctx, s.config); err != nil {
+		return nil, err
+	}
+
 	if logConfig.HTTPRequests || logConfig.HTTPResponses {
 		s.logHTTPEnabled = true
 		s.logHTTPRequests = logConfig.HTTPRequests
@@ -249,6 +267,12 @@ func (s *server) close() error {
 		}
 	}
 
+	if err := audit.Close(s.ctx); err != nil {
+		log.Error(err)
+	}
+
+	admin.Close(s.ctx)
+
 	s.ctx.Debug("shutdown server complete")
 
 	return nil
@@ -261,7 +285,6 @@ func CloseOnAbort() {
 	sigc := make(chan os.Signal, 1)
 	signal.Notify(sigc,
 		syscall.SIGKILL,
-		syscall.SIGHUP,
 		syscall.SIGINT,
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
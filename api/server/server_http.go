@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/goof"
@@ -132,7 +133,7 @@ func (s *server) initEndpoints(ctx types.Context) error {
 
 		ctx.WithFields(logFields).Info("configured endpoint")
 
-		srv, err := s.newHTTPServer(proto, addr, tlsConfig)
+		srv, err := s.newHTTPServer(endpointName, proto, addr, tlsConfig)
 		if err != nil {
 			return err
 		}
@@ -227,15 +228,23 @@ func (s *server) createMux(ctx types.Context) *mux.Router {
 }
 
 func (s *server) newHTTPServer(
-	proto, laddr string, tlsConfig *tls.Config) (*HTTPServer, error) {
+	endpoint, proto, laddr string,
+	tlsConfig *tls.Config) (*HTTPServer, error) {
 
 	var (
 		l   net.Listener
 		err error
+		hs  = &HTTPServer{endpoint: endpoint}
 	)
 
 	if tlsConfig != nil {
-		l, err = tls.Listen(proto, laddr, tlsConfig)
+		// the listener asks the server for the current TLS config for each
+		// new connection so that a reload can replace the certificates
+		// without closing the listener
+		hs.tlsConfig.Store(tlsConfig)
+		lisConfig := tlsConfig.Clone()
+		lisConfig.GetConfigForClient = hs.getTLSConfig
+		l, err = tls.Listen(proto, laddr, lisConfig)
 	} else {
 		l, err = net.Listen(proto, laddr)
 	}
@@ -254,11 +263,10 @@ func (s *server) newHTTPServer(
 	srv := &http.Server{Addr: l.Addr().String()}
 	srv.ErrorLog = golog.New(errLogger, "", 0)
 
-	return &HTTPServer{
-		srv: srv,
-		l:   l,
-		ctx: ctx,
-	}, nil
+	hs.srv = srv
+	hs.l = l
+	hs.ctx = ctx
+	return hs, nil
 }
 
 // HTTPServer contains an instance of http server and the listener.
@@ -269,9 +277,11 @@ func (s *server) newHTTPServer(
 // l   net.Listener, is a TCP or Socket listener that dispatches incoming
 // request to the router.
 type HTTPServer struct {
-	srv *http.Server
-	l   net.Listener
-	ctx types.Context
+	srv       *http.Server
+	l         net.Listener
+	ctx       types.Context
+	endpoint  string
+	tlsConfig atomic.Value
 }
 
 // Serve starts listening for inbound requests.
@@ -284,6 +294,10 @@ func (s *HTTPServer) Close() error {
 	return s.l.Close()
 }
 
+func (s *HTTPServer) getTLSConfig(*tls.ClientHelloInfo) (*tls.Config, error) {
+	return s.tlsConfig.Load().(*tls.Config), nil
+}
+
 // Context returns this server's types.
 func (s *HTTPServer) Context() types.Context {
 	return s.ctx
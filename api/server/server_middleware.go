@@ -8,6 +8,7 @@ import (
 func (s *server) initGlobalMiddleware() {
 
 	s.addGlobalMiddleware(handlers.NewQueryParamsHandler())
+	s.addGlobalMiddleware(handlers.NewMetricsHandler())
 
 	if s.logHTTPEnabled {
 		s.addGlobalMiddleware(handlers.NewLoggingHandler(
@@ -17,6 +18,12 @@ func (s *server) initGlobalMiddleware() {
 	}
 
 	s.addGlobalMiddleware(handlers.NewTransactionHandler())
+	s.addGlobalMiddleware(handlers.NewTracingHandler())
+
+	if s.auditor != nil {
+		s.addGlobalMiddleware(handlers.NewAuditHandler(s.auditor))
+	}
+
 	s.addGlobalMiddleware(handlers.NewErrorHandler())
 	s.addGlobalMiddleware(handlers.NewInstanceIDHandler())
 	s.addGlobalMiddleware(handlers.NewLocalDevicesHandler())
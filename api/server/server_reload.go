@@ -0,0 +1,164 @@
+package server
+
+import (
+	"crypto/tls"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server/admin"
+	"github.com/emccode/libstorage/api/server/services"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	apicnfg "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/api/utils/tracing"
+)
+
+var (
+	configLoader    = apicnfg.NewConfig
+	configLoaderRWL = &sync.RWMutex{}
+)
+
+// SetConfigLoader sets the function that loads a new configuration when the
+// servers are reloaded. The default loader reads the global and user config
+// files.
+func SetConfigLoader(f func() (gofig.Config, error)) {
+	configLoaderRWL.Lock()
+	defer configLoaderRWL.Unlock()
+	configLoader = f
+}
+
+func loadConfig() (gofig.Config, error) {
+	configLoaderRWL.RLock()
+	defer configLoaderRWL.RUnlock()
+	return configLoader()
+}
+
+// Reload re-reads the server's configuration and applies it. Storage services
+// that were added, changed, or removed are updated, the admin tokens set in
+// the config are applied, and the certificates of the TLS endpoints are
+// replaced. The server's listeners, name, and tasks are preserved.
+func (s *server) Reload() error {
+	config, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	return s.reload(config)
+}
+
+func (s *server) reload(config gofig.Config) error {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	s.ctx.Info("reloading server")
+
+	config = config.Scope(types.ConfigServer)
+	if err := apicnfg.Validate(config); err != nil {
+		return err
+	}
+	if err := apicnfg.ResolveSecrets(config); err != nil {
+		return err
+	}
+
+	// parse all of the TLS configs before applying any changes so that an
+	// invalid certificate does not leave the server partially reloaded
+	tlsConfigs, err := s.reloadTLSConfigs(config)
+	if err != nil {
+		return err
+	}
+
+	// the services are reloaded first since they are the most likely to
+	// fail, and the tokens and certificates must not change if they do
+	if err := services.Reload(s.ctx, config); err != nil {
+		return err
+	}
+
+	if identity := admin.Get(s.ctx); identity != nil {
+		if err := identity.Apply(config); err != nil {
+			return err
+		}
+	}
+
+	for srv, tlsConfig := range tlsConfigs {
+		srv.tlsConfig.Store(tlsConfig)
+		srv.ctx.Info("reloaded endpoint tls config")
+	}
+
+	if lvl, err := log.ParseLevel(
+		config.GetString(types.ConfigLogLevel)); err == nil {
+		context.SetLogLevel(s.ctx, lvl)
+	}
+
+	tracing.Init(config)
+
+	s.config = config
+	s.ctx.Info("reloaded server")
+
+	return nil
+}
+
+func (s *server) reloadTLSConfigs(
+	config gofig.Config) (map[*HTTPServer]*tls.Config, error) {
+
+	tlsConfigs := map[*HTTPServer]*tls.Config{}
+
+	for _, srv := range s.servers {
+
+		endpoint := types.ConfigEndpoints + "." + srv.endpoint
+		logFields := log.Fields{"endpoint": srv.endpoint}
+
+		tlsConfig, err := utils.ParseTLSConfig(
+			config.Scope(endpoint), logFields, endpoint)
+		if err != nil {
+			return nil, err
+		}
+
+		wasTLS := srv.tlsConfig.Load() != nil
+		switch {
+		case tlsConfig != nil && wasTLS:
+			tlsConfigs[srv] = tlsConfig
+		case tlsConfig != nil || wasTLS:
+			srv.ctx.WithFields(logFields).Warn(
+				"enabling or disabling tls requires a restart")
+		}
+	}
+
+	return tlsConfigs, nil
+}
+
+// Reload reloads all servers. This function can be used when a calling
+// program traps UNIX signals.
+func Reload() <-chan error {
+	errs := make(chan error)
+	go func() {
+		for _, server := range servers {
+			if err := server.Reload(); err != nil {
+				errs <- err
+			}
+		}
+		close(errs)
+		log.Info("all servers reloaded")
+	}()
+	return errs
+}
+
+// ReloadOnHangup is a helper function that can be called by programs, such as
+// a command line or service application, to reload all servers when the
+// program receives SIGHUP.
+func ReloadOnHangup() {
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGHUP)
+	go func() {
+		for range sigc {
+			log.Info("received hangup signal")
+			for err := range Reload() {
+				log.WithError(err).Error("error reloading server")
+			}
+		}
+	}()
+}
@@ -2,14 +2,18 @@ package services
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
 )
 
 var (
@@ -21,6 +25,7 @@ type serviceContainer struct {
 	config          gofig.Config
 	storageServices map[string]types.StorageService
 	taskService     *globalTaskService
+	reloadLock      sync.Mutex
 }
 
 // Init initializes the types.
@@ -88,9 +93,13 @@ func GetStorageService(
 // StorageServices returns a channel on which all the storage services are
 // received.
 func StorageServices(ctx types.Context) <-chan types.StorageService {
+	servicesByServerRWL.RLock()
+	storSvcs := getStorageServices(ctx)
+	servicesByServerRWL.RUnlock()
+
 	c := make(chan types.StorageService)
 	go func() {
-		for _, v := range getStorageServices(ctx) {
+		for _, v := range storSvcs {
 			c <- v
 		}
 		close(c)
@@ -98,57 +107,220 @@ func StorageServices(ctx types.Context) <-chan types.StorageService {
 	return c
 }
 
-func (sc *serviceContainer) initStorageServices(ctx types.Context) error {
-	if ctx == nil {
-		panic("ctx is nil")
+// Config returns the configuration with which the server's services were
+// most recently initialized or reloaded.
+func Config(ctx types.Context) gofig.Config {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
 	}
-	if sc.config == nil {
-		panic("sc.config is nil")
+
+	servicesByServerRWL.RLock()
+	defer servicesByServerRWL.RUnlock()
+	return servicesByServer[serverName].config
+}
+
+// Reload applies a new configuration to the server's storage services.
+// Services whose configuration is unchanged are left as they are. New and
+// changed services are initialized before they replace the existing ones so
+// that a service that fails to initialize does not disturb the running
+// server. The replaced and removed services are then drained of their queued
+// tasks.
+func Reload(ctx types.Context, config gofig.Config) error {
+
+	serverName, ok := context.Server(ctx)
+	if !ok {
+		panic("ctx is missing ServerName")
 	}
-	cfgSvcs := sc.config.Get(types.ConfigServices)
-	cfgSvcsMap, ok := cfgSvcs.(map[string]interface{})
+
+	servicesByServerRWL.RLock()
+	sc, ok := servicesByServer[serverName]
+	servicesByServerRWL.RUnlock()
 	if !ok {
-		driverName := sc.config.GetString("libstorage.driver")
-		if driverName == "" {
-			err := goof.WithFields(goof.Fields{
-				"configKey": types.ConfigServices,
-				"obj":       cfgSvcs,
-			}, "invalid format")
+		return goof.WithField("server", serverName, "invalid server")
+	}
+
+	return sc.reload(ctx, config)
+}
+
+func (sc *serviceContainer) reload(
+	ctx types.Context, config gofig.Config) error {
+
+	sc.reloadLock.Lock()
+	defer sc.reloadLock.Unlock()
+
+	ctx.Info("reloading server services")
+
+	cfgSvcsMap, err := getStorageServicesConfig(config)
+	if err != nil {
+		return err
+	}
+
+	drainTimeout, err := time.ParseDuration(
+		config.GetString(types.ConfigServerReloadDrainTimeout))
+	if err != nil {
+		drainTimeout = time.Minute
+	}
+
+	var (
+		created  []*storageService
+		storSvcs = map[string]types.StorageService{}
+	)
+
+	for serviceName, v := range cfgSvcsMap {
+		serviceName = strings.ToLower(serviceName)
+		settings := getStorageServiceSettings(config, serviceName, v)
+
+		if old, ok := sc.storageServices[serviceName].(*storageService); ok &&
+			reflect.DeepEqual(old.settings, settings) {
+			ctx.WithField("service", serviceName).Debug("service unchanged")
+			storSvcs[serviceName] = old
+			continue
+		}
+
+		storSvc, err := newStorageService(ctx, config, serviceName, settings)
+		if err != nil {
+			for _, s := range created {
+				s.drain(ctx, drainTimeout)
+			}
 			return err
 		}
+		created = append(created, storSvc)
+		storSvcs[serviceName] = storSvc
+	}
 
-		cfgSvcsMap = map[string]interface{}{
-			driverName: map[string]interface{}{
-				"driver": driverName,
-			},
+	var drained []*storageService
+	for serviceName, v := range sc.storageServices {
+		if storSvcs[serviceName] == v {
+			continue
+		}
+		if old, ok := v.(*storageService); ok {
+			drained = append(drained, old)
 		}
 	}
-	ctx.WithField("count", len(cfgSvcsMap)).Debug("got services map")
 
-	for serviceName := range cfgSvcsMap {
-		serviceName = strings.ToLower(serviceName)
+	servicesByServerRWL.Lock()
+	sc.config = config
+	sc.storageServices = storSvcs
+	servicesByServerRWL.Unlock()
+
+	wg := &sync.WaitGroup{}
+	for _, s := range drained {
+		wg.Add(1)
+		go func(s *storageService) {
+			defer wg.Done()
+			s.drain(ctx, drainTimeout)
+		}(s)
+	}
+	wg.Wait()
 
-		storSvc := &storageService{name: serviceName}
+	ctx.WithFields(log.Fields{
+		"created": len(created),
+		"drained": len(drained),
+		"count":   len(storSvcs),
+	}).Info("reloaded server services")
 
-		ctx := ctx.WithValue(context.StorageServiceKey, storSvc)
-		ctx.Debug("processing service config")
+	return nil
+}
 
-		scope := fmt.Sprintf("libstorage.server.services.%s", serviceName)
-		ctx.WithField("scope", scope).Debug(
-			"getting scoped config for service")
-		config := sc.config.Scope(scope)
+func (sc *serviceContainer) initStorageServices(ctx types.Context) error {
+	if ctx == nil {
+		panic("ctx is nil")
+	}
+	if sc.config == nil {
+		panic("sc.config is nil")
+	}
+	cfgSvcsMap, err := getStorageServicesConfig(sc.config)
+	if err != nil {
+		return err
+	}
+	ctx.WithField("count", len(cfgSvcsMap)).Debug("got services map")
 
-		if err := storSvc.Init(ctx, config); err != nil {
+	for serviceName, v := range cfgSvcsMap {
+		serviceName = strings.ToLower(serviceName)
+		storSvc, err := newStorageService(
+			ctx, sc.config, serviceName,
+			getStorageServiceSettings(sc.config, serviceName, v))
+		if err != nil {
 			return err
 		}
-
-		ctx.Info("created new service")
 		sc.storageServices[serviceName] = storSvc
 	}
 
 	return nil
 }
 
+// getStorageServicesConfig returns the configured services mapped to their
+// settings.
+func getStorageServicesConfig(
+	config gofig.Config) (map[string]interface{}, error) {
+
+	cfgSvcs := config.Get(types.ConfigServices)
+	if cfgSvcsMap, ok := cfgSvcs.(map[string]interface{}); ok {
+		return cfgSvcsMap, nil
+	}
+
+	driverName := config.GetString("libstorage.driver")
+	if driverName == "" {
+		return nil, goof.WithFields(goof.Fields{
+			"configKey": types.ConfigServices,
+			"obj":       cfgSvcs,
+		}, "invalid format")
+	}
+
+	return map[string]interface{}{
+		driverName: map[string]interface{}{
+			"driver": driverName,
+		},
+	}, nil
+}
+
+// getStorageServiceSettings returns the settings that are compared to
+// decide whether a service must be re-initialized when the server is
+// reloaded: the service's own config, the top-level config of its storage
+// driver, and its volume profiles, which include the global profiles.
+func getStorageServiceSettings(
+	config gofig.Config,
+	serviceName string,
+	svcSettings interface{}) []interface{} {
+
+	scope := fmt.Sprintf("libstorage.server.services.%s", serviceName)
+	svcConfig := config.Scope(scope)
+
+	// invalid profiles are reported when the service is initialized
+	profiles, _ := utils.VolumeProfiles(svcConfig)
+
+	driverName := getDriverName(svcConfig)
+	if driverName == "" {
+		return []interface{}{svcSettings, nil, profiles}
+	}
+	return []interface{}{svcSettings, config.Get(driverName), profiles}
+}
+
+func newStorageService(
+	ctx types.Context,
+	config gofig.Config,
+	serviceName string,
+	settings []interface{}) (*storageService, error) {
+
+	storSvc := &storageService{name: serviceName, settings: settings}
+
+	ctx = ctx.WithValue(context.StorageServiceKey, storSvc)
+	ctx.Debug("processing service config")
+
+	scope := fmt.Sprintf("libstorage.server.services.%s", serviceName)
+	ctx.WithField("scope", scope).Debug(
+		"getting scoped config for service")
+
+	if err := storSvc.Init(ctx, config.Scope(scope)); err != nil {
+		return nil, err
+	}
+
+	ctx.Info("created new service")
+	return storSvc, nil
+}
+
 func getTaskService(ctx types.Context) *globalTaskService {
 
 	serverName, ok := context.Server(ctx)
@@ -1,24 +1,46 @@
 package services
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/metrics"
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/api/utils/tracing"
 )
 
+func init() {
+	apiconfig.Constrain(types.ConfigServices+".*.driver", isStorageDriver)
+}
+
 type storageService struct {
 	name          string
 	driver        types.StorageDriver
 	config        gofig.Config
+	settings      []interface{}
 	taskExecQueue chan *task
+	tasks         sync.WaitGroup
+	queueRWL      sync.RWMutex
+	drained       bool
 }
 
 func (s *storageService) Init(ctx types.Context, config gofig.Config) error {
 	s.config = config
 
+	if _, err := utils.VolumeProfiles(config); err != nil {
+		return goof.WithFieldE("service", s.name, "error reading profiles", err)
+	}
+
 	if err := s.initStorageDriver(ctx); err != nil {
 		return err
 	}
@@ -27,22 +49,44 @@ func (s *storageService) Init(ctx types.Context, config gofig.Config) error {
 	go func() {
 		for t := range s.taskExecQueue {
 			execTask(t)
+			s.tasks.Done()
 		}
 	}()
 	return nil
 }
 
+// drain stops the service from queueing new tasks and waits up to the
+// provided timeout for the tasks that are already queued to complete. Tasks
+// submitted to a drained service are executed immediately.
+func (s *storageService) drain(ctx types.Context, timeout time.Duration) {
+	s.queueRWL.Lock()
+	s.drained = true
+	s.queueRWL.Unlock()
+
+	ctx = ctx.WithValue(context.StorageServiceKey, s)
+	ctx.Info("draining service")
+
+	done := make(chan int)
+	go func() {
+		s.tasks.Wait()
+		close(s.taskExecQueue)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		ctx.Info("drained service")
+	case <-time.After(timeout):
+		ctx.WithField("timeout", timeout).Warn(
+			"timed out draining service")
+	}
+}
+
 func (s *storageService) initStorageDriver(ctx types.Context) error {
-	driverName := s.config.GetString("driver")
+	driverName := getDriverName(s.config)
 	if driverName == "" {
-		driverName = s.config.GetString("libstorage.driver")
-		if driverName == "" {
-			driverName = s.config.GetString("libstorage.storage.driver")
-			if driverName == "" {
-				return goof.WithField(
-					"service", s.name, "error getting driver name")
-			}
-		}
+		return goof.WithField(
+			"service", s.name, "error getting driver name")
 	}
 
 	ctx.WithField("driverName", driverName).Debug("got driver name")
@@ -57,10 +101,63 @@ func (s *storageService) initStorageDriver(ctx types.Context) error {
 		return err
 	}
 
-	s.driver = driver
+	s.driver = s.initStorageDriverCache(
+		ctx, metrics.NewStorageDriver(
+			s.name, tracing.NewStorageDriver(s.name, driver)))
+	return nil
+}
+
+// getDriverName returns the name of the storage driver configured in a
+// service's scoped config.
+func getDriverName(config gofig.Config) string {
+	driverName := config.GetString("driver")
+	if driverName == "" {
+		driverName = config.GetString("libstorage.driver")
+		if driverName == "" {
+			driverName = config.GetString("libstorage.storage.driver")
+		}
+	}
+	return driverName
+}
+
+// isStorageDriver is a config constraint that requires the name of a
+// registered storage driver.
+func isStorageDriver(v interface{}) error {
+	if _, err := registry.NewStorageDriver(fmt.Sprintf("%v", v)); err != nil {
+		return fmt.Errorf("invalid storage driver: %v", v)
+	}
 	return nil
 }
 
+// initStorageDriverCache wraps the driver with a cache if a TTL for volumes
+// or snapshots is configured for the service. The TTLs are read from the
+// service's scope first and then from libstorage.server.cache.
+func (s *storageService) initStorageDriverCache(
+	ctx types.Context, driver types.StorageDriver) types.StorageDriver {
+
+	cacheTTL := func(key string) time.Duration {
+		key = strings.TrimPrefix(key, types.ConfigServer+".")
+		dur, err := time.ParseDuration(s.config.GetString(key))
+		if err != nil {
+			return 0
+		}
+		return dur
+	}
+
+	volumesTTL := cacheTTL(types.ConfigServerCacheVolumes)
+	snapshotsTTL := cacheTTL(types.ConfigServerCacheSnapshots)
+	if volumesTTL <= 0 && snapshotsTTL <= 0 {
+		return driver
+	}
+
+	ctx.WithFields(log.Fields{
+		"volumesTTL":   volumesTTL,
+		"snapshotsTTL": snapshotsTTL,
+	}).Info("enabled storage driver cache")
+
+	return registry.NewStorageDriverCache(driver, volumesTTL, snapshotsTTL)
+}
+
 func (s *storageService) Config() gofig.Config {
 	return s.config
 }
@@ -75,6 +172,15 @@ func (s *storageService) TaskExecute(
 	schema []byte) *types.Task {
 
 	t := newStorageServiceTask(ctx, run, s, schema)
+
+	s.queueRWL.RLock()
+	defer s.queueRWL.RUnlock()
+	if s.drained {
+		go execTask(t)
+		return &t.Task
+	}
+
+	s.tasks.Add(1)
 	go func() { s.taskExecQueue <- t }()
 	return &t.Task
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils/schema"
+	"github.com/emccode/libstorage/api/utils/tracing"
 )
 
 type task struct {
@@ -56,7 +57,11 @@ func newStorageServiceTask(
 }
 
 func execTask(t *task) {
+	ctx, span := tracing.StartSpan(t.ctx, "task")
+	span.SetAttribute("taskID", fmt.Sprintf("%d", t.ID))
+
 	defer func() {
+		span.Finish(t.Error)
 		t.CompleteTime = time.Now().Unix()
 		if t.Error != nil {
 			t.ctx.Error(t.Error)
@@ -74,9 +79,9 @@ func execTask(t *task) {
 	t.ctx.Info("executing task")
 
 	if t.storRunFunc != nil && t.storService != nil {
-		t.Result, t.Error = t.storRunFunc(t.ctx, t.storService)
+		t.Result, t.Error = t.storRunFunc(ctx, t.storService)
 	} else if t.runFunc != nil {
-		t.Result, t.Error = t.runFunc(t.ctx)
+		t.Result, t.Error = t.runFunc(ctx)
 	} else {
 		t.Error = goof.New("invalid task")
 	}
@@ -0,0 +1,305 @@
+package conformance
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+const (
+	// CheckVolumeCRUD is the check that creates, inspects, lists, copies,
+	// resizes and removes a volume.
+	CheckVolumeCRUD = "volumeCRUD"
+
+	// CheckIdempotency is the check that repeats inspections, listings and
+	// removals.
+	CheckIdempotency = "idempotency"
+
+	// CheckNotFound is the check that acts upon volumes and snapshots that do
+	// not exist.
+	CheckNotFound = "notFound"
+
+	// CheckAttachDetach is the check that attaches and detaches a volume.
+	CheckAttachDetach = "attachDetach"
+
+	// CheckSnapshotLifecycle is the check that snapshots a volume and creates,
+	// copies and removes snapshots.
+	CheckSnapshotLifecycle = "snapshotLifecycle"
+
+	// CheckFilters is the check that lists volumes and snapshots with
+	// filters.
+	CheckFilters = "filters"
+)
+
+const (
+	// CapCopy is the capability to copy volumes.
+	CapCopy = "copy"
+
+	// CapResize is the capability to resize volumes.
+	CapResize = "resize"
+
+	// CapAttach is the capability to attach volumes.
+	CapAttach = "attach"
+
+	// CapSnapshots is the capability to snapshot volumes.
+	CapSnapshots = "snapshots"
+
+	// CapCreateFromSnapshot is the capability to create volumes from
+	// snapshots.
+	CapCreateFromSnapshot = "createFromSnapshot"
+
+	// CapSnapshotCopy is the capability to copy snapshots.
+	CapSnapshotCopy = "snapshotCopy"
+)
+
+// Suite is the conformance suite of a storage driver. The suite runs a
+// standard matrix of checks against a service of the driver with the API test
+// harness, so the checks verify the behaviour that clients observe.
+type Suite struct {
+
+	// Driver is the name of the driver under test.
+	Driver string
+
+	// Service is the name of the service under test. The default is the name
+	// of the driver, which is the service that the test harness configures.
+	Service string
+
+	// Config is the config with which the test harness starts the servers.
+	Config []byte
+
+	// VolumeSize is the size, in GiB, of the volumes that the suite creates.
+	// The default is 1.
+	VolumeSize int64
+
+	// Skip are the names of the checks that are not run.
+	Skip []string
+}
+
+// Report is the result of a conformance run.
+type Report struct {
+
+	// Unsupported are the optional capabilities for which the driver returned
+	// ErrNotImplemented, or that the driver's capabilities do not include.
+	Unsupported []string
+
+	l sync.Mutex
+}
+
+// Supports returns a flag indicating whether or not the driver supports an
+// optional capability.
+func (r *Report) Supports(capability string) bool {
+	r.l.Lock()
+	defer r.l.Unlock()
+	for _, c := range r.Unsupported {
+		if c == capability {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) unsupported(capability string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+	for _, c := range r.Unsupported {
+		if c == capability {
+			return
+		}
+	}
+	r.Unsupported = append(r.Unsupported, capability)
+	sort.Strings(r.Unsupported)
+}
+
+// Run runs the suite's checks and returns the report of the run. A check that
+// fails marks the test as failed and the remaining checks still run.
+func (s *Suite) Run(t *testing.T) *Report {
+
+	rep := &Report{}
+
+	apitests.Run(t, s.Driver, s.Config,
+		func(config gofig.Config, client types.Client, t *testing.T) {
+			r := &run{
+				suite:   s,
+				report:  rep,
+				client:  client,
+				api:     client.API(),
+				service: s.service(),
+				t:       t,
+			}
+			for _, c := range checks {
+				if s.skip(c.name) {
+					t.Logf("%s: skipped", c.name)
+					continue
+				}
+				r.check = c.name
+				c.fn(r)
+			}
+		})
+
+	if len(rep.Unsupported) > 0 {
+		t.Logf("%s: unsupported capabilities: %s",
+			s.Driver, strings.Join(rep.Unsupported, ", "))
+	}
+	return rep
+}
+
+func (s *Suite) service() string {
+	if s.Service != "" {
+		return s.Service
+	}
+	return s.Driver
+}
+
+func (s *Suite) volumeSize() int64 {
+	if s.VolumeSize > 0 {
+		return s.VolumeSize
+	}
+	return 1
+}
+
+func (s *Suite) skip(check string) bool {
+	for _, name := range s.Skip {
+		if strings.EqualFold(name, check) {
+			return true
+		}
+	}
+	return false
+}
+
+// run is the state of the checks that run against a single server.
+type run struct {
+	suite   *Suite
+	report  *Report
+	client  types.Client
+	api     types.APIClient
+	service string
+	check   string
+	t       *testing.T
+}
+
+var nameSeq int64
+
+// name returns a unique name for a resource that the suite creates.
+func (r *run) name(kind string) string {
+	return fmt.Sprintf("conformance-%s-%d-%d",
+		kind, os.Getpid(), atomic.AddInt64(&nameSeq, 1))
+}
+
+func (r *run) errorf(format string, args ...interface{}) {
+	r.t.Errorf("%s: %s", r.check, fmt.Sprintf(format, args...))
+}
+
+// ok returns a flag indicating whether or not an operation succeeded, and
+// marks the check as failed if it did not.
+func (r *run) ok(op string, err error) bool {
+	if err == nil {
+		return true
+	}
+	r.errorf("%s: %v", op, err)
+	return false
+}
+
+// expect marks the check as failed if a condition is false.
+func (r *run) expect(cond bool, format string, args ...interface{}) bool {
+	if !cond {
+		r.errorf(format, args...)
+	}
+	return cond
+}
+
+// supported returns a flag indicating whether or not an operation of an
+// optional capability is supported. An operation that returns
+// ErrNotImplemented, or that the server rejects with a 501 status, marks the
+// capability as unsupported in the report.
+func (r *run) supported(capability string, err error) bool {
+	if !isNotImplemented(err) {
+		return true
+	}
+	r.report.unsupported(capability)
+	return false
+}
+
+// notFound marks the check as failed if an operation on a resource that does
+// not exist did not return a not found error. Operations that are not
+// implemented are ignored.
+func (r *run) notFound(op string, err error) {
+	if isNotImplemented(err) {
+		return
+	}
+	if err == nil {
+		r.errorf("%s: expected not found error", op)
+		return
+	}
+	r.expect(status(err) == 404,
+		"%s: expected not found error, got %d: %v", op, status(err), err)
+}
+
+func (r *run) createVolume(name string) (*types.Volume, bool) {
+	size := r.suite.volumeSize()
+	vol, err := r.api.VolumeCreate(nil, r.service, &types.VolumeCreateRequest{
+		Name: name,
+		Size: &size,
+	})
+	if !r.ok("VolumeCreate", err) {
+		return nil, false
+	}
+	return vol, true
+}
+
+// attachRequest returns a request to attach a volume with the next device
+// that the service's executor reports, if any, since drivers that take part in
+// the device workflow require it.
+func (r *run) attachRequest() *types.VolumeAttachRequest {
+	req := &types.VolumeAttachRequest{}
+	ctx := context.Background().WithValue(context.ServiceKey, r.service)
+	if nd, err := r.client.Executor().NextDevice(
+		ctx, utils.NewStore()); err == nil && nd != "" {
+		req.NextDeviceName = &nd
+	}
+	return req
+}
+
+// removeVolume removes a volume that a check created. The volume is detached
+// first and errors are ignored, since the check may have removed it.
+func (r *run) removeVolume(volumeID string) {
+	r.api.VolumeDetach(
+		nil, r.service, volumeID, &types.VolumeDetachRequest{Force: true})
+	r.api.VolumeRemove(nil, r.service, volumeID)
+}
+
+// removeSnapshot removes a snapshot that a check created. Errors are ignored,
+// since the check may have removed it.
+func (r *run) removeSnapshot(snapshotID string) {
+	r.api.SnapshotRemove(nil, r.service, snapshotID)
+}
+
+func status(err error) int {
+	if httpErr, ok := err.(goof.HTTPError); ok {
+		return httpErr.Status()
+	}
+	return 0
+}
+
+// isNotImplemented returns a flag indicating whether or not an operation
+// failed because the driver does not implement it, or because the server
+// rejected it since the driver's capabilities do not include it.
+func isNotImplemented(err error) bool {
+	if err == nil {
+		return false
+	}
+	return err.Error() == types.ErrNotImplemented.Error() ||
+		status(err) == http.StatusNotImplemented
+}
@@ -0,0 +1,331 @@
+package conformance
+
+import (
+	"fmt"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+type check struct {
+	name string
+	fn   func(r *run)
+}
+
+// checks is the conformance matrix in the order in which it runs.
+var checks = []*check{
+	{CheckVolumeCRUD, checkVolumeCRUD},
+	{CheckIdempotency, checkIdempotency},
+	{CheckNotFound, checkNotFound},
+	{CheckAttachDetach, checkAttachDetach},
+	{CheckSnapshotLifecycle, checkSnapshotLifecycle},
+	{CheckFilters, checkFilters},
+}
+
+func checkVolumeCRUD(r *run) {
+
+	name := r.name("volume")
+	vol, ok := r.createVolume(name)
+	if !ok {
+		return
+	}
+	defer r.removeVolume(vol.ID)
+
+	size := r.suite.volumeSize()
+	r.expect(vol.ID != "", "VolumeCreate: missing volume ID")
+	r.expect(vol.Name == name, "VolumeCreate: name=%s, want %s", vol.Name, name)
+	r.expect(vol.Size >= size, "VolumeCreate: size=%d, want %d", vol.Size, size)
+
+	ivol, err := r.api.VolumeInspect(nil, r.service, vol.ID, false)
+	if r.ok("VolumeInspect", err) {
+		r.expect(ivol.ID == vol.ID,
+			"VolumeInspect: id=%s, want %s", ivol.ID, vol.ID)
+		r.expect(ivol.Name == name,
+			"VolumeInspect: name=%s, want %s", ivol.Name, name)
+	}
+
+	vols, err := r.api.VolumesByService(nil, r.service, false)
+	if r.ok("VolumesByService", err) {
+		r.expect(vols[vol.ID] != nil,
+			"VolumesByService: missing volume %s", vol.ID)
+	}
+
+	copyName := r.name("copy")
+	cvol, err := r.api.VolumeCopy(nil, r.service, vol.ID,
+		&types.VolumeCopyRequest{VolumeName: copyName})
+	if r.supported(CapCopy, err) && r.ok("VolumeCopy", err) {
+		r.expect(cvol.ID != vol.ID, "VolumeCopy: copy has the source's ID")
+		r.expect(cvol.Name == copyName,
+			"VolumeCopy: name=%s, want %s", cvol.Name, copyName)
+		r.ok("VolumeRemove", r.api.VolumeRemove(nil, r.service, cvol.ID))
+	}
+
+	rvol, err := r.api.VolumeResize(nil, r.service, vol.ID,
+		&types.VolumeResizeRequest{Size: size + 1})
+	if r.supported(CapResize, err) && r.ok("VolumeResize", err) {
+		r.expect(rvol.Size >= size+1,
+			"VolumeResize: size=%d, want %d", rvol.Size, size+1)
+	}
+
+	if !r.ok("VolumeRemove", r.api.VolumeRemove(nil, r.service, vol.ID)) {
+		return
+	}
+	_, err = r.api.VolumeInspect(nil, r.service, vol.ID, false)
+	r.notFound("VolumeInspect of a removed volume", err)
+}
+
+func checkIdempotency(r *run) {
+
+	vol, ok := r.createVolume(r.name("volume"))
+	if !ok {
+		return
+	}
+	defer r.removeVolume(vol.ID)
+
+	for x := 0; x < 2; x++ {
+		ivol, err := r.api.VolumeInspect(nil, r.service, vol.ID, false)
+		if !r.ok("VolumeInspect", err) {
+			return
+		}
+		r.expect(
+			ivol.ID == vol.ID &&
+				ivol.Name == vol.Name &&
+				ivol.Size == vol.Size &&
+				ivol.Type == vol.Type,
+			"VolumeInspect: volume %s changed between inspections", vol.ID)
+
+		vols, err := r.api.VolumesByService(nil, r.service, false)
+		if !r.ok("VolumesByService", err) {
+			return
+		}
+		r.expect(vols[vol.ID] != nil,
+			"VolumesByService: missing volume %s", vol.ID)
+	}
+
+	if !r.ok("VolumeRemove", r.api.VolumeRemove(nil, r.service, vol.ID)) {
+		return
+	}
+	r.notFound("VolumeRemove of a removed volume",
+		r.api.VolumeRemove(nil, r.service, vol.ID))
+}
+
+func checkNotFound(r *run) {
+
+	volumeID := r.name("missing")
+	snapshotID := r.name("missing")
+
+	_, err := r.api.VolumeInspect(nil, r.service, volumeID, false)
+	r.notFound("VolumeInspect", err)
+
+	r.notFound("VolumeRemove", r.api.VolumeRemove(nil, r.service, volumeID))
+
+	_, err = r.api.VolumeCopy(nil, r.service, volumeID,
+		&types.VolumeCopyRequest{VolumeName: r.name("copy")})
+	r.notFound("VolumeCopy", err)
+
+	_, err = r.api.VolumeResize(nil, r.service, volumeID,
+		&types.VolumeResizeRequest{Size: r.suite.volumeSize() + 1})
+	r.notFound("VolumeResize", err)
+
+	_, err = r.api.VolumeSnapshot(nil, r.service, volumeID,
+		&types.VolumeSnapshotRequest{SnapshotName: r.name("snapshot")})
+	r.notFound("VolumeSnapshot", err)
+
+	_, _, err = r.api.VolumeAttach(nil, r.service, volumeID, r.attachRequest())
+	r.notFound("VolumeAttach", err)
+
+	_, err = r.api.VolumeDetach(nil, r.service, volumeID,
+		&types.VolumeDetachRequest{})
+	r.notFound("VolumeDetach", err)
+
+	_, err = r.api.SnapshotInspect(nil, r.service, snapshotID)
+	r.notFound("SnapshotInspect", err)
+
+	r.notFound("SnapshotRemove",
+		r.api.SnapshotRemove(nil, r.service, snapshotID))
+
+	_, err = r.api.SnapshotCopy(nil, r.service, snapshotID,
+		&types.SnapshotCopyRequest{SnapshotName: r.name("snapshot")})
+	r.notFound("SnapshotCopy", err)
+
+	_, err = r.api.VolumeCreateFromSnapshot(nil, r.service, snapshotID,
+		&types.VolumeCreateRequest{Name: r.name("volume")})
+	r.notFound("VolumeCreateFromSnapshot", err)
+}
+
+func checkAttachDetach(r *run) {
+
+	inst, err := r.api.InstanceInspect(nil, r.service)
+	if !r.ok("InstanceInspect", err) {
+		return
+	}
+	iid := inst.InstanceID
+
+	svc, err := r.api.ServiceInspect(nil, r.service)
+	if !r.ok("ServiceInspect", err) {
+		return
+	}
+
+	vol, ok := r.createVolume(r.name("volume"))
+	if !ok {
+		return
+	}
+	defer r.removeVolume(vol.ID)
+
+	avol, token, err := r.api.VolumeAttach(
+		nil, r.service, vol.ID, r.attachRequest())
+	if !r.supported(CapAttach, err) || !r.ok("VolumeAttach", err) {
+		return
+	}
+	r.expect(isAttached(avol, iid),
+		"VolumeAttach: volume %s is not attached to %s", vol.ID, iid.ID)
+
+	// a driver that takes part in the device workflow returns the token with
+	// which the client waits for the device
+	if nd := svc.Driver.NextDevice; nd != nil && !nd.Ignore {
+		r.expect(token != "", "VolumeAttach: missing attach token")
+	}
+
+	ivol, err := r.api.VolumeInspect(nil, r.service, vol.ID, true)
+	if r.ok("VolumeInspect", err) {
+		r.expect(isAttached(ivol, iid),
+			"VolumeInspect: volume %s is not attached to %s", vol.ID, iid.ID)
+	}
+
+	dvol, err := r.api.VolumeDetach(
+		nil, r.service, vol.ID, &types.VolumeDetachRequest{})
+	if !r.ok("VolumeDetach", err) {
+		return
+	}
+	r.expect(dvol == nil || !isAttached(dvol, iid),
+		"VolumeDetach: volume %s is still attached to %s", vol.ID, iid.ID)
+
+	ivol, err = r.api.VolumeInspect(nil, r.service, vol.ID, true)
+	if r.ok("VolumeInspect", err) {
+		r.expect(!isAttached(ivol, iid),
+			"VolumeInspect: volume %s is still attached to %s", vol.ID, iid.ID)
+	}
+}
+
+func checkSnapshotLifecycle(r *run) {
+
+	vol, ok := r.createVolume(r.name("volume"))
+	if !ok {
+		return
+	}
+	defer r.removeVolume(vol.ID)
+
+	name := r.name("snapshot")
+	snap, err := r.api.VolumeSnapshot(nil, r.service, vol.ID,
+		&types.VolumeSnapshotRequest{SnapshotName: name})
+	if !r.supported(CapSnapshots, err) || !r.ok("VolumeSnapshot", err) {
+		return
+	}
+	defer r.removeSnapshot(snap.ID)
+
+	r.expect(snap.ID != "", "VolumeSnapshot: missing snapshot ID")
+	r.expect(snap.Name == name,
+		"VolumeSnapshot: name=%s, want %s", snap.Name, name)
+	r.expect(snap.VolumeID == vol.ID,
+		"VolumeSnapshot: volumeID=%s, want %s", snap.VolumeID, vol.ID)
+
+	isnap, err := r.api.SnapshotInspect(nil, r.service, snap.ID)
+	if r.ok("SnapshotInspect", err) {
+		r.expect(isnap.ID == snap.ID,
+			"SnapshotInspect: id=%s, want %s", isnap.ID, snap.ID)
+	}
+
+	snaps, err := r.api.SnapshotsByService(nil, r.service)
+	if r.ok("SnapshotsByService", err) {
+		r.expect(snaps[snap.ID] != nil,
+			"SnapshotsByService: missing snapshot %s", snap.ID)
+	}
+
+	volName := r.name("volume")
+	svol, err := r.api.VolumeCreateFromSnapshot(nil, r.service, snap.ID,
+		&types.VolumeCreateRequest{Name: volName})
+	if r.supported(CapCreateFromSnapshot, err) &&
+		r.ok("VolumeCreateFromSnapshot", err) {
+		r.expect(svol.ID != vol.ID,
+			"VolumeCreateFromSnapshot: volume has the source's ID")
+		r.expect(svol.Name == volName,
+			"VolumeCreateFromSnapshot: name=%s, want %s", svol.Name, volName)
+		r.ok("VolumeRemove", r.api.VolumeRemove(nil, r.service, svol.ID))
+	}
+
+	copyName := r.name("snapshot")
+	csnap, err := r.api.SnapshotCopy(nil, r.service, snap.ID,
+		&types.SnapshotCopyRequest{SnapshotName: copyName})
+	if r.supported(CapSnapshotCopy, err) && r.ok("SnapshotCopy", err) {
+		r.expect(csnap.ID != snap.ID, "SnapshotCopy: copy has the source's ID")
+		r.expect(csnap.Name == copyName,
+			"SnapshotCopy: name=%s, want %s", csnap.Name, copyName)
+		r.ok("SnapshotRemove", r.api.SnapshotRemove(nil, r.service, csnap.ID))
+	}
+
+	if !r.ok("SnapshotRemove",
+		r.api.SnapshotRemove(nil, r.service, snap.ID)) {
+		return
+	}
+	_, err = r.api.SnapshotInspect(nil, r.service, snap.ID)
+	r.notFound("SnapshotInspect of a removed snapshot", err)
+	r.notFound("SnapshotRemove of a removed snapshot",
+		r.api.SnapshotRemove(nil, r.service, snap.ID))
+}
+
+func checkFilters(r *run) {
+
+	name := r.name("volume")
+	vol, ok := r.createVolume(name)
+	if !ok {
+		return
+	}
+	defer r.removeVolume(vol.ID)
+
+	vols, err := r.api.VolumesByService(
+		withFilter(fmt.Sprintf("(name=%s)", name)), r.service, false)
+	if r.ok("VolumesByService", err) {
+		r.expect(len(vols) == 1 && vols[vol.ID] != nil,
+			"VolumesByService: filter by name returned %d volumes", len(vols))
+	}
+
+	vols, err = r.api.VolumesByService(
+		withFilter(fmt.Sprintf("(!(name=%s))", name)), r.service, false)
+	if r.ok("VolumesByService", err) {
+		r.expect(vols[vol.ID] == nil,
+			"VolumesByService: negated filter returned volume %s", vol.ID)
+	}
+
+	_, err = r.api.VolumesByService(withFilter("name=bad"), r.service, false)
+	r.expect(err != nil, "VolumesByService: invalid filter was accepted")
+
+	snap, err := r.api.VolumeSnapshot(nil, r.service, vol.ID,
+		&types.VolumeSnapshotRequest{SnapshotName: r.name("snapshot")})
+	if !r.supported(CapSnapshots, err) || !r.ok("VolumeSnapshot", err) {
+		return
+	}
+	defer r.removeSnapshot(snap.ID)
+
+	snaps, err := r.api.SnapshotsByService(
+		withFilter(fmt.Sprintf("(volumeID=%s)", vol.ID)), r.service)
+	if r.ok("SnapshotsByService", err) {
+		r.expect(len(snaps) == 1 && snaps[snap.ID] != nil,
+			"SnapshotsByService: filter by volume ID returned %d snapshots",
+			len(snaps))
+	}
+}
+
+func withFilter(filter string) types.Context {
+	return context.Background().WithValue(context.FilterKey, filter)
+}
+
+// isAttached returns a flag indicating whether or not a volume is attached to
+// an instance.
+func isAttached(vol *types.Volume, iid *types.InstanceID) bool {
+	for _, att := range vol.Attachments {
+		if att.InstanceID != nil && att.InstanceID.ID == iid.ID {
+			return true
+		}
+	}
+	return false
+}
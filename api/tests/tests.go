@@ -22,6 +22,7 @@ import (
 	"github.com/emccode/libstorage/api/server/executors"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 	"github.com/emccode/libstorage/client"
 )
 
@@ -54,6 +55,12 @@ func init() {
 	if err != nil {
 		tcpTest = true
 	}
+
+	// the per-protocol configs and the profiles config of the harness
+	apiconfig.RegisterScope(apiconfig.NewScope("libstorage.tests.*"))
+	apiconfig.RegisterScope(apiconfig.NewScope("libstorage.profiles").
+		Key(gofig.Bool, "enabled").
+		Key(gofig.String, "groups"))
 }
 
 var (
@@ -69,8 +76,9 @@ var (
 var (
 	debugConfig = []byte(`
 libstorage:
-  readTimeout: 300
-  writeTimeout: 300
+  http:
+    readTimeout: 300
+    writeTimeout: 300
   logging:
     httpRequests: true
     httpResponses: true
@@ -103,6 +103,9 @@ type APIClient interface {
 	// ServiceInspect returns information about a service.
 	ServiceInspect(ctx Context, name string) (*ServiceInfo, error)
 
+	// ServiceProfiles returns the volume profiles of a service.
+	ServiceProfiles(ctx Context, name string) (VolumeProfileMap, error)
+
 	// Volumes returns a list of all Volumes for all Services.
 	Volumes(
 		ctx Context,
@@ -114,12 +117,36 @@ type APIClient interface {
 		service string,
 		attachments bool) (VolumeMap, error)
 
+	// VolumesPage returns a page of the Volumes for all Services and the
+	// continuation token for the next page. The token is empty if the page
+	// is the last page.
+	VolumesPage(
+		ctx Context,
+		attachments bool,
+		page *PageRequest) (ServiceVolumeMap, string, error)
+
+	// VolumesByServicePage returns a page of the Volumes for a service and
+	// the continuation token for the next page. The token is empty if the
+	// page is the last page.
+	VolumesByServicePage(
+		ctx Context,
+		service string,
+		attachments bool,
+		page *PageRequest) (VolumeMap, string, error)
+
 	// VolumeInspect gets information about a single volume.
 	VolumeInspect(
 		ctx Context,
 		service, volumeID string,
 		attachments bool) (*Volume, error)
 
+	// VolumeInspectByName gets information about a single volume by the
+	// volume's name.
+	VolumeInspectByName(
+		ctx Context,
+		service, volumeName string,
+		attachments bool) (*Volume, error)
+
 	// VolumeCreate creates a single volume.
 	VolumeCreate(
 		ctx Context,
@@ -138,6 +165,12 @@ type APIClient interface {
 		service, volumeID string,
 		request *VolumeCopyRequest) (*Volume, error)
 
+	// VolumeResize resizes a single volume.
+	VolumeResize(
+		ctx Context,
+		service, volumeID string,
+		request *VolumeResizeRequest) (*Volume, error)
+
 	// VolumeRemove removes a single volume.
 	VolumeRemove(
 		ctx Context,
@@ -182,6 +215,21 @@ type APIClient interface {
 	SnapshotsByService(
 		ctx Context, service string) (SnapshotMap, error)
 
+	// SnapshotsPage returns a page of the Snapshots for all Services and the
+	// continuation token for the next page. The token is empty if the page
+	// is the last page.
+	SnapshotsPage(
+		ctx Context,
+		page *PageRequest) (ServiceSnapshotMap, string, error)
+
+	// SnapshotsByServicePage returns a page of the Snapshots for a service
+	// and the continuation token for the next page. The token is empty if
+	// the page is the last page.
+	SnapshotsByServicePage(
+		ctx Context,
+		service string,
+		page *PageRequest) (SnapshotMap, string, error)
+
 	// SnapshotInspect gets information about a single snapshot.
 	SnapshotInspect(
 		ctx Context,
@@ -198,6 +246,12 @@ type APIClient interface {
 		service, snapshotID string,
 		request *SnapshotCopyRequest) (*Snapshot, error)
 
+	// Tasks returns all the tasks keyed by their IDs.
+	Tasks(ctx Context) (map[string]*Task, error)
+
+	// TaskInspect returns a single task.
+	TaskInspect(ctx Context, taskID int) (*Task, error)
+
 	// Executors returns information about the executors.
 	Executors(
 		ctx Context) (map[string]*ExecutorInfo, error)
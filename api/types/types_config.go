@@ -57,6 +57,12 @@ const (
 	// ConfigLogHTTPResponses is a config key.
 	ConfigLogHTTPResponses = ConfigLogging + ".httpResponses"
 
+	// ConfigTracing is a config key.
+	ConfigTracing = ConfigRoot + ".tracing"
+
+	// ConfigTracingFile is a config key.
+	ConfigTracingFile = ConfigTracing + ".file"
+
 	// ConfigHTTPDisableKeepAlive is a config key.
 	ConfigHTTPDisableKeepAlive = ConfigRoot + ".http.disableKeepAlive"
 
@@ -123,4 +129,88 @@ const (
 
 	// ConfigServerTasksLogTimeout is a config key.
 	ConfigServerTasksLogTimeout = ConfigServerTasks + ".logTimeout"
+
+	// ConfigServerCache is a config key.
+	ConfigServerCache = ConfigServer + ".cache"
+
+	// ConfigServerCacheVolumes is a config key.
+	ConfigServerCacheVolumes = ConfigServerCache + ".volumes"
+
+	// ConfigServerCacheSnapshots is a config key.
+	ConfigServerCacheSnapshots = ConfigServerCache + ".snapshots"
+
+	// ConfigServerVolumes is a config key.
+	ConfigServerVolumes = ConfigServer + ".volumes"
+
+	// ConfigServerVolumesUniqueNames is a config key.
+	ConfigServerVolumesUniqueNames = ConfigServerVolumes + ".uniqueNames"
+
+	// ConfigServerProfiles is a config key.
+	ConfigServerProfiles = ConfigServer + ".profiles"
+
+	// ConfigServerAudit is a config key.
+	ConfigServerAudit = ConfigServer + ".audit"
+
+	// ConfigServerAuditEnabled is a config key.
+	ConfigServerAuditEnabled = ConfigServerAudit + ".enabled"
+
+	// ConfigServerAuditType is a config key.
+	ConfigServerAuditType = ConfigServerAudit + ".type"
+
+	// ConfigServerAuditFile is a config key.
+	ConfigServerAuditFile = ConfigServerAudit + ".file"
+
+	// ConfigServerAuditMaxSize is a config key.
+	ConfigServerAuditMaxSize = ConfigServerAudit + ".maxSize"
+
+	// ConfigServerAuditMaxBackups is a config key.
+	ConfigServerAuditMaxBackups = ConfigServerAudit + ".maxBackups"
+
+	// ConfigServerAuditSyslogNetwork is a config key.
+	ConfigServerAuditSyslogNetwork = ConfigServerAudit + ".syslog.network"
+
+	// ConfigServerAuditSyslogAddress is a config key.
+	ConfigServerAuditSyslogAddress = ConfigServerAudit + ".syslog.address"
+
+	// ConfigServerAuditSyslogTag is a config key.
+	ConfigServerAuditSyslogTag = ConfigServerAudit + ".syslog.tag"
+
+	// ConfigServerReload is a config key.
+	ConfigServerReload = ConfigServer + ".reload"
+
+	// ConfigServerReloadDrainTimeout is a config key.
+	ConfigServerReloadDrainTimeout = ConfigServerReload + ".drainTimeout"
+
+	// ConfigServerName is a config key.
+	ConfigServerName = ConfigServer + ".name"
+
+	// ConfigServerAdminToken is a config key.
+	ConfigServerAdminToken = ConfigServer + ".adminToken"
+
+	// ConfigServerAdminTokenFile is a config key.
+	ConfigServerAdminTokenFile = ConfigServer + ".adminTokenFile"
+
+	// ConfigServerAdminTokens is a config key.
+	ConfigServerAdminTokens = ConfigServer + ".adminTokens"
+
+	// ConfigServerState is a config key.
+	ConfigServerState = ConfigServer + ".state"
+
+	// ConfigServerStateEnabled is a config key.
+	ConfigServerStateEnabled = ConfigServerState + ".enabled"
+
+	// ConfigServerStateDir is a config key.
+	ConfigServerStateDir = ConfigServerState + ".dir"
+
+	// ConfigSecrets is a config key.
+	ConfigSecrets = ConfigRoot + ".secrets"
+
+	// ConfigSecretsKeystore is a config key.
+	ConfigSecretsKeystore = ConfigSecrets + ".keystore"
+
+	// ConfigSecretsKeystorePath is a config key.
+	ConfigSecretsKeystorePath = ConfigSecretsKeystore + ".path"
+
+	// ConfigSecretsKeystoreKeyFile is a config key.
+	ConfigSecretsKeystoreKeyFile = ConfigSecretsKeystore + ".keyFile"
 )
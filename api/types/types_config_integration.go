@@ -67,6 +67,9 @@ const (
 	// ConfigIgVolOpsCreateDefaultIOPS is a config key.
 	ConfigIgVolOpsCreateDefaultIOPS = ConfigIgVolOpsCreateDefault + ".IOPS"
 
+	// ConfigIgVolOpsCreateDefaultProfile is a config key.
+	ConfigIgVolOpsCreateDefaultProfile = ConfigIgVolOpsCreateDefault + ".profile"
+
 	// ConfigIgVolOpsRemove is a config key.
 	ConfigIgVolOpsRemove = ConfigIgVolOps + ".remove"
 
@@ -9,7 +9,19 @@ type NewStorageDriver func() StorageDriver
 // VolumesOpts are options when inspecting a volume.
 type VolumesOpts struct {
 	Attachments bool
-	Opts        Store
+
+	// Page is the page of volumes that the client requested, or nil if the
+	// client requested every volume. Drivers that do not page volumes
+	// ignore it.
+	Page *Page
+
+	// Filter is the filter that the client requested, or nil if the client
+	// did not request a filter. A driver may apply the filter when it lists
+	// the volumes on the storage platform. The server applies the filter to
+	// the driver's volumes either way.
+	Filter *Filter
+
+	Opts Store
 }
 
 // VolumeInspectOpts are options when inspecting a volume.
@@ -24,9 +36,16 @@ type VolumeCreateOpts struct {
 	IOPS             *int64
 	Size             *int64
 	Type             *string
+	Profile          *string
 	Opts             Store
 }
 
+// VolumeResizeOpts are options when resizing a volume.
+type VolumeResizeOpts struct {
+	Size int64
+	Opts Store
+}
+
 // VolumeAttachOpts are options for attaching a volume.
 type VolumeAttachOpts struct {
 	NextDevice *string
@@ -48,6 +67,38 @@ type StorageDriverManager interface {
 	Driver() StorageDriver
 }
 
+// StorageDriverCache is a caching wrapper for a StorageDriver.
+type StorageDriverCache interface {
+	StorageDriver
+
+	// CacheStats returns the cache's statistics.
+	CacheStats() *CacheStats
+
+	// CacheInvalidate removes all entries from the cache.
+	CacheInvalidate()
+}
+
+// CacheStats are statistics about a StorageDriverCache.
+type CacheStats struct {
+	// VolumesTTL is the duration for which volumes are cached.
+	VolumesTTL string `json:"volumesTTL" yaml:"volumesTTL"`
+
+	// SnapshotsTTL is the duration for which snapshots are cached.
+	SnapshotsTTL string `json:"snapshotsTTL" yaml:"snapshotsTTL"`
+
+	// Entries is the number of entries currently in the cache.
+	Entries int `json:"entries"`
+
+	// Hits is the number of lookups served from the cache.
+	Hits int64 `json:"hits"`
+
+	// Misses is the number of lookups sent to the driver.
+	Misses int64 `json:"misses"`
+
+	// Invalidations is the number of times the cache has been invalidated.
+	Invalidations int64 `json:"invalidations"`
+}
+
 /*
 StorageDriver is a libStorage driver used by the routes to implement the
 backend functionality.
@@ -129,7 +180,8 @@ type StorageDriver interface {
 		volumeID string,
 		opts *VolumeDetachOpts) (*Volume, error)
 
-	// Snapshots returns all volumes or a filtered list of snapshots.
+	// Snapshots returns all snapshots or a filtered list of snapshots. The
+	// compiled filter, if any, is returned by filters.FromStore(opts).
 	Snapshots(
 		ctx Context,
 		opts Store) ([]*Snapshot, error)
@@ -154,3 +206,25 @@ type StorageDriver interface {
 		snapshotID string,
 		opts Store) error
 }
+
+// StorageDriverVolumeResizer is a StorageDriver that can resize volumes.
+// Resizing a volume is an optional operation, so a driver implements this
+// interface only if its storage platform supports it.
+type StorageDriverVolumeResizer interface {
+
+	// VolumeResize resizes a volume. The size is in GiB.
+	VolumeResize(
+		ctx Context,
+		volumeID string,
+		opts *VolumeResizeOpts) (*Volume, error)
+}
+
+// StorageDriverCapabilities is a StorageDriver that describes the optional
+// operations and volume options that it supports. The server returns the
+// capabilities with a service's information, and rejects requests for the
+// operations that a driver does not support before they reach the driver.
+type StorageDriverCapabilities interface {
+
+	// Capabilities returns the driver's capabilities.
+	Capabilities(ctx Context) (*Capabilities, error)
+}
@@ -41,6 +41,31 @@ type ErrDriverTypeErr struct{ goof.Goof }
 // the objects for which the process did complete.
 type ErrBatchProcess struct{ goof.Goof }
 
+// ErrDuplicateName occurs when a volume is created with the name of an
+// existing volume while volume names must be unique, or when a volume is
+// addressed by a name that more than one volume has.
+type ErrDuplicateName struct{ goof.Goof }
+
 // ErrBadFilter occurs when a bad filter is supplied via the filter query
 // string.
 type ErrBadFilter struct{ goof.Goof }
+
+// ErrBadPage occurs when a bad limit, offset, continuation token, or sort is
+// supplied via the query string.
+type ErrBadPage struct{ goof.Goof }
+
+// ErrBadRequest occurs when a request's body or the resource that its path
+// names is not valid for the request's route.
+type ErrBadRequest struct{ goof.Goof }
+
+// ErrBadProfile occurs when a request to create a volume names a volume
+// profile that the service does not have.
+type ErrBadProfile struct{ goof.Goof }
+
+// ErrInvalidConfig occurs when a config has unknown keys or keys with invalid
+// values. The error's fields describe the problem with each key.
+type ErrInvalidConfig struct{ goof.Goof }
+
+// ErrUnsupportedCapability occurs when an operation or volume option is
+// requested of a service whose driver does not support it.
+type ErrUnsupportedCapability struct{ goof.Goof }
@@ -13,9 +13,18 @@ const (
 	// sent from the client.
 	TransactionHeader = "Libstorage-Tx"
 
+	// TraceParentHeader is the HTTP header that contains the W3C trace
+	// context of the client span that sent a request.
+	TraceParentHeader = "Traceparent"
+
 	// ServerNameHeader is the HTTP header that contains the randomly generated
 	// name the server creates for unique identification when the server starts
 	// for the first time. This header is provided with every response sent
 	// from the server.
 	ServerNameHeader = "Libstorage-Servername"
+
+	// NextTokenHeader is the HTTP header that contains the continuation token
+	// for the next page of a paged listing. The header is omitted from the
+	// response with the last page.
+	NextTokenHeader = "Libstorage-Nexttoken"
 )
@@ -34,3 +34,15 @@ type Middleware interface {
 		r *http.Request,
 		store Store) error
 }
+
+// SchemaMiddleware is middleware that validates a route's request and
+// response payloads against JSON schemas.
+type SchemaMiddleware interface {
+	Middleware
+
+	// RequestSchema returns the JSON schema of the request payload, or nil.
+	RequestSchema() []byte
+
+	// ResponseSchema returns the JSON schema of the response payload, or nil.
+	ResponseSchema() []byte
+}
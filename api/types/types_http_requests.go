@@ -11,6 +11,7 @@ type VolumeCreateRequest struct {
 	IOPS             *int64                 `json:"iops,omitempty"`
 	Size             *int64                 `json:"size,omitempty"`
 	Type             *string                `json:"type,omitempty"`
+	Profile          *string                `json:"profile,omitempty"`
 	Opts             map[string]interface{} `json:"opts,omitempty"`
 }
 
@@ -26,6 +27,12 @@ type VolumeSnapshotRequest struct {
 	Opts         map[string]interface{} `json:"opts,omitempty"`
 }
 
+// VolumeResizeRequest is the JSON body for resizing a volume.
+type VolumeResizeRequest struct {
+	Size int64                  `json:"size"`
+	Opts map[string]interface{} `json:"opts,omitempty"`
+}
+
 // VolumeAttachRequest is the JSON body for attaching a volume to an instance.
 type VolumeAttachRequest struct {
 	Force          bool                   `json:"force,omitempty"`
@@ -44,4 +44,54 @@ type Route interface {
 
 	// GetMiddlewares returns a list of route-specific middleware.
 	GetMiddlewares() []Middleware
+
+	// Doc documents the route.
+	Doc(doc *RouteDoc) Route
+
+	// GetDoc returns the route's documentation, or nil if the route is not
+	// documented.
+	GetDoc() *RouteDoc
+}
+
+// RouteDoc documents a route in the server's OpenAPI document. The route's
+// method, path, path parameters, and the query strings it must match are
+// documented from the route itself, and the schemas of the request and
+// response payloads are those of the route's SchemaMiddleware.
+type RouteDoc struct {
+
+	// Summary is a short description of what the route does.
+	Summary string
+
+	// Params are the optional query parameters that the route accepts.
+	Params []*RouteParam
+
+	// Status is the status code of a successful response. The default is
+	// 200.
+	Status int
+
+	// ContentType is the content type of a successful response. The default
+	// is application/json.
+	ContentType string
+
+	// ResponseSchema is the JSON schema of a successful response for a route
+	// that does not validate its response.
+	ResponseSchema []byte
+
+	// Async indicates that the route runs as a task. The route accepts the
+	// async query parameter and responds with the task when it is set.
+	Async bool
+}
+
+// RouteParam documents a query parameter.
+type RouteParam struct {
+
+	// Name is the name of the parameter.
+	Name string
+
+	// Type is the JSON type of the parameter's value, such as string,
+	// boolean, or integer.
+	Type string
+
+	// Description describes the parameter.
+	Description string
 }
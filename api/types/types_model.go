@@ -1,5 +1,10 @@
 package types
 
+import (
+	"strings"
+	"time"
+)
+
 // StorageType is the type of storage a driver provides.
 type StorageType string
 
@@ -263,8 +268,130 @@ type DriverInfo struct {
 
 	// NextDevice is the next available device information for the service.
 	NextDevice *NextDeviceInfo `json:"nextDevice,omitempty" yaml:"nextDevice,omitempty"`
+
+	// Capabilities are the optional operations and volume options that the
+	// driver supports. The field is omitted if the driver does not describe
+	// its capabilities.
+	Capabilities *Capabilities `json:"capabilities,omitempty" yaml:"capabilities,omitempty"`
 }
 
+const (
+	// CapabilitySnapshots is the capability to snapshot volumes, and to copy
+	// snapshots and create volumes from them.
+	CapabilitySnapshots = "snapshots"
+
+	// CapabilityCopy is the capability to copy volumes.
+	CapabilityCopy = "copy"
+
+	// CapabilityResize is the capability to resize volumes.
+	CapabilityResize = "resize"
+
+	// CapabilityMultiAttach is the capability to attach a volume to more than
+	// one instance at a time.
+	CapabilityMultiAttach = "multiAttach"
+
+	// CapabilityIOPS is the capability to provision volumes with IOPS.
+	CapabilityIOPS = "iops"
+
+	// CapabilityVolumeType is the capability to create a volume of a type.
+	CapabilityVolumeType = "volumeType"
+
+	// CapabilityMaxSize is the capability to create a volume of a size.
+	CapabilityMaxSize = "maxSize"
+)
+
+// Capabilities describes the optional operations and volume options that a
+// driver supports.
+type Capabilities struct {
+	// Snapshots indicates whether or not the driver can snapshot volumes.
+	Snapshots bool `json:"snapshots"`
+
+	// Copy indicates whether or not the driver can copy volumes.
+	Copy bool `json:"copy"`
+
+	// Resize indicates whether or not the driver can resize volumes.
+	Resize bool `json:"resize"`
+
+	// MultiAttach indicates whether or not a volume can be attached to more
+	// than one instance at a time.
+	MultiAttach bool `json:"multiAttach" yaml:"multiAttach"`
+
+	// IOPS indicates whether or not volumes can be provisioned with IOPS.
+	IOPS bool `json:"iops"`
+
+	// VolumeTypes are the types of volumes the driver can create. Any type
+	// is accepted if the list is empty.
+	VolumeTypes []string `json:"volumeTypes,omitempty" yaml:"volumeTypes,omitempty"`
+
+	// MaxSize is the maximum size, in GiB, of a volume. Zero is no limit.
+	MaxSize int64 `json:"maxSize,omitempty" yaml:"maxSize,omitempty"`
+}
+
+// Supports returns a flag indicating whether or not the driver supports one
+// of the optional operations: snapshots, copy, resize, multiAttach or iops.
+func (c *Capabilities) Supports(capability string) bool {
+	switch capability {
+	case CapabilitySnapshots:
+		return c.Snapshots
+	case CapabilityCopy:
+		return c.Copy
+	case CapabilityResize:
+		return c.Resize
+	case CapabilityMultiAttach:
+		return c.MultiAttach
+	case CapabilityIOPS:
+		return c.IOPS
+	}
+	return false
+}
+
+// SupportsVolumeType returns a flag indicating whether or not the driver can
+// create a volume of a type.
+func (c *Capabilities) SupportsVolumeType(volumeType string) bool {
+	if len(c.VolumeTypes) == 0 {
+		return true
+	}
+	for _, t := range c.VolumeTypes {
+		if strings.EqualFold(t, volumeType) {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportsSize returns a flag indicating whether or not the driver can create
+// a volume of a size, in GiB.
+func (c *Capabilities) SupportsSize(size int64) bool {
+	return c.MaxSize <= 0 || size <= c.MaxSize
+}
+
+// VolumeProfile is a named set of options with which a service creates
+// volumes, such as a volume type and IOPS. A volume that is created with a
+// profile gets the profile's options unless the request sets them.
+type VolumeProfile struct {
+	// Name is the profile's name.
+	Name string `json:"name"`
+
+	// AvailabilityZone is the zone in which volumes are created.
+	AvailabilityZone string `json:"availabilityZone,omitempty" yaml:"availabilityZone,omitempty"`
+
+	// IOPS is the IOPS with which volumes are created.
+	IOPS int64 `json:"iops,omitempty" yaml:"iops,omitempty"`
+
+	// Size is the size, in GiB, of volumes that are created without one.
+	Size int64 `json:"size,omitempty" yaml:"size,omitempty"`
+
+	// Type is the type of volumes.
+	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+	// Opts are the driver-specific options with which volumes are created,
+	// such as ScaleIO's thinOrThick.
+	Opts map[string]interface{} `json:"opts,omitempty" yaml:"opts,omitempty"`
+}
+
+// VolumeProfileMap is the response for listing the profiles of a service.
+type VolumeProfileMap map[string]*VolumeProfile
+
 // NextDeviceInfo assists the libStorage client in determining the
 // next available device name by providing the driver's device prefix and
 // optional pattern.
@@ -336,3 +463,73 @@ type Task struct {
 	// Error contains the error if the task was unsuccessful.
 	Error error `json:"error,omitempty" yaml:",omitempty"`
 }
+
+// AuditOutcome is the outcome of an audited operation.
+type AuditOutcome string
+
+const (
+	// AuditOutcomeSuccess is the outcome of an operation that succeeded.
+	AuditOutcomeSuccess AuditOutcome = "success"
+
+	// AuditOutcomeFailure is the outcome of an operation that failed.
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditRecord is a record of a mutating operation received by the server.
+type AuditRecord struct {
+	// Time is the time at which the operation was received.
+	Time time.Time `json:"time" yaml:"time"`
+
+	// Service is the name of the service on which the operation was invoked.
+	Service string `json:"service,omitempty" yaml:",omitempty"`
+
+	// Route is the name of the route that handled the operation.
+	Route string `json:"route" yaml:"route"`
+
+	// Method is the operation's HTTP method.
+	Method string `json:"method" yaml:"method"`
+
+	// VolumeID is the ID of the volume on which the operation was invoked,
+	// or of the volume that the operation created.
+	VolumeID string `json:"volumeID,omitempty" yaml:"volumeID,omitempty"`
+
+	// SnapshotID is the ID of the snapshot on which the operation was
+	// invoked, or of the snapshot that the operation created.
+	SnapshotID string `json:"snapshotID,omitempty" yaml:"snapshotID,omitempty"`
+
+	// InstanceID is the ID of the instance that invoked the operation.
+	InstanceID string `json:"instanceID,omitempty" yaml:"instanceID,omitempty"`
+
+	// TransactionID is the ID of the operation's transaction.
+	TransactionID string `json:"txID,omitempty" yaml:"txID,omitempty"`
+
+	// User is the name of the user that invoked the operation.
+	User string `json:"user,omitempty" yaml:",omitempty"`
+
+	// Outcome is the outcome of the operation.
+	Outcome AuditOutcome `json:"outcome" yaml:"outcome"`
+
+	// StatusCode is the HTTP status code of the operation's response.
+	StatusCode int `json:"statusCode" yaml:"statusCode"`
+
+	// Error is the error returned by a failed operation.
+	Error string `json:"error,omitempty" yaml:",omitempty"`
+
+	// Duration is the number of milliseconds the operation took to complete.
+	// The duration of an asynchronous operation includes its task.
+	Duration int64 `json:"duration" yaml:"duration"`
+}
+
+// AdminToken is a named token that authorizes requests to the server's admin
+// routes.
+type AdminToken struct {
+	// Name is the name of the token.
+	Name string `json:"name" yaml:"name"`
+
+	// Token is the token's value. It is omitted when tokens are listed.
+	Token string `json:"token,omitempty" yaml:"token,omitempty"`
+
+	// Expires is the epoch time at which the token expires. A token with a
+	// zero value never expires.
+	Expires int64 `json:"expires,omitempty" yaml:"expires,omitempty"`
+}
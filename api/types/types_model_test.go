@@ -85,3 +85,42 @@ func TestInstanceWithOnlyInstanceIDMarshalToYAML(t *testing.T) {
 
 	fmt.Println(string(out))
 }
+
+func TestCapabilitiesSupports(t *testing.T) {
+
+	c := &Capabilities{Snapshots: true, IOPS: true}
+	for _, tc := range []struct {
+		capability string
+		supported  bool
+	}{
+		{CapabilitySnapshots, true},
+		{CapabilityIOPS, true},
+		{CapabilityCopy, false},
+		{CapabilityResize, false},
+		{CapabilityMultiAttach, false},
+		{"unknown", false},
+	} {
+		if c.Supports(tc.capability) != tc.supported {
+			t.Errorf("Supports(%q) != %v", tc.capability, tc.supported)
+		}
+	}
+
+	if !c.SupportsVolumeType("any") {
+		t.Error("empty volume types should accept any type")
+	}
+	c.VolumeTypes = []string{"gp2", "io1"}
+	if !c.SupportsVolumeType("IO1") {
+		t.Error("volume type io1 should be supported")
+	}
+	if c.SupportsVolumeType("sc1") {
+		t.Error("volume type sc1 should not be supported")
+	}
+
+	if !c.SupportsSize(1 << 20) {
+		t.Error("zero max size should accept any size")
+	}
+	c.MaxSize = 16
+	if !c.SupportsSize(16) || c.SupportsSize(17) {
+		t.Error("max size of 16 not enforced")
+	}
+}
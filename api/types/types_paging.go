@@ -0,0 +1,85 @@
+package types
+
+import "encoding/json"
+
+// PageRequest is a client's request for one page of a sorted listing of
+// volumes or snapshots.
+type PageRequest struct {
+
+	// Limit is the maximum number of objects in the page. Zero means the
+	// page includes every object after the offset.
+	Limit int
+
+	// Offset is the number of objects, in sort order, that precede the page.
+	Offset int
+
+	// Token is the continuation token returned with the previous page. A
+	// token replaces the offset.
+	Token string
+
+	// Sort is a comma-separated list of the fields by which objects are
+	// sorted, such as name,-size. A field prefixed with a minus sign is
+	// sorted in descending order.
+	Sort string
+}
+
+// Page describes the page of a listing that the server returns.
+//
+// A storage driver that pages volumes on the storage platform may use the
+// page of a VolumesOpts to list only the page's volumes. Such a driver sets
+// Paged to true and sets NextToken to the driver's own continuation token,
+// or to an empty string for the last page. Otherwise the server sorts and
+// pages the driver's volumes itself.
+type Page struct {
+
+	// Limit is the maximum number of objects in the page. Zero means the
+	// page includes every object after the offset.
+	Limit int
+
+	// Offset is the number of objects, in sort order, that precede the page.
+	Offset int
+
+	// Token is the continuation token that a storage driver returned with the
+	// previous page.
+	Token string
+
+	// Sort are the keys by which objects are sorted.
+	Sort []*SortKey
+
+	// Paged is set by a storage driver that listed only the page's objects.
+	Paged bool
+
+	// NextToken is set by a storage driver that listed only the page's
+	// objects to the token for the next page.
+	NextToken string
+}
+
+// SortKey is a field by which objects are sorted.
+type SortKey struct {
+
+	// Field is the name of the field.
+	Field string
+
+	// Descending indicates whether the objects are sorted in descending
+	// order.
+	Descending bool
+}
+
+// PagedResult is the result of a task that lists a page of volumes or
+// snapshots. The result marshals to JSON as the page's objects. The
+// continuation token for the next page is returned in the NextTokenHeader
+// header of the response.
+type PagedResult struct {
+
+	// Result is the page's objects.
+	Result interface{}
+
+	// NextToken is the continuation token for the next page, or an empty
+	// string if the page is the last page.
+	NextToken string
+}
+
+// MarshalJSON marshals the page's objects to JSON.
+func (r *PagedResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Result)
+}
@@ -52,4 +52,8 @@ type Server interface {
 
 	// Addrs returns the server's configured endpoint addresses.
 	Addrs() []string
+
+	// Reload re-reads the server's configuration and applies it without
+	// closing the server's endpoints.
+	Reload() error
 }
@@ -1,5 +1,7 @@
 package types
 
+import "github.com/akutz/gofig"
+
 // Service is the base type for services.
 type Service interface {
 	Driver
@@ -28,6 +30,9 @@ type StorageTaskRunFunc func(
 type StorageService interface {
 	Service
 
+	// Config returns the service's scoped configuration.
+	Config() gofig.Config
+
 	// Driver returns the service's StorageDriver.
 	Driver() StorageDriver
 
@@ -0,0 +1,225 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+const keystoreName = "keystore"
+
+var keystoreLock = &sync.Mutex{}
+
+// Keystore is a local file of named secrets. Each secret is encrypted with
+// AES-256-GCM using the key in the keystore's key file, which is created the
+// first time a secret is set.
+type Keystore struct {
+	path    string
+	keyFile string
+}
+
+// NewKeystore returns the keystore described by the config's
+// libstorage.secrets.keystore properties.
+func NewKeystore(config gofig.Config) *Keystore {
+	path, keyFile := keystorePaths(config)
+	return &Keystore{path: path, keyFile: keyFile}
+}
+
+// Get returns the value of the secret with the specified name.
+func (k *Keystore) Get(name string) (string, error) {
+
+	keystoreLock.Lock()
+	defer keystoreLock.Unlock()
+
+	secrets, err := k.read()
+	if err != nil {
+		return "", err
+	}
+	sealed, ok := secrets[name]
+	if !ok {
+		return "", utils.NewNotFoundError(name)
+	}
+
+	gcm, err := k.cipher(false)
+	if err != nil {
+		return "", err
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(buf) < gcm.NonceSize() {
+		return "", goof.WithField("name", name, "invalid keystore secret")
+	}
+	nonce, ciphertext := buf[:gcm.NonceSize()], buf[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return "", goof.WithFieldE(
+			"name", name, "error decrypting keystore secret", err)
+	}
+	return string(plaintext), nil
+}
+
+// Set stores the secret with the specified name, replacing the secret's
+// previous value.
+func (k *Keystore) Set(name, value string) error {
+
+	if name == "" {
+		return goof.New("missing secret name")
+	}
+
+	keystoreLock.Lock()
+	defer keystoreLock.Unlock()
+
+	secrets, err := k.read()
+	if err != nil {
+		return err
+	}
+
+	gcm, err := k.cipher(true)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(value), []byte(name))
+	secrets[name] = base64.StdEncoding.EncodeToString(sealed)
+
+	return k.write(secrets)
+}
+
+// Remove removes the secret with the specified name.
+func (k *Keystore) Remove(name string) error {
+
+	keystoreLock.Lock()
+	defer keystoreLock.Unlock()
+
+	secrets, err := k.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return utils.NewNotFoundError(name)
+	}
+	delete(secrets, name)
+	return k.write(secrets)
+}
+
+// Names returns the sorted names of the keystore's secrets.
+func (k *Keystore) Names() ([]string, error) {
+
+	keystoreLock.Lock()
+	defer keystoreLock.Unlock()
+
+	secrets, err := k.read()
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// keystorePaths returns the path to the config's keystore file and the path
+// to the file with the keystore's encryption key.
+func keystorePaths(config gofig.Config) (string, string) {
+	path := config.GetString(types.ConfigSecretsKeystorePath)
+	if path == "" {
+		path = types.Lib.Join(fmt.Sprintf("%s.json", keystoreName))
+	}
+	keyFile := config.GetString(types.ConfigSecretsKeystoreKeyFile)
+	if keyFile == "" {
+		keyFile = types.Etc.Join(fmt.Sprintf("%s.key", keystoreName))
+	}
+	return path, keyFile
+}
+
+func (k *Keystore) read() (map[string]string, error) {
+	secrets := map[string]string{}
+	buf, err := ioutil.ReadFile(k.path)
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &secrets); err != nil {
+		return nil, goof.WithFieldE("path", k.path, "invalid keystore", err)
+	}
+	return secrets, nil
+}
+
+func (k *Keystore) write(secrets map[string]string) error {
+	buf, err := json.MarshalIndent(secrets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(k.path, buf)
+}
+
+// cipher returns the keystore's cipher. The key file is created if it does
+// not exist and create is true.
+func (k *Keystore) cipher(create bool) (cipher.AEAD, error) {
+
+	buf, err := ioutil.ReadFile(k.keyFile)
+	if os.IsNotExist(err) && create {
+		key := make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		buf = []byte(hex.EncodeToString(key))
+		if err := writeFile(k.keyFile, buf); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, goof.WithFieldE(
+			"path", k.keyFile, "error reading keystore key", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(buf)))
+	if err != nil || len(key) != 32 {
+		return nil, goof.WithField(
+			"path", k.keyFile, "invalid keystore key")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFile writes the file with permissions that allow only its owner to
+// read it. The data is written to a temporary file first so that a partial
+// write never replaces the file.
+func writeFile(filePath string, buf []byte) error {
+	if err := os.MkdirAll(path.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	tmp := filePath + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filePath)
+}
@@ -0,0 +1,435 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/gotil"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+const (
+	rootPrefix = types.ConfigRoot + "."
+
+	// Redacted is the value that replaces the value of a secure key when a
+	// config is redacted.
+	Redacted = "******"
+)
+
+var (
+	schemaKeys    = map[string]*key{}
+	schemaDrivers = map[string]bool{}
+	schemaScopes  []*Scope
+	schemaRWL     = &sync.RWMutex{}
+)
+
+func init() {
+	RegisterScope(NewScope(types.ConfigServer))
+	RegisterScope(NewScope(types.ConfigClient))
+	RegisterScope(newProfileScope(types.ConfigServerProfiles + ".*"))
+	RegisterScope(newProfileScope(types.ConfigServices + ".*.profiles.*"))
+	RegisterScope(NewScope(types.ConfigServices+".*").
+		Key(gofig.String, "driver").
+		AllowDriverKeys())
+	RegisterScope(NewScope(types.ConfigEndpoints+".*").
+		Key(gofig.String, "address", EndpointAddress))
+	RegisterScope(NewScope(types.ConfigServerAdminTokens+".*").
+		Key(gofig.SecureString, "token").
+		Key(gofig.String, "tokenFile").
+		Key(gofig.String, "expires"))
+}
+
+// newProfileScope returns the scope of a volume profile. The profile's opts
+// are the options of drivers and are not validated.
+func newProfileScope(path string) *Scope {
+	return NewScope(path).
+		Key(gofig.String, "availabilityZone").
+		Key(gofig.Int, "iops", NonNegative).
+		Key(gofig.Int, "size", NonNegative).
+		Key(gofig.String, "type").
+		AllowDriverKeys()
+}
+
+// Constraint returns an error if a config value is invalid.
+type Constraint func(v interface{}) error
+
+type key struct {
+	keyType     gofig.KeyType
+	constraints []Constraint
+}
+
+// Registration is a gofig registration that also records the types and
+// constraints of its keys so that configs can be validated.
+type Registration struct {
+	*gofig.Registration
+	keys map[string]*key
+}
+
+// NewRegistration returns a new registration with the specified name.
+func NewRegistration(name string) *Registration {
+	return &Registration{
+		Registration: gofig.NewRegistration(name),
+		keys:         map[string]*key{},
+	}
+}
+
+// Key adds a key to the registration. The arguments are the same as those of
+// the gofig registration's Key function.
+func (r *Registration) Key(
+	keyType gofig.KeyType,
+	short string,
+	defaultVal interface{},
+	description string,
+	keys ...interface{}) {
+
+	if len(keys) == 0 {
+		panic(fmt.Errorf("missing config key name: %s", description))
+	}
+	r.Registration.Key(keyType, short, defaultVal, description, keys...)
+	r.keys[keyName(keys[0])] = &key{keyType: keyType}
+}
+
+// Declare adds a key that may appear in a config but that is not bound to a
+// default value, flag, or environment variable.
+func (r *Registration) Declare(
+	keyType gofig.KeyType, name string, constraints ...Constraint) {
+	r.keys[keyName(name)] = &key{keyType: keyType, constraints: constraints}
+}
+
+// Constrain adds constraints to one of the registration's keys.
+func (r *Registration) Constrain(name string, constraints ...Constraint) {
+	k, ok := r.keys[keyName(name)]
+	if !ok {
+		panic(fmt.Errorf("unknown config key: %s", name))
+	}
+	k.constraints = append(k.constraints, constraints...)
+}
+
+// Register registers the registration with gofig and adds its keys to the
+// schema against which configs are validated. The constraints of a key that
+// is already in the schema are added to those of the existing key. The first
+// element of a key outside of the libstorage root, such as vfs in vfs.root,
+// is recorded as the prefix of a driver's keys.
+func Register(r *Registration) {
+	gofig.Register(r.Registration)
+
+	schemaRWL.Lock()
+	defer schemaRWL.Unlock()
+	for name, k := range r.keys {
+		if !strings.HasPrefix(name, rootPrefix) {
+			if i := strings.Index(name, "."); i > 0 {
+				schemaDrivers[name[:i]] = true
+			}
+		}
+		if ek, ok := schemaKeys[name]; ok {
+			ek.constraints = append(ek.constraints, k.constraints...)
+			continue
+		}
+		schemaKeys[name] = k
+	}
+}
+
+// Scope is a config path below which keys are relative to the scope, such as
+// the settings of a service or an endpoint. An element of the path that is *
+// matches any single element, such as a service's name.
+//
+// A key below a scope is valid if the rest of the key is one of the scope's
+// keys, or is a registered key with or without the libstorage root.
+type Scope struct {
+	path    []string
+	keys    map[string]*key
+	drivers bool
+}
+
+// NewScope returns a new scope for the specified path.
+func NewScope(path string) *Scope {
+	return &Scope{
+		path: strings.Split(keyName(path), "."),
+		keys: map[string]*key{},
+	}
+}
+
+// Key adds a key that is valid only directly below the scope.
+func (s *Scope) Key(
+	keyType gofig.KeyType, name string, constraints ...Constraint) *Scope {
+	s.keys[keyName(name)] = &key{keyType: keyType, constraints: constraints}
+	return s
+}
+
+// AllowDriverKeys indicates that the scope may include the keys of drivers,
+// such as vfs.root. The keys of drivers that are not registered are ignored,
+// while an unknown key with the prefix of a registered driver is not.
+func (s *Scope) AllowDriverKeys() *Scope {
+	s.drivers = true
+	return s
+}
+
+// relative returns the part of the key below the scope.
+func (s *Scope) relative(name string) (string, bool) {
+	parts := strings.SplitN(name, ".", len(s.path)+1)
+	if len(parts) <= len(s.path) {
+		return "", false
+	}
+	for i, p := range s.path {
+		if p != "*" && p != parts[i] {
+			return "", false
+		}
+	}
+	return parts[len(s.path)], true
+}
+
+// RegisterScope adds a scope to the schema against which configs are
+// validated.
+func RegisterScope(s *Scope) {
+	schemaRWL.Lock()
+	defer schemaRWL.Unlock()
+	schemaScopes = append(schemaScopes, s)
+}
+
+// Constrain adds constraints to a registered key. The key of a scope is
+// specified as the scope's path followed by the key's name.
+func Constrain(name string, constraints ...Constraint) {
+
+	name = keyName(name)
+
+	schemaRWL.Lock()
+	defer schemaRWL.Unlock()
+
+	if k, ok := schemaKeys[name]; ok {
+		k.constraints = append(k.constraints, constraints...)
+		return
+	}
+	for _, s := range schemaScopes {
+		path := strings.Join(s.path, ".")
+		if !strings.HasPrefix(name, path+".") {
+			continue
+		}
+		if k, ok := s.keys[name[len(path)+1:]]; ok {
+			k.constraints = append(k.constraints, constraints...)
+			return
+		}
+	}
+	panic(fmt.Errorf("unknown config key: %s", name))
+}
+
+// Validate validates the config against the registered keys and scopes. The
+// returned error describes every key below the libstorage root that is not
+// known and every key with an invalid value. Keys outside of the libstorage
+// root that are not registered, such as those of drivers that are not loaded,
+// are ignored.
+func Validate(config gofig.Config) error {
+
+	schemaRWL.RLock()
+	defer schemaRWL.RUnlock()
+
+	problems := map[string]interface{}{}
+	for _, name := range config.AllKeys() {
+		k, known := lookup(keyName(name))
+		if !known {
+			problems[name] = "unknown key"
+			continue
+		}
+		if k == nil {
+			continue
+		}
+		if err := k.validate(config.Get(name)); err != nil {
+			problems[name] = err.Error()
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return utils.NewInvalidConfigErr(problems)
+}
+
+// Redact returns the config's settings with the values of secure keys that
+// are set replaced by the Redacted placeholder. The settings are nested by the
+// elements of their keys.
+func Redact(config gofig.Config) map[string]interface{} {
+
+	schemaRWL.RLock()
+	defer schemaRWL.RUnlock()
+
+	names := config.AllKeys()
+	sort.Strings(names)
+
+	settings := map[string]interface{}{}
+	for _, name := range names {
+		v := config.Get(name)
+		if k, _ := lookup(keyName(name)); k != nil &&
+			k.keyType == gofig.SecureString && v != "" {
+			v = Redacted
+		}
+
+		m := settings
+		parts := strings.Split(keyName(name), ".")
+		for _, p := range parts[:len(parts)-1] {
+			pm, ok := m[p].(map[string]interface{})
+			if !ok {
+				pm = map[string]interface{}{}
+				m[p] = pm
+			}
+			m = pm
+		}
+		m[parts[len(parts)-1]] = v
+	}
+
+	return settings
+}
+
+// lookup returns the registered key for the specified name. A nil key and a
+// true value are returned for keys that are ignored. The caller must hold the
+// schema's read lock.
+func lookup(name string) (*key, bool) {
+
+	if k, ok := schemaKeys[name]; ok {
+		return k, true
+	}
+	if !strings.HasPrefix(name, rootPrefix) {
+		return nil, true
+	}
+
+	for _, s := range schemaScopes {
+		rel, ok := s.relative(name)
+		if !ok {
+			continue
+		}
+		if k, ok := s.keys[rel]; ok {
+			return k, true
+		}
+		if strings.HasPrefix(rel, rootPrefix) {
+			if k, ok := lookup(rel); ok {
+				return k, true
+			}
+			continue
+		}
+		if k, ok := schemaKeys[rel]; ok {
+			return k, true
+		}
+		if k, ok := lookup(rootPrefix + rel); ok && k != nil {
+			return k, true
+		}
+		if s.drivers {
+			if i := strings.Index(rel, "."); i > 0 && !schemaDrivers[rel[:i]] {
+				return nil, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func (k *key) validate(v interface{}) error {
+	if err := validateType(k.keyType, v); err != nil {
+		return err
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil
+	}
+	for _, c := range k.constraints {
+		if err := c(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateType(keyType gofig.KeyType, v interface{}) error {
+	switch keyType {
+	case gofig.Int:
+		switch tv := v.(type) {
+		case int, int8, int16, int32, int64,
+			uint, uint8, uint16, uint32, uint64:
+			return nil
+		case float32:
+			if float64(tv) == math.Trunc(float64(tv)) {
+				return nil
+			}
+		case float64:
+			if tv == math.Trunc(tv) {
+				return nil
+			}
+		case string:
+			if _, err := strconv.Atoi(tv); tv == "" || err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid int: %v", v)
+	case gofig.Bool:
+		switch tv := v.(type) {
+		case bool:
+			return nil
+		case string:
+			if _, err := strconv.ParseBool(tv); tv == "" || err == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid bool: %v", v)
+	}
+	return nil
+}
+
+// Duration is a constraint that requires a duration such as 30s.
+func Duration(v interface{}) error {
+	if _, err := time.ParseDuration(fmt.Sprintf("%v", v)); err != nil {
+		return fmt.Errorf("invalid duration: %v", v)
+	}
+	return nil
+}
+
+// Address is a constraint that requires a network address such as
+// tcp://127.0.0.1:7979 or unix:///var/run/libstorage/localhost.sock.
+func Address(v interface{}) error {
+	if _, _, err := gotil.ParseAddress(fmt.Sprintf("%v", v)); err != nil {
+		return fmt.Errorf("invalid address: %v", v)
+	}
+	return nil
+}
+
+// EndpointAddress is a constraint that requires a network address or one of
+// the endpoint types, tcp or unix, for which an address is generated.
+func EndpointAddress(v interface{}) error {
+	if types.ParseEndpointType(fmt.Sprintf("%v", v)) !=
+		types.UnknownEndpointType {
+		return nil
+	}
+	return Address(v)
+}
+
+// NonNegative is a constraint that requires a number that is zero or greater.
+func NonNegative(v interface{}) error {
+	i, err := strconv.ParseFloat(fmt.Sprintf("%v", v), 64)
+	if err != nil || i < 0 {
+		return fmt.Errorf("invalid value: %v: must be zero or greater", v)
+	}
+	return nil
+}
+
+// OneOf returns a constraint that requires one of the specified values. The
+// comparison is not case sensitive.
+func OneOf(values ...string) Constraint {
+	return func(v interface{}) error {
+		sv := fmt.Sprintf("%v", v)
+		for _, val := range values {
+			if strings.EqualFold(sv, val) {
+				return nil
+			}
+		}
+		return fmt.Errorf(
+			"invalid value: %v: must be one of %s",
+			v, strings.Join(values, ", "))
+	}
+}
+
+func keyName(name interface{}) string {
+	return strings.ToLower(fmt.Sprintf("%v", name))
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+func init() {
+	r := NewRegistration("Schema Tests")
+	r.Key(gofig.String, "", "30s", "", "libstorage.test.timeout")
+	r.Key(gofig.Int, "", 0, "", "libstorage.test.count")
+	r.Key(gofig.Bool, "", false, "", "libstorage.test.enabled")
+	r.Key(gofig.String, "", "", "", "libstorage.test.mode")
+	r.Key(gofig.SecureString, "", "", "", "testdriver.password")
+	r.Declare(gofig.String, types.ConfigTLSCertFile)
+	r.Declare(gofig.String, types.ConfigRoot+".storage.driver")
+	r.Constrain("libstorage.test.timeout", Duration)
+	r.Constrain("libstorage.test.count", NonNegative)
+	r.Constrain("libstorage.test.mode", OneOf("fast", "slow"))
+	Register(r)
+}
+
+func newTestConfig(t *testing.T, yaml string) gofig.Config {
+	config := gofig.New()
+	if err := config.ReadConfig(bytes.NewReader([]byte(yaml))); err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(newTestConfig(t, `
+libstorage:
+  test:
+    timeout: 1m
+    count: 3
+    enabled: true
+    mode: Fast
+  server:
+    endpoints:
+      localhost:
+        address: tcp://127.0.0.1:7979
+        tls:
+          certFile: /etc/libstorage/server.crt
+      auto:
+        address: unix
+    services:
+      test:
+        driver: testdriver
+        libstorage:
+          storage:
+            driver: testdriver
+        testdriver:
+          password: secret
+        unloaded:
+          key: value
+    adminTokens:
+      ops:
+        token: ops-token
+        expires: 24h
+  client:
+    tls:
+      certFile: /etc/libstorage/client.crt
+unloaded:
+  key: value
+`)))
+}
+
+func TestValidateProblems(t *testing.T) {
+	err := Validate(newTestConfig(t, `
+libstorage:
+  test:
+    timeout: 30
+    count: -1
+    enabled: maybe
+    mode: medium
+    unknown: true
+  server:
+    endpoints:
+      localhost:
+        address: localhost
+        tls:
+          certFlie: /etc/libstorage/server.crt
+    services:
+      test:
+        drivr: testdriver
+        testdriver:
+          pasword: secret
+testdriver:
+  password: secret
+`))
+
+	if !assert.IsType(t, &types.ErrInvalidConfig{}, err) {
+		t.FailNow()
+	}
+	problems := err.(*types.ErrInvalidConfig).Fields()
+	assert.Len(t, problems, 9)
+	for _, k := range []string{
+		"libstorage.test.timeout",
+		"libstorage.test.count",
+		"libstorage.test.enabled",
+		"libstorage.test.mode",
+		"libstorage.server.endpoints.localhost.address",
+	} {
+		assert.Contains(t, problems, k)
+	}
+	for _, k := range []string{
+		"libstorage.test.unknown",
+		"libstorage.server.endpoints.localhost.tls.certflie",
+		"libstorage.server.services.test.drivr",
+		"libstorage.server.services.test.testdriver.pasword",
+	} {
+		assert.Equal(t, "unknown key", problems[k])
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	assert.NoError(t, Validate(newTestConfig(t, `
+libstorage:
+  server:
+    profiles:
+      gold:
+        type: ssd
+        iops: 1000
+        opts:
+          thinOrThick: ThickProvisioned
+    services:
+      test:
+        driver: testdriver
+        profiles:
+          silver:
+            size: 8
+            availabilityZone: zone-1
+`)))
+
+	err := Validate(newTestConfig(t, `
+libstorage:
+  server:
+    profiles:
+      gold:
+        iops: fast
+        tpye: ssd
+    services:
+      test:
+        driver: testdriver
+        profiles:
+          silver:
+            size: -1
+`))
+	if !assert.IsType(t, &types.ErrInvalidConfig{}, err) {
+		t.FailNow()
+	}
+	problems := err.(*types.ErrInvalidConfig).Fields()
+	assert.Len(t, problems, 3)
+	assert.Contains(t, problems, "libstorage.server.profiles.gold.iops")
+	assert.Equal(t,
+		"unknown key", problems["libstorage.server.profiles.gold.tpye"])
+	assert.Contains(t,
+		problems, "libstorage.server.services.test.profiles.silver.size")
+}
+
+func TestConstrainScopeKey(t *testing.T) {
+	RegisterScope(NewScope("libstorage.constrained.*").
+		Key(gofig.String, "value"))
+	Constrain("libstorage.constrained.*.value", OneOf("a"))
+
+	assert.NoError(t, Validate(newTestConfig(t, `
+libstorage:
+  constrained:
+    x:
+      value: a
+`)))
+	assert.Error(t, Validate(newTestConfig(t, `
+libstorage:
+  constrained:
+    x:
+      value: b
+`)))
+}
+
+func TestRedact(t *testing.T) {
+	settings := Redact(newTestConfig(t, `
+libstorage:
+  server:
+    adminTokens:
+      ops:
+        token: ops-token
+    services:
+      test:
+        testdriver:
+          password: secret
+testdriver:
+  password: secret
+  endpoint: localhost
+`))
+
+	get := func(keys ...string) interface{} {
+		var v interface{} = settings
+		for _, k := range keys {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			v = m[k]
+		}
+		return v
+	}
+
+	assert.Equal(t, Redacted, get("testdriver", "password"))
+	assert.Equal(t, "localhost", get("testdriver", "endpoint"))
+	assert.Equal(t, Redacted, get(
+		"libstorage", "server", "services", "test", "testdriver", "password"))
+	assert.Equal(t, Redacted, get(
+		"libstorage", "server", "admintokens", "ops", "token"))
+}
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+)
+
+// SecretProvider resolves a reference to a secret, such as
+// file:///run/secrets/password, to the secret's value.
+type SecretProvider interface {
+
+	// Secret returns the value of the secret identified by the reference.
+	Secret(config gofig.Config, ref *url.URL) (string, error)
+}
+
+var (
+	secretProviders    = map[string]SecretProvider{}
+	secretProvidersRWL = &sync.RWMutex{}
+
+	secretEnvVars    = map[string]bool{}
+	secretEnvVarsRWL = &sync.RWMutex{}
+)
+
+func init() {
+	RegisterSecretProvider("file", &fileSecretProvider{})
+	RegisterSecretProvider("env", &envSecretProvider{})
+	RegisterSecretProvider("keystore", &keystoreSecretProvider{})
+}
+
+// RegisterSecretProvider registers a secret provider for the references
+// with the specified URL scheme.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersRWL.Lock()
+	defer secretProvidersRWL.Unlock()
+	secretProviders[strings.ToLower(scheme)] = p
+}
+
+// ResolveSecrets replaces the values of the config's secure keys that are
+// references to secrets with the values of the secrets. A value is a
+// reference if it is a URL whose scheme has a registered secret provider.
+func ResolveSecrets(config gofig.Config) error {
+
+	schemaRWL.RLock()
+	refs := map[string]*url.URL{}
+	for _, name := range config.AllKeys() {
+		k, _ := lookup(keyName(name))
+		if k == nil || k.keyType != gofig.SecureString {
+			continue
+		}
+		if ref := secretRef(config.Get(name)); ref != nil {
+			refs[name] = ref
+		}
+	}
+	schemaRWL.RUnlock()
+
+	for name, ref := range refs {
+		secretProvidersRWL.RLock()
+		p := secretProviders[ref.Scheme]
+		secretProvidersRWL.RUnlock()
+
+		secret, err := p.Secret(config, ref)
+		if err != nil {
+			return goof.WithFieldsE(goof.Fields{
+				"key":    name,
+				"scheme": ref.Scheme,
+			}, "error resolving secret", err)
+		}
+		config.Set(name, secret)
+	}
+
+	return nil
+}
+
+// RedactEnv returns the environment variables with the values of the
+// variables that hold secrets replaced by the Redacted placeholder. These are
+// the variables that are bound to secure keys and the variables that secure
+// keys refer to with env:// references.
+func RedactEnv(env []string) []string {
+
+	schemaRWL.RLock()
+	secure := map[string]bool{}
+	for name, k := range schemaKeys {
+		if k.keyType == gofig.SecureString {
+			secure[envVarName(name)] = true
+		}
+	}
+	schemaRWL.RUnlock()
+
+	secretEnvVarsRWL.RLock()
+	defer secretEnvVarsRWL.RUnlock()
+
+	redacted := make([]string, len(env))
+	for i, v := range env {
+		parts := strings.SplitN(v, "=", 2)
+		name := strings.ToUpper(parts[0])
+		if len(parts) == 2 && (secure[name] || secretEnvVars[name]) {
+			v = parts[0] + "=" + Redacted
+		}
+		redacted[i] = v
+	}
+	return redacted
+}
+
+// secretRef returns the value as a reference to a secret, or nil if the value
+// is not a reference.
+func secretRef(v interface{}) *url.URL {
+	sv, ok := v.(string)
+	if !ok || !strings.Contains(sv, "://") {
+		return nil
+	}
+	ref, err := url.Parse(sv)
+	if err != nil {
+		return nil
+	}
+	ref.Scheme = strings.ToLower(ref.Scheme)
+
+	secretProvidersRWL.RLock()
+	defer secretProvidersRWL.RUnlock()
+	if _, ok := secretProviders[ref.Scheme]; !ok {
+		return nil
+	}
+	return ref
+}
+
+func envVarName(name string) string {
+	return strings.ToUpper(strings.Replace(name, ".", "_", -1))
+}
+
+// fileSecretProvider reads a secret from a file such as a Docker or
+// Kubernetes secret, file:///run/secrets/password. Leading and trailing
+// whitespace is removed.
+type fileSecretProvider struct{}
+
+func (p *fileSecretProvider) Secret(
+	config gofig.Config, ref *url.URL) (string, error) {
+
+	path := ref.Host + ref.Path
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", goof.WithFieldE("path", path, "error reading secret", err)
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// envSecretProvider reads a secret from the environment variable that the
+// reference names, env://SCALEIO_PASSWORD.
+type envSecretProvider struct{}
+
+func (p *envSecretProvider) Secret(
+	config gofig.Config, ref *url.URL) (string, error) {
+
+	name := ref.Host
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", goof.WithField("name", name, "missing secret env var")
+	}
+
+	secretEnvVarsRWL.Lock()
+	defer secretEnvVarsRWL.Unlock()
+	secretEnvVars[strings.ToUpper(name)] = true
+
+	return v, nil
+}
+
+// keystoreSecretProvider reads a secret from the config's keystore,
+// keystore://scaleio-password.
+type keystoreSecretProvider struct{}
+
+func (p *keystoreSecretProvider) Secret(
+	config gofig.Config, ref *url.URL) (string, error) {
+	return NewKeystore(config).Get(ref.Host + ref.Path)
+}
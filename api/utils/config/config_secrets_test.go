@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+func newTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "libstorage-secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestResolveSecrets(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	secretFile := path.Join(dir, "password")
+	assert.NoError(t, ioutil.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+	os.Setenv("TEST_SECRET_PASSWORD", "env-secret")
+	defer os.Unsetenv("TEST_SECRET_PASSWORD")
+
+	keystoreYAML := fmt.Sprintf(`
+  secrets:
+    keystore:
+      path: %[1]s/keystore.json
+      keyFile: %[1]s/keystore.key
+`, dir)
+
+	ks := NewKeystore(newTestConfig(t, "libstorage:"+keystoreYAML))
+	assert.NoError(t, ks.Set("ops", "keystore-secret"))
+
+	config := newTestConfig(t, fmt.Sprintf(`
+libstorage:%s
+  server:
+    adminTokens:
+      ops:
+        token: keystore://ops
+    services:
+      test:
+        testdriver:
+          password: env://TEST_SECRET_PASSWORD
+testdriver:
+  password: file://%s
+`, keystoreYAML, secretFile))
+
+	assert.NoError(t, ResolveSecrets(config))
+	assert.Equal(t, "file-secret", config.GetString("testdriver.password"))
+	assert.Equal(t, "env-secret", config.GetString(
+		"libstorage.server.services.test.testdriver.password"))
+	assert.Equal(t, "keystore-secret", config.GetString(
+		types.ConfigServerAdminTokens+".ops.token"))
+
+	env := RedactEnv([]string{
+		"TEST_SECRET_PASSWORD=env-secret",
+		"TESTDRIVER_PASSWORD=secret",
+		"HOME=/root",
+	})
+	assert.Equal(t, []string{
+		"TEST_SECRET_PASSWORD=" + Redacted,
+		"TESTDRIVER_PASSWORD=" + Redacted,
+		"HOME=/root",
+	}, env)
+}
+
+func TestResolveSecretsErrors(t *testing.T) {
+	assert.Error(t, ResolveSecrets(newTestConfig(t, `
+testdriver:
+  password: env://TEST_SECRET_MISSING
+`)))
+	assert.Error(t, ResolveSecrets(newTestConfig(t, `
+testdriver:
+  password: file:///missing/secret
+`)))
+
+	// values that are not references, or that are not secure, are not resolved
+	config := newTestConfig(t, `
+libstorage:
+  test:
+    mode: file:///missing/secret
+testdriver:
+  password: http://localhost
+`)
+	assert.NoError(t, ResolveSecrets(config))
+	assert.Equal(t, "http://localhost", config.GetString("testdriver.password"))
+}
+
+func TestKeystore(t *testing.T) {
+	dir := newTestDir(t)
+	defer os.RemoveAll(dir)
+
+	ks := &Keystore{
+		path:    path.Join(dir, "keystore.json"),
+		keyFile: path.Join(dir, "keystore.key"),
+	}
+
+	_, err := ks.Get("missing")
+	assert.IsType(t, &types.ErrNotFound{}, err)
+
+	assert.NoError(t, ks.Set("b", "secret-b"))
+	assert.NoError(t, ks.Set("a", "secret-a"))
+
+	v, err := ks.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret-a", v)
+
+	names, err := ks.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	buf, err := ioutil.ReadFile(ks.path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(buf), "secret-a")
+
+	fi, err := os.Stat(ks.keyFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	assert.NoError(t, ks.Remove("b"))
+	assert.IsType(t, &types.ErrNotFound{}, ks.Remove("b"))
+
+	// a secret cannot be decrypted with another key
+	assert.NoError(t, os.Remove(ks.keyFile))
+	assert.NoError(t, (&Keystore{
+		path:    path.Join(dir, "other.json"),
+		keyFile: ks.keyFile,
+	}).Set("c", "secret-c"))
+	_, err = ks.Get("a")
+	assert.Error(t, err)
+}
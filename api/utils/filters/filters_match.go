@@ -0,0 +1,183 @@
+package filters
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+// StoreKey is the key of a filter in a request's store. The key's value is
+// the filter string from the query string until a router compiles it with
+// FromStore, and the compiled filter after that.
+const StoreKey = "filter"
+
+// FromStore returns the compiled filter from the store, compiling the
+// store's filter string and replacing it with the compiled filter if
+// necessary. A nil filter is returned if the store does not have a filter.
+//
+// Storage drivers may use FromStore to push the filter of a request that
+// lists snapshots down to the storage platform.
+func FromStore(store types.Store) (*types.Filter, error) {
+	if store == nil || !store.IsSet(StoreKey) {
+		return nil, nil
+	}
+	if f, ok := store.Get(StoreKey).(*types.Filter); ok {
+		return f, nil
+	}
+	fsz := store.GetString(StoreKey)
+	f, err := CompileFilter(fsz)
+	if err != nil {
+		return nil, utils.NewBadFilterErr(fsz, err)
+	}
+	store.Set(StoreKey, f)
+	return f, nil
+}
+
+// Match returns a flag indicating whether the object matches the filter.
+//
+// The filter's left operands are paths into the object's JSON
+// representation. The elements of a path are separated by dots, such as
+// attachments.instanceID.id or fields.owner, and are not case sensitive. A
+// path that crosses an array refers to the values of every element of the
+// array, and a comparison is true if it is true for any of those values.
+//
+// Comparisons are not case sensitive. The >= and <= operators compare
+// numbers numerically when both operands are numbers.
+func Match(f *types.Filter, obj interface{}) (bool, error) {
+	if f == nil {
+		return true, nil
+	}
+	buf, err := json.Marshal(obj)
+	if err != nil {
+		return false, goof.WithError("error marshaling filtered object", err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(buf, &v); err != nil {
+		return false, goof.WithError("error unmarshaling filtered object", err)
+	}
+	return match(f, v), nil
+}
+
+func match(f *types.Filter, v interface{}) bool {
+	switch f.Op {
+	case filterAnd:
+		for _, c := range f.Children {
+			if !match(c, v) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, c := range f.Children {
+			if match(c, v) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return len(f.Children) == 1 && !match(f.Children[0], v)
+	}
+
+	for _, val := range resolve(v, strings.Split(f.Left, ".")) {
+		if compare(f, val) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the values at the path.
+func resolve(v interface{}, path []string) []interface{} {
+	if len(path) == 0 {
+		return []interface{}{v}
+	}
+	switch tv := v.(type) {
+	case []interface{}:
+		vals := []interface{}{}
+		for _, e := range tv {
+			vals = append(vals, resolve(e, path)...)
+		}
+		return vals
+	case map[string]interface{}:
+		if e, ok := tv[path[0]]; ok {
+			return resolve(e, path[1:])
+		}
+		for k, e := range tv {
+			if strings.EqualFold(k, path[0]) {
+				return resolve(e, path[1:])
+			}
+		}
+	}
+	return nil
+}
+
+func compare(f *types.Filter, v interface{}) bool {
+
+	if f.Op == filterPresent {
+		return v != nil
+	}
+
+	var s string
+	switch tv := v.(type) {
+	case string:
+		s = tv
+	case float64:
+		s = strconv.FormatFloat(tv, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(tv)
+	default:
+		return false
+	}
+
+	left, right := strings.ToLower(s), strings.ToLower(f.Right)
+
+	switch f.Op {
+	case filterEqualityMatch:
+		if c, ok := compareNumbers(left, right); ok {
+			return c == 0
+		}
+		return left == right
+	case filterApproxMatch:
+		return strings.TrimSpace(left) == strings.TrimSpace(right)
+	case filterSubstrings:
+		return strings.Contains(left, right)
+	case filterSubstringsPrefix:
+		return strings.HasSuffix(left, right)
+	case filterSubstringsPostfix:
+		return strings.HasPrefix(left, right)
+	case filterGreaterOrEqual:
+		if c, ok := compareNumbers(left, right); ok {
+			return c >= 0
+		}
+		return left >= right
+	case filterLessOrEqual:
+		if c, ok := compareNumbers(left, right); ok {
+			return c <= 0
+		}
+		return left <= right
+	}
+	return false
+}
+
+func compareNumbers(a, b string) (int, bool) {
+	af, err := strconv.ParseFloat(a, 64)
+	if err != nil {
+		return 0, false
+	}
+	bf, err := strconv.ParseFloat(b, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case af < bf:
+		return -1, true
+	case af > bf:
+		return 1, true
+	}
+	return 0, true
+}
@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+var testVolume = &types.Volume{
+	ID:   "vol-000",
+	Name: "Volume 000",
+	Size: 10240,
+	IOPS: 1000,
+	Attachments: []*types.VolumeAttachment{
+		&types.VolumeAttachment{
+			DeviceName: "/dev/xvda",
+			InstanceID: &types.InstanceID{ID: "i-000", Driver: "vfs"},
+		},
+		&types.VolumeAttachment{
+			DeviceName: "/dev/xvdb",
+			InstanceID: &types.InstanceID{ID: "i-001", Driver: "vfs"},
+		},
+	},
+	Fields: map[string]string{"owner": "root@example.com"},
+}
+
+func assertMatch(t *testing.T, expected bool, s string, obj interface{}) {
+	f, err := CompileFilter(s)
+	if !assert.NoError(t, err, s) {
+		return
+	}
+	ok, err := Match(f, obj)
+	assert.NoError(t, err, s)
+	assert.Equal(t, expected, ok, s)
+}
+
+func TestMatchEquality(t *testing.T) {
+	assertMatch(t, true, `(name=volume 000)`, testVolume)
+	assertMatch(t, false, `(name=Volume 001)`, testVolume)
+	assertMatch(t, true, `(ID=vol-000)`, testVolume)
+	assertMatch(t, true, `(size=10240)`, testVolume)
+	assertMatch(t, true, `(size=10240.0)`, testVolume)
+	assertMatch(t, false, `(bogus=10240)`, testVolume)
+}
+
+func TestMatchDottedPaths(t *testing.T) {
+	assertMatch(t, true, `(fields.owner=root@example.com)`, testVolume)
+	assertMatch(t, true, `(attachments.instanceID.id=i-001)`, testVolume)
+	assertMatch(t, false, `(attachments.instanceID.id=i-002)`, testVolume)
+	assertMatch(t, true, `(attachments.deviceName=*xvdb)`, testVolume)
+	assertMatch(t, true, `(attachments=*)`, testVolume)
+	assertMatch(t, false, `(status=*)`, testVolume)
+}
+
+func TestMatchComparisons(t *testing.T) {
+	assertMatch(t, true, `(size>=10240)`, testVolume)
+	assertMatch(t, true, `(size>=9999)`, testVolume)
+	assertMatch(t, false, `(size>=20000)`, testVolume)
+	assertMatch(t, true, `(iops<=1000)`, testVolume)
+	assertMatch(t, false, `(iops<=999)`, testVolume)
+	assertMatch(t, true, `(&(size>=1024)(size<=20480))`, testVolume)
+	assertMatch(t, true, `(name>=Volume 000)`, testVolume)
+}
+
+func TestMatchSubstrings(t *testing.T) {
+	assertMatch(t, true, `(name=*ume*)`, testVolume)
+	assertMatch(t, true, `(name=vol*)`, testVolume)
+	assertMatch(t, true, `(name=*000)`, testVolume)
+	assertMatch(t, false, `(name=*001)`, testVolume)
+	assertMatch(t, true, `(name~= Volume 000 )`, testVolume)
+}
+
+func TestMatchCompound(t *testing.T) {
+	assertMatch(t, true,
+		`(&(name=Volume 000)(|(size=1)(size=10240)))`, testVolume)
+	assertMatch(t, false, `(!(name=Volume 000))`, testVolume)
+	assertMatch(t, true, `(!(attachments.instanceID.id=i-002))`, testVolume)
+}
+
+func TestMatchSnapshot(t *testing.T) {
+	s := &types.Snapshot{ID: "snap-000", VolumeID: "vol-000", StartTime: 100}
+	assertMatch(t, true, `(volumeID=vol-000)`, s)
+	assertMatch(t, true, `(startTime>=99)`, s)
+	assertMatch(t, false, `(startTime<=99)`, s)
+}
+
+func TestFromStore(t *testing.T) {
+	f, err := FromStore(utils.NewStore())
+	assert.NoError(t, err)
+	assert.Nil(t, f)
+
+	store := utils.NewStore()
+	store.Set(StoreKey, "(name=vol-000)")
+	f, err = FromStore(store)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, "name", f.Left)
+	assert.Equal(t, f, store.Get(StoreKey))
+
+	f2, err := FromStore(store)
+	assert.NoError(t, err)
+	assert.True(t, f == f2)
+
+	store.Set(StoreKey, "name=vol-000")
+	_, err = FromStore(store)
+	assert.IsType(t, &types.ErrBadFilter{}, err)
+}
@@ -0,0 +1,394 @@
+/*
+Package paging sorts volumes and snapshots and splits listings of them into
+pages.
+
+A continuation token is opaque to clients. A token that the server creates
+holds the offset of the next page. A token that a storage driver creates is
+wrapped so that the server can tell the two apart and return the driver's
+token to the driver with the request for the next page.
+*/
+package paging
+
+import (
+	"encoding/base64"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+const (
+	// LimitParam is the query parameter with the maximum number of objects
+	// in a page.
+	LimitParam = "limit"
+
+	// OffsetParam is the query parameter with the number of objects that
+	// precede a page.
+	OffsetParam = "offset"
+
+	// TokenParam is the query parameter with a continuation token.
+	TokenParam = "token"
+
+	// SortParam is the query parameter with the sort keys.
+	SortParam = "sort"
+
+	offsetTokenPrefix = "o:"
+	driverTokenPrefix = "d:"
+)
+
+var (
+	// VolumeSortFields are the fields by which volumes may be sorted.
+	VolumeSortFields = []string{
+		"service", "id", "name", "type", "size", "iops",
+		"availabilityZone", "status",
+	}
+
+	// SnapshotSortFields are the fields by which snapshots may be sorted.
+	SnapshotSortFields = []string{
+		"service", "id", "name", "volumeID", "volumeSize", "startTime",
+		"status",
+	}
+)
+
+// ParsePage returns the page that the query parameters request, or nil if
+// the parameters do not request a page. The sort keys must be among the
+// specified fields.
+func ParsePage(query url.Values, fields []string) (*types.Page, error) {
+
+	if _, ok := query[LimitParam]; !ok {
+		if _, ok := query[OffsetParam]; !ok {
+			if _, ok := query[TokenParam]; !ok {
+				if _, ok := query[SortParam]; !ok {
+					return nil, nil
+				}
+			}
+		}
+	}
+
+	page := &types.Page{}
+
+	var err error
+	if page.Limit, err = parseInt(query, LimitParam); err != nil {
+		return nil, err
+	}
+	if page.Offset, err = parseInt(query, OffsetParam); err != nil {
+		return nil, err
+	}
+
+	if tok := query.Get(TokenParam); tok != "" {
+		offset, driverToken, err := DecodeToken(tok)
+		if err != nil {
+			return nil, err
+		}
+		page.Offset = offset
+		page.Token = driverToken
+	}
+
+	if page.Sort, err = ParseSort(query.Get(SortParam), fields); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// ParseSort parses a comma-separated list of sort keys, such as name,-size.
+// The keys must be among the specified fields. The names of the fields are
+// not case sensitive.
+func ParseSort(s string, fields []string) ([]*types.SortKey, error) {
+	keys := []*types.SortKey{}
+	if s == "" {
+		return keys, nil
+	}
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		key := &types.SortKey{}
+		if strings.HasPrefix(f, "-") {
+			key.Descending = true
+			f = f[1:]
+		} else if strings.HasPrefix(f, "+") {
+			f = f[1:]
+		}
+		for _, field := range fields {
+			if strings.EqualFold(f, field) {
+				key.Field = field
+			}
+		}
+		if key.Field == "" {
+			return nil, utils.NewBadPageErr(SortParam, s)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// EncodeOffsetToken returns a continuation token for the page at the
+// specified offset.
+func EncodeOffsetToken(offset int) string {
+	return encodeToken(offsetTokenPrefix + strconv.Itoa(offset))
+}
+
+// EncodeDriverToken returns a continuation token that wraps a storage
+// driver's continuation token. An empty driver token, which marks the last
+// page, results in an empty token.
+func EncodeDriverToken(driverToken string) string {
+	if driverToken == "" {
+		return ""
+	}
+	return encodeToken(driverTokenPrefix + driverToken)
+}
+
+// DecodeToken returns the offset or the storage driver's continuation token
+// held by a continuation token.
+func DecodeToken(tok string) (int, string, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(tok)
+	if err != nil {
+		return 0, "", utils.NewBadPageErr(TokenParam, tok)
+	}
+	v := string(buf)
+	switch {
+	case strings.HasPrefix(v, offsetTokenPrefix):
+		offset, err := strconv.Atoi(v[len(offsetTokenPrefix):])
+		if err != nil || offset < 0 {
+			return 0, "", utils.NewBadPageErr(TokenParam, tok)
+		}
+		return offset, "", nil
+	case strings.HasPrefix(v, driverTokenPrefix):
+		return 0, v[len(driverTokenPrefix):], nil
+	}
+	return 0, "", utils.NewBadPageErr(TokenParam, tok)
+}
+
+// PageVolumes returns the page of the volumes and the continuation token for
+// the next page. The token is empty if the page is the last page.
+func PageVolumes(
+	page *types.Page,
+	svm types.ServiceVolumeMap) (types.ServiceVolumeMap, string) {
+
+	l := list{keys: page.Sort}
+	for service, vm := range svm {
+		for id, v := range vm {
+			l.items = append(l.items, &item{service, id, v})
+		}
+	}
+
+	items, next := l.page(page)
+
+	reply := types.ServiceVolumeMap{}
+	for service := range svm {
+		reply[service] = types.VolumeMap{}
+	}
+	for _, i := range items {
+		reply[i.service][i.id] = i.obj.(*types.Volume)
+	}
+	return reply, next
+}
+
+// PageSnapshots returns the page of the snapshots and the continuation token
+// for the next page. The token is empty if the page is the last page.
+func PageSnapshots(
+	page *types.Page,
+	ssm types.ServiceSnapshotMap) (types.ServiceSnapshotMap, string) {
+
+	l := list{keys: page.Sort}
+	for service, sm := range ssm {
+		for id, s := range sm {
+			l.items = append(l.items, &item{service, id, s})
+		}
+	}
+
+	items, next := l.page(page)
+
+	reply := types.ServiceSnapshotMap{}
+	for service := range ssm {
+		reply[service] = types.SnapshotMap{}
+	}
+	for _, i := range items {
+		reply[i.service][i.id] = i.obj.(*types.Snapshot)
+	}
+	return reply, next
+}
+
+// SortedVolumes returns the volumes sorted by the sort keys and the names of
+// the volumes' services.
+func SortedVolumes(
+	svm types.ServiceVolumeMap,
+	keys []*types.SortKey) ([]string, []*types.Volume) {
+
+	l := list{keys: keys}
+	for service, vm := range svm {
+		for id, v := range vm {
+			l.items = append(l.items, &item{service, id, v})
+		}
+	}
+	sort.Sort(&l)
+
+	services := make([]string, len(l.items))
+	vols := make([]*types.Volume, len(l.items))
+	for i, item := range l.items {
+		services[i] = item.service
+		vols[i] = item.obj.(*types.Volume)
+	}
+	return services, vols
+}
+
+// SortedSnapshots returns the snapshots sorted by the sort keys and the names
+// of the snapshots' services.
+func SortedSnapshots(
+	ssm types.ServiceSnapshotMap,
+	keys []*types.SortKey) ([]string, []*types.Snapshot) {
+
+	l := list{keys: keys}
+	for service, sm := range ssm {
+		for id, s := range sm {
+			l.items = append(l.items, &item{service, id, s})
+		}
+	}
+	sort.Sort(&l)
+
+	services := make([]string, len(l.items))
+	snaps := make([]*types.Snapshot, len(l.items))
+	for i, item := range l.items {
+		services[i] = item.service
+		snaps[i] = item.obj.(*types.Snapshot)
+	}
+	return services, snaps
+}
+
+type item struct {
+	service string
+	id      string
+	obj     interface{}
+}
+
+// list is a sortable list of volumes or snapshots. Objects that are equal by
+// the sort keys are sorted by service and then by ID so that the order of a
+// listing, and thus the content of its pages, is stable.
+type list struct {
+	items []*item
+	keys  []*types.SortKey
+}
+
+func (l *list) Len() int {
+	return len(l.items)
+}
+
+func (l *list) Swap(i, j int) {
+	l.items[i], l.items[j] = l.items[j], l.items[i]
+}
+
+func (l *list) Less(i, j int) bool {
+	a, b := l.items[i], l.items[j]
+	for _, k := range l.keys {
+		c := compare(a.value(k.Field), b.value(k.Field))
+		if c == 0 {
+			continue
+		}
+		if k.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+	if a.service != b.service {
+		return a.service < b.service
+	}
+	return a.id < b.id
+}
+
+// page sorts the list and returns the page's items and the continuation
+// token for the next page.
+func (l *list) page(page *types.Page) ([]*item, string) {
+	sort.Sort(l)
+
+	start := page.Offset
+	if start > len(l.items) {
+		start = len(l.items)
+	}
+	end := len(l.items)
+	if page.Limit > 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+
+	next := ""
+	if end < len(l.items) {
+		next = EncodeOffsetToken(end)
+	}
+	return l.items[start:end], next
+}
+
+func (i *item) value(field string) interface{} {
+	if field == "service" {
+		return i.service
+	}
+	switch o := i.obj.(type) {
+	case *types.Volume:
+		switch field {
+		case "id":
+			return o.ID
+		case "name":
+			return o.Name
+		case "type":
+			return o.Type
+		case "size":
+			return o.Size
+		case "iops":
+			return o.IOPS
+		case "availabilityZone":
+			return o.AvailabilityZone
+		case "status":
+			return o.Status
+		}
+	case *types.Snapshot:
+		switch field {
+		case "id":
+			return o.ID
+		case "name":
+			return o.Name
+		case "volumeID":
+			return o.VolumeID
+		case "volumeSize":
+			return o.VolumeSize
+		case "startTime":
+			return o.StartTime
+		case "status":
+			return o.Status
+		}
+	}
+	return nil
+}
+
+func compare(a, b interface{}) int {
+	switch av := a.(type) {
+	case int64:
+		bv, _ := b.(int64)
+		switch {
+		case av < bv:
+			return -1
+		case av > bv:
+			return 1
+		}
+		return 0
+	case string:
+		bv, _ := b.(string)
+		return strings.Compare(strings.ToLower(av), strings.ToLower(bv))
+	}
+	return 0
+}
+
+func parseInt(query url.Values, param string) (int, error) {
+	v := query.Get(param)
+	if v == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil || i < 0 {
+		return 0, utils.NewBadPageErr(param, v)
+	}
+	return i, nil
+}
+
+func encodeToken(v string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(v))
+}
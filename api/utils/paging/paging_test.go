@@ -0,0 +1,147 @@
+package paging
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+func newVolumes() types.ServiceVolumeMap {
+	return types.ServiceVolumeMap{
+		"vfs": types.VolumeMap{
+			"vfs-000": &types.Volume{ID: "vfs-000", Name: "c", Size: 10},
+			"vfs-001": &types.Volume{ID: "vfs-001", Name: "A", Size: 20},
+			"vfs-002": &types.Volume{ID: "vfs-002", Name: "b", Size: 20},
+		},
+		"vfs2": types.VolumeMap{
+			"vfs-000": &types.Volume{ID: "vfs-000", Name: "d", Size: 5},
+		},
+	}
+}
+
+func TestParsePageNone(t *testing.T) {
+	page, err := ParsePage(url.Values{"attachments": {"true"}}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, page)
+}
+
+func TestParsePage(t *testing.T) {
+	page, err := ParsePage(url.Values{
+		LimitParam:  {"2"},
+		OffsetParam: {"4"},
+		SortParam:   {"Name,-size"},
+	}, VolumeSortFields)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, page.Limit)
+	assert.Equal(t, 4, page.Offset)
+	assert.Equal(t, []*types.SortKey{
+		&types.SortKey{Field: "name"},
+		&types.SortKey{Field: "size", Descending: true},
+	}, page.Sort)
+}
+
+func TestParsePageErrors(t *testing.T) {
+	for _, q := range []url.Values{
+		{LimitParam: {"x"}},
+		{LimitParam: {"-1"}},
+		{OffsetParam: {"1.5"}},
+		{TokenParam: {"!!"}},
+		{TokenParam: {encodeToken("x:1")}},
+		{TokenParam: {encodeToken("o:-1")}},
+		{SortParam: {"name,bogus"}},
+	} {
+		_, err := ParsePage(q, VolumeSortFields)
+		if assert.Error(t, err, q.Encode()) {
+			assert.IsType(t, &types.ErrBadPage{}, err, q.Encode())
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	offset, driverToken, err := DecodeToken(EncodeOffsetToken(42))
+	assert.NoError(t, err)
+	assert.Equal(t, 42, offset)
+	assert.Empty(t, driverToken)
+
+	offset, driverToken, err = DecodeToken(EncodeDriverToken("next/page"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, offset)
+	assert.Equal(t, "next/page", driverToken)
+
+	assert.Empty(t, EncodeDriverToken(""))
+
+	page, err := ParsePage(
+		url.Values{TokenParam: {EncodeOffsetToken(3)}}, VolumeSortFields)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Offset)
+}
+
+func TestPageVolumes(t *testing.T) {
+	svm := newVolumes()
+	page := &types.Page{Limit: 3, Sort: []*types.SortKey{
+		&types.SortKey{Field: "size", Descending: true},
+		&types.SortKey{Field: "name"},
+	}}
+
+	reply, next := PageVolumes(page, svm)
+	assert.NotEmpty(t, next)
+	assert.Len(t, reply["vfs"], 3)
+	assert.Len(t, reply["vfs2"], 0)
+
+	page.Offset, _, _ = DecodeToken(next)
+	assert.Equal(t, 3, page.Offset)
+	reply, next = PageVolumes(page, svm)
+	assert.Empty(t, next)
+	assert.Len(t, reply["vfs"], 0)
+	assert.Contains(t, reply["vfs2"], "vfs-000")
+
+	page.Offset = 10
+	reply, next = PageVolumes(page, svm)
+	assert.Empty(t, next)
+	assert.Len(t, reply["vfs"], 0)
+	assert.Len(t, reply["vfs2"], 0)
+}
+
+func TestSortedVolumes(t *testing.T) {
+	keys, err := ParseSort("-size,name", VolumeSortFields)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	services, vols := SortedVolumes(newVolumes(), keys)
+	names := []string{}
+	for _, v := range vols {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{"A", "b", "c", "d"}, names)
+	assert.Equal(t, []string{"vfs", "vfs", "vfs", "vfs2"}, services)
+
+	// objects that are equal by the sort keys are sorted by service and ID
+	services, vols = SortedVolumes(newVolumes(), nil)
+	assert.Equal(t, []string{"vfs", "vfs", "vfs", "vfs2"}, services)
+	assert.Equal(t, "vfs-000", vols[0].ID)
+	assert.Equal(t, "vfs-002", vols[2].ID)
+}
+
+func TestSortedSnapshots(t *testing.T) {
+	keys, err := ParseSort("-startTime", SnapshotSortFields)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, snaps := SortedSnapshots(types.ServiceSnapshotMap{
+		"vfs": types.SnapshotMap{
+			"s1": &types.Snapshot{ID: "s1", StartTime: 1},
+			"s2": &types.Snapshot{ID: "s2", StartTime: 3},
+			"s3": &types.Snapshot{ID: "s3", StartTime: 2},
+		},
+	}, keys)
+	ids := []string{}
+	for _, s := range snaps {
+		ids = append(ids, s.ID)
+	}
+	assert.Equal(t, []string{"s2", "s3", "s1"}, ids)
+}
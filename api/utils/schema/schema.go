@@ -49,12 +49,28 @@ var (
 	// ServiceInfoMapSchema is the JSON schemea for a map[string]*ServiceInfo.
 	ServiceInfoMapSchema = buildSchemaVar("serviceInfoMap")
 
+	// VolumeProfileMapSchema is the JSON schema for the VolumeProfileMap
+	// resource.
+	VolumeProfileMapSchema = buildSchemaVar("volumeProfileMap")
+
 	// DriverInfoSchema is the JSON schema for the DriverInfo resource.
 	DriverInfoSchema = buildSchemaVar("driverInfo")
 
 	// ExecutorInfoSchema is the JSON schema for the ExecutorInfo resource.
 	ExecutorInfoSchema = buildSchemaVar("executorInfo")
 
+	// TaskSchema is the JSON schema for the Task resource.
+	TaskSchema = buildSchemaVar("task")
+
+	// TaskMapSchema is the JSON schema for the TaskMap resource.
+	TaskMapSchema = buildSchemaVar("taskMap")
+
+	// ExecutorInfoMapSchema is the JSON schema for a map[string]*ExecutorInfo.
+	ExecutorInfoMapSchema = buildSchemaVar("executorInfoMap")
+
+	// ErrorSchema is the JSON schema for an error response.
+	ErrorSchema = buildSchemaVar("error")
+
 	// VolumeCreateRequestSchema is the JSON schema for a Volume creation
 	// request.
 	VolumeCreateRequestSchema = buildSchemaVar("volumeCreateRequest")
@@ -63,6 +79,10 @@ var (
 	// request.
 	VolumeCopyRequestSchema = buildSchemaVar("volumeCopyRequest")
 
+	// VolumeResizeRequestSchema is the JSON schema for a Volume resize
+	// request.
+	VolumeResizeRequestSchema = buildSchemaVar("volumeResizeRequest")
+
 	// VolumeSnapshotRequestSchema is the JSON schema for a Volume snapshot
 	// request.
 	VolumeSnapshotRequestSchema = buildSchemaVar("volumeSnapshotRequest")
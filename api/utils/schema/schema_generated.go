@@ -247,7 +247,8 @@ const (
                     "type": "string",
                     "description": "Type is the type of storage the driver provides: block, nas, object."
                 },
-                "nextDevice": { "$ref": "#/definitions/nextDeviceInfo" }
+                "nextDevice": { "$ref": "#/definitions/nextDeviceInfo" },
+                "capabilities": { "$ref": "#/definitions/capabilities" }
             },
             "required": [ "name", "type" ],
             "additionalProperties": false
@@ -279,6 +280,44 @@ const (
         },
 
 
+        "capabilities": {
+            "type": "object",
+            "description": "Capabilities are the optional operations and volume options that a driver supports.",
+            "properties": {
+                "snapshots": {
+                    "type": "boolean",
+                    "description": "Snapshots indicates whether or not the driver can snapshot volumes."
+                },
+                "copy": {
+                    "type": "boolean",
+                    "description": "Copy indicates whether or not the driver can copy volumes."
+                },
+                "resize": {
+                    "type": "boolean",
+                    "description": "Resize indicates whether or not the driver can resize volumes."
+                },
+                "multiAttach": {
+                    "type": "boolean",
+                    "description": "MultiAttach indicates whether or not a volume can be attached to more than one instance at a time."
+                },
+                "iops": {
+                    "type": "boolean",
+                    "description": "IOPS indicates whether or not volumes can be provisioned with IOPS."
+                },
+                "volumeTypes": {
+                    "type": "array",
+                    "description": "VolumeTypes are the types of volumes the driver can create. Any type is accepted if the list is empty.",
+                    "items": { "type": "string" }
+                },
+                "maxSize": {
+                    "type": "number",
+                    "description": "MaxSize is the maximum size, in GiB, of a volume. Zero is no limit."
+                }
+            },
+            "additionalProperties": false
+        },
+
+
         "nextDeviceInfo": {
             "type": "object",
             "properties": {
@@ -381,6 +420,46 @@ const (
         },
 
 
+        "volumeProfile": {
+            "type": "object",
+            "description": "A named set of options with which a service creates volumes.",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "description": "The profile's name."
+                },
+                "availabilityZone": {
+                    "type": "string",
+                    "description": "The zone in which volumes are created."
+                },
+                "iops": {
+                    "type": "number",
+                    "description": "The IOPS with which volumes are created."
+                },
+                "size": {
+                    "type": "number",
+                    "description": "The size, in GiB, of volumes that are created without one."
+                },
+                "type": {
+                    "type": "string",
+                    "description": "The type of volumes."
+                },
+                "opts": { "$ref" : "#/definitions/opts" }
+            },
+            "required": [ "name" ],
+            "additionalProperties": false
+        },
+
+
+        "volumeProfileMap": {
+            "type": "object",
+            "patternProperties": {
+                "^.+$": { "$ref": "#/definitions/volumeProfile" }
+            },
+            "additionalProperties": false
+        },
+
+
         "driverInfoMap": {
             "type": "object",
             "patternProperties": {
@@ -428,6 +507,9 @@ const (
                 "type": {
                     "type": "string"
                 },
+                "profile": {
+                    "type": "string"
+                },
                 "opts": { "$ref" : "#/definitions/opts" }
             },
             "required": [ "name" ],
@@ -448,6 +530,19 @@ const (
         },
 
 
+        "volumeResizeRequest": {
+            "type": "object",
+            "properties": {
+                "size": {
+                    "type": "number"
+                },
+                "opts": { "$ref" : "#/definitions/opts" }
+            },
+            "required": [ "size" ],
+            "additionalProperties": false
+        },
+
+
         "volumeSnapshotRequest": {
             "type": "object",
             "properties": {
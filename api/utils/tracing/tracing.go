@@ -0,0 +1,176 @@
+// Package tracing provides spans that follow an operation from the libStorage
+// client to the server, its tasks and storage drivers, and to the executor.
+// The span context is propagated using the W3C Trace Context format, in the
+// traceparent HTTP header between the client and server and in the
+// LIBSTORAGE_TRACEPARENT environment variable from the client to the executor.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+// EnvTraceParent is the environment variable used to propagate the span
+// context to the executor.
+const EnvTraceParent = "LIBSTORAGE_TRACEPARENT"
+
+var traceParentRX = regexp.MustCompile(
+	`^00-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})$`)
+
+// Span is a timed operation that is part of a trace.
+type Span struct {
+
+	// TraceID is the ID of the trace to which the span belongs.
+	TraceID string `json:"traceID"`
+
+	// SpanID is the ID of the span.
+	SpanID string `json:"spanID"`
+
+	// ParentID is the ID of the span's parent.
+	ParentID string `json:"parentID,omitempty"`
+
+	// Name is the name of the span.
+	Name string `json:"name"`
+
+	// Start is the time at which the span started.
+	Start time.Time `json:"start"`
+
+	// End is the time at which the span finished.
+	End time.Time `json:"end"`
+
+	// Attributes are the span's attributes.
+	Attributes map[string]string `json:"attributes,omitempty"`
+
+	// Error is the error with which the span finished.
+	Error string `json:"error,omitempty"`
+
+	sampled  bool
+	remote   bool
+	rwl      *sync.RWMutex
+	finished bool
+}
+
+// StartSpan starts a new span that is a child of the span in the provided
+// context or, if there is no such span, the root of a new trace. The returned
+// context contains the new span.
+func StartSpan(ctx types.Context, name string) (types.Context, *Span) {
+	s := &Span{
+		SpanID:     newID(8),
+		Name:       name,
+		Start:      time.Now().UTC(),
+		Attributes: map[string]string{},
+		sampled:    true,
+		rwl:        &sync.RWMutex{},
+	}
+	if p, ok := FromContext(ctx); ok {
+		s.TraceID = p.TraceID
+		s.ParentID = p.SpanID
+		s.sampled = p.sampled
+	} else {
+		s.TraceID = newID(16)
+	}
+	return ctx.WithValue(context.SpanKey, s), s
+}
+
+// FromContext returns the span in the provided context.
+func FromContext(ctx types.Context) (*Span, bool) {
+	s, ok := ctx.Value(context.SpanKey).(*Span)
+	return s, ok
+}
+
+// WithRemoteParent returns a context with the remote span described by the
+// provided traceparent value so that the next span started with the context
+// is its child. The provided context is returned as is if the value is empty
+// or invalid.
+func WithRemoteParent(ctx types.Context, traceParent string) types.Context {
+	s, err := ParseTraceParent(traceParent)
+	if err != nil {
+		return ctx
+	}
+	return ctx.WithValue(context.SpanKey, s)
+}
+
+// ParseTraceParent parses a W3C traceparent value into a remote span.
+func ParseTraceParent(traceParent string) (*Span, error) {
+	m := traceParentRX.FindStringSubmatch(traceParent)
+	if len(m) == 0 {
+		return nil, fmt.Errorf("invalid traceparent: %s", traceParent)
+	}
+	flags, err := hex.DecodeString(m[3])
+	if err != nil {
+		return nil, err
+	}
+	return &Span{
+		TraceID: m[1],
+		SpanID:  m[2],
+		sampled: flags[0]&1 == 1,
+		remote:  true,
+		rwl:     &sync.RWMutex{},
+	}, nil
+}
+
+// EnvVar returns the environment variable that propagates the span in the
+// provided context to an executor. An empty string is returned if the
+// context has no span.
+func EnvVar(ctx types.Context) string {
+	s, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s=%s", EnvTraceParent, s)
+}
+
+// String returns the span's context as a W3C traceparent value.
+func (s *Span) String() string {
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%s-%s", s.TraceID, s.SpanID, flags)
+}
+
+// SetAttribute sets one of the span's attributes.
+func (s *Span) SetAttribute(key, val string) {
+	s.rwl.Lock()
+	defer s.rwl.Unlock()
+	if s.Attributes == nil {
+		s.Attributes = map[string]string{}
+	}
+	s.Attributes[key] = val
+}
+
+// Finish finishes the span with the provided error, which may be nil, and
+// exports it if it is sampled. Remote spans and spans that are already
+// finished are ignored.
+func (s *Span) Finish(err error) {
+	s.rwl.Lock()
+	if s.remote || s.finished {
+		s.rwl.Unlock()
+		return
+	}
+	s.finished = true
+	s.End = time.Now().UTC()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.rwl.Unlock()
+
+	if s.sampled {
+		export(s)
+	}
+}
+
+func newID(size int) string {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+var (
+	exporter    Exporter
+	exporterRWL = &sync.RWMutex{}
+)
+
+// Exporter exports finished spans.
+type Exporter interface {
+
+	// Export exports a finished span.
+	Export(s *Span) error
+}
+
+// FileExporter exports spans as lines of JSON appended to a file. The file is
+// opened in append mode when the first span is exported and stays open until
+// the exporter is closed, so multiple processes, such as the server and the
+// executor, may export spans to the same file.
+type FileExporter struct {
+	sync.Mutex
+	Path string
+	f    *os.File
+}
+
+// Export appends the span to the exporter's file.
+func (e *FileExporter) Export(s *Span) error {
+	s.rwl.RLock()
+	buf, err := json.Marshal(s)
+	s.rwl.RUnlock()
+	if err != nil {
+		return err
+	}
+	buf = append(buf, '\n')
+
+	e.Lock()
+	defer e.Unlock()
+
+	if e.f == nil {
+		f, err := os.OpenFile(
+			e.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		e.f = f
+	}
+	_, err = e.f.Write(buf)
+	return err
+}
+
+// Close closes the exporter's file. The file is opened again if another span
+// is exported.
+func (e *FileExporter) Close() error {
+	e.Lock()
+	defer e.Unlock()
+	if e.f == nil {
+		return nil
+	}
+	err := e.f.Close()
+	e.f = nil
+	return err
+}
+
+// SetExporter sets the exporter to which finished spans are exported. A nil
+// exporter disables the export of spans. The previous exporter is closed if
+// it is a FileExporter.
+func SetExporter(e Exporter) {
+	exporterRWL.Lock()
+	defer exporterRWL.Unlock()
+	setExporter(e)
+}
+
+func setExporter(e Exporter) {
+	if fe, ok := exporter.(*FileExporter); ok && fe != e {
+		if err := fe.Close(); err != nil {
+			log.WithError(err).Warn("error closing span file")
+		}
+	}
+	exporter = e
+}
+
+// Init configures the exporter from the provided config. If the
+// libstorage.tracing.file property is set, spans are exported to that file.
+// Otherwise the export of spans is disabled.
+func Init(config gofig.Config) {
+	filePath := config.GetString(types.ConfigTracingFile)
+
+	exporterRWL.Lock()
+	defer exporterRWL.Unlock()
+
+	if filePath == "" {
+		setExporter(nil)
+		return
+	}
+	if fe, ok := exporter.(*FileExporter); ok && fe.Path == filePath {
+		return
+	}
+	setExporter(&FileExporter{Path: filePath})
+}
+
+func export(s *Span) {
+	exporterRWL.RLock()
+	e := exporter
+	exporterRWL.RUnlock()
+
+	if e == nil {
+		return
+	}
+	if err := e.Export(s); err != nil {
+		log.WithError(err).Warn("error exporting span")
+	}
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import "github.com/emccode/libstorage/api/types"
+
+type storageDriver struct {
+	types.StorageDriver
+	service string
+}
+
+// NewStorageDriver returns a storage driver that starts a span for each of the
+// calls made to the provided driver on behalf of the named service.
+func NewStorageDriver(
+	service string, d types.StorageDriver) types.StorageDriver {
+
+	return &storageDriver{StorageDriver: d, service: service}
+}
+
+// startSpan starts a span for a call to the driver.
+func (d *storageDriver) startSpan(
+	ctx types.Context, op string) (types.Context, *Span) {
+
+	ctx, span := StartSpan(ctx, "driver."+op)
+	span.SetAttribute("service", d.service)
+	span.SetAttribute("driver", d.StorageDriver.Name())
+	return ctx, span
+}
+
+// finish finishes a span with the error to which the pointer refers. It is
+// meant to be deferred with a pointer to a function's named error result.
+func finish(span *Span, err *error) {
+	span.Finish(*err)
+}
+
+func (d *storageDriver) NextDeviceInfo(
+	ctx types.Context) (ndi *types.NextDeviceInfo, err error) {
+
+	ctx, span := d.startSpan(ctx, "NextDeviceInfo")
+	defer finish(span, &err)
+	return d.StorageDriver.NextDeviceInfo(ctx)
+}
+
+func (d *storageDriver) Type(
+	ctx types.Context) (st types.StorageType, err error) {
+
+	ctx, span := d.startSpan(ctx, "Type")
+	defer finish(span, &err)
+	return d.StorageDriver.Type(ctx)
+}
+
+func (d *storageDriver) Capabilities(
+	ctx types.Context) (caps *types.Capabilities, err error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	ctx, span := d.startSpan(ctx, "Capabilities")
+	defer finish(span, &err)
+	return sd.Capabilities(ctx)
+}
+
+func (d *storageDriver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (i *types.Instance, err error) {
+
+	ctx, span := d.startSpan(ctx, "InstanceInspect")
+	defer finish(span, &err)
+	return d.StorageDriver.InstanceInspect(ctx, opts)
+}
+
+func (d *storageDriver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) (vols []*types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "Volumes")
+	defer finish(span, &err)
+	return d.StorageDriver.Volumes(ctx, opts)
+}
+
+func (d *storageDriver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeInspect")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeInspect(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCreate")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeCreate(ctx, name, opts)
+}
+
+func (d *storageDriver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID,
+	volumeName string,
+	opts *types.VolumeCreateOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCreateFromSnapshot")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeCreateFromSnapshot(
+		ctx, snapshotID, volumeName, opts)
+}
+
+func (d *storageDriver) VolumeCopy(
+	ctx types.Context,
+	volumeID,
+	volumeName string,
+	opts types.Store) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeCopy")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeCopy(ctx, volumeID, volumeName, opts)
+}
+
+func (d *storageDriver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (vol *types.Volume, err error) {
+
+	sd, ok := d.StorageDriver.(types.StorageDriverVolumeResizer)
+	if !ok {
+		return nil, types.ErrNotImplemented
+	}
+	ctx, span := d.startSpan(ctx, "VolumeResize")
+	defer finish(span, &err)
+	return sd.VolumeResize(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID,
+	snapshotName string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeSnapshot")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeSnapshot(ctx, volumeID, snapshotName, opts)
+}
+
+func (d *storageDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) (err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeRemove")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeRemove(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (vol *types.Volume, tok string, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeAttach")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeAttach(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (vol *types.Volume, err error) {
+
+	ctx, span := d.startSpan(ctx, "VolumeDetach")
+	defer finish(span, &err)
+	return d.StorageDriver.VolumeDetach(ctx, volumeID, opts)
+}
+
+func (d *storageDriver) Snapshots(
+	ctx types.Context,
+	opts types.Store) (snaps []*types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "Snapshots")
+	defer finish(span, &err)
+	return d.StorageDriver.Snapshots(ctx, opts)
+}
+
+func (d *storageDriver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "SnapshotInspect")
+	defer finish(span, &err)
+	return d.StorageDriver.SnapshotInspect(ctx, snapshotID, opts)
+}
+
+func (d *storageDriver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID,
+	snapshotName,
+	destinationID string,
+	opts types.Store) (snap *types.Snapshot, err error) {
+
+	ctx, span := d.startSpan(ctx, "SnapshotCopy")
+	defer finish(span, &err)
+	return d.StorageDriver.SnapshotCopy(
+		ctx, snapshotID, snapshotName, destinationID, opts)
+}
+
+func (d *storageDriver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (err error) {
+
+	ctx, span := d.startSpan(ctx, "SnapshotRemove")
+	defer finish(span, &err)
+	return d.StorageDriver.SnapshotRemove(ctx, snapshotID, opts)
+}
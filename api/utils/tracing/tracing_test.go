@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+func TestStartSpan(t *testing.T) {
+	ctx, parent := StartSpan(context.Background(), "parent")
+	assert.Len(t, parent.TraceID, 32)
+	assert.Len(t, parent.SpanID, 16)
+	assert.Empty(t, parent.ParentID)
+
+	ctx, child := StartSpan(ctx, "child")
+	assert.Equal(t, parent.TraceID, child.TraceID)
+	assert.Equal(t, parent.SpanID, child.ParentID)
+
+	s, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, child, s)
+}
+
+func TestTraceParent(t *testing.T) {
+	_, s := StartSpan(context.Background(), "client")
+
+	ctx := WithRemoteParent(context.Background(), s.String())
+	_, child := StartSpan(ctx, "server")
+	assert.Equal(t, s.TraceID, child.TraceID)
+	assert.Equal(t, s.SpanID, child.ParentID)
+
+	assert.Equal(t, EnvTraceParent+"="+s.String(), EnvVar(ctx))
+
+	_, err := ParseTraceParent("invalid")
+	assert.Error(t, err)
+
+	// an invalid traceparent starts a new trace
+	ctx = WithRemoteParent(context.Background(), "invalid")
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+	assert.Empty(t, EnvVar(ctx))
+}
+
+type testDriver struct {
+	types.StorageDriver
+}
+
+func (d *testDriver) Name() string {
+	return "testDriver"
+}
+
+func (d *testDriver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if _, ok := FromContext(ctx); !ok {
+		return errors.New("missing span")
+	}
+	return errors.New("volume not found")
+}
+
+func TestFileExporter(t *testing.T) {
+	f, err := ioutil.TempFile("", "libstorage-spans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.RemoveAll(f.Name())
+
+	SetExporter(&FileExporter{Path: f.Name()})
+	defer SetExporter(nil)
+
+	ctx, parent := StartSpan(context.Background(), "server.volumeRemove")
+	d := NewStorageDriver("testService", &testDriver{})
+	assert.Error(t, d.VolumeRemove(ctx, "vol-000", nil))
+	parent.Finish(nil)
+
+	// finishing a span more than once exports it only once
+	parent.Finish(nil)
+
+	f, err = os.Open(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var spans []*Span
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s := &Span{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), s))
+		spans = append(spans, s)
+	}
+	assert.NoError(t, scanner.Err())
+
+	if !assert.Len(t, spans, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "driver.VolumeRemove", spans[0].Name)
+	assert.Equal(t, parent.SpanID, spans[0].ParentID)
+	assert.Equal(t, "volume not found", spans[0].Error)
+	assert.Equal(t, "testService", spans[0].Attributes["service"])
+	assert.Equal(t, "testDriver", spans[0].Attributes["driver"])
+	assert.Equal(t, "server.volumeRemove", spans[1].Name)
+	assert.False(t, spans[1].End.Before(spans[1].Start))
+}
+
+func TestInit(t *testing.T) {
+	config := gofig.New()
+	config.Set(types.ConfigTracingFile, "/tmp/libstorage-spans.log")
+	Init(config)
+	fe, ok := exporter.(*FileExporter)
+	if assert.True(t, ok) {
+		assert.Equal(t, "/tmp/libstorage-spans.log", fe.Path)
+	}
+
+	// a config without the property disables the export of spans
+	Init(gofig.New())
+	assert.Nil(t, exporter)
+}
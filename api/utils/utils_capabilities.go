@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"github.com/emccode/libstorage/api/types"
+)
+
+// DriverCapabilities returns the capabilities of a storage driver. A nil
+// value is returned if the driver does not describe its capabilities.
+func DriverCapabilities(
+	ctx types.Context,
+	driver types.StorageDriver) (*types.Capabilities, error) {
+
+	d, ok := driver.(types.StorageDriverCapabilities)
+	if !ok {
+		return nil, nil
+	}
+	caps, err := d.Capabilities(ctx)
+	if err == types.ErrNotImplemented {
+		return nil, nil
+	}
+	return caps, err
+}
+
+// CheckCapabilities returns an ErrUnsupportedCapability error if the driver
+// of a service describes its capabilities and does not support one of the
+// given operations. Nothing is checked for drivers that do not describe
+// their capabilities.
+func CheckCapabilities(
+	ctx types.Context,
+	service types.StorageService,
+	capabilities ...string) error {
+
+	caps, err := DriverCapabilities(ctx, service.Driver())
+	if err != nil || caps == nil {
+		return err
+	}
+	for _, c := range capabilities {
+		if !caps.Supports(c) {
+			return NewUnsupportedCapabilityErr(service.Name(), c, nil)
+		}
+	}
+	return nil
+}
+
+// CheckVolumeCapabilities returns an ErrUnsupportedCapability error if the
+// driver of a service describes its capabilities and cannot provide a volume
+// with the given size, IOPS and type. Nil values are not checked.
+func CheckVolumeCapabilities(
+	ctx types.Context,
+	service types.StorageService,
+	size, iops *int64,
+	volumeType *string) error {
+
+	caps, err := DriverCapabilities(ctx, service.Driver())
+	if err != nil || caps == nil {
+		return err
+	}
+	if size != nil && !caps.SupportsSize(*size) {
+		return NewUnsupportedCapabilityErr(
+			service.Name(), types.CapabilityMaxSize, *size)
+	}
+	if iops != nil && *iops > 0 && !caps.IOPS {
+		return NewUnsupportedCapabilityErr(
+			service.Name(), types.CapabilityIOPS, *iops)
+	}
+	if volumeType != nil && *volumeType != "" &&
+		!caps.SupportsVolumeType(*volumeType) {
+		return NewUnsupportedCapabilityErr(
+			service.Name(), types.CapabilityVolumeType, *volumeType)
+	}
+	return nil
+}
+
+// CheckAttachCapabilities returns an ErrUnsupportedCapability error if the
+// driver of a service describes its capabilities, cannot attach a volume to
+// more than one instance, and the volume is attached to an instance other
+// than the specified one.
+func CheckAttachCapabilities(
+	ctx types.Context,
+	service types.StorageService,
+	volumeID string,
+	iid *types.InstanceID) error {
+
+	caps, err := DriverCapabilities(ctx, service.Driver())
+	if err != nil || caps == nil || caps.MultiAttach {
+		return err
+	}
+	v, err := service.Driver().VolumeInspect(
+		ctx, volumeID, &types.VolumeInspectOpts{
+			Attachments: true,
+			Opts:        NewStore(),
+		})
+	if err != nil {
+		return err
+	}
+	for _, a := range v.Attachments {
+		if a.InstanceID != nil && a.InstanceID.ID != iid.ID {
+			return NewUnsupportedCapabilityErr(
+				service.Name(), types.CapabilityMultiAttach, nil)
+		}
+	}
+	return nil
+}
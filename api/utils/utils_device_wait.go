@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"strings"
+	"time"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+// LocalDevicesFunc is a function that returns the system's local devices.
+type LocalDevicesFunc func() (*types.LocalDevices, error)
+
+// DeviceWatcher waits for devices to be presented to the local system. On
+// Linux the watcher is event-driven, re-examining the local devices whenever
+// one of the watched paths changes or a udev event is received. When no
+// event source is available the watcher falls back to polling.
+type DeviceWatcher struct {
+
+	// Paths is the list of paths watched for changes. Paths that do not exist
+	// are ignored.
+	Paths []string
+
+	// Netlink is a flag indicating whether or not to listen for kernel and
+	// udev netlink events.
+	Netlink bool
+
+	// PollInterval is the interval at which the local devices are examined
+	// when no event source is available.
+	PollInterval time.Duration
+
+	// EventPollInterval is the interval at which the local devices are
+	// examined when an event source is available. It guards against devices
+	// that appear without generating an event.
+	EventPollInterval time.Duration
+}
+
+// NewDeviceWatcher returns a new DeviceWatcher that watches the default
+// device paths and udev events.
+func NewDeviceWatcher() *DeviceWatcher {
+	return &DeviceWatcher{
+		Paths:             []string{"/dev/disk/by-id", "/dev", "/sys/block"},
+		Netlink:           true,
+		PollInterval:      500 * time.Millisecond,
+		EventPollInterval: 5 * time.Second,
+	}
+}
+
+// WaitForDevice blocks until the provided attach token appears in the map
+// returned from the LocalDevicesFunc, the timeout expires, or the context is
+// cancelled, whichever occurs first.
+//
+// The return value is a boolean flag indicating whether or not a match was
+// discovered as well as the result of the last LocalDevicesFunc call before a
+// match is discovered or the timeout expires.
+func (w *DeviceWatcher) WaitForDevice(
+	ctx types.Context,
+	opts *types.WaitForDeviceOpts,
+	f LocalDevicesFunc) (bool, *types.LocalDevices, error) {
+
+	token := strings.ToLower(opts.Token)
+
+	ldl := func() (bool, *types.LocalDevices, error) {
+		ldm, err := f()
+		if err != nil {
+			return false, nil, err
+		}
+		for k := range ldm.DeviceMap {
+			if strings.ToLower(k) == token {
+				return true, ldm, nil
+			}
+		}
+		return false, ldm, nil
+	}
+
+	// the device may already be present
+	found, ld, err := ldl()
+	if found || err != nil {
+		return found, ld, err
+	}
+
+	events, closeEvents := watchDevices(ctx, w.Paths, w.Netlink)
+	defer closeEvents()
+
+	interval := w.PollInterval
+	if events != nil {
+		interval = w.EventPollInterval
+		ctx.Debug("waiting on device events")
+	} else {
+		ctx.Debug("polling for devices")
+	}
+
+	var (
+		timeoutC = time.After(opts.Timeout)
+		ticker   = time.NewTicker(interval)
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false, ld, ctx.Err()
+		case <-timeoutC:
+			return false, ld, nil
+		case <-events:
+		case <-ticker.C:
+		}
+		if found, ld, err = ldl(); found || err != nil {
+			return found, ld, err
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// +build linux
+
+package utils
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+const (
+	inotifyDeviceMask = syscall.IN_CREATE |
+		syscall.IN_DELETE |
+		syscall.IN_MOVED_TO |
+		syscall.IN_ATTRIB
+
+	// ueventGroups is the bitmask of the netlink multicast groups on which
+	// the kernel (1) and udev (2) publish device events.
+	ueventGroups = 1 | 2
+)
+
+// watchDevices returns a channel that receives a value whenever one of the
+// provided paths changes or a uevent is received. A nil channel is returned
+// if no event source could be established.
+func watchDevices(
+	ctx types.Context,
+	paths []string,
+	netlink bool) (<-chan struct{}, func()) {
+
+	var files []*os.File
+
+	if f, err := newInotifyFile(paths); err != nil {
+		ctx.WithError(err).Debug("error watching device paths")
+	} else if f != nil {
+		files = append(files, f)
+	}
+
+	if netlink {
+		if f, err := newUEventFile(); err != nil {
+			ctx.WithError(err).Debug("error listening for uevents")
+		} else {
+			files = append(files, f)
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, func() {}
+	}
+
+	c := make(chan struct{}, 1)
+	for _, f := range files {
+		go func(f *os.File) {
+			buf := make([]byte, 8192)
+			for {
+				if _, err := f.Read(buf); err != nil {
+					return
+				}
+				select {
+				case c <- struct{}{}:
+				default:
+				}
+			}
+		}(f)
+	}
+
+	return c, func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}
+}
+
+func newInotifyFile(paths []string) (*os.File, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := 0
+	for _, p := range paths {
+		if _, err := syscall.InotifyAddWatch(
+			fd, p, inotifyDeviceMask); err == nil {
+			watched++
+		}
+	}
+
+	if watched == 0 {
+		syscall.Close(fd)
+		return nil, nil
+	}
+
+	return os.NewFile(uintptr(fd), "inotify"), nil
+}
+
+func newUEventFile() (*os.File, error) {
+	fd, err := syscall.Socket(
+		syscall.AF_NETLINK,
+		syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: ueventGroups,
+	}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return os.NewFile(uintptr(fd), "uevent"), nil
+}
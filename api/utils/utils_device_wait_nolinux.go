@@ -0,0 +1,15 @@
+// +build !linux
+
+package utils
+
+import "github.com/emccode/libstorage/api/types"
+
+// watchDevices always returns a nil channel on systems without an event
+// source, causing the DeviceWatcher to fall back to polling.
+func watchDevices(
+	ctx types.Context,
+	paths []string,
+	netlink bool) (<-chan struct{}, func()) {
+
+	return nil, func() {}
+}
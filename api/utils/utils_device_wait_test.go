@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+)
+
+func newTestDeviceDir(t *testing.T) (string, LocalDevicesFunc) {
+	dir, err := ioutil.TempDir("", "libstorage-devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() (*types.LocalDevices, error) {
+		fis, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		ld := &types.LocalDevices{Driver: "test", DeviceMap: map[string]string{}}
+		for _, fi := range fis {
+			ld.DeviceMap[fi.Name()] = path.Join(dir, fi.Name())
+		}
+		return ld, nil
+	}
+}
+
+func newTestWaitForDeviceOpts(token string) *types.WaitForDeviceOpts {
+	return &types.WaitForDeviceOpts{Token: token, Timeout: 10 * time.Second}
+}
+
+func TestWaitForDeviceAlreadyPresent(t *testing.T) {
+	dir, ldf := newTestDeviceDir(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(path.Join(dir, "xvdb"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &DeviceWatcher{Paths: []string{dir}, PollInterval: time.Hour}
+	found, ld, err := w.WaitForDevice(
+		context.Background(), newTestWaitForDeviceOpts("XVDB"), ldf)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Contains(t, ld.DeviceMap, "xvdb")
+}
+
+func TestWaitForDeviceEvent(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("device events are only supported on linux")
+	}
+
+	dir, ldf := newTestDeviceDir(t)
+	defer os.RemoveAll(dir)
+
+	// a watcher that only polls once an hour must be woken by an event
+	w := &DeviceWatcher{
+		Paths:             []string{dir},
+		PollInterval:      time.Hour,
+		EventPollInterval: time.Hour,
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		ioutil.WriteFile(path.Join(dir, "xvdc"), nil, 0644)
+	}()
+
+	start := time.Now()
+	found, ld, err := w.WaitForDevice(
+		context.Background(), newTestWaitForDeviceOpts("xvdc"), ldf)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Contains(t, ld.DeviceMap, "xvdc")
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestWaitForDevicePoll(t *testing.T) {
+	dir, ldf := newTestDeviceDir(t)
+	defer os.RemoveAll(dir)
+
+	w := &DeviceWatcher{
+		Paths:        []string{path.Join(dir, "missing")},
+		PollInterval: 50 * time.Millisecond,
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		ioutil.WriteFile(path.Join(dir, "xvdd"), nil, 0644)
+	}()
+
+	found, ld, err := w.WaitForDevice(
+		context.Background(), newTestWaitForDeviceOpts("xvdd"), ldf)
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Contains(t, ld.DeviceMap, "xvdd")
+}
+
+func TestWaitForDeviceTimeout(t *testing.T) {
+	dir, ldf := newTestDeviceDir(t)
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(path.Join(dir, "xvda"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &DeviceWatcher{
+		Paths:             []string{dir},
+		PollInterval:      50 * time.Millisecond,
+		EventPollInterval: 50 * time.Millisecond,
+	}
+	opts := newTestWaitForDeviceOpts("xvde")
+	opts.Timeout = 200 * time.Millisecond
+
+	found, ld, err := w.WaitForDevice(context.Background(), opts, ldf)
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.NotNil(t, ld)
+	assert.Contains(t, ld.DeviceMap, "xvda")
+}
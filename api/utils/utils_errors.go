@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"fmt"
+
 	"github.com/akutz/goof"
 
 	"github.com/emccode/libstorage/api/types"
@@ -31,6 +33,20 @@ func NewNotFoundError(resourceID string) error {
 	}
 }
 
+// NewBadRequestErr returns a new ErrBadRequest error. The inner error may be
+// nil.
+func NewBadRequestErr(msg string, fields goof.Fields, err error) error {
+	return &types.ErrBadRequest{Goof: goof.WithFieldsE(fields, msg, err)}
+}
+
+// NewBadProfileErr returns a new ErrBadProfile error.
+func NewBadProfileErr(service, profile string) error {
+	return &types.ErrBadProfile{Goof: goof.WithFields(goof.Fields{
+		"service": service,
+		"profile": profile,
+	}, fmt.Sprintf("service %s has no profile %s", service, profile))}
+}
+
 // NewMissingInstanceIDError returns a new ErrMissingInstanceID error.
 func NewMissingInstanceIDError(service string) error {
 	return &types.ErrMissingInstanceID{
@@ -59,8 +75,44 @@ func NewBatchProcessErr(completed interface{}, err error) error {
 		"completed", completed, "batch processing error", err)}
 }
 
+// NewDuplicateNameErr returns a new ErrDuplicateName error.
+func NewDuplicateNameErr(name string) error {
+	return &types.ErrDuplicateName{
+		Goof: goof.WithField("name", name, "duplicate volume name"),
+	}
+}
+
 // NewBadFilterErr returns a new ErrBadFilter error.
 func NewBadFilterErr(filter string, err error) error {
 	return &types.ErrBadFilter{Goof: goof.WithFieldE(
 		"filter", filter, "bad filter", err)}
 }
+
+// NewBadPageErr returns a new ErrBadPage error.
+func NewBadPageErr(param, value string) error {
+	return &types.ErrBadPage{Goof: goof.WithField(param, value, "bad page")}
+}
+
+// NewInvalidConfigErr returns a new ErrInvalidConfig error.
+func NewInvalidConfigErr(problems map[string]interface{}) error {
+	return &types.ErrInvalidConfig{Goof: goof.WithFields(
+		problems, "invalid config")}
+}
+
+// NewUnsupportedCapabilityErr returns a new ErrUnsupportedCapability error.
+// The value is the requested value of a volume option, such as a volume type,
+// and is omitted if nil. The message names the service and the capability,
+// since clients receive only the message of an error.
+func NewUnsupportedCapabilityErr(
+	service, capability string, value interface{}) error {
+	fields := goof.Fields{
+		"service":    service,
+		"capability": capability,
+	}
+	msg := fmt.Sprintf("service %s does not support %s", service, capability)
+	if value != nil {
+		fields["value"] = value
+		msg = fmt.Sprintf("%s %v", msg, value)
+	}
+	return &types.ErrUnsupportedCapability{Goof: goof.WithFields(fields, msg)}
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MountsFilePath is the path to the table of the mounted file systems.
+const MountsFilePath = "/proc/mounts"
+
+// MountFilter returns a flag indicating whether or not an entry of a mount
+// table is included in the mounts that LocalMounts and ParseMounts return.
+type MountFilter func(source, target, fsType string) bool
+
+// LocalMounts returns the sources and mount points of the file systems in
+// the local system's mount table that the filter includes. An empty map is
+// returned on systems without procfs since there is no mount table to read.
+func LocalMounts(filter MountFilter) (map[string]string, error) {
+	f, err := os.Open(MountsFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMounts(f, filter)
+}
+
+// ParseMounts returns the sources and mount points of the file systems in a
+// mount table that the filter includes. The octal escape sequences with which
+// the table encodes spaces and other special characters are decoded before
+// the filter is invoked.
+func ParseMounts(r io.Reader, filter MountFilter) (map[string]string, error) {
+	mounts := map[string]string{}
+	scn := bufio.NewScanner(r)
+	for scn.Scan() {
+		f := strings.Fields(scn.Text())
+		if len(f) < 3 {
+			continue
+		}
+		source, target := unescapeMount(f[0]), unescapeMount(f[1])
+		if filter != nil && !filter(source, target, f[2]) {
+			continue
+		}
+		mounts[source] = target
+	}
+	return mounts, scn.Err()
+}
+
+func unescapeMount(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+4 <= len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				buf = append(buf, byte(n))
+				i += 3
+				continue
+			}
+		}
+		buf = append(buf, s[i])
+	}
+	return string(buf)
+}
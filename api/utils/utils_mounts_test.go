@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMounts = `proc /proc proc rw,nosuid,nodev,noexec,relatime 0 0
+/dev/xvda1 / ext4 rw,relatime,data=ordered 0 0
+server:/exports/data /mnt/data nfs4 rw,relatime,vers=4.1 0 0
+server:/exports/my\040data /mnt/my\040data nfs rw,relatime,vers=3 0 0
+s3://bucket /mnt/bucket fuse.s3fs rw,nosuid,nodev 0 0
+`
+
+func TestParseMounts(t *testing.T) {
+	mounts, err := ParseMounts(strings.NewReader(testMounts), nil)
+	assert.NoError(t, err)
+	assert.Len(t, mounts, 5)
+
+	mounts, err = ParseMounts(
+		strings.NewReader(testMounts),
+		func(source, target, fsType string) bool {
+			return strings.HasPrefix(fsType, "nfs")
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"server:/exports/data":    "/mnt/data",
+		"server:/exports/my data": "/mnt/my data",
+	}, mounts)
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/types"
+)
+
+// VolumeProfiles returns the volume profiles in a service's scoped config.
+// The profiles are merged by name from libstorage.server.profiles and the
+// service's scope: a profile that the service defines overrides, one by one,
+// the properties and driver options of the global profile with the same
+// name, and adds to the global profiles if there is none. The names of the
+// profiles are lower-case.
+func VolumeProfiles(config gofig.Config) (types.VolumeProfileMap, error) {
+
+	profiles := types.VolumeProfileMap{}
+	for _, src := range profileSources(config) {
+		cfg, ok := src.config.Get(src.key).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name := range cfg {
+			name = strings.ToLower(name)
+			p, ok := profiles[name]
+			if !ok {
+				p = &types.VolumeProfile{Name: name}
+				profiles[name] = p
+			}
+			readVolumeProfile(
+				src.config, fmt.Sprintf("%s.%s", src.key, name), p)
+		}
+	}
+
+	for name, p := range profiles {
+		if p.IOPS < 0 || p.Size < 0 {
+			return nil, goof.WithFields(goof.Fields{
+				"profile": name,
+				"iops":    p.IOPS,
+				"size":    p.Size,
+			}, "invalid volume profile")
+		}
+	}
+
+	return profiles, nil
+}
+
+type profileSource struct {
+	config gofig.Config
+	key    string
+}
+
+// profileSources returns the configs and keys of the profiles that are set
+// in each of a scoped config's scopes, from the outermost scope to the
+// innermost. The profiles of a scope are read from the scope's parent, which
+// is how a scoped config reads its own keys.
+func profileSources(config gofig.Config) []*profileSource {
+	key := strings.TrimPrefix(
+		types.ConfigServerProfiles, types.ConfigServer+".")
+
+	srcs := []*profileSource{}
+	for c := config; c != nil; c = c.Parent() {
+		src := &profileSource{config: c, key: key}
+		if p := c.Parent(); p != nil {
+			src = &profileSource{config: p, key: c.GetScope() + "." + key}
+		}
+		if src.config.IsSet(src.key) {
+			srcs = append([]*profileSource{src}, srcs...)
+		}
+	}
+	return srcs
+}
+
+// readVolumeProfile sets the properties of a profile that are set in a
+// config, and adds the profile's driver options to those of the profile.
+func readVolumeProfile(
+	config gofig.Config, scope string, p *types.VolumeProfile) {
+	if k := scope + ".availabilityZone"; config.IsSet(k) {
+		p.AvailabilityZone = config.GetString(k)
+	}
+	if k := scope + ".iops"; config.IsSet(k) {
+		p.IOPS = int64(config.GetInt(k))
+	}
+	if k := scope + ".size"; config.IsSet(k) {
+		p.Size = int64(config.GetInt(k))
+	}
+	if k := scope + ".type"; config.IsSet(k) {
+		p.Type = config.GetString(k)
+	}
+	opts, ok := config.Get(scope + ".opts").(map[string]interface{})
+	if !ok {
+		return
+	}
+	if p.Opts == nil {
+		p.Opts = map[string]interface{}{}
+	}
+	for k, v := range opts {
+		p.Opts[k] = v
+	}
+}
+
+// ApplyVolumeProfile expands the profile that a request to create a volume
+// names. The profile's options are set in the request's store unless the
+// request sets them, and the profile's driver options are merged with those
+// of the request. Empty and zero values do not set an option. An
+// ErrBadProfile error is returned if the service does not have the profile.
+func ApplyVolumeProfile(
+	service types.StorageService, store types.Store) error {
+
+	name := store.GetStringPtr("profile")
+	if name == nil || *name == "" {
+		return nil
+	}
+
+	profiles, err := VolumeProfiles(service.Config())
+	if err != nil {
+		return err
+	}
+	p, ok := profiles[strings.ToLower(*name)]
+	if !ok {
+		return NewBadProfileErr(service.Name(), *name)
+	}
+
+	if p.AvailabilityZone != "" && !isSetString(store, "availabilityZone") {
+		store.Set("availabilityZone", p.AvailabilityZone)
+	}
+	if p.IOPS > 0 && !isSetInt64(store, "iops") {
+		store.Set("iops", p.IOPS)
+	}
+	if p.Size > 0 && !isSetInt64(store, "size") {
+		store.Set("size", p.Size)
+	}
+	if p.Type != "" && !isSetString(store, "type") {
+		store.Set("type", p.Type)
+	}
+
+	if len(p.Opts) == 0 {
+		return nil
+	}
+	opts := store.GetStore("opts")
+	if opts == nil {
+		opts = NewStore()
+		store.Set("opts", opts)
+	}
+	for k, v := range p.Opts {
+		if !opts.IsSet(k) {
+			opts.Set(k, v)
+		}
+	}
+	return nil
+}
+
+func isSetString(store types.Store, key string) bool {
+	v := store.GetStringPtr(key)
+	return v != nil && *v != ""
+}
+
+func isSetInt64(store types.Store, key string) bool {
+	v := store.GetInt64Ptr(key)
+	return v != nil && *v != 0
+}
@@ -1,53 +1,233 @@
 #include <stdio.h>
 #include <stdlib.h>
+#include <string.h>
 #include <inttypes.h>
 #include "libstor-c.h"
 
+#define TEST_TIMEOUT    60000
+#define TEST_VOL_NAME   "libstor-c-test"
+#define TEST_VOL_COPY   "libstor-c-test-copy"
+#define TEST_SNAP_NAME  "libstor-c-test-snap"
+
+static int failures = 0;
+
+// check records the outcome of a test step. if the result has an error the
+// error is printed and released and the step is marked as failed.
+static void* check(const char* step, result r) {
+    if (r.err) {
+        printf("FAIL %s: %s\n", step, r.err);
+        free_string(r.err);
+        failures++;
+        return NULL;
+    }
+    printf("ok   %s\n", step);
+    return r.val;
+}
+
+static void expect(const char* step, int cond) {
+    if (cond) {
+        printf("ok   %s\n", step);
+        return;
+    }
+    printf("FAIL %s\n", step);
+    failures++;
+}
+
+static void print_volume(volume* vol) {
+    printf("       id:     %s\n", vol->id);
+    printf("       name:   %s\n", vol->name);
+    printf("       iops:   %" PRId64 "\n", vol->iops);
+    printf("       size:   %" PRId64 "\n", vol->size);
+    printf("       type:   %s\n", vol->volume_type);
+    printf("       zone:   %s\n", vol->availability_zone);
+    printf("       status: %s\n", vol->status);
+    printf("       netnam: %s\n", vol->network_name);
+    for (int x = 0; x < vol->attachments_c; x++) {
+        volume_attachment* a = vol->attachments[x];
+        printf("       attachment: %s %s\n",
+            a->instance_id ? a->instance_id->id : "", a->device_name);
+    }
+}
+
+static int volume_map_contains(volume_map* vm, const char* volume_id) {
+    for (int x = 0; x < vm->volumes_c; x++) {
+        if (strcmp(vm->volume_ids[x], volume_id) == 0) {
+            return 1;
+        }
+    }
+    return 0;
+}
+
+static void test_volumes(h client_id, char* service) {
+
+    volume_create_opts* opts = new_volume_create_opts();
+    opts->size = 1;
+
+    volume* vol = check("volume_create",
+        volume_create(client_id, service, TEST_VOL_NAME, opts));
+    free_volume_create_opts(opts);
+    if (!vol) {
+        return;
+    }
+    expect("volume_create name", strcmp(vol->name, TEST_VOL_NAME) == 0);
+    expect("volume_create size", vol->size == 1);
+    print_volume(vol);
+
+    volume* ivol = check("volume_inspect",
+        volume_inspect(client_id, service, vol->id, 0));
+    if (ivol) {
+        expect("volume_inspect id", strcmp(ivol->id, vol->id) == 0);
+        free_volume(ivol);
+    }
+
+    service_volume_map* svm = check("volumes", volumes(client_id, 0));
+    if (svm) {
+        int found = 0;
+        for (int x = 0; x < svm->services_c; x++) {
+            if (strcmp(svm->service_names[x], service) == 0) {
+                found = volume_map_contains(svm->volumes[x], vol->id);
+            }
+        }
+        expect("volumes contains volume", found);
+        free_service_volume_map(svm);
+    }
+
+    volume_map* vm = check("volumes_by_service",
+        volumes_by_service(client_id, service, 0));
+    if (vm) {
+        expect("volumes_by_service contains volume",
+            volume_map_contains(vm, vol->id));
+        free_volume_map(vm);
+    }
+
+    attach_result* ar = check("volume_attach",
+        volume_attach(client_id, service, vol->id, NULL, 0));
+    if (ar) {
+        expect("volume_attach attachments", ar->volume->attachments_c > 0);
+        free_attach_result(ar);
+
+        volume* dvol = check("volume_detach",
+            volume_detach(client_id, service, vol->id, 0));
+        if (dvol) {
+            expect("volume_detach attachments", dvol->attachments_c == 0);
+            free_volume(dvol);
+        }
+    }
+
+    volume* cvol = check("volume_copy",
+        volume_copy(client_id, service, vol->id, TEST_VOL_COPY));
+    if (cvol) {
+        expect("volume_copy name", strcmp(cvol->name, TEST_VOL_COPY) == 0);
+        check("volume_remove copy",
+            volume_remove(client_id, service, cvol->id));
+        free_volume(cvol);
+    }
+
+    snapshot* snap = check("volume_snapshot",
+        volume_snapshot(client_id, service, vol->id, TEST_SNAP_NAME));
+    if (snap) {
+        expect("volume_snapshot volume_id",
+            strcmp(snap->volume_id, vol->id) == 0);
+
+        snapshot* isnap = check("snapshot_inspect",
+            snapshot_inspect(client_id, service, snap->id));
+        if (isnap) {
+            expect("snapshot_inspect name",
+                strcmp(isnap->name, TEST_SNAP_NAME) == 0);
+            free_snapshot(isnap);
+        }
+
+        snapshot_map* sm = check("snapshots_by_service",
+            snapshots_by_service(client_id, service));
+        if (sm) {
+            expect("snapshots_by_service count", sm->snapshots_c > 0);
+            free_snapshot_map(sm);
+        }
+
+        service_snapshot_map* ssm = check("snapshots", snapshots(client_id));
+        if (ssm) {
+            expect("snapshots count", ssm->services_c > 0);
+            free_service_snapshot_map(ssm);
+        }
+
+        volume* svol = check("volume_create_from_snapshot",
+            volume_create_from_snapshot(
+                client_id, service, snap->id, TEST_VOL_COPY, NULL));
+        if (svol) {
+            check("volume_remove from snapshot",
+                volume_remove(client_id, service, svol->id));
+            free_volume(svol);
+        }
+
+        check("snapshot_remove",
+            snapshot_remove(client_id, service, snap->id));
+        free_snapshot(snap);
+    }
+
+    check("volume_remove", volume_remove(client_id, service, vol->id));
+
+    result r = volume_inspect(client_id, service, vol->id, 0);
+    expect("volume_inspect after remove", r.err != NULL);
+    free_string(r.err);
+    free_volume(r.val);
+
+    free_volume(vol);
+}
+
 int main(int argc, char** argv) {
 
     if (argc < 2) {
-        printf("usage: libstor-c CONFIG\n");
+        printf("usage: libstor-c CONFIG [SERVICE]\n");
         return 1;
     }
 
     result c = new_client(argv[1]);
     if (c.err) {
         printf("libstor-c: error: %s\n", c.err);
+        free_string(c.err);
         return 1;
     }
-    h client_id = *((h*)c.val);
+    h* client_id = (h*)c.val;
+
+    check("set_timeout", set_timeout(*client_id, TEST_TIMEOUT));
 
-    result v = volumes(client_id, 0);
-    if (v.err) {
-        printf("libstor-c: error: %s\n", v.err);
-        close(client_id);
+    service_info_list* sil = check("services", services(*client_id));
+    if (!sil || sil->services_c == 0) {
+        printf("libstor-c: error: no services\n");
+        free_service_info_list(sil);
+        close(*client_id);
+        free_client_id(client_id);
         return 1;
     }
-    service_volume_map svm = *((service_volume_map*)v.val);
-
-    for (int x = 0; x < svm.services_c; x++) {
 
-        printf("service: %s\n", svm.service_names[x]);
+    char* service = argc > 2 ? argv[2] : sil->services[0]->name;
+    for (int x = 0; x < sil->services_c; x++) {
+        service_info* si = sil->services[x];
+        printf("       service: %s (%s)\n", si->name,
+            si->driver ? si->driver->name : "");
+    }
 
-        volume_map vm = *svm.volumes[x];
+    service_info* si = check("service_inspect",
+        service_inspect(*client_id, service));
+    if (si) {
+        expect("service_inspect name", strcmp(si->name, service) == 0);
+        free_service_info(si);
+    }
 
-        for (int y = 0; y < vm.volumes_c; y++) {
-            volume vol = *vm.volumes[y];
-            printf("\n");
-            printf("  - volume: %s\n", vm.volume_ids[y]);
-            printf("            name:   %s\n", vol.name);
-            printf("            iops:   %" PRId64 "\n", vol.iops);
-            printf("            size:   %" PRId64 "\n", vol.size);
-            printf("            type:   %s\n", vol.volume_type);
-            printf("            zone:   %s\n", vol.availability_zone);
-            printf("            status: %s\n", vol.status);
-            printf("            netnam: %s\n", vol.network_name);
-        }
+    test_volumes(*client_id, service);
 
-        printf("\n");
-    }
+    result r = volumes(*client_id + 1, 0);
+    expect("invalid client id", r.err != NULL);
+    free_string(r.err);
+    free_service_volume_map(r.val);
 
-    close(client_id);
-	return 0;
+    free_service_info_list(sil);
+    close(*client_id);
+    free_client_id(client_id);
 
+    if (failures > 0) {
+        printf("libstor-c: %d failure(s)\n", failures);
+        return 1;
+    }
+    return 0;
 }
@@ -7,10 +7,120 @@ h* new_client_id(unsigned long long val) {
     return client_id;
 }
 
+void free_client_id(h* p) {
+    free(p);
+}
+
+void free_string(char* p) {
+    free(p);
+}
+
 volume_map* new_volume_map() {
-    return (volume_map*)malloc(sizeof(volume_map));
+    return (volume_map*)calloc(1, sizeof(volume_map));
+}
+
+void free_volume_map(volume_map* p) {
+    if (!p) return;
+    for (int x = 0; x < p->volumes_c; x++) {
+        free(p->volume_ids[x]);
+        free_volume(p->volumes[x]);
+    }
+    free(p->volume_ids);
+    free(p->volumes);
+    free(p);
 }
 
 service_volume_map* new_service_volume_map() {
-    return (service_volume_map*)malloc(sizeof(service_volume_map));
+    return (service_volume_map*)calloc(1, sizeof(service_volume_map));
+}
+
+void free_service_volume_map(service_volume_map* p) {
+    if (!p) return;
+    for (int x = 0; x < p->services_c; x++) {
+        free(p->service_names[x]);
+        free_volume_map(p->volumes[x]);
+    }
+    free(p->service_names);
+    free(p->volumes);
+    free(p);
+}
+
+snapshot_map* new_snapshot_map() {
+    return (snapshot_map*)calloc(1, sizeof(snapshot_map));
+}
+
+void free_snapshot_map(snapshot_map* p) {
+    if (!p) return;
+    for (int x = 0; x < p->snapshots_c; x++) {
+        free(p->snapshot_ids[x]);
+        free_snapshot(p->snapshots[x]);
+    }
+    free(p->snapshot_ids);
+    free(p->snapshots);
+    free(p);
+}
+
+service_snapshot_map* new_service_snapshot_map() {
+    return (service_snapshot_map*)calloc(1, sizeof(service_snapshot_map));
+}
+
+void free_service_snapshot_map(service_snapshot_map* p) {
+    if (!p) return;
+    for (int x = 0; x < p->services_c; x++) {
+        free(p->service_names[x]);
+        free_snapshot_map(p->snapshots[x]);
+    }
+    free(p->service_names);
+    free(p->snapshots);
+    free(p);
+}
+
+service_info_list* new_service_info_list() {
+    return (service_info_list*)calloc(1, sizeof(service_info_list));
+}
+
+void free_service_info_list(service_info_list* p) {
+    if (!p) return;
+    for (int x = 0; x < p->services_c; x++) {
+        free_service_info(p->services[x]);
+    }
+    free(p->services);
+    free(p);
+}
+
+service_instance_map* new_service_instance_map() {
+    return (service_instance_map*)calloc(1, sizeof(service_instance_map));
+}
+
+void free_service_instance_map(service_instance_map* p) {
+    if (!p) return;
+    for (int x = 0; x < p->instances_c; x++) {
+        free(p->service_names[x]);
+        free_instance(p->instances[x]);
+    }
+    free(p->service_names);
+    free(p->instances);
+    free(p);
+}
+
+attach_result* new_attach_result() {
+    return (attach_result*)calloc(1, sizeof(attach_result));
+}
+
+void free_attach_result(attach_result* p) {
+    if (!p) return;
+    free_volume(p->volume);
+    free(p->attach_token);
+    free(p);
+}
+
+mount_result* new_mount_result() {
+    return (mount_result*)calloc(1, sizeof(mount_result));
+}
+
+void free_mount_result(mount_result* p) {
+    if (!p) return;
+    free(p->mount_point);
+    free_volume(p->volume);
+    free(p);
 }
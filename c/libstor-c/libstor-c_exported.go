@@ -6,121 +6,543 @@ import "C"
 
 import (
 	"sync"
+	"time"
 	"unsafe"
 
 	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
 )
 
 var (
 	clients    = map[C.h]types.Client{}
+	timeouts   = map[C.h]time.Duration{}
 	clientsRWL = sync.RWMutex{}
 )
 
+// new_client creates a new libStorage client from the config file at the
+// provided path. The result's val is a h* that must be released with
+// free_client_id.
+//
 //export new_client
 func new_client(configPath *C.char) C.result {
 	clientsRWL.Lock()
 	defer clientsRWL.Unlock()
 
-	result := C.result{}
-
 	client_id, err := newClientID()
 	if err != nil {
-		result.err = C.CString(err.Error())
-		return result
+		return newErrResult(err)
 	}
 
 	c, err := newWithConfig(C.GoString(configPath))
 	if err != nil {
-		result.err = C.CString(err.Error())
-		return result
+		C.free_client_id(client_id)
+		return newErrResult(err)
 	}
 
 	clients[*client_id] = c
-	result.val = unsafe.Pointer(client_id)
-	return result
+	return newValResult(unsafe.Pointer(client_id))
 }
 
+// close releases the client with the provided ID.
+//
 //export close
 func close(clientID C.h) {
 	clientsRWL.Lock()
 	defer clientsRWL.Unlock()
 	delete(clients, clientID)
+	delete(timeouts, clientID)
 }
 
+// set_timeout sets the number of milliseconds after which the client's calls
+// are canceled. A value less than or equal to zero disables the timeout.
+//
+//export set_timeout
+func set_timeout(clientID C.h, millis C.longlong) C.result {
+	if _, err := getClient(clientID); err != nil {
+		return newErrResult(err)
+	}
+	clientsRWL.Lock()
+	defer clientsRWL.Unlock()
+	if millis <= 0 {
+		delete(timeouts, clientID)
+	} else {
+		timeouts[clientID] = time.Duration(millis) * time.Millisecond
+	}
+	return C.result{}
+}
+
+// instances returns a service_instance_map*.
+//
+//export instances
+func instances(clientID C.h) C.result {
+	c, ctx, cancel, err := newContext(clientID, nil)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	instances, err := c.API().Instances(ctx)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceInstanceMap(instances)))
+}
+
+// instance_inspect returns an instance*.
+//
+//export instance_inspect
+func instance_inspect(clientID C.h, service *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	instance, err := c.API().InstanceInspect(ctx, C.GoString(service))
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCInstance(instance)))
+}
+
+// services returns a service_info_list*.
+//
+//export services
+func services(clientID C.h) C.result {
+	c, ctx, cancel, err := newContext(clientID, nil)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	services, err := c.API().Services(ctx)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceInfoList(services)))
+}
+
+// service_inspect returns a service_info*.
+//
+//export service_inspect
+func service_inspect(clientID C.h, service *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	si, err := c.API().ServiceInspect(ctx, C.GoString(service))
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceInfo(si)))
+}
+
+// volumes returns a service_volume_map*.
+//
 //export volumes
 func volumes(clientID C.h, attachments C.short) C.result {
-	result := C.result{}
+	c, ctx, cancel, err := newContext(clientID, nil)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	svm, err := c.API().Volumes(ctx, attachments > 0)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceVolumeMap(svm)))
+}
+
+// volumes_by_service returns a volume_map*.
+//
+//export volumes_by_service
+func volumes_by_service(
+	clientID C.h, service *C.char, attachments C.short) C.result {
 
-	c, err := getClient(clientID)
+	c, ctx, cancel, err := newContext(clientID, service)
 	if err != nil {
-		result.err = C.CString(err.Error())
-		return result
+		return newErrResult(err)
 	}
+	defer cancel()
 
-	svcToVolMap, err := c.API().Volumes(nil, attachments > 0)
+	vm, err := c.API().VolumesByService(
+		ctx, C.GoString(service), attachments > 0)
 	if err != nil {
-		result.err = C.CString(err.Error())
-		return result
+		return newErrResult(err)
 	}
+	return newValResult(unsafe.Pointer(toCVolumeMap(vm)))
+}
 
-	service_names := []*C.char{}
-	volume_maps := []*C.volume_map{}
+// volume_inspect returns a volume*.
+//
+//export volume_inspect
+func volume_inspect(
+	clientID C.h, service, volumeID *C.char, attachments C.short) C.result {
 
-	for service, volMap := range svcToVolMap {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
 
-		service_names = append(service_names, C.CString(service))
+	v, err := c.API().VolumeInspect(
+		ctx, C.GoString(service), C.GoString(volumeID), attachments > 0)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCVolume(v)))
+}
 
-		volumes := []*C.volume{}
-		volume_ids := []*C.char{}
+// volume_create returns a volume*. The opts argument may be NULL.
+//
+//export volume_create
+func volume_create(
+	clientID C.h,
+	service, volumeName *C.char,
+	opts *C.volume_create_opts) C.result {
 
-		for volumeID, volume := range volMap {
-			volume_ids = append(volume_ids, C.CString(volumeID))
-			cVol, err := toCVolume(volume)
-			if err != nil {
-				result.err = C.CString(err.Error())
-				return result
-			}
-			volumes = append(volumes, cVol)
-		}
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
 
-		lcVolMap := len(volMap)
-		cVolMap := C.new_volume_map()
-		cVolMap.volumes_c = C.int(lcVolMap)
-		if lcVolMap > 0 {
-			cVolMap.volume_ids = &volume_ids[0]
-			cVolMap.volumes = &volumes[0]
-		}
-		volume_maps = append(volume_maps, cVolMap)
+	v, err := c.API().VolumeCreate(
+		ctx,
+		C.GoString(service),
+		toVolumeCreateRequest(C.GoString(volumeName), opts))
+	if err != nil {
+		return newErrResult(err)
 	}
+	return newValResult(unsafe.Pointer(toCVolume(v)))
+}
+
+// volume_create_from_snapshot returns a volume*. The opts argument may be
+// NULL.
+//
+//export volume_create_from_snapshot
+func volume_create_from_snapshot(
+	clientID C.h,
+	service, snapshotID, volumeName *C.char,
+	opts *C.volume_create_opts) C.result {
 
-	lcSvcVolMap := len(svcToVolMap)
-	cSvcVolMap := C.new_service_volume_map()
-	cSvcVolMap.services_c = C.int(lcSvcVolMap)
-	if lcSvcVolMap > 0 {
-		cSvcVolMap.service_names = &service_names[0]
-		cSvcVolMap.volumes = &volume_maps[0]
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
 	}
+	defer cancel()
 
-	result.val = unsafe.Pointer(cSvcVolMap)
-	return result
+	v, err := c.API().VolumeCreateFromSnapshot(
+		ctx,
+		C.GoString(service),
+		C.GoString(snapshotID),
+		toVolumeCreateRequest(C.GoString(volumeName), opts))
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCVolume(v)))
 }
 
+// volume_copy returns a volume*.
+//
+//export volume_copy
+func volume_copy(
+	clientID C.h, service, volumeID, volumeName *C.char) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	v, err := c.API().VolumeCopy(
+		ctx,
+		C.GoString(service),
+		C.GoString(volumeID),
+		&types.VolumeCopyRequest{VolumeName: C.GoString(volumeName)})
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCVolume(v)))
+}
+
+// volume_remove removes a volume. The result has no val.
+//
+//export volume_remove
+func volume_remove(clientID C.h, service, volumeID *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	if err := c.API().VolumeRemove(
+		ctx, C.GoString(service), C.GoString(volumeID)); err != nil {
+		return newErrResult(err)
+	}
+	return C.result{}
+}
+
+// volume_attach returns an attach_result*. The nextDevice argument may be
+// NULL.
+//
+//export volume_attach
+func volume_attach(
+	clientID C.h,
+	service, volumeID, nextDevice *C.char,
+	force C.short) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	req := &types.VolumeAttachRequest{Force: force > 0}
+	if nextDevice != nil {
+		nd := C.GoString(nextDevice)
+		req.NextDeviceName = &nd
+	}
+
+	v, token, err := c.API().VolumeAttach(
+		ctx, C.GoString(service), C.GoString(volumeID), req)
+	if err != nil {
+		return newErrResult(err)
+	}
+
+	ar := C.new_attach_result()
+	ar.volume = toCVolume(v)
+	ar.attach_token = C.CString(token)
+	return newValResult(unsafe.Pointer(ar))
+}
+
+// volume_detach returns a volume*. The val is NULL if the driver does not
+// return the detached volume.
+//
+//export volume_detach
+func volume_detach(
+	clientID C.h, service, volumeID *C.char, force C.short) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	v, err := c.API().VolumeDetach(
+		ctx,
+		C.GoString(service),
+		C.GoString(volumeID),
+		&types.VolumeDetachRequest{Force: force > 0})
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCVolume(v)))
+}
+
+// volume_detach_all returns a service_volume_map* of the detached volumes.
+//
+//export volume_detach_all
+func volume_detach_all(clientID C.h, force C.short) C.result {
+	c, ctx, cancel, err := newContext(clientID, nil)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	svm, err := c.API().VolumeDetachAll(
+		ctx, &types.VolumeDetachRequest{Force: force > 0})
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceVolumeMap(svm)))
+}
+
+// volume_snapshot returns a snapshot*.
+//
+//export volume_snapshot
+func volume_snapshot(
+	clientID C.h, service, volumeID, snapshotName *C.char) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	s, err := c.API().VolumeSnapshot(
+		ctx,
+		C.GoString(service),
+		C.GoString(volumeID),
+		&types.VolumeSnapshotRequest{SnapshotName: C.GoString(snapshotName)})
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCSnapshot(s)))
+}
+
+// snapshots returns a service_snapshot_map*.
+//
+//export snapshots
+func snapshots(clientID C.h) C.result {
+	c, ctx, cancel, err := newContext(clientID, nil)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	ssm, err := c.API().Snapshots(ctx)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCServiceSnapshotMap(ssm)))
+}
+
+// snapshots_by_service returns a snapshot_map*.
+//
+//export snapshots_by_service
+func snapshots_by_service(clientID C.h, service *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	sm, err := c.API().SnapshotsByService(ctx, C.GoString(service))
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCSnapshotMap(sm)))
+}
+
+// snapshot_inspect returns a snapshot*.
+//
+//export snapshot_inspect
+func snapshot_inspect(clientID C.h, service, snapshotID *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	s, err := c.API().SnapshotInspect(
+		ctx, C.GoString(service), C.GoString(snapshotID))
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCSnapshot(s)))
+}
+
+// snapshot_copy returns a snapshot*. The destinationID argument may be NULL.
+//
+//export snapshot_copy
+func snapshot_copy(
+	clientID C.h,
+	service, snapshotID, snapshotName, destinationID *C.char) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	req := &types.SnapshotCopyRequest{SnapshotName: C.GoString(snapshotName)}
+	if destinationID != nil {
+		req.DestinationID = C.GoString(destinationID)
+	}
+
+	s, err := c.API().SnapshotCopy(
+		ctx, C.GoString(service), C.GoString(snapshotID), req)
+	if err != nil {
+		return newErrResult(err)
+	}
+	return newValResult(unsafe.Pointer(toCSnapshot(s)))
+}
+
+// snapshot_remove removes a snapshot. The result has no val.
+//
+//export snapshot_remove
+func snapshot_remove(clientID C.h, service, snapshotID *C.char) C.result {
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	if err := c.API().SnapshotRemove(
+		ctx, C.GoString(service), C.GoString(snapshotID)); err != nil {
+		return newErrResult(err)
+	}
+	return C.result{}
+}
+
+// mount attaches and mounts a volume by its ID and returns a mount_result*.
+//
 //export mount
 func mount(clientID C.h, service, volumeID *C.char) C.result {
-	return C.result{}
+	return doMount(clientID, service, C.GoString(volumeID), "")
 }
 
+// mount_with_name attaches and mounts a volume by its name and returns a
+// mount_result*.
+//
 //export mount_with_name
 func mount_with_name(clientID C.h, service, volumeName *C.char) C.result {
-	return C.result{}
+	return doMount(clientID, service, "", C.GoString(volumeName))
 }
 
+// unmount unmounts and detaches a volume by its ID. The result has no val.
+//
 //export unmount
 func unmount(clientID C.h, service, volumeID *C.char) C.result {
-	return C.result{}
+	return doUnmount(clientID, service, C.GoString(volumeID), "")
 }
 
+// unmount_with_name unmounts and detaches a volume by its name. The result
+// has no val.
+//
 //export unmount_with_name
 func unmount_with_name(clientID C.h, service, volumeName *C.char) C.result {
+	return doUnmount(clientID, service, "", C.GoString(volumeName))
+}
+
+func doMount(
+	clientID C.h, service *C.char, volumeID, volumeName string) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	mountPoint, v, err := c.Integration().Mount(
+		ctx, volumeID, volumeName,
+		&types.VolumeMountOpts{Opts: utils.NewStore()})
+	if err != nil {
+		return newErrResult(err)
+	}
+
+	mr := C.new_mount_result()
+	mr.mount_point = C.CString(mountPoint)
+	mr.volume = toCVolume(v)
+	return newValResult(unsafe.Pointer(mr))
+}
+
+func doUnmount(
+	clientID C.h, service *C.char, volumeID, volumeName string) C.result {
+
+	c, ctx, cancel, err := newContext(clientID, service)
+	if err != nil {
+		return newErrResult(err)
+	}
+	defer cancel()
+
+	if err := c.Integration().Unmount(
+		ctx, volumeID, volumeName, utils.NewStore()); err != nil {
+		return newErrResult(err)
+	}
 	return C.result{}
 }
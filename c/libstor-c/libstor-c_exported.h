@@ -4,12 +4,21 @@
 typedef unsigned long long h;
 
 h* new_client_id(unsigned long long seed);
+void free_client_id(h* p);
 
+// the result of an exported function. if err is not NULL then it must be
+// released with free_string and val is NULL. otherwise val, if not NULL,
+// points to the object returned by the function and must be released with
+// the free_* function for that type. a function that returns a volume* or a
+// snapshot* sets val to NULL when the server does not return the object, as
+// volume_detach does for drivers that do not report the detached volume.
 typedef struct {
     void*       val;
     char*       err;
 } result;
 
+void free_string(char* p);
+
 typedef struct {
     int            volumes_c;
     char**         volume_ids;
@@ -17,6 +26,7 @@ typedef struct {
 } volume_map;
 
 volume_map* new_volume_map();
+void free_volume_map(volume_map* p);
 
 typedef struct {
     int                   services_c;
@@ -25,3 +35,57 @@ typedef struct {
 } service_volume_map;
 
 service_volume_map* new_service_volume_map();
+void free_service_volume_map(service_volume_map* p);
+
+typedef struct {
+    int            snapshots_c;
+    char**         snapshot_ids;
+    snapshot**     snapshots;
+} snapshot_map;
+
+snapshot_map* new_snapshot_map();
+void free_snapshot_map(snapshot_map* p);
+
+typedef struct {
+    int                   services_c;
+    char**                service_names;
+    snapshot_map**        snapshots;
+} service_snapshot_map;
+
+service_snapshot_map* new_service_snapshot_map();
+void free_service_snapshot_map(service_snapshot_map* p);
+
+typedef struct {
+    int                   services_c;
+    service_info**        services;
+} service_info_list;
+
+service_info_list* new_service_info_list();
+void free_service_info_list(service_info_list* p);
+
+typedef struct {
+    int                   instances_c;
+    char**                service_names;
+    instance**            instances;
+} service_instance_map;
+
+service_instance_map* new_service_instance_map();
+void free_service_instance_map(service_instance_map* p);
+
+// the result of attaching a volume
+typedef struct {
+    volume*     volume;         // NULL if the server returns no volume
+    char*       attach_token;
+} attach_result;
+
+attach_result* new_attach_result();
+void free_attach_result(attach_result* p);
+
+// the result of mounting a volume
+typedef struct {
+    char*       mount_point;
+    volume*     volume;         // NULL if the server returns no volume
+} mount_result;
+
+mount_result* new_mount_result();
+void free_mount_result(mount_result* p);
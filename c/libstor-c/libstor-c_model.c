@@ -2,17 +2,105 @@
 #include "libstor-c_types.h"
 
 volume* new_volume() {
-    return (volume*)malloc(sizeof(volume));
+    return (volume*)calloc(1, sizeof(volume));
 }
 
 volume_attachment* new_volume_attachment() {
-    return (volume_attachment*)malloc(sizeof(volume_attachment));
+    return (volume_attachment*)calloc(1, sizeof(volume_attachment));
 }
 
 instance* new_instance() {
-    return (instance*)malloc(sizeof(instance));
+    return (instance*)calloc(1, sizeof(instance));
 }
 
 instance_id* new_instance_id() {
-    return (instance_id*)malloc(sizeof(instance_id));
+    return (instance_id*)calloc(1, sizeof(instance_id));
+}
+
+snapshot* new_snapshot() {
+    return (snapshot*)calloc(1, sizeof(snapshot));
+}
+
+driver_info* new_driver_info() {
+    return (driver_info*)calloc(1, sizeof(driver_info));
+}
+
+service_info* new_service_info() {
+    return (service_info*)calloc(1, sizeof(service_info));
+}
+
+volume_create_opts* new_volume_create_opts() {
+    return (volume_create_opts*)calloc(1, sizeof(volume_create_opts));
+}
+
+void free_instance_id(instance_id* p) {
+    if (!p) return;
+    free(p->id);
+    free(p);
+}
+
+void free_instance(instance* p) {
+    if (!p) return;
+    free_instance_id(p->instance_id);
+    free(p->name);
+    free(p->provider_name);
+    free(p->region);
+    free(p);
+}
+
+void free_volume_attachment(volume_attachment* p) {
+    if (!p) return;
+    free(p->volume_id);
+    free_instance_id(p->instance_id);
+    free(p->device_name);
+    free(p->mount_point);
+    free(p->status);
+    free(p);
+}
+
+void free_volume(volume* p) {
+    if (!p) return;
+    free(p->id);
+    free(p->name);
+    free(p->status);
+    free(p->volume_type);
+    free(p->availability_zone);
+    free(p->network_name);
+    for (int x = 0; x < p->attachments_c; x++) {
+        free_volume_attachment(p->attachments[x]);
+    }
+    free(p->attachments);
+    free(p);
+}
+
+void free_snapshot(snapshot* p) {
+    if (!p) return;
+    free(p->id);
+    free(p->name);
+    free(p->description);
+    free(p->status);
+    free(p->volume_id);
+    free(p);
+}
+
+void free_driver_info(driver_info* p) {
+    if (!p) return;
+    free(p->name);
+    free(p->storage_type);
+    free(p);
+}
+
+void free_service_info(service_info* p) {
+    if (!p) return;
+    free(p->name);
+    free_instance(p->instance);
+    free_driver_info(p->driver);
+    free(p);
+}
+
+void free_volume_create_opts(volume_create_opts* p) {
+    if (!p) return;
+    free(p->availability_zone);
+    free(p->volume_type);
+    free(p);
 }
@@ -54,3 +54,53 @@ typedef struct {
 } volume;
 
 volume* new_volume();
+
+// a volume snapshot
+typedef struct {
+    char*      id;
+    char*      name;
+    char*      description;
+    int64_t    start_time;          // the snapshot's creation time (epoch)
+    char*      status;
+    char*      volume_id;           // the ID of the snapshot's source volume
+    int64_t    volume_size;         // the size of the source volume
+} snapshot;
+
+snapshot* new_snapshot();
+
+// provides information about a storage driver
+typedef struct {
+    char*       name;
+    char*       storage_type;       // block, nas, or object
+} driver_info;
+
+driver_info* new_driver_info();
+
+// provides information about a service
+typedef struct {
+    char*            name;
+    instance*        instance;      // may be NULL
+    driver_info*     driver;
+} service_info;
+
+service_info* new_service_info();
+
+// optional fields used when creating a volume. a NULL string or a zero
+// numeric value indicates the field is not set.
+typedef struct {
+    char*      availability_zone;
+    char*      volume_type;
+    int64_t    iops;
+    int64_t    size;
+} volume_create_opts;
+
+volume_create_opts* new_volume_create_opts();
+
+void free_instance_id(instance_id* p);
+void free_instance(instance* p);
+void free_volume_attachment(volume_attachment* p);
+void free_volume(volume* p);
+void free_snapshot(snapshot* p);
+void free_driver_info(driver_info* p);
+void free_service_info(service_info* p);
+void free_volume_create_opts(volume_create_opts* p);
@@ -1,6 +1,7 @@
 package main
 
 //#cgo CFLAGS: -I${SRCDIR}
+//#include <stdlib.h>
 //#include "libstor-c_types.h"
 import "C"
 
@@ -8,82 +9,281 @@ import (
 	"encoding/binary"
 	"math/rand"
 	"time"
+	"unsafe"
 
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+	gocontext "golang.org/x/net/context"
 
+	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/client"
 )
 
-func toCVolume(v *types.Volume) (*C.volume, error) {
+// maxArrayLen is the length of the array type used to index C arrays.
+const maxArrayLen = 1 << 28
 
-	lcva, cva, err := toCVolumeAttachments(v)
-	if err != nil {
-		return nil, err
+// newPtrArray allocates a zeroed C array of n pointers. The array is released
+// by the free_* function of the structure to which it is assigned.
+func newPtrArray(n int) unsafe.Pointer {
+	return C.calloc(C.size_t(n), C.size_t(unsafe.Sizeof(uintptr(0))))
+}
+
+func toCVolumeMap(vm types.VolumeMap) *C.volume_map {
+	cvm := C.new_volume_map()
+	n := len(vm)
+	if n == 0 {
+		return cvm
+	}
+	cvm.volume_ids = (**C.char)(newPtrArray(n))
+	cvm.volumes = (**C.volume)(newPtrArray(n))
+	cvm.volumes_c = C.int(n)
+
+	ids := (*[maxArrayLen]*C.char)(unsafe.Pointer(cvm.volume_ids))[:n:n]
+	vols := (*[maxArrayLen]*C.volume)(unsafe.Pointer(cvm.volumes))[:n:n]
+
+	x := 0
+	for volumeID, v := range vm {
+		ids[x] = C.CString(volumeID)
+		vols[x] = toCVolume(v)
+		x++
+	}
+	return cvm
+}
+
+func toCServiceVolumeMap(svm types.ServiceVolumeMap) *C.service_volume_map {
+	csvm := C.new_service_volume_map()
+	n := len(svm)
+	if n == 0 {
+		return csvm
+	}
+	csvm.service_names = (**C.char)(newPtrArray(n))
+	csvm.volumes = (**C.volume_map)(newPtrArray(n))
+	csvm.services_c = C.int(n)
+
+	names := (*[maxArrayLen]*C.char)(unsafe.Pointer(csvm.service_names))[:n:n]
+	vms := (*[maxArrayLen]*C.volume_map)(unsafe.Pointer(csvm.volumes))[:n:n]
+
+	x := 0
+	for service, vm := range svm {
+		names[x] = C.CString(service)
+		vms[x] = toCVolumeMap(vm)
+		x++
+	}
+	return csvm
+}
+
+func toCSnapshotMap(sm types.SnapshotMap) *C.snapshot_map {
+	csm := C.new_snapshot_map()
+	n := len(sm)
+	if n == 0 {
+		return csm
+	}
+	csm.snapshot_ids = (**C.char)(newPtrArray(n))
+	csm.snapshots = (**C.snapshot)(newPtrArray(n))
+	csm.snapshots_c = C.int(n)
+
+	ids := (*[maxArrayLen]*C.char)(unsafe.Pointer(csm.snapshot_ids))[:n:n]
+	snaps := (*[maxArrayLen]*C.snapshot)(unsafe.Pointer(csm.snapshots))[:n:n]
+
+	x := 0
+	for snapshotID, s := range sm {
+		ids[x] = C.CString(snapshotID)
+		snaps[x] = toCSnapshot(s)
+		x++
 	}
+	return csm
+}
 
-	cv := C.volume{
-		id:                C.CString(v.ID),
-		name:              C.CString(v.Name),
-		size:              C.int64_t(v.Size),
-		iops:              C.int64_t(v.IOPS),
-		status:            C.CString(v.Status),
-		volume_type:       C.CString(v.Type),
-		availability_zone: C.CString(v.AvailabilityZone),
-		network_name:      C.CString(v.NetworkName),
+func toCServiceSnapshotMap(
+	ssm types.ServiceSnapshotMap) *C.service_snapshot_map {
+
+	cssm := C.new_service_snapshot_map()
+	n := len(ssm)
+	if n == 0 {
+		return cssm
 	}
-	cv.attachments_c = lcva
-	if lcva > 0 {
-		cv.attachments = &cva[0]
+	cssm.service_names = (**C.char)(newPtrArray(n))
+	cssm.snapshots = (**C.snapshot_map)(newPtrArray(n))
+	cssm.services_c = C.int(n)
+
+	names := (*[maxArrayLen]*C.char)(unsafe.Pointer(cssm.service_names))[:n:n]
+	sms := (*[maxArrayLen]*C.snapshot_map)(unsafe.Pointer(cssm.snapshots))[:n:n]
+
+	x := 0
+	for service, sm := range ssm {
+		names[x] = C.CString(service)
+		sms[x] = toCSnapshotMap(sm)
+		x++
 	}
+	return cssm
+}
 
-	return &cv, nil
+func toCServiceInfoList(
+	services map[string]*types.ServiceInfo) *C.service_info_list {
+
+	csil := C.new_service_info_list()
+	n := len(services)
+	if n == 0 {
+		return csil
+	}
+	csil.services = (**C.service_info)(newPtrArray(n))
+	csil.services_c = C.int(n)
+
+	infos := (*[maxArrayLen]*C.service_info)(unsafe.Pointer(csil.services))[:n:n]
+
+	x := 0
+	for _, si := range services {
+		infos[x] = toCServiceInfo(si)
+		x++
+	}
+	return csil
 }
 
-func toCVolumeAttachments(
-	v *types.Volume) (C.int, []*C.volume_attachment, error) {
+func toCServiceInstanceMap(
+	instances map[string]*types.Instance) *C.service_instance_map {
+
+	csim := C.new_service_instance_map()
+	n := len(instances)
+	if n == 0 {
+		return csim
+	}
+	csim.service_names = (**C.char)(newPtrArray(n))
+	csim.instances = (**C.instance)(newPtrArray(n))
+	csim.instances_c = C.int(n)
+
+	names := (*[maxArrayLen]*C.char)(unsafe.Pointer(csim.service_names))[:n:n]
+	insts := (*[maxArrayLen]*C.instance)(unsafe.Pointer(csim.instances))[:n:n]
 
-	la := C.int(len(v.Attachments))
-	if la == 0 {
-		return 0, nil, nil
+	x := 0
+	for service, i := range instances {
+		names[x] = C.CString(service)
+		insts[x] = toCInstance(i)
+		x++
 	}
+	return csim
+}
 
-	ca := make([]*C.volume_attachment, la)
-	for x, a := range v.Attachments {
-		cva, err := toCVolumeAttachment(a)
-		if err != nil {
-			return 0, nil, err
+func toCVolume(v *types.Volume) *C.volume {
+	if v == nil {
+		return nil
+	}
+	cv := C.new_volume()
+	cv.id = C.CString(v.ID)
+	cv.name = C.CString(v.Name)
+	cv.size = C.int64_t(v.Size)
+	cv.iops = C.int64_t(v.IOPS)
+	cv.status = C.CString(v.Status)
+	cv.volume_type = C.CString(v.Type)
+	cv.availability_zone = C.CString(v.AvailabilityZone)
+	cv.network_name = C.CString(v.NetworkName)
+
+	if n := len(v.Attachments); n > 0 {
+		cv.attachments = (**C.volume_attachment)(newPtrArray(n))
+		cv.attachments_c = C.int(n)
+		cva := (*[maxArrayLen]*C.volume_attachment)(
+			unsafe.Pointer(cv.attachments))[:n:n]
+		for x, a := range v.Attachments {
+			cva[x] = toCVolumeAttachment(a)
 		}
-		ca[x] = cva
 	}
 
-	return la, ca, nil
+	return cv
 }
 
-func toCVolumeAttachment(
-	va *types.VolumeAttachment) (*C.volume_attachment, error) {
+func toCVolumeAttachment(va *types.VolumeAttachment) *C.volume_attachment {
+	if va == nil {
+		return nil
+	}
+	cva := C.new_volume_attachment()
+	cva.volume_id = C.CString(va.VolumeID)
+	cva.device_name = C.CString(va.DeviceName)
+	cva.mount_point = C.CString(va.MountPoint)
+	cva.status = C.CString(va.Status)
+	cva.instance_id = toCInstanceID(va.InstanceID)
+	return cva
+}
 
-	ciid, err := toCInstanceID(va.InstanceID)
-	if err != nil {
-		return nil, err
+func toCInstanceID(i *types.InstanceID) *C.instance_id {
+	if i == nil {
+		return nil
 	}
+	ciid := C.new_instance_id()
+	ciid.id = C.CString(i.ID)
+	return ciid
+}
 
-	cva := C.volume_attachment{
-		volume_id:   C.CString(va.VolumeID),
-		device_name: C.CString(va.DeviceName),
-		mount_point: C.CString(va.MountPoint),
-		status:      C.CString(va.Status),
+func toCInstance(i *types.Instance) *C.instance {
+	if i == nil {
+		return nil
 	}
-	cva.instance_id = ciid
+	ci := C.new_instance()
+	ci.instance_id = toCInstanceID(i.InstanceID)
+	ci.name = C.CString(i.Name)
+	ci.provider_name = C.CString(i.ProviderName)
+	ci.region = C.CString(i.Region)
+	return ci
+}
 
-	return &cva, nil
+func toCSnapshot(s *types.Snapshot) *C.snapshot {
+	if s == nil {
+		return nil
+	}
+	cs := C.new_snapshot()
+	cs.id = C.CString(s.ID)
+	cs.name = C.CString(s.Name)
+	cs.description = C.CString(s.Description)
+	cs.start_time = C.int64_t(s.StartTime)
+	cs.status = C.CString(s.Status)
+	cs.volume_id = C.CString(s.VolumeID)
+	cs.volume_size = C.int64_t(s.VolumeSize)
+	return cs
 }
 
-func toCInstanceID(i *types.InstanceID) (*C.instance_id, error) {
-	return &C.instance_id{
-		id: C.CString(i.ID),
-	}, nil
+func toCServiceInfo(si *types.ServiceInfo) *C.service_info {
+	csi := C.new_service_info()
+	csi.name = C.CString(si.Name)
+	csi.instance = toCInstance(si.Instance)
+	if si.Driver != nil {
+		csi.driver = C.new_driver_info()
+		csi.driver.name = C.CString(si.Driver.Name)
+		csi.driver.storage_type = C.CString(string(si.Driver.Type))
+	}
+	return csi
+}
+
+func toVolumeCreateRequest(
+	name string, opts *C.volume_create_opts) *types.VolumeCreateRequest {
+
+	req := &types.VolumeCreateRequest{Name: name}
+	if opts == nil {
+		return req
+	}
+	if opts.availability_zone != nil {
+		az := C.GoString(opts.availability_zone)
+		req.AvailabilityZone = &az
+	}
+	if opts.volume_type != nil {
+		vt := C.GoString(opts.volume_type)
+		req.Type = &vt
+	}
+	if opts.iops > 0 {
+		iops := int64(opts.iops)
+		req.IOPS = &iops
+	}
+	if opts.size > 0 {
+		size := int64(opts.size)
+		req.Size = &size
+	}
+	return req
+}
+
+func newErrResult(err error) C.result {
+	return C.result{err: C.CString(err.Error())}
+}
+
+func newValResult(val unsafe.Pointer) C.result {
+	return C.result{val: val}
 }
 
 func newWithConfig(configPath string) (types.Client, error) {
@@ -104,6 +304,41 @@ func getClient(clientID C.h) (types.Client, error) {
 	return c, nil
 }
 
+// newContext returns the client for the provided client ID as well as a new
+// context for a single call. The context is bound to the client, the
+// optional service, and the client's timeout. The returned cancel function
+// must be invoked once the call is complete.
+func newContext(
+	clientID C.h,
+	service *C.char) (types.Client, types.Context, func(), error) {
+
+	c, err := getClient(clientID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	clientsRWL.RLock()
+	timeout := timeouts[clientID]
+	clientsRWL.RUnlock()
+
+	var (
+		goCtx  = gocontext.Background()
+		cancel = func() {}
+	)
+	if timeout > 0 {
+		var cancelFunc gocontext.CancelFunc
+		goCtx, cancelFunc = gocontext.WithTimeout(goCtx, timeout)
+		cancel = func() { cancelFunc() }
+	}
+
+	ctx := context.New(goCtx).WithValue(context.ClientKey, c)
+	if service != nil {
+		ctx = ctx.WithValue(context.ServiceKey, C.GoString(service))
+	}
+
+	return c, ctx, cancel, nil
+}
+
 var (
 	rng = rand.New(rand.NewSource(time.Now().Unix()))
 )
@@ -0,0 +1,206 @@
+package lsc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/gotil"
+	flag "github.com/spf13/pflag"
+
+	"github.com/emccode/libstorage/api/context"
+	apitypes "github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/client"
+)
+
+var (
+	cliFlags *flag.FlagSet
+
+	flagConfig           *string
+	flagHost             *string
+	flagService          *string
+	flagOutput           *string
+	flagLogLvl           *string
+	flagHelp             *bool
+	flagFilter           *string
+	flagAttachments      *bool
+	flagForce            *bool
+	flagSize             *int64
+	flagIOPS             *int64
+	flagType             *string
+	flagAvailabilityZone *string
+	flagFromSnapshot     *string
+	flagNextDevice       *string
+	flagDestinationID    *string
+)
+
+func init() {
+	cliFlags = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flagConfig = cliFlags.StringP("config", "c", "", "path")
+	flagHost = cliFlags.StringP("host", "h", "", "<proto>://<addr>")
+	flagService = cliFlags.StringP("service", "s", "", "service name")
+	flagOutput = cliFlags.StringP("output", "o", outputTable, "table|json|yaml")
+	flagLogLvl = cliFlags.StringP("log", "l", "error", "error|warn|info|debug")
+	flagHelp = cliFlags.BoolP("help", "?", false, "print usage")
+	flagFilter = cliFlags.String("filter", "", "LDAP-style filter")
+	flagAttachments = cliFlags.BoolP(
+		"attachments", "a", false, "include volume attachments")
+	flagForce = cliFlags.Bool("force", false, "force an attach or detach")
+	flagSize = cliFlags.Int64("size", 0, "volume size")
+	flagIOPS = cliFlags.Int64("iops", 0, "volume IOPS")
+	flagType = cliFlags.String("type", "", "volume type")
+	flagAvailabilityZone = cliFlags.String(
+		"availabilityZone", "", "volume availability zone")
+	flagFromSnapshot = cliFlags.String(
+		"fromSnapshot", "", "create the volume from a snapshot ID")
+	flagNextDevice = cliFlags.String(
+		"nextDevice", "", "next available device name")
+	flagDestinationID = cliFlags.String(
+		"destinationID", "", "snapshot copy destination ID")
+	flag.CommandLine.AddFlagSet(cliFlags)
+}
+
+// Run runs the client CLI.
+func Run() {
+
+	flag.Usage = printUsage
+	flag.Parse()
+
+	if *flagHelp || len(flag.Args()) == 0 {
+		flag.Usage()
+	}
+
+	switch *flagOutput {
+	case outputTable, outputJSON, outputYAML:
+	default:
+		exitWithError(fmt.Errorf("invalid output format: %s", *flagOutput))
+	}
+
+	if *flagHost != "" {
+		os.Setenv("LIBSTORAGE_HOST", *flagHost)
+	}
+	os.Setenv("LIBSTORAGE_LOGGING_LEVEL", *flagLogLvl)
+	if lvl, err := log.ParseLevel(*flagLogLvl); err == nil {
+		log.SetLevel(lvl)
+	}
+
+	config, err := newConfig()
+	if err != nil {
+		exitWithError(err)
+	}
+	disablePathCache(config)
+
+	c, err := client.New(nil, config)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	service := *flagService
+	if service == "" {
+		service = config.GetString(apitypes.ConfigService)
+	}
+
+	ctx := context.Background()
+	if *flagFilter != "" {
+		ctx = ctx.WithValue(context.FilterKey, *flagFilter)
+	}
+
+	cmd := &command{
+		ctx:     ctx,
+		client:  c,
+		service: service,
+	}
+
+	result, err := cmd.run(flag.Args())
+	if err != nil {
+		exitWithError(err)
+	}
+	if result == nil {
+		return
+	}
+
+	if err := writeOutput(os.Stdout, *flagOutput, result); err != nil {
+		exitWithError(err)
+	}
+}
+
+func newConfig() (gofig.Config, error) {
+	if *flagConfig == "" {
+		return apiconfig.NewConfig()
+	}
+	if !gotil.FileExists(*flagConfig) {
+		return nil, fmt.Errorf("invalid config file: %s", *flagConfig)
+	}
+	config := gofig.New()
+	if err := config.ReadConfigFile(*flagConfig); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// disablePathCache prevents the integration driver from listing volumes in
+// the background since the CLI exits as soon as its command completes.
+func disablePathCache(config gofig.Config) {
+	config.Set(apitypes.ConfigIgVolOpsPathCacheEnabled, false)
+}
+
+func exitWithError(err error) {
+	fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+	os.Exit(1)
+}
+
+func printUsage() {
+	firstLine := fmt.Sprintf("usage: %s", os.Args[0])
+	fmt.Fprintf(os.Stderr, "%s\n", firstLine)
+	padFmt := fmt.Sprintf("%%%ds\n", len(firstLine))
+	fmt.Fprintf(os.Stderr, padFmt, "[-options] <resource> [<command>] [<args>]")
+	fmt.Fprintf(os.Stderr, "\n")
+
+	fmt.Fprintln(os.Stderr, cliFlags.FlagUsages())
+	fmt.Fprintf(os.Stderr, commandsUsage, os.Args[0])
+	fmt.Fprintln(os.Stderr, outputUsage)
+
+	os.Exit(1)
+}
+
+var (
+	commandsUsage = `  Resources and Commands
+
+    After all of the flags and options are processed, the remaining
+    arguments on the command line are parsed as a resource, a command,
+    and the command's arguments. The command defaults to "ls".
+
+` + strings.Join([]string{
+		"      services   ls | inspect SERVICE",
+		"      instances  ls | inspect SERVICE",
+		"      volumes    ls | inspect ID | create NAME | copy ID NAME |",
+		"                 resize ID --size SIZE | snapshot ID NAME |",
+		"                 attach ID | detach ID | rm ID",
+		"      snapshots  ls | inspect ID | copy ID NAME | rm ID",
+		"      tasks      ls | inspect ID",
+		"      executors  ls | inspect NAME",
+	}, "\n") + `
+
+    Commands that operate on a single volume or snapshot require a
+    service, either with the -s flag or the libstorage.service config
+    property. The volumes ls command honors the --filter flag.
+
+    Some examples:
+
+      %[1]s -s vfs volumes ls --filter "(name=data)"
+
+      %[1]s -s vfs -o json volumes create data --size 10
+
+      %[1]s -s vfs volumes attach vfs-000
+
+`
+
+	outputUsage = `  Output Formats
+
+    The -o flag formats results as a table, JSON, or YAML. The JSON and
+    YAML formats emit the same objects that the libStorage API returns.
+`
+)
@@ -0,0 +1,287 @@
+package lsc
+
+import (
+	"strconv"
+
+	"github.com/akutz/goof"
+
+	apitypes "github.com/emccode/libstorage/api/types"
+)
+
+// command executes a single resource command against a libStorage client.
+type command struct {
+	ctx     apitypes.Context
+	client  apitypes.Client
+	service string
+}
+
+// attachResult is the result of attaching a volume.
+type attachResult struct {
+	Volume      *apitypes.Volume `json:"volume"`
+	AttachToken string           `json:"attachToken" yaml:"attachToken"`
+}
+
+// removeResult is the result of removing a volume or snapshot.
+type removeResult struct {
+	ID string `json:"id" yaml:"id"`
+}
+
+func (c *command) run(args []string) (interface{}, error) {
+
+	resource := args[0]
+	cmd := "ls"
+	if len(args) > 1 {
+		cmd = args[1]
+	}
+	if len(args) > 2 {
+		args = args[2:]
+	} else {
+		args = nil
+	}
+
+	switch resource {
+	case "services", "service":
+		return c.services(cmd, args)
+	case "instances", "instance":
+		return c.instances(cmd, args)
+	case "volumes", "volume":
+		return c.volumes(cmd, args)
+	case "snapshots", "snapshot":
+		return c.snapshots(cmd, args)
+	case "tasks", "task":
+		return c.tasks(cmd, args)
+	case "executors", "executor":
+		return c.executors(cmd, args)
+	}
+
+	return nil, goof.WithField("resource", resource, "invalid resource")
+}
+
+func (c *command) services(cmd string, args []string) (interface{}, error) {
+	api := c.client.API()
+	switch cmd {
+	case "ls":
+		return api.Services(c.ctx)
+	case "inspect":
+		if err := requireArgs(cmd, args, "SERVICE"); err != nil {
+			return nil, err
+		}
+		return api.ServiceInspect(c.ctx, args[0])
+	}
+	return nil, invalidCommand("services", cmd)
+}
+
+func (c *command) instances(cmd string, args []string) (interface{}, error) {
+	api := c.client.API()
+	switch cmd {
+	case "ls":
+		return api.Instances(c.ctx)
+	case "inspect":
+		if err := requireArgs(cmd, args, "SERVICE"); err != nil {
+			return nil, err
+		}
+		return api.InstanceInspect(c.ctx, args[0])
+	}
+	return nil, invalidCommand("instances", cmd)
+}
+
+func (c *command) volumes(cmd string, args []string) (interface{}, error) {
+
+	api := c.client.API()
+	attachments := *flagAttachments
+
+	if cmd == "ls" {
+		if c.service == "" {
+			return api.Volumes(c.ctx, attachments)
+		}
+		return api.VolumesByService(c.ctx, c.service, attachments)
+	}
+
+	if err := c.requireService(); err != nil {
+		return nil, err
+	}
+
+	switch cmd {
+	case "inspect":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		return api.VolumeInspect(c.ctx, c.service, args[0], attachments)
+	case "create":
+		if err := requireArgs(cmd, args, "NAME"); err != nil {
+			return nil, err
+		}
+		req := newVolumeCreateRequest(args[0])
+		if *flagFromSnapshot != "" {
+			return api.VolumeCreateFromSnapshot(
+				c.ctx, c.service, *flagFromSnapshot, req)
+		}
+		return api.VolumeCreate(c.ctx, c.service, req)
+	case "copy":
+		if err := requireArgs(cmd, args, "ID", "NAME"); err != nil {
+			return nil, err
+		}
+		return api.VolumeCopy(c.ctx, c.service, args[0],
+			&apitypes.VolumeCopyRequest{VolumeName: args[1]})
+	case "resize":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		if *flagSize <= 0 {
+			return nil, goof.New("resize requires --size")
+		}
+		return api.VolumeResize(c.ctx, c.service, args[0],
+			&apitypes.VolumeResizeRequest{Size: *flagSize})
+	case "snapshot":
+		if err := requireArgs(cmd, args, "ID", "NAME"); err != nil {
+			return nil, err
+		}
+		return api.VolumeSnapshot(c.ctx, c.service, args[0],
+			&apitypes.VolumeSnapshotRequest{SnapshotName: args[1]})
+	case "attach":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		req := &apitypes.VolumeAttachRequest{Force: *flagForce}
+		if *flagNextDevice != "" {
+			req.NextDeviceName = flagNextDevice
+		}
+		vol, token, err := api.VolumeAttach(c.ctx, c.service, args[0], req)
+		if err != nil {
+			return nil, err
+		}
+		return &attachResult{Volume: vol, AttachToken: token}, nil
+	case "detach":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		return api.VolumeDetach(c.ctx, c.service, args[0],
+			&apitypes.VolumeDetachRequest{Force: *flagForce})
+	case "rm":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		if err := api.VolumeRemove(c.ctx, c.service, args[0]); err != nil {
+			return nil, err
+		}
+		return &removeResult{ID: args[0]}, nil
+	}
+
+	return nil, invalidCommand("volumes", cmd)
+}
+
+func (c *command) snapshots(cmd string, args []string) (interface{}, error) {
+
+	api := c.client.API()
+
+	if cmd == "ls" {
+		if c.service == "" {
+			return api.Snapshots(c.ctx)
+		}
+		return api.SnapshotsByService(c.ctx, c.service)
+	}
+
+	if err := c.requireService(); err != nil {
+		return nil, err
+	}
+
+	switch cmd {
+	case "inspect":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		return api.SnapshotInspect(c.ctx, c.service, args[0])
+	case "copy":
+		if err := requireArgs(cmd, args, "ID", "NAME"); err != nil {
+			return nil, err
+		}
+		return api.SnapshotCopy(c.ctx, c.service, args[0],
+			&apitypes.SnapshotCopyRequest{
+				SnapshotName:  args[1],
+				DestinationID: *flagDestinationID,
+			})
+	case "rm":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		if err := api.SnapshotRemove(c.ctx, c.service, args[0]); err != nil {
+			return nil, err
+		}
+		return &removeResult{ID: args[0]}, nil
+	}
+
+	return nil, invalidCommand("snapshots", cmd)
+}
+
+func (c *command) tasks(cmd string, args []string) (interface{}, error) {
+	api := c.client.API()
+	switch cmd {
+	case "ls":
+		return api.Tasks(c.ctx)
+	case "inspect":
+		if err := requireArgs(cmd, args, "ID"); err != nil {
+			return nil, err
+		}
+		taskID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return nil, goof.WithField("taskID", args[0], "invalid task ID")
+		}
+		return api.TaskInspect(c.ctx, taskID)
+	}
+	return nil, invalidCommand("tasks", cmd)
+}
+
+func (c *command) executors(cmd string, args []string) (interface{}, error) {
+	api := c.client.API()
+	switch cmd {
+	case "ls":
+		return api.Executors(c.ctx)
+	case "inspect":
+		if err := requireArgs(cmd, args, "NAME"); err != nil {
+			return nil, err
+		}
+		return api.ExecutorHead(c.ctx, args[0])
+	}
+	return nil, invalidCommand("executors", cmd)
+}
+
+func (c *command) requireService() error {
+	if c.service == "" {
+		return goof.New("service required")
+	}
+	return nil
+}
+
+func newVolumeCreateRequest(name string) *apitypes.VolumeCreateRequest {
+	req := &apitypes.VolumeCreateRequest{Name: name}
+	if *flagSize > 0 {
+		req.Size = flagSize
+	}
+	if *flagIOPS > 0 {
+		req.IOPS = flagIOPS
+	}
+	if *flagType != "" {
+		req.Type = flagType
+	}
+	if *flagAvailabilityZone != "" {
+		req.AvailabilityZone = flagAvailabilityZone
+	}
+	return req
+}
+
+func requireArgs(cmd string, args []string, names ...string) error {
+	if len(args) < len(names) {
+		return goof.WithFields(goof.Fields{
+			"command": cmd,
+			"args":    names,
+		}, "missing arguments")
+	}
+	return nil
+}
+
+func invalidCommand(resource, cmd string) error {
+	return goof.WithFields(goof.Fields{
+		"resource": resource,
+		"command":  cmd,
+	}, "invalid command")
+}
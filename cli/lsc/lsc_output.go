@@ -0,0 +1,307 @@
+package lsc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	apitypes "github.com/emccode/libstorage/api/types"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+// writeOutput writes the result to the writer in the provided format.
+func writeOutput(w io.Writer, format string, v interface{}) error {
+	switch format {
+	case outputJSON:
+		buf, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(buf))
+		return err
+	case outputYAML:
+		buf, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(buf)
+		return err
+	}
+	return writeTable(w, v)
+}
+
+// writeTable writes the result to the writer as a table.
+func writeTable(w io.Writer, v interface{}) error {
+
+	var (
+		header []string
+		rows   [][]string
+	)
+
+	switch tv := v.(type) {
+	case map[string]*apitypes.ServiceInfo:
+		header = serviceHeader
+		for _, k := range sortedKeys(tv) {
+			rows = append(rows, serviceRow(tv[k]))
+		}
+	case *apitypes.ServiceInfo:
+		header = serviceHeader
+		rows = append(rows, serviceRow(tv))
+	case map[string]*apitypes.Instance:
+		header = instanceHeader
+		for _, k := range sortedKeys(tv) {
+			rows = append(rows, instanceRow(k, tv[k]))
+		}
+	case *apitypes.Instance:
+		header = instanceHeader[1:]
+		rows = append(rows, instanceRow("", tv)[1:])
+	case apitypes.ServiceVolumeMap:
+		header = append([]string{"SERVICE"}, volumeHeader...)
+		for _, s := range sortedKeys(tv) {
+			for _, k := range sortedKeys(tv[s]) {
+				rows = append(rows,
+					append([]string{s}, volumeRow(tv[s][k])...))
+			}
+		}
+	case apitypes.VolumeMap:
+		header = volumeHeader
+		for _, k := range sortedKeys(tv) {
+			rows = append(rows, volumeRow(tv[k]))
+		}
+	case *apitypes.Volume:
+		header = volumeHeader
+		rows = append(rows, volumeRow(tv))
+	case *attachResult:
+		header = append(volumeHeader, "TOKEN")
+		rows = append(rows, append(volumeRow(tv.Volume), tv.AttachToken))
+	case apitypes.ServiceSnapshotMap:
+		header = append([]string{"SERVICE"}, snapshotHeader...)
+		for _, s := range sortedKeys(tv) {
+			for _, k := range sortedKeys(tv[s]) {
+				rows = append(rows,
+					append([]string{s}, snapshotRow(tv[s][k])...))
+			}
+		}
+	case apitypes.SnapshotMap:
+		header = snapshotHeader
+		for _, k := range sortedKeys(tv) {
+			rows = append(rows, snapshotRow(tv[k]))
+		}
+	case *apitypes.Snapshot:
+		header = snapshotHeader
+		rows = append(rows, snapshotRow(tv))
+	case map[string]*apitypes.Task:
+		header = taskHeader
+		keys := sortedKeys(tv)
+		sort.Sort(byTaskID{keys, tv})
+		for _, k := range keys {
+			rows = append(rows, taskRow(tv[k]))
+		}
+	case *apitypes.Task:
+		header = taskHeader
+		rows = append(rows, taskRow(tv))
+	case map[string]*apitypes.ExecutorInfo:
+		header = executorHeader
+		for _, k := range sortedKeys(tv) {
+			rows = append(rows, executorRow(tv[k]))
+		}
+	case *apitypes.ExecutorInfo:
+		header = executorHeader
+		rows = append(rows, executorRow(tv))
+	case *removeResult:
+		_, err := fmt.Fprintln(w, tv.ID)
+		return err
+	default:
+		return writeOutput(w, outputYAML, v)
+	}
+
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, r := range rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// trim the padding tabwriter leaves after empty trailing columns
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(
+			w, strings.TrimRight(line, " \n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	serviceHeader  = []string{"NAME", "DRIVER", "TYPE"}
+	instanceHeader = []string{"SERVICE", "ID", "NAME", "PROVIDER", "REGION"}
+	volumeHeader   = []string{
+		"ID", "NAME", "SIZE", "IOPS", "TYPE", "ZONE", "STATUS", "ATTACHMENTS"}
+	snapshotHeader = []string{
+		"ID", "NAME", "VOLUME", "SIZE", "STATUS", "STARTED"}
+	taskHeader = []string{
+		"ID", "STATE", "USER", "QUEUED", "STARTED", "COMPLETED", "ERROR"}
+	executorHeader = []string{"NAME", "SIZE", "MD5", "MODIFIED"}
+)
+
+func serviceRow(si *apitypes.ServiceInfo) []string {
+	row := []string{si.Name, "", ""}
+	if si.Driver != nil {
+		row[1] = si.Driver.Name
+		row[2] = string(si.Driver.Type)
+	}
+	return row
+}
+
+func instanceRow(service string, i *apitypes.Instance) []string {
+	row := []string{service, "", "", "", ""}
+	if i == nil {
+		return row
+	}
+	if i.InstanceID != nil {
+		row[1] = i.InstanceID.ID
+	}
+	row[2] = i.Name
+	row[3] = i.ProviderName
+	row[4] = i.Region
+	return row
+}
+
+func volumeRow(v *apitypes.Volume) []string {
+	if v == nil {
+		return make([]string, len(volumeHeader))
+	}
+	var devices []string
+	for _, a := range v.Attachments {
+		switch {
+		case a.DeviceName != "":
+			devices = append(devices, a.DeviceName)
+		case a.InstanceID != nil:
+			devices = append(devices, a.InstanceID.ID)
+		}
+	}
+	return []string{
+		v.ID,
+		v.Name,
+		fmt.Sprintf("%d", v.Size),
+		fmt.Sprintf("%d", v.IOPS),
+		v.Type,
+		v.AvailabilityZone,
+		v.Status,
+		strings.Join(devices, ","),
+	}
+}
+
+func snapshotRow(s *apitypes.Snapshot) []string {
+	return []string{
+		s.ID,
+		s.Name,
+		s.VolumeID,
+		fmt.Sprintf("%d", s.VolumeSize),
+		s.Status,
+		formatEpoch(s.StartTime),
+	}
+}
+
+func taskRow(t *apitypes.Task) []string {
+	row := []string{
+		fmt.Sprintf("%d", t.ID),
+		string(t.State),
+		t.User,
+		formatEpoch(t.QueueTime),
+		formatEpoch(t.StartTime),
+		formatEpoch(t.CompleteTime),
+		"",
+	}
+	if t.Error != nil {
+		row[6] = t.Error.Error()
+	}
+	return row
+}
+
+func executorRow(ei *apitypes.ExecutorInfo) []string {
+	return []string{
+		ei.Name,
+		fmt.Sprintf("%d", ei.Size),
+		ei.MD5Checksum,
+		formatEpoch(ei.LastModified),
+	}
+}
+
+func formatEpoch(epoch int64) string {
+	if epoch == 0 {
+		return ""
+	}
+	return time.Unix(epoch, 0).UTC().Format(time.RFC3339)
+}
+
+// sortedKeys returns the sorted keys of a map with string keys.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch tm := m.(type) {
+	case map[string]*apitypes.ServiceInfo:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case map[string]*apitypes.Instance:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case apitypes.ServiceVolumeMap:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case apitypes.VolumeMap:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case apitypes.ServiceSnapshotMap:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case apitypes.SnapshotMap:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case map[string]*apitypes.Task:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case map[string]*apitypes.ExecutorInfo:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// byTaskID sorts task keys by the tasks' numeric IDs.
+type byTaskID struct {
+	keys  []string
+	tasks map[string]*apitypes.Task
+}
+
+func (b byTaskID) Len() int      { return len(b.keys) }
+func (b byTaskID) Swap(i, j int) { b.keys[i], b.keys[j] = b.keys[j], b.keys[i] }
+func (b byTaskID) Less(i, j int) bool {
+	return b.tasks[b.keys[i]].ID < b.tasks[b.keys[j]].ID
+}
@@ -0,0 +1,66 @@
+package lsc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apitypes "github.com/emccode/libstorage/api/types"
+)
+
+func newTestVolumeMap() apitypes.VolumeMap {
+	return apitypes.VolumeMap{
+		"vfs-001": &apitypes.Volume{
+			ID:   "vfs-001",
+			Name: "data",
+			Size: 10,
+			Attachments: []*apitypes.VolumeAttachment{
+				&apitypes.VolumeAttachment{DeviceName: "/dev/xvdb"},
+			},
+		},
+		"vfs-000": &apitypes.Volume{ID: "vfs-000", Name: "logs", Size: 1},
+	}
+}
+
+func TestWriteTableServiceVolumeMap(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeOutput(buf, outputTable, apitypes.ServiceVolumeMap{
+		"vfs": newTestVolumeMap(),
+	}))
+	assert.Equal(t,
+		"SERVICE  ID       NAME  SIZE  IOPS  TYPE  ZONE  STATUS  ATTACHMENTS\n"+
+			"vfs      vfs-000  logs  1     0\n"+
+			"vfs      vfs-001  data  10    0                         /dev/xvdb\n",
+		buf.String())
+}
+
+func TestWriteTableTasks(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeOutput(buf, outputTable, map[string]*apitypes.Task{
+		"10": &apitypes.Task{ID: 10, State: apitypes.TaskStateQueued},
+		"9":  &apitypes.Task{ID: 9, State: apitypes.TaskStateSuccess},
+	}))
+	assert.Equal(t,
+		"ID  STATE    USER  QUEUED  STARTED  COMPLETED  ERROR\n"+
+			"9   success\n"+
+			"10  queued\n",
+		buf.String())
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeOutput(buf, outputJSON, &removeResult{ID: "vfs-000"}))
+	assert.Equal(t, "{\n  \"id\": \"vfs-000\"\n}\n", buf.String())
+}
+
+func TestWriteYAML(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.NoError(t, writeOutput(buf, outputYAML, &attachResult{
+		Volume:      &apitypes.Volume{ID: "vfs-000", Name: "logs"},
+		AttachToken: "1234",
+	}))
+	assert.Equal(t,
+		"volume:\n  name: logs\n  id: vfs-000\n  type: \"\"\nattachToken: \"1234\"\n",
+		buf.String())
+}
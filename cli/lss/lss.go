@@ -2,8 +2,11 @@ package lss
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
@@ -32,6 +35,8 @@ var (
 	flagVersion     *bool
 	flagEnv         *bool
 	flagPrintConfig *bool
+	flagValidate    *bool
+	flagSetSecret   *string
 	config          gofig.Config
 )
 
@@ -44,6 +49,10 @@ func init() {
 	flagVersion = cliFlags.Bool("version", false, "print version info")
 	flagEnv = cliFlags.Bool("env", false, "print env info")
 	flagPrintConfig = cliFlags.Bool("printConfig", false, "print config info")
+	flagValidate = cliFlags.Bool(
+		"validateConfig", false, "validate and print config info")
+	flagSetSecret = cliFlags.String(
+		"setSecret", "", "store the secret read from stdin in the keystore")
 	flagVerbose = cliFlags.BoolP("verbose", "v", false, "print verbose usage")
 	flag.CommandLine.AddFlagSet(cliFlags)
 }
@@ -72,23 +81,29 @@ func Run() {
 	// if a config is specified then do not care about any other options
 	if flagConfig != nil && gotil.FileExists(*flagConfig) {
 
-		config = gofig.New()
-
-		if err := config.ReadConfigFile(*flagConfig); err != nil {
+		var err error
+		if config, err = readConfigFile(*flagConfig); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
 			os.Exit(1)
 		}
 
 		if flagPrintConfig != nil && *flagPrintConfig {
-			jstr, err := config.ToJSON()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
-				os.Exit(1)
-			}
-			fmt.Fprintln(os.Stdout, jstr)
-			os.Exit(0)
+			printConfig(config)
+		}
+
+		if flagSetSecret != nil && *flagSetSecret != "" {
+			setSecret(config, *flagSetSecret)
 		}
 
+		if flagValidate != nil && *flagValidate {
+			validateConfig(config)
+		}
+
+		server.SetConfigLoader(func() (gofig.Config, error) {
+			return readConfigFile(*flagConfig)
+		})
+		server.ReloadOnHangup()
+
 		s, errs, err := server.Serve(nil, config)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
@@ -116,6 +131,10 @@ func Run() {
 		flag.CommandLine.AddFlagSet(fs)
 	}
 
+	if flagSetSecret != nil && *flagSetSecret != "" {
+		setSecret(config, *flagSetSecret)
+	}
+
 	if flagHelp != nil && *flagHelp {
 		flag.Usage()
 	}
@@ -138,18 +157,54 @@ func Run() {
 	}
 
 	if flagPrintConfig != nil && *flagPrintConfig {
-		jstr, err := config.ToJSON()
+		printConfig(config)
+	}
+
+	if err := readServicesConfig(config, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+
+	if flagValidate != nil && *flagValidate {
+		validateConfig(config)
+	}
+
+	server.CloseOnAbort()
+	server.SetConfigLoader(func() (gofig.Config, error) {
+		config, err := apiconfig.NewConfig()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
-			os.Exit(1)
+			return nil, err
 		}
-		fmt.Fprintln(os.Stdout, jstr)
-		os.Exit(0)
+		if err := readServicesConfig(config, flag.Args()); err != nil {
+			return nil, err
+		}
+		return config, nil
+	})
+	server.ReloadOnHangup()
+
+	_, errs, err := server.Serve(nil, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+		os.Exit(1)
 	}
 
+	<-errs
+}
+
+func readConfigFile(path string) (gofig.Config, error) {
+	config := gofig.New()
+	if err := config.ReadConfigFile(path); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readServicesConfig reads the services described by the command line
+// arguments, formatted as driver[:service], into the config.
+func readServicesConfig(config gofig.Config, args []string) error {
 	buf := &bytes.Buffer{}
 	fmt.Fprintf(buf, "libstorage:\n  server:\n    services:\n")
-	for _, ds := range flag.Args() {
+	for _, ds := range args {
 		dsp := strings.Split(ds, ":")
 		dn := dsp[0]
 		sn := dsp[0]
@@ -158,27 +213,66 @@ func Run() {
 		}
 		fmt.Fprintf(buf, "      %s:\n        driver: %s\n", sn, dn)
 	}
-	if err := config.ReadConfig(buf); err != nil {
+	return config.ReadConfig(buf)
+}
+
+// validateConfig prints the config with the values of its secure keys
+// redacted if the config is valid. Otherwise every unknown key and every key
+// with an invalid value is printed.
+func validateConfig(config gofig.Config) {
+
+	if err := apiconfig.Validate(config); err != nil {
+		if ice, ok := err.(*apitypes.ErrInvalidConfig); ok {
+			problems := ice.Fields()
+			keys := []string{}
+			for k := range problems {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", k, problems[k])
+			}
+		}
 		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
 		os.Exit(1)
 	}
 
-	server.CloseOnAbort()
+	printConfig(config)
+}
 
-	_, errs, err := server.Serve(nil, config)
+// printConfig prints the config with the values of its secure keys redacted.
+func printConfig(config gofig.Config) {
+	buf, err := json.MarshalIndent(apiconfig.Redact(config), "", "  ")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
 		os.Exit(1)
 	}
+	fmt.Fprintln(os.Stdout, string(buf))
+	os.Exit(0)
+}
 
-	<-errs
+// setSecret stores the secret read from stdin in the config's keystore. A
+// secure key refers to the secret as keystore://name.
+func setSecret(config gofig.Config, name string) {
+	buf, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+	ks := apiconfig.NewKeystore(config)
+	if err := ks.Set(name, strings.TrimSpace(string(buf))); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: error: %v\n", os.Args[0], err)
+		os.Exit(1)
+	}
+	os.Exit(0)
 }
 
 func printUsage() {
 	firstLine := fmt.Sprintf("usage: %s", os.Args[0])
 	fmt.Fprintf(os.Stderr, "%s\n", firstLine)
 	padFmt := fmt.Sprintf("%%%ds\n", len(firstLine))
-	fmt.Fprintf(os.Stderr, padFmt, "-c,--config <configFilePath> [--printConfig]")
+	fmt.Fprintf(os.Stderr, padFmt, "-c,--config <configFilePath> [--printConfig|--validateConfig]")
+	fmt.Fprintf(os.Stderr, padFmt, "[-c,--config <configFilePath>] --setSecret <name> < <secretFile>")
 	fmt.Fprintf(os.Stderr, padFmt, "--version")
 	fmt.Fprintf(os.Stderr, padFmt, "--env")
 	fmt.Fprintf(os.Stderr, padFmt, "[-options] <driver>[:<service>] [<driver>[:<service>]...]")
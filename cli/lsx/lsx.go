@@ -9,13 +9,13 @@ import (
 	"os"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/registry"
 	apitypes "github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
 	apiconfig "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/api/utils/tracing"
 
 	_ "github.com/emccode/libstorage/imports/config"
 	_ "github.com/emccode/libstorage/imports/executors"
@@ -49,7 +49,9 @@ func Run() {
 	}
 
 	apiconfig.UpdateLogLevel(config)
-	ctx := context.Background()
+	tracing.Init(config)
+	ctx := tracing.WithRemoteParent(
+		context.Background(), os.Getenv(tracing.EnvTraceParent))
 
 	if err := d.Init(ctx, config); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
@@ -63,6 +65,9 @@ func Run() {
 	cmd = strings.ToLower(cmd)
 	store := utils.NewStore()
 
+	ctx, span := tracing.StartSpan(ctx, "lsx."+cmd)
+	span.SetAttribute("executor", driverName)
+
 	var (
 		result   interface{}
 		op       string
@@ -115,39 +120,13 @@ func Run() {
 			Timeout: utils.DeviceAttachTimeout(args[5]),
 		}
 
-		ldl := func() (bool, *apitypes.LocalDevices, error) {
-			ldm, err := d.LocalDevices(ctx, &opts.LocalDevicesOpts)
-			if err != nil {
-				return false, nil, err
-			}
-			for k := range ldm.DeviceMap {
-				if strings.ToLower(k) == opts.Token {
-					return true, ldm, nil
-				}
-			}
-			return false, ldm, nil
-		}
-
-		var (
-			found    bool
-			opErr    error
-			opResult *apitypes.LocalDevices
-			timeoutC = time.After(opts.Timeout)
-			tick     = time.Tick(500 * time.Millisecond)
-		)
-
-	TimeoutLoop:
-
-		for {
-			select {
-			case <-timeoutC:
-				exitCode = 255
-				break TimeoutLoop
-			case <-tick:
-				if found, opResult, opErr = ldl(); found || opErr != nil {
-					break TimeoutLoop
-				}
-			}
+		found, opResult, opErr := utils.NewDeviceWatcher().WaitForDevice(
+			ctx, opts,
+			func() (*apitypes.LocalDevices, error) {
+				return d.LocalDevices(ctx, &opts.LocalDevicesOpts)
+			})
+		if opErr == nil && !found {
+			exitCode = 255
 		}
 
 		if opErr != nil {
@@ -158,6 +137,8 @@ func Run() {
 		}
 	}
 
+	span.Finish(err)
+
 	if err != nil {
 		fmt.Fprintf(os.Stderr,
 			"error: error getting %s: %v\n", op, err)
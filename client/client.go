@@ -11,6 +11,7 @@ import (
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
 	apicnfg "github.com/emccode/libstorage/api/utils/config"
+	"github.com/emccode/libstorage/api/utils/tracing"
 
 	// load the local imports
 	_ "github.com/emccode/libstorage/imports/local"
@@ -38,6 +39,13 @@ func New(goCtx gocontext.Context, config gofig.Config) (types.Client, error) {
 
 	config = config.Scope(types.ConfigClient)
 	types.BackCompat(config)
+	if err := apicnfg.Validate(config); err != nil {
+		return nil, err
+	}
+	if err := apicnfg.ResolveSecrets(config); err != nil {
+		return nil, err
+	}
+	tracing.Init(config)
 
 	var (
 		c   *client
@@ -470,6 +470,30 @@ func (d *driver) Create(
 	if opts.Opts.IsSet("iops") {
 		IOPS = opts.Opts.GetInt64("iops")
 	}
+	if profile := d.profile(); profile != "" {
+		optsNew.Profile = &profile
+	}
+	if opts.Opts.IsSet("profile") {
+		profile := opts.Opts.GetString("profile")
+		optsNew.Profile = &profile
+	}
+
+	// a named profile supplies its own values, so only send the ones the
+	// user set explicitly instead of the configured defaults
+	if optsNew.Profile != nil {
+		if !opts.Opts.IsSet("availabilityZone") {
+			optsNew.AvailabilityZone = nil
+		}
+		if !opts.Opts.IsSet("size") {
+			optsNew.Size = nil
+		}
+		if !opts.Opts.IsSet("volumeType") && !opts.Opts.IsSet("type") {
+			optsNew.Type = nil
+		}
+		if !opts.Opts.IsSet("iops") {
+			optsNew.IOPS = nil
+		}
+	}
 
 	optsNew.Opts = opts.Opts
 
@@ -479,6 +503,7 @@ func (d *driver) Create(
 		"size":             size,
 		"volumeType":       volumeType,
 		"IOPS":             IOPS,
+		"profile":          optsNew.Profile,
 		"opts":             opts}).Info("creating volume")
 
 	client := context.MustClient(ctx)
@@ -567,6 +592,10 @@ func (d *driver) availabilityZone() string {
 	return d.config.GetString(types.ConfigIgVolOpsCreateDefaultAZ)
 }
 
+func (d *driver) profile() string {
+	return d.config.GetString(types.ConfigIgVolOpsCreateDefaultProfile)
+}
+
 func (d *driver) fsType() string {
 	return d.config.GetString(types.ConfigIgVolOpsCreateDefaultFsType)
 }
@@ -580,17 +609,18 @@ func (d *driver) volumeCreateImplicit() bool {
 }
 
 func registerConfig() {
-	r := gofig.NewRegistration("Docker")
+	r := apiconfig.NewRegistration("Docker")
 	r.Key(gofig.String, "", "ext4", "",
 		types.ConfigIgVolOpsCreateDefaultFsType)
 	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsCreateDefaultType)
 	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsCreateDefaultIOPS)
 	r.Key(gofig.String, "", "16", "", types.ConfigIgVolOpsCreateDefaultSize)
 	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsCreateDefaultAZ)
+	r.Key(gofig.String, "", "", "", types.ConfigIgVolOpsCreateDefaultProfile)
 	r.Key(gofig.String, "", types.Lib.Join("volumes"), "",
 		types.ConfigIgVolOpsMountPath)
 	r.Key(gofig.String, "", "/data", "", types.ConfigIgVolOpsMountRootPath)
 	r.Key(gofig.Bool, "", true, "", types.ConfigIgVolOpsCreateImplicit)
 	r.Key(gofig.Bool, "", false, "", types.ConfigIgVolOpsMountPreempt)
-	gofig.Register(r)
+	apiconfig.Register(r)
 }
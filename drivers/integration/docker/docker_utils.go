@@ -2,6 +2,7 @@ package docker
 
 import (
 	"fmt"
+	"net/http"
 	"path"
 	"strings"
 
@@ -51,6 +52,17 @@ func (d *driver) volumeInspectByIDOrName(
 		if err != nil {
 			return nil, err
 		}
+	} else if api := client.API(); api != nil {
+		serviceName, ok := context.ServiceName(ctx)
+		if !ok {
+			return nil, goof.New("missing service name")
+		}
+		var err error
+		obj, err = api.VolumeInspectByName(
+			ctx, serviceName, volumeName, attachments)
+		if err != nil && !isErrNotFound(err) {
+			return nil, err
+		}
 	} else {
 		objs, err := client.Storage().Volumes(ctx, &types.VolumesOpts{
 			Attachments: false})
@@ -80,9 +92,11 @@ func (d *driver) volumeInspectByIDOrName(
 }
 
 func isErrNotFound(err error) bool {
-	switch err.(type) {
+	switch terr := err.(type) {
 	case *types.ErrNotFound:
 		return true
+	case goof.HTTPError:
+		return terr.Status() == http.StatusNotFound
 	default:
 		return false
 	}
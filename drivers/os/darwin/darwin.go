@@ -10,6 +10,7 @@ import (
 
 	"github.com/emccode/libstorage/api/registry"
 	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const driverName = "darwin"
@@ -22,7 +23,7 @@ var (
 
 func init() {
 	registry.RegisterOSDriver(driverName, newDriver)
-	gofig.Register(configRegistration())
+	apiconfig.Register(configRegistration())
 }
 
 type driver struct {
@@ -85,7 +86,7 @@ func (d *driver) Format(
 	return nil
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Darwin")
+func configRegistration() *apiconfig.Registration {
+	r := apiconfig.NewRegistration("Darwin")
 	return r
 }
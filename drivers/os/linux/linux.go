@@ -16,6 +16,7 @@ import (
 
 	"github.com/emccode/libstorage/api/registry"
 	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const driverName = "linux"
@@ -28,7 +29,7 @@ var (
 
 func init() {
 	registry.RegisterOSDriver(driverName, newDriver)
-	gofig.Register(configRegistration())
+	apiconfig.Register(configRegistration())
 }
 
 type driver struct {
@@ -81,6 +82,18 @@ func (d *driver) Mount(
 	deviceName, mountPoint string,
 	opts *types.DeviceMountOpts) error {
 
+	if d.isFuseDevice(deviceName) {
+
+		if err := d.fuseMount(deviceName, mountPoint, opts); err != nil {
+			return err
+		}
+
+		os.MkdirAll(d.volumeMountPath(mountPoint), d.fileModeMountPath())
+		os.Chmod(d.volumeMountPath(mountPoint), d.fileModeMountPath())
+
+		return nil
+	}
+
 	if d.isNfsDevice(deviceName) {
 
 		if err := d.nfsMount(deviceName, mountPoint); err != nil {
@@ -138,6 +151,11 @@ func (d *driver) Format(
 	deviceName string,
 	opts *types.DeviceFormatOpts) error {
 
+	// the file system of a FUSE device is not formatted
+	if d.isFuseDevice(deviceName) {
+		return nil
+	}
+
 	fsType, err := probeFsType(deviceName)
 	if err != nil && err != errUnknownFileSystem {
 		return err
@@ -191,6 +209,48 @@ func (d *driver) nfsMount(device, target string) error {
 	return nil
 }
 
+// isFuseDevice returns a flag indicating whether or not a device is a URL
+// that is mounted with the FUSE helper.
+func (d *driver) isFuseDevice(device string) bool {
+	return strings.Contains(device, "://")
+}
+
+// escapeFuseOption escapes the backslashes and commas in the value of a FUSE
+// mount option so that a comma in the value does not end the option.
+func escapeFuseOption(v string) string {
+	return strings.NewReplacer(`\`, `\\`, ",", `\,`).Replace(v)
+}
+
+// fuseMount mounts a URL with the FUSE helper. The helper is invoked with the
+// URL, the mount point, and the mount options, which set the name of the file
+// system to the URL so that the mount is listed by the URL in the mount table.
+func (d *driver) fuseMount(
+	device, target string, opts *types.DeviceMountOpts) error {
+
+	helper := d.fuseHelper()
+	if helper == "" {
+		return goof.WithField(
+			"deviceName", device, "fuse helper required")
+	}
+
+	options := []string{"fsname=" + escapeFuseOption(device)}
+	if o := d.fuseOptions(); o != "" {
+		options = append(options, o)
+	}
+	if opts != nil && opts.MountOptions != "" {
+		options = append(options, opts.MountOptions)
+	}
+
+	command := exec.Command(
+		helper, device, target, "-o", strings.Join(options, ","))
+	output, err := command.CombinedOutput()
+	if err != nil {
+		return goof.WithError(fmt.Sprintf("failed mounting: %s", output), err)
+	}
+
+	return nil
+}
+
 func (d *driver) fileModeMountPath() (fileMode os.FileMode) {
 	return os.FileMode(d.volumeFileMode())
 }
@@ -258,9 +318,19 @@ func (d *driver) volumeRootPath() string {
 	return d.config.GetString("linux.volume.rootpath")
 }
 
-func configRegistration() *gofig.Registration {
-	r := gofig.NewRegistration("Linux")
+func (d *driver) fuseHelper() string {
+	return d.config.GetString("linux.fuse.helper")
+}
+
+func (d *driver) fuseOptions() string {
+	return d.config.GetString("linux.fuse.options")
+}
+
+func configRegistration() *apiconfig.Registration {
+	r := apiconfig.NewRegistration("Linux")
 	r.Key(gofig.Int, "", 0700, "", "linux.volume.filemode")
 	r.Key(gofig.String, "", "/data", "", "linux.volume.rootpath")
+	r.Key(gofig.String, "", "", "", "linux.fuse.helper")
+	r.Key(gofig.String, "", "", "", "linux.fuse.options")
 	return r
 }
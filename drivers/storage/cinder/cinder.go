@@ -0,0 +1,162 @@
+package cinder
+
+import (
+	"time"
+
+	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// Name is the name of the storage driver
+	Name = "cinder"
+
+	// TokenLength is the length of the prefix of a volume's ID that the
+	// hypervisor uses as the serial number of the volume's device.
+	TokenLength = 20
+
+	defaultStatusTimeout  = 10 * time.Minute
+	defaultStatusInterval = 2 * time.Second
+)
+
+func init() {
+	registerConfig()
+}
+
+func registerConfig() {
+	r := apiconfig.NewRegistration("Cinder")
+	r.Key(gofig.String, "", "", "", "cinder.authURL")
+	r.Key(gofig.String, "", "", "", "cinder.userID")
+	r.Key(gofig.String, "", "", "", "cinder.userName")
+	r.Key(gofig.SecureString, "", "", "", "cinder.password")
+	r.Key(gofig.String, "", "Default", "", "cinder.domainName")
+	r.Key(gofig.String, "", "", "", "cinder.projectID")
+	r.Key(gofig.String, "", "", "", "cinder.projectName")
+	r.Key(gofig.String, "", "", "", "cinder.projectDomainName")
+	r.Key(gofig.String, "", "", "", "cinder.region")
+	r.Key(gofig.String, "", "public", "", "cinder.endpointType")
+	r.Key(gofig.String, "", "", "", "cinder.availabilityZone")
+	r.Key(gofig.String, "", "", "", "cinder.volumeType")
+	r.Key(gofig.String, "", "10m", "", "cinder.statusTimeout")
+	r.Key(gofig.String, "", "2s", "", "cinder.statusInterval")
+	r.Key(gofig.String, "", "http://169.254.169.254", "", "cinder.metadataURL")
+	r.Key(gofig.String, "", "/mnt/config", "", "cinder.configDrivePath")
+	r.Key(gofig.String, "", "/dev/disk/by-id", "", "cinder.diskByIDPath")
+	apiconfig.Register(r)
+}
+
+// AuthURL returns the URL of the Keystone v3 API.
+func AuthURL(config gofig.Config) string {
+	return config.GetString("cinder.authURL")
+}
+
+// UserID returns the ID of the user with which the driver authenticates.
+func UserID(config gofig.Config) string {
+	return config.GetString("cinder.userID")
+}
+
+// UserName returns the name of the user with which the driver
+// authenticates. The name is used only if the user ID is not configured.
+func UserName(config gofig.Config) string {
+	return config.GetString("cinder.userName")
+}
+
+// Password returns the password of the user.
+func Password(config gofig.Config) string {
+	return config.GetString("cinder.password")
+}
+
+// DomainName returns the name of the user's domain.
+func DomainName(config gofig.Config) string {
+	return config.GetString("cinder.domainName")
+}
+
+// ProjectID returns the ID of the project whose volumes are managed.
+func ProjectID(config gofig.Config) string {
+	return config.GetString("cinder.projectID")
+}
+
+// ProjectName returns the name of the project whose volumes are managed.
+// The name is used only if the project ID is not configured.
+func ProjectName(config gofig.Config) string {
+	return config.GetString("cinder.projectName")
+}
+
+// ProjectDomainName returns the name of the project's domain. It defaults
+// to the user's domain.
+func ProjectDomainName(config gofig.Config) string {
+	if v := config.GetString("cinder.projectDomainName"); v != "" {
+		return v
+	}
+	return DomainName(config)
+}
+
+// Region returns the region whose endpoints are used, or an empty string
+// for the first endpoint of each service.
+func Region(config gofig.Config) string {
+	return config.GetString("cinder.region")
+}
+
+// EndpointType returns the interface of the endpoints that are used, such
+// as "public" or "internal".
+func EndpointType(config gofig.Config) string {
+	return config.GetString("cinder.endpointType")
+}
+
+// AvailabilityZone returns the availability zone of a volume that is
+// created without an availability zone and without an instance.
+func AvailabilityZone(config gofig.Config) string {
+	return config.GetString("cinder.availabilityZone")
+}
+
+// VolumeType returns the type of a volume that is created without a type,
+// or an empty string for Cinder's default type.
+func VolumeType(config gofig.Config) string {
+	return config.GetString("cinder.volumeType")
+}
+
+// StatusTimeout returns how long to wait for a volume or snapshot to reach
+// the state that an operation requires.
+func StatusTimeout(config gofig.Config) time.Duration {
+	return duration(config, "cinder.statusTimeout", defaultStatusTimeout)
+}
+
+// StatusInterval returns the interval at which the state of a volume or
+// snapshot is examined while waiting for it to change.
+func StatusInterval(config gofig.Config) time.Duration {
+	return duration(config, "cinder.statusInterval", defaultStatusInterval)
+}
+
+// MetadataURL returns the URL of the OpenStack metadata service.
+func MetadataURL(config gofig.Config) string {
+	return config.GetString("cinder.metadataURL")
+}
+
+// ConfigDrivePath returns the path at which the instance's config drive is
+// mounted.
+func ConfigDrivePath(config gofig.Config) string {
+	return config.GetString("cinder.configDrivePath")
+}
+
+// DiskByIDPath returns the path to the directory in which udev links the
+// disks by their serial numbers.
+func DiskByIDPath(config gofig.Config) string {
+	return config.GetString("cinder.diskByIDPath")
+}
+
+// Token returns the attach token of a volume, which is the prefix of the
+// volume's ID that the hypervisor uses as its device's serial number.
+func Token(volumeID string) string {
+	if len(volumeID) > TokenLength {
+		return volumeID[:TokenLength]
+	}
+	return volumeID
+}
+
+func duration(config gofig.Config, key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(config.GetString(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,326 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// VolumeService is the catalog type of the Cinder v3 API.
+	VolumeService = "volumev3"
+
+	// VolumeServiceV2 is the catalog type of the Cinder v2 API, which is
+	// used if the catalog does not have the v3 API.
+	VolumeServiceV2 = "volumev2"
+
+	// ComputeService is the catalog type of the Nova API.
+	ComputeService = "compute"
+
+	authTokenHeader    = "X-Auth-Token"
+	subjectTokenHeader = "X-Subject-Token"
+)
+
+// AuthOpts are the options with which a client authenticates with Keystone.
+type AuthOpts struct {
+	// AuthURL is the URL of the Keystone v3 API.
+	AuthURL string
+
+	UserID     string
+	UserName   string
+	Password   string
+	DomainName string
+
+	ProjectID         string
+	ProjectName       string
+	ProjectDomainName string
+}
+
+// Client is a client for the Cinder and Nova APIs of a project.
+type Client struct {
+	sync.Mutex
+	auth         *AuthOpts
+	region       string
+	endpointType string
+	client       *http.Client
+
+	token     string
+	expires   time.Time
+	endpoints map[string]string
+}
+
+// New returns a client that authenticates with Keystone and uses the
+// endpoints of a region and interface from the service catalog.
+func New(auth *AuthOpts, region, endpointType string) *Client {
+	return &Client{
+		auth:         auth,
+		region:       region,
+		endpointType: endpointType,
+		client:       &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Error is an error that an OpenStack API returned.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error returns the error's message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns a flag indicating whether or not an error is the error
+// that an OpenStack API returns for a resource that does not exist.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+type authRequest struct {
+	Auth struct {
+		Identity struct {
+			Methods  []string `json:"methods"`
+			Password struct {
+				User authUser `json:"user"`
+			} `json:"password"`
+		} `json:"identity"`
+		Scope *authScope `json:"scope,omitempty"`
+	} `json:"auth"`
+}
+
+type authUser struct {
+	ID       string      `json:"id,omitempty"`
+	Name     string      `json:"name,omitempty"`
+	Password string      `json:"password"`
+	Domain   *authDomain `json:"domain,omitempty"`
+}
+
+type authScope struct {
+	Project struct {
+		ID     string      `json:"id,omitempty"`
+		Name   string      `json:"name,omitempty"`
+		Domain *authDomain `json:"domain,omitempty"`
+	} `json:"project"`
+}
+
+type authDomain struct {
+	Name string `json:"name"`
+}
+
+type authResponse struct {
+	Token struct {
+		ExpiresAt time.Time `json:"expires_at"`
+		Catalog   []struct {
+			Type      string `json:"type"`
+			Endpoints []struct {
+				Interface string `json:"interface"`
+				Region    string `json:"region"`
+				RegionID  string `json:"region_id"`
+				URL       string `json:"url"`
+			} `json:"endpoints"`
+		} `json:"catalog"`
+	} `json:"token"`
+}
+
+// Authenticate gets a new token and service catalog from Keystone.
+func (c *Client) Authenticate() error {
+	c.Lock()
+	defer c.Unlock()
+	return c.authenticate()
+}
+
+func (c *Client) authenticate() error {
+	req := &authRequest{}
+	req.Auth.Identity.Methods = []string{"password"}
+	user := &req.Auth.Identity.Password.User
+	user.Password = c.auth.Password
+	if c.auth.UserID != "" {
+		user.ID = c.auth.UserID
+	} else {
+		user.Name = c.auth.UserName
+		user.Domain = &authDomain{Name: c.auth.DomainName}
+	}
+
+	if c.auth.ProjectID != "" || c.auth.ProjectName != "" {
+		req.Auth.Scope = &authScope{}
+		if c.auth.ProjectID != "" {
+			req.Auth.Scope.Project.ID = c.auth.ProjectID
+		} else {
+			req.Auth.Scope.Project.Name = c.auth.ProjectName
+			req.Auth.Scope.Project.Domain = &authDomain{
+				Name: c.auth.ProjectDomainName,
+			}
+		}
+	}
+
+	res := &authResponse{}
+	hdr, err := c.send(
+		"POST",
+		strings.TrimSuffix(c.auth.AuthURL, "/")+"/auth/tokens",
+		"", req, res)
+	if err != nil {
+		return goof.WithError("error authenticating with keystone", err)
+	}
+
+	endpoints := map[string]string{}
+	for _, svc := range res.Token.Catalog {
+		for _, ep := range svc.Endpoints {
+			if ep.Interface != c.endpointType {
+				continue
+			}
+			if c.region != "" &&
+				ep.Region != c.region && ep.RegionID != c.region {
+				continue
+			}
+			if _, ok := endpoints[svc.Type]; !ok {
+				endpoints[svc.Type] = strings.TrimSuffix(ep.URL, "/")
+			}
+		}
+	}
+
+	c.token = hdr.Get(subjectTokenHeader)
+	c.expires = res.Token.ExpiresAt
+	c.endpoints = endpoints
+	return nil
+}
+
+// endpoint returns a valid token and the endpoint of the first of the
+// services that is in the catalog.
+func (c *Client) endpoint(services ...string) (string, string, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.token == "" ||
+		(!c.expires.IsZero() && time.Now().Add(time.Minute).After(c.expires)) {
+		if err := c.authenticate(); err != nil {
+			return "", "", err
+		}
+	}
+
+	for _, svc := range services {
+		if ep, ok := c.endpoints[svc]; ok {
+			return c.token, ep, nil
+		}
+	}
+	return "", "", goof.WithFields(goof.Fields{
+		"services":     services,
+		"region":       c.region,
+		"endpointType": c.endpointType,
+	}, "service not in catalog")
+}
+
+// volume sends a request to the Cinder API.
+func (c *Client) volume(method, path string, in, out interface{}) error {
+	return c.do(
+		[]string{VolumeService, VolumeServiceV2}, method, path, in, out)
+}
+
+// compute sends a request to the Nova API.
+func (c *Client) compute(method, path string, in, out interface{}) error {
+	return c.do([]string{ComputeService}, method, path, in, out)
+}
+
+// do sends a request to a service and authenticates again if the token is
+// rejected.
+func (c *Client) do(
+	services []string, method, path string, in, out interface{}) error {
+
+	token, ep, err := c.endpoint(services...)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.send(method, ep+path, token, in, out)
+	if e, ok := err.(*Error); ok && e.StatusCode == http.StatusUnauthorized {
+		c.Lock()
+		c.token = ""
+		c.Unlock()
+		if token, ep, err = c.endpoint(services...); err != nil {
+			return err
+		}
+		_, err = c.send(method, ep+path, token, in, out)
+	}
+	return err
+}
+
+func (c *Client) send(
+	method, url, token string,
+	in, out interface{}) (http.Header, error) {
+
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set(authTokenHeader, token)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, goof.WithFieldsE(goof.Fields{
+			"method": method,
+			"url":    url,
+		}, "error sending openstack request", err)
+	}
+	defer res.Body.Close()
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		return nil, &Error{
+			StatusCode: res.StatusCode,
+			Message:    errorMessage(buf),
+		}
+	}
+
+	if out == nil || len(buf) == 0 {
+		return res.Header, nil
+	}
+	if err := json.Unmarshal(buf, out); err != nil {
+		return nil, goof.WithFieldE(
+			"url", url, "error decoding openstack response", err)
+	}
+	return res.Header, nil
+}
+
+// errorMessage returns the message of an error response, which is an
+// object with one key, such as "itemNotFound", whose value has the message.
+func errorMessage(buf []byte) string {
+	var res map[string]struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(buf, &res); err == nil {
+		for _, v := range res {
+			if v.Message != "" {
+				return v.Message
+			}
+		}
+	}
+	return strings.TrimSpace(string(buf))
+}
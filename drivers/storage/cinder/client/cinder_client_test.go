@@ -0,0 +1,126 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthenticateScope(t *testing.T) {
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v3/auth/tokens" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			req := &authRequest{}
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				t.Fatal(err)
+			}
+			user := req.Auth.Identity.Password.User
+			if user.ID != "uid" || user.Name != "" || user.Domain != nil {
+				t.Fatalf("invalid user: %+v", user)
+			}
+			if req.Auth.Scope == nil || req.Auth.Scope.Project.ID != "pid" {
+				t.Fatalf("invalid scope: %+v", req.Auth.Scope)
+			}
+			w.Header().Set(subjectTokenHeader, "token")
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": {"catalog": [
+	{"type": "volumev2", "endpoints": [
+		{"interface": "public", "region_id": "r1", "url": "%[1]s/v2/"}
+	]},
+	{"type": "volumev3", "endpoints": [
+		{"interface": "admin", "region_id": "r1", "url": "http://admin"},
+		{"interface": "public", "region_id": "r2", "url": "http://r2"},
+		{"interface": "public", "region_id": "r1", "url": "%[1]s/v3"}
+	]}
+]}}`, ts.URL)
+		}))
+	defer ts.Close()
+
+	c := New(&AuthOpts{
+		AuthURL:   ts.URL + "/v3/",
+		UserID:    "uid",
+		ProjectID: "pid",
+	}, "r1", "public")
+	if err := c.Authenticate(); err != nil {
+		t.Fatal(err)
+	}
+
+	token, ep, err := c.endpoint(VolumeService, VolumeServiceV2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "token" || ep != ts.URL+"/v3" {
+		t.Fatalf("invalid endpoint: %s %s", token, ep)
+	}
+
+	if _, _, err := c.endpoint(ComputeService); err == nil {
+		t.Fatal("compute service in catalog")
+	}
+}
+
+func TestError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"itemNotFound": {
+				"message": "Volume x could not be found.", "code": 404}}`)
+		}))
+	defer ts.Close()
+
+	_, err := (&Client{client: http.DefaultClient}).send(
+		"GET", ts.URL, "token", nil, nil)
+	if !IsNotFound(err) {
+		t.Fatalf("invalid error: %v", err)
+	}
+	if e := err.(*Error); e.Message != "Volume x could not be found." {
+		t.Fatalf("invalid message: %s", e.Message)
+	}
+}
+
+func TestPages(t *testing.T) {
+	c := &Client{}
+	paths := []string{}
+	links := []string{
+		"http://cinder:8776/v3/pid/volumes/detail?limit=2&marker=b",
+		"http://cinder:8776/v3/pid/volumes/detail?limit=2&marker=d",
+		"",
+	}
+	err := c.pages("/volumes/detail", func(path string) (string, error) {
+		paths = append(paths, path)
+		return links[len(paths)-1], nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{
+		"/volumes/detail",
+		"/volumes/detail?limit=2&marker=b",
+		"/volumes/detail?limit=2&marker=d",
+	}
+	if fmt.Sprint(paths) != fmt.Sprint(exp) {
+		t.Fatalf("invalid paths: %v", paths)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	exp := time.Date(2016, 10, 19, 12, 30, 15, 123456000, time.UTC)
+	for _, s := range []string{
+		"2016-10-19T12:30:15.123456",
+		"2016-10-19T12:30:15.123456Z",
+	} {
+		if act := ParseTime(s); !act.Equal(exp) {
+			t.Fatalf("invalid time: %s: %v", s, act)
+		}
+	}
+	exp = time.Date(2016, 10, 19, 12, 30, 15, 0, time.UTC)
+	if act := ParseTime("2016-10-19T12:30:15"); !act.Equal(exp) {
+		t.Fatalf("invalid time: %v", act)
+	}
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+// metadataPath is the path of the instance's metadata in the config drive
+// and in the metadata service.
+const metadataPath = "openstack/latest/meta_data.json"
+
+// InstanceMetadata is the metadata of an OpenStack instance.
+type InstanceMetadata struct {
+	UUID             string `json:"uuid"`
+	Name             string `json:"name"`
+	AvailabilityZone string `json:"availability_zone"`
+}
+
+// GetInstanceMetadata returns the metadata of the instance from the config
+// drive mounted at a path, or else from the metadata service at an
+// endpoint.
+func GetInstanceMetadata(
+	configDrivePath, metadataURL string) (*InstanceMetadata, error) {
+
+	md := &InstanceMetadata{}
+
+	if configDrivePath != "" {
+		buf, err := ioutil.ReadFile(path.Join(configDrivePath, metadataPath))
+		if err == nil {
+			if err := json.Unmarshal(buf, md); err != nil {
+				return nil, goof.WithFieldE(
+					"path", configDrivePath,
+					"error decoding config drive metadata", err)
+			}
+			return md, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	u := strings.TrimSuffix(metadataURL, "/") + "/" + metadataPath
+	client := &http.Client{Timeout: 5 * time.Second}
+	res, err := client.Get(u)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"url", u, "error getting instance metadata", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, goof.WithFields(goof.Fields{
+			"url":    u,
+			"status": res.StatusCode,
+		}, "error getting instance metadata")
+	}
+	if err := json.NewDecoder(res.Body).Decode(md); err != nil {
+		return nil, goof.WithFieldE(
+			"url", u, "error decoding instance metadata", err)
+	}
+	return md, nil
+}
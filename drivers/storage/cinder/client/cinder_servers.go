@@ -0,0 +1,65 @@
+package client
+
+import (
+	"net/url"
+)
+
+// Server is a Nova server.
+type Server struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Status           string `json:"status"`
+	AvailabilityZone string `json:"OS-EXT-AZ:availability_zone"`
+}
+
+// VolumeAttachment is the attachment of a volume to a server that Nova
+// manages.
+type VolumeAttachment struct {
+	ID       string `json:"id"`
+	ServerID string `json:"serverId"`
+	VolumeID string `json:"volumeId"`
+	Device   string `json:"device,omitempty"`
+}
+
+// Server returns a server.
+func (c *Client) Server(serverID string) (*Server, error) {
+	var res struct {
+		Server *Server `json:"server"`
+	}
+	if err := c.compute(
+		"GET", "/servers/"+url.QueryEscape(serverID), nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Server, nil
+}
+
+// AttachVolume attaches a volume to a server. Nova chooses the device if the
+// device is empty.
+func (c *Client) AttachVolume(
+	serverID, volumeID, device string) (*VolumeAttachment, error) {
+
+	req := map[string]interface{}{
+		"volumeAttachment": &VolumeAttachment{
+			VolumeID: volumeID,
+			Device:   device,
+		},
+	}
+	var res struct {
+		VolumeAttachment *VolumeAttachment `json:"volumeAttachment"`
+	}
+	if err := c.compute(
+		"POST", "/servers/"+url.QueryEscape(serverID)+"/os-volume_attachments",
+		req, &res); err != nil {
+		return nil, err
+	}
+	return res.VolumeAttachment, nil
+}
+
+// DetachVolume detaches a volume from a server.
+func (c *Client) DetachVolume(serverID, volumeID string) error {
+	return c.compute(
+		"DELETE",
+		"/servers/"+url.QueryEscape(serverID)+
+			"/os-volume_attachments/"+url.QueryEscape(volumeID),
+		nil, nil)
+}
@@ -0,0 +1,222 @@
+package client
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Volume is a Cinder volume.
+type Volume struct {
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description,omitempty"`
+	Size             int64             `json:"size"`
+	Status           string            `json:"status"`
+	AvailabilityZone string            `json:"availability_zone"`
+	VolumeType       string            `json:"volume_type"`
+	SnapshotID       string            `json:"snapshot_id,omitempty"`
+	SourceVolID      string            `json:"source_volid,omitempty"`
+	Metadata         map[string]string `json:"metadata"`
+	Attachments      []*Attachment     `json:"attachments"`
+	CreatedAt        string            `json:"created_at"`
+}
+
+// Attachment is the attachment of a volume to a server.
+type Attachment struct {
+	ID         string `json:"attachment_id"`
+	VolumeID   string `json:"volume_id"`
+	ServerID   string `json:"server_id"`
+	HostName   string `json:"host_name,omitempty"`
+	Device     string `json:"device"`
+	AttachedAt string `json:"attached_at,omitempty"`
+}
+
+// Snapshot is a Cinder snapshot.
+type Snapshot struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	VolumeID    string            `json:"volume_id"`
+	Size        int64             `json:"size"`
+	Status      string            `json:"status"`
+	Metadata    map[string]string `json:"metadata"`
+	CreatedAt   string            `json:"created_at"`
+}
+
+// CreateVolumeOpts are the options for creating a volume.
+type CreateVolumeOpts struct {
+	Name             string            `json:"name,omitempty"`
+	Size             int64             `json:"size,omitempty"`
+	AvailabilityZone string            `json:"availability_zone,omitempty"`
+	VolumeType       string            `json:"volume_type,omitempty"`
+	SnapshotID       string            `json:"snapshot_id,omitempty"`
+	SourceVolID      string            `json:"source_volid,omitempty"`
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateSnapshotOpts are the options for creating a snapshot.
+type CreateSnapshotOpts struct {
+	VolumeID    string            `json:"volume_id"`
+	Name        string            `json:"name,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Force       bool              `json:"force"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+type link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// Volumes returns the volumes of the project.
+func (c *Client) Volumes() ([]*Volume, error) {
+	vols := []*Volume{}
+	err := c.pages("/volumes/detail", func(path string) (string, error) {
+		var res struct {
+			Volumes []*Volume `json:"volumes"`
+			Links   []*link   `json:"volumes_links"`
+		}
+		if err := c.volume("GET", path, nil, &res); err != nil {
+			return "", err
+		}
+		vols = append(vols, res.Volumes...)
+		return next(res.Links), nil
+	})
+	return vols, err
+}
+
+// Volume returns a volume.
+func (c *Client) Volume(volumeID string) (*Volume, error) {
+	var res struct {
+		Volume *Volume `json:"volume"`
+	}
+	if err := c.volume(
+		"GET", "/volumes/"+url.QueryEscape(volumeID), nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Volume, nil
+}
+
+// CreateVolume creates a volume.
+func (c *Client) CreateVolume(opts *CreateVolumeOpts) (*Volume, error) {
+	req := map[string]interface{}{"volume": opts}
+	var res struct {
+		Volume *Volume `json:"volume"`
+	}
+	if err := c.volume("POST", "/volumes", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Volume, nil
+}
+
+// DeleteVolume deletes a volume.
+func (c *Client) DeleteVolume(volumeID string) error {
+	return c.volume(
+		"DELETE", "/volumes/"+url.QueryEscape(volumeID), nil, nil)
+}
+
+// ExtendVolume changes the size of a volume to the specified number of GiB.
+func (c *Client) ExtendVolume(volumeID string, size int64) error {
+	req := map[string]interface{}{
+		"os-extend": map[string]int64{"new_size": size},
+	}
+	return c.volume(
+		"POST", "/volumes/"+url.QueryEscape(volumeID)+"/action", req, nil)
+}
+
+// Snapshots returns the snapshots of the project.
+func (c *Client) Snapshots() ([]*Snapshot, error) {
+	snaps := []*Snapshot{}
+	err := c.pages("/snapshots/detail", func(path string) (string, error) {
+		var res struct {
+			Snapshots []*Snapshot `json:"snapshots"`
+			Links     []*link     `json:"snapshots_links"`
+		}
+		if err := c.volume("GET", path, nil, &res); err != nil {
+			return "", err
+		}
+		snaps = append(snaps, res.Snapshots...)
+		return next(res.Links), nil
+	})
+	return snaps, err
+}
+
+// Snapshot returns a snapshot.
+func (c *Client) Snapshot(snapshotID string) (*Snapshot, error) {
+	var res struct {
+		Snapshot *Snapshot `json:"snapshot"`
+	}
+	if err := c.volume(
+		"GET", "/snapshots/"+url.QueryEscape(snapshotID),
+		nil, &res); err != nil {
+		return nil, err
+	}
+	return res.Snapshot, nil
+}
+
+// CreateSnapshot creates a snapshot of a volume.
+func (c *Client) CreateSnapshot(opts *CreateSnapshotOpts) (*Snapshot, error) {
+	req := map[string]interface{}{"snapshot": opts}
+	var res struct {
+		Snapshot *Snapshot `json:"snapshot"`
+	}
+	if err := c.volume("POST", "/snapshots", req, &res); err != nil {
+		return nil, err
+	}
+	return res.Snapshot, nil
+}
+
+// DeleteSnapshot deletes a snapshot.
+func (c *Client) DeleteSnapshot(snapshotID string) error {
+	return c.volume(
+		"DELETE", "/snapshots/"+url.QueryEscape(snapshotID), nil, nil)
+}
+
+// pages calls f with the path of each page of a list until f returns an
+// empty link to the next page. A link is a full URL, so the path of the next
+// page is the part of the link that starts with the list's path.
+func (c *Client) pages(path string, f func(string) (string, error)) error {
+	base := path
+	for {
+		href, err := f(path)
+		if err != nil || href == "" {
+			return err
+		}
+		u, err := url.Parse(href)
+		if err != nil {
+			return err
+		}
+		i := strings.LastIndex(u.Path, base)
+		if i < 0 {
+			return nil
+		}
+		path = u.Path[i:]
+		if u.RawQuery != "" {
+			path += "?" + u.RawQuery
+		}
+	}
+}
+
+func next(links []*link) string {
+	for _, l := range links {
+		if l.Rel == "next" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// ParseTime parses the time at which a volume or snapshot was created.
+// OpenStack omits the time zone, which is UTC.
+func ParseTime(s string) time.Time {
+	for _, layout := range []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999",
+	} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/drivers/storage/cinder"
+	cinderclient "github.com/emccode/libstorage/drivers/storage/cinder/client"
+)
+
+// serialPrefixes are the prefixes of the names of the links to the disks
+// whose serial numbers are the prefixes of the volumes' IDs, for virtio and
+// virtio-scsi disks.
+var serialPrefixes = []string{
+	"virtio-",
+	"scsi-0QEMU_QEMU_HARDDISK_",
+}
+
+// driver is the storage executor for the cinder storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(cinder.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return cinder.Name
+}
+
+// InstanceID returns the UUID of the instance from the config drive or the
+// metadata service.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	md, err := cinderclient.GetInstanceMetadata(
+		cinder.ConfigDrivePath(d.config), cinder.MetadataURL(d.config))
+	if err != nil {
+		return nil, err
+	}
+
+	iid := &types.InstanceID{ID: md.UUID, Driver: cinder.Name}
+	if err := iid.MarshalMetadata(md); err != nil {
+		return nil, err
+	}
+
+	return iid, nil
+}
+
+// NextDevice returns the next available device (not implemented).
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns a map of the serial numbers of the disks, which are
+// the attach tokens of the volumes, and the paths to their devices.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	dir := cinder.DiskByIDPath(d.config)
+	devs := map[string]string{}
+
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, fi := range fis {
+		name := fi.Name()
+		if strings.Contains(name, "-part") {
+			continue
+		}
+		for _, p := range serialPrefixes {
+			if !strings.HasPrefix(name, p) {
+				continue
+			}
+			dev, err := filepath.EvalSymlinks(path.Join(dir, name))
+			if err != nil {
+				break
+			}
+			devs[cinder.Token(name[len(p):])] = dev
+			break
+		}
+	}
+
+	return &types.LocalDevices{Driver: cinder.Name, DeviceMap: devs}, nil
+}
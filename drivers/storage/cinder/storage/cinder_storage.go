@@ -0,0 +1,716 @@
+package storage
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
+	"github.com/emccode/libstorage/drivers/storage/cinder"
+	cinderclient "github.com/emccode/libstorage/drivers/storage/cinder/client"
+)
+
+const (
+	// defaultSize is the size, in GiB, of a volume that is created without
+	// a size.
+	defaultSize = 1
+
+	statusAvailable = "available"
+	statusInUse     = "in-use"
+	statusError     = "error"
+)
+
+// driver is the storage driver for OpenStack Cinder volumes. Volumes are
+// attached to instances with Nova, and a volume's metadata is its fields.
+type driver struct {
+	sync.Mutex
+	config   gofig.Config
+	client   *cinderclient.Client
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func init() {
+	registry.RegisterStorageDriver(cinder.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return cinder.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.timeout = cinder.StatusTimeout(config)
+	d.interval = cinder.StatusInterval(config)
+
+	fields := log.Fields{
+		"authURL":      cinder.AuthURL(config),
+		"userID":       cinder.UserID(config),
+		"userName":     cinder.UserName(config),
+		"domainName":   cinder.DomainName(config),
+		"projectID":    cinder.ProjectID(config),
+		"projectName":  cinder.ProjectName(config),
+		"region":       cinder.Region(config),
+		"endpointType": cinder.EndpointType(config),
+	}
+
+	if cinder.AuthURL(config) == "" {
+		return goof.WithFields(fields, "cinder authURL required")
+	}
+	if cinder.UserID(config) == "" && cinder.UserName(config) == "" {
+		return goof.WithFields(fields, "cinder userID or userName required")
+	}
+
+	d.client = cinderclient.New(&cinderclient.AuthOpts{
+		AuthURL:           cinder.AuthURL(config),
+		UserID:            cinder.UserID(config),
+		UserName:          cinder.UserName(config),
+		Password:          cinder.Password(config),
+		DomainName:        cinder.DomainName(config),
+		ProjectID:         cinder.ProjectID(config),
+		ProjectName:       cinder.ProjectName(config),
+		ProjectDomainName: cinder.ProjectDomainName(config),
+	}, cinder.Region(config), cinder.EndpointType(config))
+
+	if err := d.client.Authenticate(); err != nil {
+		return goof.WithFieldsE(fields, "error initializing cinder", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow. Nova chooses the device to which a volume is attached.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return &types.NextDeviceInfo{
+		Ignore: true,
+	}, nil
+}
+
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots: true,
+		Copy:      true,
+		Resize:    true,
+	}, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	if iid.ID != "" {
+		return &types.Instance{InstanceID: iid}, nil
+	}
+
+	md := &cinderclient.InstanceMetadata{}
+	if err := iid.UnmarshalMetadata(md); err != nil {
+		return nil, err
+	}
+
+	return &types.Instance{
+		Name: md.Name,
+		InstanceID: &types.InstanceID{
+			ID:     md.UUID,
+			Driver: iid.Driver,
+		},
+	}, nil
+}
+
+// Volumes returns all volumes or a filtered list of volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	cvols, err := d.client.Volumes()
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := opts != nil && opts.Attachments
+
+	vols := []*types.Volume{}
+	for _, v := range cvols {
+		vol := d.toVolume(ctx, v, attachments)
+		if opts != nil && opts.Filter != nil {
+			ok, err := filters.Match(opts.Filter, vol)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		vols = append(vols, vol)
+	}
+
+	return utils.SortVolumeByID(vols), nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return d.toVolume(ctx, v, opts != nil && opts.Attachments), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	cvo := d.createVolumeOpts(ctx, name, opts)
+	if cvo.Size == 0 {
+		cvo.Size = defaultSize
+	}
+	return d.createVolume(ctx, cvo)
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if _, err := d.getSnapshot(snapshotID); err != nil {
+		return nil, err
+	}
+
+	cvo := d.createVolumeOpts(ctx, volumeName, opts)
+	cvo.SnapshotID = snapshotID
+	return d.createVolume(ctx, cvo)
+}
+
+// VolumeCopy copies an existing volume and its metadata.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	md := metadata(opts)
+	for k, val := range v.Metadata {
+		if _, ok := md[k]; !ok {
+			md[k] = val
+		}
+	}
+
+	return d.createVolume(ctx, &cinderclient.CreateVolumeOpts{
+		Name:             volumeName,
+		SourceVolID:      volumeID,
+		AvailabilityZone: v.AvailabilityZone,
+		VolumeType:       v.VolumeType,
+		Metadata:         md,
+	})
+}
+
+// VolumeResize grows a volume. Cinder cannot shrink a volume.
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Size < v.Size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     v.Size,
+			"newSize":  opts.Size,
+		}, "cannot shrink volume")
+	}
+
+	if opts.Size > v.Size {
+		if err := d.client.ExtendVolume(volumeID, opts.Size); err != nil {
+			return nil, err
+		}
+		if v, err = d.waitVolume(volumeID, func(v *cinderclient.Volume) bool {
+			return v.Size == opts.Size && v.Status == statusAvailable
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.toVolume(ctx, v, false), nil
+}
+
+// VolumeSnapshot snapshots a volume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if _, err := d.getVolume(volumeID); err != nil {
+		return nil, err
+	}
+
+	// a snapshot of an attached volume is only crash consistent, which is
+	// what the snapshots of the other drivers are too
+	s, err := d.client.CreateSnapshot(&cinderclient.CreateSnapshotOpts{
+		VolumeID: volumeID,
+		Name:     snapshotName,
+		Force:    true,
+		Metadata: metadata(opts),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s, err = d.waitSnapshot(s.ID); err != nil {
+		return nil, err
+	}
+	return toSnapshot(s), nil
+}
+
+// VolumeRemove removes a volume.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if err := d.client.DeleteVolume(volumeID); err != nil {
+		return notFound(err, volumeID)
+	}
+	return nil
+}
+
+// VolumeAttach attaches a volume to the instance with Nova. The attach token
+// is the prefix of the volume's ID that is the serial number of its device.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	serverID := inst.InstanceID.ID
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, att := range v.Attachments {
+		fields := goof.Fields{
+			"volumeID":   volumeID,
+			"instanceID": att.ServerID,
+		}
+		if att.ServerID == serverID {
+			return nil, "", goof.WithFields(
+				fields, "volume already attached to instance")
+		}
+		if !opts.Force {
+			return nil, "", goof.WithFields(
+				fields, "volume already attached to another instance")
+		}
+		if err := d.client.DetachVolume(att.ServerID, volumeID); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(v.Attachments) > 0 {
+		if _, err := d.waitVolume(volumeID, isAvailable); err != nil {
+			return nil, "", err
+		}
+	}
+
+	device := ""
+	if opts.NextDevice != nil {
+		device = *opts.NextDevice
+	}
+	if _, err := d.client.AttachVolume(
+		serverID, volumeID, device); err != nil {
+		return nil, "", err
+	}
+
+	v, err = d.waitVolume(volumeID, func(v *cinderclient.Volume) bool {
+		return v.Status == statusInUse && attachedTo(v, serverID)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return d.toVolume(ctx, v, true), cinder.Token(volumeID), nil
+}
+
+// VolumeDetach detaches a volume from the instance.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	serverID := inst.InstanceID.ID
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attachedTo(v, serverID) {
+		if err := d.client.DetachVolume(serverID, volumeID); err != nil {
+			return nil, err
+		}
+		v, err = d.waitVolume(volumeID, func(v *cinderclient.Volume) bool {
+			return !attachedTo(v, serverID) && v.Status != "detaching"
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return d.toVolume(ctx, v, false), nil
+}
+
+// Snapshots returns all snapshots or a filtered list of snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	filter, err := filters.FromStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	csnaps, err := d.client.Snapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []*types.Snapshot{}
+	for _, s := range csnaps {
+		snap := toSnapshot(s)
+		if filter != nil {
+			ok, err := filters.Match(filter, snap)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	s, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	return toSnapshot(s), nil
+}
+
+// SnapshotCopy copies an existing snapshot. Cinder cannot copy a snapshot.
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+	return nil, types.ErrNotImplemented
+}
+
+// SnapshotRemove removes a snapshot.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if err := d.client.DeleteSnapshot(snapshotID); err != nil {
+		return notFound(err, snapshotID)
+	}
+	return nil
+}
+
+// createVolumeOpts returns the options for creating a volume. A volume is
+// created in the configured availability zone, or else in the instance's
+// availability zone, if the options do not have an availability zone.
+func (d *driver) createVolumeOpts(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) *cinderclient.CreateVolumeOpts {
+
+	cvo := &cinderclient.CreateVolumeOpts{
+		Name:             name,
+		VolumeType:       cinder.VolumeType(d.config),
+		AvailabilityZone: cinder.AvailabilityZone(d.config),
+		Metadata:         metadata(opts.Opts),
+	}
+
+	if opts.Size != nil {
+		cvo.Size = *opts.Size
+	}
+	if opts.Type != nil && *opts.Type != "" {
+		cvo.VolumeType = *opts.Type
+	}
+
+	if opts.AvailabilityZone != nil && *opts.AvailabilityZone != "" {
+		cvo.AvailabilityZone = *opts.AvailabilityZone
+	} else if cvo.AvailabilityZone == "" {
+		if _, ok := context.InstanceID(ctx); ok {
+			if inst, err := d.InstanceInspect(ctx, nil); err == nil {
+				srv, err := d.client.Server(inst.InstanceID.ID)
+				if err == nil {
+					cvo.AvailabilityZone = srv.AvailabilityZone
+				}
+			}
+		}
+	}
+
+	return cvo
+}
+
+// createVolume creates a volume and waits for it to become available.
+func (d *driver) createVolume(
+	ctx types.Context,
+	cvo *cinderclient.CreateVolumeOpts) (*types.Volume, error) {
+
+	v, err := d.client.CreateVolume(cvo)
+	if err != nil {
+		return nil, err
+	}
+
+	if v, err = d.waitVolume(v.ID, isAvailable); err != nil {
+		return nil, err
+	}
+	return d.toVolume(ctx, v, false), nil
+}
+
+func (d *driver) getVolume(volumeID string) (*cinderclient.Volume, error) {
+	v, err := d.client.Volume(volumeID)
+	if err != nil {
+		return nil, notFound(err, volumeID)
+	}
+	return v, nil
+}
+
+func (d *driver) getSnapshot(
+	snapshotID string) (*cinderclient.Snapshot, error) {
+
+	s, err := d.client.Snapshot(snapshotID)
+	if err != nil {
+		return nil, notFound(err, snapshotID)
+	}
+	return s, nil
+}
+
+// waitVolume waits for a volume to satisfy a condition.
+func (d *driver) waitVolume(
+	volumeID string,
+	cond func(*cinderclient.Volume) bool) (*cinderclient.Volume, error) {
+
+	deadline := time.Now().Add(d.timeout)
+	for {
+		v, err := d.getVolume(volumeID)
+		if err != nil {
+			return nil, err
+		}
+		if cond(v) {
+			return v, nil
+		}
+		fields := goof.Fields{
+			"volumeID": volumeID,
+			"status":   v.Status,
+		}
+		if strings.HasPrefix(v.Status, statusError) {
+			return nil, goof.WithFields(fields, "volume failed")
+		}
+		if time.Now().After(deadline) {
+			fields["timeout"] = d.timeout
+			return nil, goof.WithFields(fields, "timed out waiting for volume")
+		}
+		time.Sleep(d.interval)
+	}
+}
+
+// waitSnapshot waits for a snapshot to become available.
+func (d *driver) waitSnapshot(
+	snapshotID string) (*cinderclient.Snapshot, error) {
+
+	deadline := time.Now().Add(d.timeout)
+	for {
+		s, err := d.getSnapshot(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		if s.Status == statusAvailable {
+			return s, nil
+		}
+		fields := goof.Fields{
+			"snapshotID": snapshotID,
+			"status":     s.Status,
+		}
+		if strings.HasPrefix(s.Status, statusError) {
+			return nil, goof.WithFields(fields, "snapshot failed")
+		}
+		if time.Now().After(deadline) {
+			fields["timeout"] = d.timeout
+			return nil, goof.WithFields(
+				fields, "timed out waiting for snapshot")
+		}
+		time.Sleep(d.interval)
+	}
+}
+
+// toVolume returns a volume. The device of an attachment to the instance is
+// the local device whose serial number is the volume's attach token, if the
+// context has the instance's local devices, because Nova's device name is
+// only a hint to the hypervisor.
+func (d *driver) toVolume(
+	ctx types.Context,
+	v *cinderclient.Volume,
+	attachments bool) *types.Volume {
+
+	vol := &types.Volume{
+		ID:               v.ID,
+		Name:             v.Name,
+		Size:             v.Size,
+		AvailabilityZone: v.AvailabilityZone,
+		Type:             v.VolumeType,
+		Status:           v.Status,
+		Fields:           map[string]string{},
+	}
+	for k, val := range v.Metadata {
+		vol.Fields[k] = val
+	}
+
+	if !attachments {
+		return vol
+	}
+
+	iid, iidOK := context.InstanceID(ctx)
+	ld, ldOK := context.LocalDevices(ctx)
+	token := strings.ToLower(cinder.Token(v.ID))
+
+	for _, att := range v.Attachments {
+		dev := att.Device
+		if iidOK && ldOK && att.ServerID == iid.ID {
+			for k, ldev := range ld.DeviceMap {
+				if strings.ToLower(k) == token {
+					dev = ldev
+					break
+				}
+			}
+		}
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID: v.ID,
+			InstanceID: &types.InstanceID{
+				ID:     att.ServerID,
+				Driver: cinder.Name,
+			},
+			DeviceName: dev,
+			Status:     v.Status,
+		})
+	}
+
+	return vol
+}
+
+func toSnapshot(s *cinderclient.Snapshot) *types.Snapshot {
+	snap := &types.Snapshot{
+		ID:          s.ID,
+		Name:        s.Name,
+		Description: s.Description,
+		VolumeID:    s.VolumeID,
+		VolumeSize:  s.Size,
+		Status:      s.Status,
+		StartTime:   cinderclient.ParseTime(s.CreatedAt).Unix(),
+		Fields:      map[string]string{},
+	}
+	for k, val := range s.Metadata {
+		snap.Fields[k] = val
+	}
+	return snap
+}
+
+func isAvailable(v *cinderclient.Volume) bool {
+	return v.Status == statusAvailable && len(v.Attachments) == 0
+}
+
+func attachedTo(v *cinderclient.Volume, serverID string) bool {
+	for _, att := range v.Attachments {
+		if att.ServerID == serverID {
+			return true
+		}
+	}
+	return false
+}
+
+// metadata returns the custom fields in a store as a volume's or snapshot's
+// metadata.
+func metadata(opts types.Store) map[string]string {
+	md := map[string]string{}
+	if opts == nil {
+		return md
+	}
+	if customFields := opts.GetStore("opts"); customFields != nil {
+		for _, k := range customFields.Keys() {
+			md[k] = customFields.GetString(k)
+		}
+	}
+	return md
+}
+
+// notFound returns a not found error if an error is the error that Cinder
+// returns for a missing resource.
+func notFound(err error, id string) error {
+	if cinderclient.IsNotFound(err) {
+		return utils.NewNotFoundError(id)
+	}
+	return err
+}
@@ -0,0 +1,565 @@
+package cinder
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cinderclient "github.com/emccode/libstorage/drivers/storage/cinder/client"
+)
+
+const (
+	testUserName    = "libstorage"
+	testPassword    = "secret"
+	testProjectName = "libstorage"
+	testProjectID   = "0123456789abcdef0123456789abcdef"
+	testRegion      = "RegionOne"
+	testServerID    = "8a5d1f2c-0d3b-4b51-9b5e-1c2d3e4f5a6b"
+	testServerName  = "node1"
+	testOtherServer = "5e4f3a2b-1c0d-4e9f-8a7b-6c5d4e3f2a1b"
+	testAZ          = "nova"
+
+	// testPageSize is the number of resources in each page of a list, which
+	// is small so that the driver follows the links to the next pages.
+	testPageSize = 2
+)
+
+// openStack is a stand-in for the Keystone, Cinder, and Nova APIs and the
+// metadata service of one instance. Volumes that are attached to the
+// instance are linked in a fake /dev/disk/by-id as virtio disks.
+//
+// Resources reach their next state one get after they change, so the driver
+// waits on them as it does on OpenStack.
+type openStack struct {
+	sync.Mutex
+	*httptest.Server
+
+	diskDir string
+	devDir  string
+
+	token     string
+	authCount int
+	lastID    int
+
+	vols      map[string]*cinderclient.Volume
+	snaps     map[string]*cinderclient.Snapshot
+	servers   map[string]*cinderclient.Server
+	detaching map[string]string
+}
+
+func newOpenStack(diskDir, devDir string) *openStack {
+	s := &openStack{
+		diskDir:   diskDir,
+		devDir:    devDir,
+		vols:      map[string]*cinderclient.Volume{},
+		snaps:     map[string]*cinderclient.Snapshot{},
+		detaching: map[string]string{},
+		servers: map[string]*cinderclient.Server{
+			testServerID: {
+				ID:               testServerID,
+				Name:             testServerName,
+				Status:           "ACTIVE",
+				AvailabilityZone: testAZ,
+			},
+			testOtherServer: {
+				ID:               testOtherServer,
+				Name:             "node2",
+				Status:           "ACTIVE",
+				AvailabilityZone: testAZ,
+			},
+		},
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *openStack) newID() string {
+	s.lastID++
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", s.lastID, s.lastID)
+}
+
+// expireToken makes the token invalid so that the driver must authenticate
+// again.
+func (s *openStack) expireToken() {
+	s.Lock()
+	defer s.Unlock()
+	s.token = "expired"
+}
+
+// attach attaches a volume to a server as if another client had.
+func (s *openStack) attach(volumeID, serverID string) {
+	s.Lock()
+	defer s.Unlock()
+	v := s.vols[volumeID]
+	v.Status = "in-use"
+	v.Attachments = append(v.Attachments, &cinderclient.Attachment{
+		ID:       s.newID(),
+		VolumeID: volumeID,
+		ServerID: serverID,
+		Device:   "/dev/vdc",
+	})
+}
+
+func (s *openStack) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	p := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.URL.Path == "/openstack/latest/meta_data.json":
+		writeJSON(w, http.StatusOK, &cinderclient.InstanceMetadata{
+			UUID:             testServerID,
+			Name:             testServerName,
+			AvailabilityZone: testAZ,
+		})
+		return
+
+	case r.Method == "POST" && r.URL.Path == "/identity/v3/auth/tokens":
+		s.authenticate(w, r)
+		return
+	}
+
+	if s.token == "" || r.Header.Get("X-Auth-Token") != s.token {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	switch {
+	case len(p) > 3 && p[0] == "volume" && p[2] == testProjectID:
+		s.serveVolume(w, r, p[3:])
+	case len(p) > 2 && p[0] == "compute":
+		s.serveCompute(w, r, p[2:])
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *openStack) authenticate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Auth struct {
+			Identity struct {
+				Password struct {
+					User struct {
+						Name     string `json:"name"`
+						Password string `json:"password"`
+						Domain   struct {
+							Name string `json:"name"`
+						} `json:"domain"`
+					} `json:"user"`
+				} `json:"password"`
+			} `json:"identity"`
+			Scope struct {
+				Project struct {
+					Name string `json:"name"`
+				} `json:"project"`
+			} `json:"scope"`
+		} `json:"auth"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	user := req.Auth.Identity.Password.User
+	if user.Name != testUserName || user.Password != testPassword ||
+		user.Domain.Name != "Default" ||
+		req.Auth.Scope.Project.Name != testProjectName {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	s.authCount++
+	s.token = fmt.Sprintf("token-%d", s.authCount)
+
+	type endpoint struct {
+		Interface string `json:"interface"`
+		Region    string `json:"region"`
+		URL       string `json:"url"`
+	}
+	type service struct {
+		Type      string     `json:"type"`
+		Endpoints []endpoint `json:"endpoints"`
+	}
+	res := map[string]interface{}{
+		"token": map[string]interface{}{
+			"expires_at": time.Now().Add(time.Hour).UTC(),
+			"catalog": []service{
+				{"volumev3", []endpoint{
+					{"internal", testRegion, "http://internal.invalid"},
+					{"public", "RegionTwo", "http://region2.invalid"},
+					{"public", testRegion,
+						s.URL + "/volume/v3/" + testProjectID},
+				}},
+				{"compute", []endpoint{
+					{"public", testRegion, s.URL + "/compute/v2.1/"},
+				}},
+			},
+		},
+	}
+
+	w.Header().Set("X-Subject-Token", s.token)
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *openStack) serveVolume(
+	w http.ResponseWriter, r *http.Request, p []string) {
+
+	switch {
+
+	case r.Method == "GET" && len(p) == 2 && p[0] == "volumes" &&
+		p[1] == "detail":
+		ids := []string{}
+		for id := range s.vols {
+			ids = append(ids, id)
+		}
+		items := []interface{}{}
+		page, next := s.page(r, ids, "/volumes/detail")
+		for _, id := range page {
+			items = append(items, s.getVolume(id))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"volumes": items, "volumes_links": next})
+
+	case r.Method == "GET" && len(p) == 2 && p[0] == "volumes":
+		if _, ok := s.vols[p[1]]; !ok {
+			writeNotFound(w, "volume", p[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"volume": s.getVolume(p[1])})
+
+	case r.Method == "POST" && len(p) == 1 && p[0] == "volumes":
+		var req struct {
+			Volume *cinderclient.CreateVolumeOpts `json:"volume"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		o := req.Volume
+		v := &cinderclient.Volume{
+			ID:               s.newID(),
+			Name:             o.Name,
+			Size:             o.Size,
+			Status:           "creating",
+			AvailabilityZone: o.AvailabilityZone,
+			VolumeType:       o.VolumeType,
+			SnapshotID:       o.SnapshotID,
+			SourceVolID:      o.SourceVolID,
+			Metadata:         o.Metadata,
+			Attachments:      []*cinderclient.Attachment{},
+			CreatedAt:        time.Now().UTC().Format("2006-01-02T15:04:05.000000"),
+		}
+		if v.AvailabilityZone == "" {
+			v.AvailabilityZone = testAZ
+		}
+		if v.VolumeType == "" {
+			v.VolumeType = "__DEFAULT__"
+		}
+		if v.SnapshotID != "" {
+			snap, ok := s.snaps[v.SnapshotID]
+			if !ok {
+				writeNotFound(w, "snapshot", v.SnapshotID)
+				return
+			}
+			if v.Size == 0 {
+				v.Size = snap.Size
+			}
+		}
+		if v.SourceVolID != "" {
+			src, ok := s.vols[v.SourceVolID]
+			if !ok {
+				writeNotFound(w, "volume", v.SourceVolID)
+				return
+			}
+			if v.Size == 0 {
+				v.Size = src.Size
+			}
+		}
+		if v.Size == 0 {
+			writeError(w, http.StatusBadRequest, "size required")
+			return
+		}
+		s.vols[v.ID] = v
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"volume": v})
+
+	case r.Method == "DELETE" && len(p) == 2 && p[0] == "volumes":
+		v, ok := s.vols[p[1]]
+		if !ok {
+			writeNotFound(w, "volume", p[1])
+			return
+		}
+		if len(v.Attachments) > 0 {
+			writeError(w, http.StatusBadRequest, "volume is attached")
+			return
+		}
+		delete(s.vols, p[1])
+		w.WriteHeader(http.StatusAccepted)
+
+	case r.Method == "POST" && len(p) == 3 && p[0] == "volumes" &&
+		p[2] == "action":
+		v, ok := s.vols[p[1]]
+		if !ok {
+			writeNotFound(w, "volume", p[1])
+			return
+		}
+		var req struct {
+			Extend *struct {
+				NewSize int64 `json:"new_size"`
+			} `json:"os-extend"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil ||
+			req.Extend == nil || req.Extend.NewSize <= v.Size {
+			writeError(w, http.StatusBadRequest, "invalid action")
+			return
+		}
+		v.Status = "extending"
+		v.Size = req.Extend.NewSize
+		w.WriteHeader(http.StatusAccepted)
+
+	case r.Method == "GET" && len(p) == 2 && p[0] == "snapshots" &&
+		p[1] == "detail":
+		ids := []string{}
+		for id := range s.snaps {
+			ids = append(ids, id)
+		}
+		items := []interface{}{}
+		page, next := s.page(r, ids, "/snapshots/detail")
+		for _, id := range page {
+			items = append(items, s.getSnapshot(id))
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"snapshots": items, "snapshots_links": next})
+
+	case r.Method == "GET" && len(p) == 2 && p[0] == "snapshots":
+		if _, ok := s.snaps[p[1]]; !ok {
+			writeNotFound(w, "snapshot", p[1])
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"snapshot": s.getSnapshot(p[1])})
+
+	case r.Method == "POST" && len(p) == 1 && p[0] == "snapshots":
+		var req struct {
+			Snapshot *cinderclient.CreateSnapshotOpts `json:"snapshot"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		o := req.Snapshot
+		v, ok := s.vols[o.VolumeID]
+		if !ok {
+			writeNotFound(w, "volume", o.VolumeID)
+			return
+		}
+		if v.Status != "available" && !o.Force {
+			writeError(w, http.StatusBadRequest, "volume is in use")
+			return
+		}
+		snap := &cinderclient.Snapshot{
+			ID:          s.newID(),
+			Name:        o.Name,
+			Description: o.Description,
+			VolumeID:    v.ID,
+			Size:        v.Size,
+			Status:      "creating",
+			Metadata:    o.Metadata,
+			CreatedAt:   time.Now().UTC().Format("2006-01-02T15:04:05.000000"),
+		}
+		s.snaps[snap.ID] = snap
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"snapshot": snap})
+
+	case r.Method == "DELETE" && len(p) == 2 && p[0] == "snapshots":
+		if _, ok := s.snaps[p[1]]; !ok {
+			writeNotFound(w, "snapshot", p[1])
+			return
+		}
+		delete(s.snaps, p[1])
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *openStack) serveCompute(
+	w http.ResponseWriter, r *http.Request, p []string) {
+
+	if len(p) < 2 || p[0] != "servers" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	srv, ok := s.servers[p[1]]
+	if !ok {
+		writeNotFound(w, "server", p[1])
+		return
+	}
+
+	switch {
+
+	case r.Method == "GET" && len(p) == 2:
+		writeJSON(w, http.StatusOK, map[string]interface{}{"server": srv})
+
+	case r.Method == "POST" && len(p) == 3 &&
+		p[2] == "os-volume_attachments":
+		var req struct {
+			Attachment *cinderclient.VolumeAttachment `json:"volumeAttachment"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		v, ok := s.vols[req.Attachment.VolumeID]
+		if !ok {
+			writeNotFound(w, "volume", req.Attachment.VolumeID)
+			return
+		}
+		if v.Status != "available" {
+			writeError(w, http.StatusBadRequest, "volume is not available")
+			return
+		}
+		att := &cinderclient.Attachment{
+			ID:       s.newID(),
+			VolumeID: v.ID,
+			ServerID: srv.ID,
+			Device:   "/dev/vdb",
+		}
+		v.Status = "attaching"
+		v.Attachments = append(v.Attachments, att)
+		if srv.ID == testServerID {
+			s.linkDisk(v.ID)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"volumeAttachment": &cinderclient.VolumeAttachment{
+				ID:       v.ID,
+				ServerID: srv.ID,
+				VolumeID: v.ID,
+				Device:   att.Device,
+			}})
+
+	case r.Method == "DELETE" && len(p) == 4 &&
+		p[2] == "os-volume_attachments":
+		v, ok := s.vols[p[3]]
+		if !ok {
+			writeNotFound(w, "volume", p[3])
+			return
+		}
+		for _, att := range v.Attachments {
+			if att.ServerID == srv.ID {
+				v.Status = "detaching"
+				s.detaching[v.ID] = srv.ID
+				if srv.ID == testServerID {
+					os.Remove(path.Join(s.diskDir, diskName(v.ID)))
+				}
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+		}
+		writeNotFound(w, "attachment", p[3])
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// page returns the IDs in a page of a list and the links to the next page.
+func (s *openStack) page(
+	r *http.Request, ids []string, listPath string) ([]string, []interface{}) {
+
+	sort.Strings(ids)
+	marker := r.URL.Query().Get("marker")
+	start := 0
+	if marker != "" {
+		start = sort.SearchStrings(ids, marker) + 1
+	}
+	end := start + testPageSize
+	if end >= len(ids) {
+		return ids[start:], []interface{}{}
+	}
+	return ids[start:end], []interface{}{map[string]string{
+		"rel": "next",
+		"href": fmt.Sprintf("%s/volume/v3/%s%s?limit=%d&marker=%s",
+			s.URL, testProjectID, listPath, testPageSize, ids[end-1]),
+	}}
+}
+
+// getVolume advances a volume to its next state and returns it.
+func (s *openStack) getVolume(id string) *cinderclient.Volume {
+	v := s.vols[id]
+	switch v.Status {
+	case "creating", "extending":
+		v.Status = "available"
+	case "attaching":
+		v.Status = "in-use"
+	case "detaching":
+		atts := []*cinderclient.Attachment{}
+		for _, att := range v.Attachments {
+			if att.ServerID != s.detaching[id] {
+				atts = append(atts, att)
+			}
+		}
+		delete(s.detaching, id)
+		v.Attachments = atts
+		v.Status = "in-use"
+		if len(atts) == 0 {
+			v.Status = "available"
+		}
+	}
+	return v
+}
+
+// getSnapshot advances a snapshot to its next state and returns it.
+func (s *openStack) getSnapshot(id string) *cinderclient.Snapshot {
+	snap := s.snaps[id]
+	if snap.Status == "creating" {
+		snap.Status = "available"
+	}
+	return snap
+}
+
+// linkDisk creates a device for a volume and links it in the fake
+// /dev/disk/by-id with the name that udev gives a virtio disk.
+func (s *openStack) linkDisk(volumeID string) {
+	dev := path.Join(s.devDir, "vd"+volumeID[:8])
+	f, err := os.Create(dev)
+	if err != nil {
+		return
+	}
+	f.Close()
+	os.Symlink(dev, path.Join(s.diskDir, diskName(volumeID)))
+}
+
+func diskName(volumeID string) string {
+	return "virtio-" + volumeID[:20]
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeNotFound(w http.ResponseWriter, kind, id string) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{
+		"itemNotFound": map[string]interface{}{
+			"message": fmt.Sprintf("%s %s could not be found.", kind, id),
+			"code":    http.StatusNotFound,
+		}})
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{
+			"message": msg,
+			"code":    status,
+		}})
+}
@@ -0,0 +1,328 @@
+package cinder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+
+	// load the driver
+	"github.com/emccode/libstorage/drivers/storage/cinder"
+	_ "github.com/emccode/libstorage/drivers/storage/cinder/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/cinder/storage"
+)
+
+var (
+	testDirs     []string
+	testDirsLock = &sync.Mutex{}
+)
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	for _, d := range testDirs {
+		os.RemoveAll(d)
+	}
+	os.Exit(ec)
+}
+
+func newTestDir(t *testing.T) string {
+	d, err := ioutil.TempDir("", "libstorage-cinder")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	testDirsLock.Lock()
+	defer testDirsLock.Unlock()
+	testDirs = append(testDirs, d)
+	return d
+}
+
+// newTestConfig returns a new OpenStack stand-in and the configuration of a
+// driver that uses it, and the path to the driver's config drive, which is
+// not mounted.
+func newTestConfig(t *testing.T) (*openStack, []byte, string) {
+	d := newTestDir(t)
+	diskDir := path.Join(d, "by-id")
+	devDir := path.Join(d, "dev")
+	for _, p := range []string{diskDir, devDir} {
+		if !assert.NoError(t, os.Mkdir(p, 0755)) {
+			t.FailNow()
+		}
+	}
+
+	s := newOpenStack(diskDir, devDir)
+
+	configDrive := path.Join(d, "config")
+	return s, []byte(fmt.Sprintf(`
+cinder:
+  authURL:         %[1]s/identity/v3
+  userName:        %[2]s
+  password:        %[3]s
+  projectName:     %[4]s
+  region:          %[5]s
+  metadataURL:     %[1]s
+  configDrivePath: %[6]s
+  diskByIDPath:    %[7]s
+  statusInterval:  10ms
+  statusTimeout:   10s
+`, s.URL, testUserName, testPassword, testProjectName, testRegion,
+		configDrive, diskDir)), configDrive
+}
+
+func TestServices(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		if assert.Contains(t, reply, cinder.Name) {
+			assert.Equal(t, types.Block, reply[cinder.Name].Driver.Type)
+			assert.True(t, reply[cinder.Name].Driver.NextDevice.Ignore)
+		}
+	}
+	apitests.Run(t, cinder.Name, tc, tf)
+}
+
+func TestInstanceID(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, cinder.Name)
+		iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+		if assert.NoError(t, err) {
+			assert.Equal(t, testServerID, iid.ID)
+		}
+	}
+	apitests.Run(t, cinder.Name, tc, tf)
+}
+
+func TestInstanceIDConfigDrive(t *testing.T) {
+	s, tc, configDrive := newTestConfig(t)
+	defer s.Close()
+
+	mdDir := path.Join(configDrive, "openstack", "latest")
+	assert.NoError(t, os.MkdirAll(mdDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(path.Join(mdDir, "meta_data.json"),
+		[]byte(`{"uuid":"config-drive-uuid","name":"node1"}`), 0644))
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, cinder.Name)
+		iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "config-drive-uuid", iid.ID)
+		}
+	}
+	apitests.Run(t, cinder.Name, tc, tf)
+}
+
+func TestVolumeLifecycle(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(2)
+		volType := "ssd"
+		vol, err := client.API().VolumeCreate(
+			nil, cinder.Name, &types.VolumeCreateRequest{
+				Name: "vol1",
+				Size: &size,
+				Type: &volType,
+				Opts: map[string]interface{}{"owner": "libstorage"},
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "vol1", vol.Name)
+		assert.Equal(t, size, vol.Size)
+		assert.Equal(t, volType, vol.Type)
+		assert.Equal(t, testAZ, vol.AvailabilityZone)
+		assert.Equal(t, "available", vol.Status)
+		assert.Equal(t, "libstorage", vol.Fields["owner"])
+
+		az := "zone2"
+		vol2, err := client.API().VolumeCreate(
+			nil, cinder.Name, &types.VolumeCreateRequest{
+				Name:             "vol2",
+				AvailabilityZone: &az,
+			})
+		if assert.NoError(t, err) {
+			assert.Equal(t, az, vol2.AvailabilityZone)
+			assert.Equal(t, int64(1), vol2.Size)
+		}
+
+		resized, err := client.API().VolumeResize(
+			nil, cinder.Name, vol.ID, &types.VolumeResizeRequest{Size: 4})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), resized.Size)
+			assert.Equal(t, "available", resized.Status)
+		}
+		_, err = client.API().VolumeResize(
+			nil, cinder.Name, vol.ID, &types.VolumeResizeRequest{Size: 1})
+		assert.Error(t, err)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, cinder.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "snap1", snap.Name)
+		assert.Equal(t, vol.ID, snap.VolumeID)
+		assert.Equal(t, int64(4), snap.VolumeSize)
+		assert.Equal(t, "available", snap.Status)
+		assert.InDelta(t, time.Now().Unix(), snap.StartTime, 60)
+
+		vol3, err := client.API().VolumeCreateFromSnapshot(
+			nil, cinder.Name, snap.ID,
+			&types.VolumeCreateRequest{Name: "vol3"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol3", vol3.Name)
+			assert.Equal(t, int64(4), vol3.Size)
+		}
+
+		vol4, err := client.API().VolumeCopy(
+			nil, cinder.Name, vol.ID,
+			&types.VolumeCopyRequest{VolumeName: "vol4"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol4", vol4.Name)
+			assert.Equal(t, int64(4), vol4.Size)
+			assert.Equal(t, volType, vol4.Type)
+			assert.Equal(t, "libstorage", vol4.Fields["owner"])
+		}
+
+		_, err = client.API().SnapshotCopy(
+			nil, cinder.Name, snap.ID,
+			&types.SnapshotCopyRequest{SnapshotName: "snap2"})
+		assert.Error(t, err)
+
+		// the token expires and the driver authenticates again
+		authCount := s.authCount
+		s.expireToken()
+
+		vols, err := client.API().VolumesByService(nil, cinder.Name, false)
+		if assert.NoError(t, err) {
+			assert.Len(t, vols, 4)
+		}
+		assert.Equal(t, authCount+1, s.authCount)
+
+		snaps, err := client.API().SnapshotsByService(nil, cinder.Name)
+		if assert.NoError(t, err) {
+			assert.Len(t, snaps, 1)
+		}
+
+		assert.NoError(t, client.API().SnapshotRemove(
+			nil, cinder.Name, snap.ID))
+		assert.Error(t, client.API().SnapshotRemove(
+			nil, cinder.Name, snap.ID))
+
+		for _, v := range []*types.Volume{vol, vol2, vol3, vol4} {
+			assert.NoError(t, client.API().VolumeRemove(nil, cinder.Name, v.ID))
+		}
+		_, err = client.API().VolumeInspect(nil, cinder.Name, vol.ID, false)
+		assert.Error(t, err)
+		assert.Error(t, client.API().VolumeRemove(nil, cinder.Name, vol.ID))
+	}
+	apitests.Run(t, cinder.Name, tc, tf)
+}
+
+func TestVolumeAttach(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, cinder.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		vol, attTokn, err := client.API().VolumeAttach(
+			nil, cinder.Name, vol.ID, &types.VolumeAttachRequest{})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, cinder.Token(vol.ID), attTokn)
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, testServerID, vol.Attachments[0].InstanceID.ID)
+		}
+
+		// the device appears with the token as its serial number
+		ctx := context.Background().WithValue(context.ServiceKey, cinder.Name)
+		found, ld, err := client.Executor().WaitForDevice(
+			ctx, &types.WaitForDeviceOpts{
+				LocalDevicesOpts: types.LocalDevicesOpts{
+					ScanType: types.DeviceScanQuick,
+					Opts:     utils.NewStore(),
+				},
+				Token:   attTokn,
+				Timeout: 10 * time.Second,
+			})
+		if assert.NoError(t, err) && assert.True(t, found) {
+			dev := path.Join(s.devDir, "vd"+vol.ID[:8])
+			assert.Equal(t, dev, ld.DeviceMap[attTokn])
+
+			// the attachment's device is the local device
+			vol, err = client.API().VolumeInspect(
+				nil, cinder.Name, vol.ID, true)
+			if assert.NoError(t, err) && assert.Len(t, vol.Attachments, 1) {
+				assert.Equal(t, dev, vol.Attachments[0].DeviceName)
+			}
+		}
+
+		_, _, err = client.API().VolumeAttach(
+			nil, cinder.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.Error(t, err)
+
+		vol, err = client.API().VolumeDetach(
+			nil, cinder.Name, vol.ID, &types.VolumeDetachRequest{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "available", vol.Status)
+		}
+
+		vol, err = client.API().VolumeInspect(nil, cinder.Name, vol.ID, true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 0)
+		}
+
+		// a volume that is attached to another instance is only attached
+		// to this instance if the attach is forced
+		s.attach(vol.ID, testOtherServer)
+		_, _, err = client.API().VolumeAttach(
+			nil, cinder.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.Error(t, err)
+
+		vol, _, err = client.API().VolumeAttach(
+			nil, cinder.Name, vol.ID, &types.VolumeAttachRequest{Force: true})
+		if assert.NoError(t, err) && assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, testServerID, vol.Attachments[0].InstanceID.ID)
+		}
+
+		assert.Error(t, client.API().VolumeRemove(nil, cinder.Name, vol.ID))
+		_, err = client.API().VolumeDetach(
+			nil, cinder.Name, vol.ID, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.NoError(t, client.API().VolumeRemove(nil, cinder.Name, vol.ID))
+	}
+	apitests.Run(t, cinder.Name, tc, tf)
+}
+
+func TestConformance(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	rep := (&conformance.Suite{
+		Driver: cinder.Name,
+		Config: tc,
+	}).Run(t)
+	assert.Equal(t, []string{conformance.CapSnapshotCopy}, rep.Unsupported)
+}
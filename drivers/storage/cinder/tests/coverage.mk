@@ -0,0 +1,2 @@
+CINDER_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/cinder
+TEST_COVERPKG_./drivers/storage/cinder/tests := $(CINDER_COVERPKG),$(CINDER_COVERPKG)/executor
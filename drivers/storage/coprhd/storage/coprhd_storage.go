@@ -149,6 +149,13 @@ func (d *driver) InstanceID(ctx types.Context) (string, error) {
 	return context.InstanceID(ctx)
 }
 
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{}, nil
+}
+
 // InstanceInspect returns an instance.
 func (d *driver) InstanceInspect(
 	ctx types.Context,
@@ -0,0 +1,198 @@
+package client
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// APIVersion is the version of the EC2 API that the client speaks.
+	APIVersion = "2016-11-15"
+
+	service = "ec2"
+)
+
+// Credentials are AWS security credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expiration is the time at which temporary credentials expire, or the
+	// zero time if the credentials do not expire.
+	Expiration time.Time
+}
+
+// CredentialsFunc returns the credentials with which requests are signed.
+type CredentialsFunc func() (*Credentials, error)
+
+// StaticCredentials returns a CredentialsFunc that always returns the same
+// credentials.
+func StaticCredentials(
+	accessKeyID, secretAccessKey, sessionToken string) CredentialsFunc {
+
+	creds := &Credentials{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
+	}
+	return func() (*Credentials, error) {
+		return creds, nil
+	}
+}
+
+// CachedCredentials returns a CredentialsFunc that calls f again only when
+// the credentials that f last returned are about to expire.
+func CachedCredentials(f CredentialsFunc) CredentialsFunc {
+	var (
+		lock  sync.Mutex
+		creds *Credentials
+	)
+	return func() (*Credentials, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if creds != nil && (creds.Expiration.IsZero() ||
+			time.Now().Add(5*time.Minute).Before(creds.Expiration)) {
+			return creds, nil
+		}
+		c, err := f()
+		if err != nil {
+			return nil, err
+		}
+		creds = c
+		return creds, nil
+	}
+}
+
+// Client is a client for the EC2 API of a region.
+type Client struct {
+	endpoint string
+	region   string
+	creds    CredentialsFunc
+	client   *http.Client
+}
+
+// New returns a client for the EC2 API of a region at an endpoint.
+func New(endpoint, region string, creds CredentialsFunc) *Client {
+	return &Client{
+		endpoint: endpoint,
+		region:   region,
+		creds:    creds,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Region returns the region of the client.
+func (c *Client) Region() string {
+	return c.region
+}
+
+// Error is an error that the EC2 API returned.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+// Error returns the error's message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// IsNotFound returns a flag indicating whether or not an error is the error
+// that the EC2 API returns for a volume, snapshot, or instance that does not
+// exist.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	if !ok {
+		return false
+	}
+	switch e.Code {
+	case "InvalidVolume.NotFound",
+		"InvalidSnapshot.NotFound",
+		"InvalidInstanceID.NotFound":
+		return true
+	}
+	return false
+}
+
+type errorResponse struct {
+	Errors []struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Errors>Error"`
+	RequestID string `xml:"RequestID"`
+}
+
+// do sends a request for an action and decodes the response into out.
+func (c *Client) do(action string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("Action", action)
+	params.Set("Version", APIVersion)
+	body := []byte(params.Encode())
+
+	req, err := http.NewRequest("POST", c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(
+		"Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	creds, err := c.creds()
+	if err != nil {
+		return goof.WithError("error getting aws credentials", err)
+	}
+	Sign(req, body, creds, c.region, service, time.Now())
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"action":   action,
+			"endpoint": c.endpoint,
+		}, "error sending ec2 request", err)
+	}
+	defer res.Body.Close()
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode >= 300 {
+		er := &errorResponse{}
+		if err := xml.Unmarshal(buf, er); err != nil || len(er.Errors) == 0 {
+			return &Error{
+				StatusCode: res.StatusCode,
+				Code:       http.StatusText(res.StatusCode),
+				Message:    strings.TrimSpace(string(buf)),
+			}
+		}
+		return &Error{
+			StatusCode: res.StatusCode,
+			Code:       er.Errors[0].Code,
+			Message:    er.Errors[0].Message,
+			RequestID:  er.RequestID,
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := xml.Unmarshal(buf, out); err != nil {
+		return goof.WithFieldE(
+			"action", action, "error decoding ec2 response", err)
+	}
+	return nil
+}
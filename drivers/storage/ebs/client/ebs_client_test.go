@@ -0,0 +1,155 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// the get-vanilla case of the signature version 4 test suite
+	req, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	Sign(req, nil, creds, "us-east-1", "service",
+		time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	exp := "AWS4-HMAC-SHA256 " +
+		"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=" +
+		"5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if act := req.Header.Get("Authorization"); act != exp {
+		t.Fatalf("invalid authorization: %s", act)
+	}
+	if act := req.Header.Get("X-Amz-Date"); act != "20150830T123600Z" {
+		t.Fatalf("invalid date: %s", act)
+	}
+}
+
+func TestSignSessionToken(t *testing.T) {
+	req, err := http.NewRequest("POST", "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+	}
+	Sign(req, nil, creds, "us-east-1", service, time.Now())
+
+	if act := req.Header.Get("X-Amz-Security-Token"); act != "token" {
+		t.Fatalf("invalid security token: %s", act)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"),
+		"SignedHeaders=host;x-amz-date;x-amz-security-token,") {
+		t.Fatal("security token not signed")
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	req, err := http.NewRequest(
+		"GET", "https://example.amazonaws.com/?b=2&a=1+1&a=0&c=%7E%2F", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if act := canonicalQuery(req.URL.Query()); act != "a=0&a=1%201&b=2&c=~%2F" {
+		t.Fatalf("invalid canonical query: %s", act)
+	}
+}
+
+func TestError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if act := r.PostForm.Get("Action"); act != "DeleteVolume" {
+				t.Fatalf("invalid action: %s", act)
+			}
+			if act := r.PostForm.Get("Version"); act != APIVersion {
+				t.Fatalf("invalid version: %s", act)
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Response>
+	<Errors>
+		<Error>
+			<Code>InvalidVolume.NotFound</Code>
+			<Message>The volume 'vol-1' does not exist.</Message>
+		</Error>
+	</Errors>
+	<RequestID>req-1</RequestID>
+</Response>`)
+		}))
+	defer ts.Close()
+
+	c := New(ts.URL, "us-east-1", StaticCredentials("id", "secret", ""))
+	err := c.DeleteVolume("vol-1")
+	if !IsNotFound(err) {
+		t.Fatalf("invalid error: %v", err)
+	}
+	e := err.(*Error)
+	if e.StatusCode != http.StatusBadRequest || e.RequestID != "req-1" {
+		t.Fatalf("invalid error: %+v", e)
+	}
+}
+
+func TestDescribeVolumesPages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+			if r.PostForm.Get("NextToken") == "" {
+				fmt.Fprint(w, `<DescribeVolumesResponse>
+	<volumeSet><item><volumeId>vol-1</volumeId></item></volumeSet>
+	<nextToken>page2</nextToken>
+</DescribeVolumesResponse>`)
+				return
+			}
+			fmt.Fprint(w, `<DescribeVolumesResponse>
+	<volumeSet><item>
+		<volumeId>vol-2</volumeId>
+		<attachmentSet><item><instanceId>i-1</instanceId></item></attachmentSet>
+		<tagSet><item><key>Name</key><value>data</value></item></tagSet>
+	</item></volumeSet>
+</DescribeVolumesResponse>`)
+		}))
+	defer ts.Close()
+
+	c := New(ts.URL, "us-east-1", StaticCredentials("id", "secret", ""))
+	vols, err := c.DescribeVolumes(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vols) != 2 || vols[0].VolumeID != "vol-1" ||
+		vols[1].VolumeID != "vol-2" {
+		t.Fatalf("invalid volumes: %+v", vols)
+	}
+	if len(vols[1].Attachments) != 1 ||
+		vols[1].Attachments[0].InstanceID != "i-1" {
+		t.Fatalf("invalid attachments: %+v", vols[1].Attachments)
+	}
+	if len(vols[1].Tags) != 1 || vols[1].Tags[0].Value != "data" {
+		t.Fatalf("invalid tags: %+v", vols[1].Tags)
+	}
+}
+
+func TestRegionOf(t *testing.T) {
+	if act := RegionOf("us-west-2b"); act != "us-west-2" {
+		t.Fatalf("invalid region: %s", act)
+	}
+	if act := RegionOf(""); act != "" {
+		t.Fatalf("invalid region: %s", act)
+	}
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	metadataTokenPath = "/latest/api/token"
+	metadataPath      = "/latest/meta-data/"
+	metadataTokenTTL  = "21600"
+)
+
+// Metadata is a client for the EC2 instance metadata service. It uses
+// session tokens when the service supports them.
+type Metadata struct {
+	endpoint string
+	client   *http.Client
+}
+
+// InstanceIdentity is the identity of an EC2 instance.
+type InstanceIdentity struct {
+	InstanceID       string `json:"instanceID"`
+	AvailabilityZone string `json:"availabilityZone"`
+	Region           string `json:"region"`
+}
+
+// NewMetadata returns a client for the instance metadata service at an
+// endpoint.
+func NewMetadata(endpoint string) *Metadata {
+	return &Metadata{
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Get returns the value of a metadata item, such as "instance-id".
+func (m *Metadata) Get(item string) (string, error) {
+	req, err := http.NewRequest("GET", m.endpoint+metadataPath+item, nil)
+	if err != nil {
+		return "", err
+	}
+	if token := m.token(); token != "" {
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+	}
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return "", goof.WithFieldE(
+			"item", item, "error getting instance metadata", err)
+	}
+	defer res.Body.Close()
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", goof.WithFields(goof.Fields{
+			"item":   item,
+			"status": res.StatusCode,
+		}, "error getting instance metadata")
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// token returns a session token, or an empty string if the service does not
+// issue session tokens.
+func (m *Metadata) token() string {
+	req, err := http.NewRequest("PUT", m.endpoint+metadataTokenPath, nil)
+	if err != nil {
+		return ""
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", metadataTokenTTL)
+
+	res, err := m.client.Do(req)
+	if err != nil {
+		return ""
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ""
+	}
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(buf))
+}
+
+// InstanceIdentity returns the identity of the instance.
+func (m *Metadata) InstanceIdentity() (*InstanceIdentity, error) {
+	id, err := m.Get("instance-id")
+	if err != nil {
+		return nil, err
+	}
+	az, err := m.Get("placement/availability-zone")
+	if err != nil {
+		return nil, err
+	}
+	return &InstanceIdentity{
+		InstanceID:       id,
+		AvailabilityZone: az,
+		Region:           RegionOf(az),
+	}, nil
+}
+
+// Credentials returns a CredentialsFunc that returns the temporary
+// credentials of the instance's IAM role.
+func (m *Metadata) Credentials() CredentialsFunc {
+	return CachedCredentials(func() (*Credentials, error) {
+		role, err := m.Get("iam/security-credentials/")
+		if err != nil {
+			return nil, err
+		}
+		if i := strings.Index(role, "\n"); i > -1 {
+			role = role[:i]
+		}
+
+		buf, err := m.Get("iam/security-credentials/" + role)
+		if err != nil {
+			return nil, err
+		}
+
+		var res struct {
+			AccessKeyID     string    `json:"AccessKeyId"`
+			SecretAccessKey string    `json:"SecretAccessKey"`
+			Token           string    `json:"Token"`
+			Expiration      time.Time `json:"Expiration"`
+		}
+		if err := json.Unmarshal([]byte(buf), &res); err != nil {
+			return nil, goof.WithFieldE(
+				"role", role, "error decoding role credentials", err)
+		}
+
+		return &Credentials{
+			AccessKeyID:     res.AccessKeyID,
+			SecretAccessKey: res.SecretAccessKey,
+			SessionToken:    res.Token,
+			Expiration:      res.Expiration,
+		}, nil
+	})
+}
+
+// RegionOf returns the region of an availability zone.
+func RegionOf(availabilityZone string) string {
+	if availabilityZone == "" {
+		return ""
+	}
+	return availabilityZone[:len(availabilityZone)-1]
+}
@@ -0,0 +1,324 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Volume is an EBS volume.
+type Volume struct {
+	VolumeID         string        `xml:"volumeId"`
+	Size             int64         `xml:"size"`
+	SnapshotID       string        `xml:"snapshotId"`
+	AvailabilityZone string        `xml:"availabilityZone"`
+	State            string        `xml:"status"`
+	CreateTime       time.Time     `xml:"createTime"`
+	VolumeType       string        `xml:"volumeType"`
+	IOPS             int64         `xml:"iops"`
+	Encrypted        bool          `xml:"encrypted"`
+	KMSKeyID         string        `xml:"kmsKeyId"`
+	Attachments      []*Attachment `xml:"attachmentSet>item"`
+	Tags             []*Tag        `xml:"tagSet>item"`
+}
+
+// Attachment is the attachment of an EBS volume to an instance.
+type Attachment struct {
+	VolumeID   string    `xml:"volumeId"`
+	InstanceID string    `xml:"instanceId"`
+	Device     string    `xml:"device"`
+	State      string    `xml:"status"`
+	AttachTime time.Time `xml:"attachTime"`
+}
+
+// Snapshot is an EBS snapshot.
+type Snapshot struct {
+	SnapshotID  string    `xml:"snapshotId"`
+	VolumeID    string    `xml:"volumeId"`
+	State       string    `xml:"status"`
+	StartTime   time.Time `xml:"startTime"`
+	Progress    string    `xml:"progress"`
+	VolumeSize  int64     `xml:"volumeSize"`
+	Description string    `xml:"description"`
+	Encrypted   bool      `xml:"encrypted"`
+	Tags        []*Tag    `xml:"tagSet>item"`
+}
+
+// Instance is an EC2 instance.
+type Instance struct {
+	InstanceID       string `xml:"instanceId"`
+	AvailabilityZone string `xml:"placement>availabilityZone"`
+	State            string `xml:"instanceState>name"`
+}
+
+// Tag is the tag of an EC2 resource.
+type Tag struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+// Filter is a filter of the resources that a describe action returns.
+type Filter struct {
+	Name   string
+	Values []string
+}
+
+// CreateVolumeOpts are the options for creating a volume.
+type CreateVolumeOpts struct {
+	AvailabilityZone string
+	Size             int64
+	SnapshotID       string
+	VolumeType       string
+	IOPS             int64
+	Encrypted        bool
+	KMSKeyID         string
+}
+
+// DescribeVolumes returns the volumes with the specified IDs, or all volumes
+// if no IDs are specified, that match the filters.
+func (c *Client) DescribeVolumes(
+	volumeIDs []string, filters []*Filter) ([]*Volume, error) {
+
+	params := url.Values{}
+	addList(params, "VolumeId", volumeIDs)
+	addFilters(params, filters)
+
+	vols := []*Volume{}
+	err := pages(func(next string) (string, error) {
+		var res struct {
+			Volumes   []*Volume `xml:"volumeSet>item"`
+			NextToken string    `xml:"nextToken"`
+		}
+		if next != "" {
+			params.Set("NextToken", next)
+		}
+		if err := c.do("DescribeVolumes", params, &res); err != nil {
+			return "", err
+		}
+		vols = append(vols, res.Volumes...)
+		return res.NextToken, nil
+	})
+	return vols, err
+}
+
+// CreateVolume creates a volume.
+func (c *Client) CreateVolume(opts *CreateVolumeOpts) (*Volume, error) {
+	params := url.Values{}
+	params.Set("AvailabilityZone", opts.AvailabilityZone)
+	if opts.Size > 0 {
+		params.Set("Size", strconv.FormatInt(opts.Size, 10))
+	}
+	if opts.SnapshotID != "" {
+		params.Set("SnapshotId", opts.SnapshotID)
+	}
+	if opts.VolumeType != "" {
+		params.Set("VolumeType", opts.VolumeType)
+	}
+	if opts.IOPS > 0 {
+		params.Set("Iops", strconv.FormatInt(opts.IOPS, 10))
+	}
+	if opts.Encrypted {
+		params.Set("Encrypted", "true")
+		if opts.KMSKeyID != "" {
+			params.Set("KmsKeyId", opts.KMSKeyID)
+		}
+	}
+
+	vol := &Volume{}
+	if err := c.do("CreateVolume", params, vol); err != nil {
+		return nil, err
+	}
+	return vol, nil
+}
+
+// DeleteVolume deletes a volume.
+func (c *Client) DeleteVolume(volumeID string) error {
+	params := url.Values{}
+	params.Set("VolumeId", volumeID)
+	return c.do("DeleteVolume", params, nil)
+}
+
+// ModifyVolume changes the size of a volume to the specified number of GiB.
+func (c *Client) ModifyVolume(volumeID string, size int64) error {
+	params := url.Values{}
+	params.Set("VolumeId", volumeID)
+	params.Set("Size", strconv.FormatInt(size, 10))
+	return c.do("ModifyVolume", params, nil)
+}
+
+// AttachVolume attaches a volume to an instance as a device.
+func (c *Client) AttachVolume(
+	volumeID, instanceID, device string) (*Attachment, error) {
+
+	params := url.Values{}
+	params.Set("VolumeId", volumeID)
+	params.Set("InstanceId", instanceID)
+	params.Set("Device", device)
+
+	att := &Attachment{}
+	if err := c.do("AttachVolume", params, att); err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// DetachVolume detaches a volume from an instance. The instance ID may be
+// empty if the volume is attached to only one instance.
+func (c *Client) DetachVolume(
+	volumeID, instanceID string, force bool) (*Attachment, error) {
+
+	params := url.Values{}
+	params.Set("VolumeId", volumeID)
+	if instanceID != "" {
+		params.Set("InstanceId", instanceID)
+	}
+	if force {
+		params.Set("Force", "true")
+	}
+
+	att := &Attachment{}
+	if err := c.do("DetachVolume", params, att); err != nil {
+		return nil, err
+	}
+	return att, nil
+}
+
+// DescribeSnapshots returns the snapshots with the specified IDs that match
+// the filters. If no IDs are specified the snapshots that the account owns
+// are returned.
+func (c *Client) DescribeSnapshots(
+	snapshotIDs []string, filters []*Filter) ([]*Snapshot, error) {
+
+	params := url.Values{}
+	if len(snapshotIDs) == 0 {
+		params.Set("Owner.1", "self")
+	}
+	addList(params, "SnapshotId", snapshotIDs)
+	addFilters(params, filters)
+
+	snaps := []*Snapshot{}
+	err := pages(func(next string) (string, error) {
+		var res struct {
+			Snapshots []*Snapshot `xml:"snapshotSet>item"`
+			NextToken string      `xml:"nextToken"`
+		}
+		if next != "" {
+			params.Set("NextToken", next)
+		}
+		if err := c.do("DescribeSnapshots", params, &res); err != nil {
+			return "", err
+		}
+		snaps = append(snaps, res.Snapshots...)
+		return res.NextToken, nil
+	})
+	return snaps, err
+}
+
+// CreateSnapshot creates a snapshot of a volume.
+func (c *Client) CreateSnapshot(
+	volumeID, description string) (*Snapshot, error) {
+
+	params := url.Values{}
+	params.Set("VolumeId", volumeID)
+	if description != "" {
+		params.Set("Description", description)
+	}
+
+	snap := &Snapshot{}
+	if err := c.do("CreateSnapshot", params, snap); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// CopySnapshot copies a snapshot from a region to the client's region and
+// returns the ID of the copy.
+func (c *Client) CopySnapshot(
+	sourceRegion, sourceSnapshotID, description string) (string, error) {
+
+	params := url.Values{}
+	params.Set("SourceRegion", sourceRegion)
+	params.Set("SourceSnapshotId", sourceSnapshotID)
+	if description != "" {
+		params.Set("Description", description)
+	}
+
+	var res struct {
+		SnapshotID string `xml:"snapshotId"`
+	}
+	if err := c.do("CopySnapshot", params, &res); err != nil {
+		return "", err
+	}
+	return res.SnapshotID, nil
+}
+
+// DeleteSnapshot deletes a snapshot.
+func (c *Client) DeleteSnapshot(snapshotID string) error {
+	params := url.Values{}
+	params.Set("SnapshotId", snapshotID)
+	return c.do("DeleteSnapshot", params, nil)
+}
+
+// CreateTags adds tags to a resource or overwrites the values of its
+// existing tags.
+func (c *Client) CreateTags(resourceID string, tags []*Tag) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	params := url.Values{}
+	params.Set("ResourceId.1", resourceID)
+	for i, t := range tags {
+		params.Set(fmt.Sprintf("Tag.%d.Key", i+1), t.Key)
+		params.Set(fmt.Sprintf("Tag.%d.Value", i+1), t.Value)
+	}
+	return c.do("CreateTags", params, nil)
+}
+
+// DescribeInstances returns the instances with the specified IDs.
+func (c *Client) DescribeInstances(instanceIDs []string) ([]*Instance, error) {
+	params := url.Values{}
+	addList(params, "InstanceId", instanceIDs)
+
+	var res struct {
+		Reservations []struct {
+			Instances []*Instance `xml:"instancesSet>item"`
+		} `xml:"reservationSet>item"`
+	}
+	if err := c.do("DescribeInstances", params, &res); err != nil {
+		return nil, err
+	}
+
+	insts := []*Instance{}
+	for _, r := range res.Reservations {
+		insts = append(insts, r.Instances...)
+	}
+	return insts, nil
+}
+
+// pages calls f with the token of each page of a describe action's results
+// until f returns an empty token for the next page.
+func pages(f func(string) (string, error)) error {
+	next := ""
+	for {
+		var err error
+		if next, err = f(next); err != nil || next == "" {
+			return err
+		}
+	}
+}
+
+func addList(params url.Values, name string, vals []string) {
+	for i, v := range vals {
+		params.Set(fmt.Sprintf("%s.%d", name, i+1), v)
+	}
+}
+
+func addFilters(params url.Values, filters []*Filter) {
+	for i, f := range filters {
+		params.Set(fmt.Sprintf("Filter.%d.Name", i+1), f.Name)
+		for j, v := range f.Values {
+			params.Set(fmt.Sprintf("Filter.%d.Value.%d", i+1, j+1), v)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package ebs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// Name is the name of the storage driver
+	Name = "ebs"
+
+	// NameTag is the key of the tag that holds the name of a volume or
+	// snapshot.
+	NameTag = "Name"
+
+	defaultStatusTimeout  = 10 * time.Minute
+	defaultStatusInterval = 2 * time.Second
+)
+
+func init() {
+	registerConfig()
+}
+
+func registerConfig() {
+	r := apiconfig.NewRegistration("EBS")
+	r.Key(gofig.String, "", "", "", "ebs.accessKey")
+	r.Key(gofig.SecureString, "", "", "", "ebs.secretKey")
+	r.Key(gofig.String, "", "", "", "ebs.region")
+	r.Key(gofig.String, "", "", "", "ebs.endpoint")
+	r.Key(gofig.String, "", "http://169.254.169.254", "", "ebs.metadataURL")
+	r.Key(gofig.String, "", "gp2", "", "ebs.volumeType")
+	r.Key(gofig.Bool, "", false, "", "ebs.encrypted")
+	r.Key(gofig.String, "", "", "", "ebs.kmsKeyID")
+	r.Key(gofig.String, "", "10m", "", "ebs.statusTimeout")
+	r.Key(gofig.String, "", "2s", "", "ebs.statusInterval")
+	r.Key(gofig.String, "", "/sys/block", "", "ebs.sysBlockPath")
+	apiconfig.Register(r)
+}
+
+// AccessKey returns the AWS access key ID.
+func AccessKey(config gofig.Config) string {
+	return config.GetString("ebs.accessKey")
+}
+
+// SecretKey returns the AWS secret access key.
+func SecretKey(config gofig.Config) string {
+	return config.GetString("ebs.secretKey")
+}
+
+// Region returns the AWS region in which the volumes are managed.
+func Region(config gofig.Config) string {
+	return config.GetString("ebs.region")
+}
+
+// Endpoint returns the URL of the EC2 API of a region. A configured endpoint
+// is used for every region.
+func Endpoint(config gofig.Config, region string) string {
+	if ep := config.GetString("ebs.endpoint"); ep != "" {
+		return ep
+	}
+	return fmt.Sprintf("https://ec2.%s.amazonaws.com", region)
+}
+
+// MetadataURL returns the URL of the EC2 instance metadata service.
+func MetadataURL(config gofig.Config) string {
+	return config.GetString("ebs.metadataURL")
+}
+
+// VolumeType returns the type of a volume that is created without a type.
+func VolumeType(config gofig.Config) string {
+	return config.GetString("ebs.volumeType")
+}
+
+// Encrypted returns a flag indicating whether or not new volumes are
+// encrypted.
+func Encrypted(config gofig.Config) bool {
+	return config.GetBool("ebs.encrypted")
+}
+
+// KMSKeyID returns the ID of the KMS key with which new volumes are
+// encrypted, or an empty string for the default EBS key.
+func KMSKeyID(config gofig.Config) string {
+	return config.GetString("ebs.kmsKeyID")
+}
+
+// StatusTimeout returns how long to wait for a volume or snapshot to reach
+// the state that an operation requires.
+func StatusTimeout(config gofig.Config) time.Duration {
+	return duration(config, "ebs.statusTimeout", defaultStatusTimeout)
+}
+
+// StatusInterval returns the interval at which the state of a volume or
+// snapshot is examined while waiting for it to change.
+func StatusInterval(config gofig.Config) time.Duration {
+	return duration(config, "ebs.statusInterval", defaultStatusInterval)
+}
+
+// SysBlockPath returns the path to the directory in which the kernel lists
+// the block devices.
+func SysBlockPath(config gofig.Config) string {
+	return config.GetString("ebs.sysBlockPath")
+}
+
+func duration(config gofig.Config, key string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(config.GetString(key)); err == nil && d > 0 {
+		return d
+	}
+	return def
+}
@@ -0,0 +1,80 @@
+// +build linux
+
+package ebs
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// nvmeAdminCmd is the ioctl that sends an admin command to an NVMe
+	// controller.
+	nvmeAdminCmd = 0xC0484E41
+
+	// nvmeIdentify is the opcode of the identify admin command.
+	nvmeIdentify = 0x06
+
+	nvmeIdentifySize = 4096
+
+	// nvmeVendorOffset is the offset of the vendor specific area of the
+	// identify controller data. EBS stores the name of the device with
+	// which a volume was attached in the first 32 bytes of the area.
+	nvmeVendorOffset = 3072
+	nvmeBdevSize     = 32
+)
+
+type nvmePassthruCmd struct {
+	opcode      uint8
+	flags       uint8
+	rsvd1       uint16
+	nsid        uint32
+	cdw2        uint32
+	cdw3        uint32
+	metadata    uint64
+	addr        uint64
+	metadataLen uint32
+	dataLen     uint32
+	cdw10       uint32
+	cdw11       uint32
+	cdw12       uint32
+	cdw13       uint32
+	cdw14       uint32
+	cdw15       uint32
+	timeoutMS   uint32
+	result      uint32
+}
+
+// NVMeDeviceName returns the name of the device with which the EBS volume
+// that an NVMe device presents was attached, such as "/dev/xvdf".
+func NVMeDeviceName(dev string) (string, error) {
+	f, err := os.Open(dev)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	buf := make([]byte, nvmeIdentifySize)
+	cmd := &nvmePassthruCmd{
+		opcode:  nvmeIdentify,
+		addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		dataLen: nvmeIdentifySize,
+		cdw10:   1,
+	}
+
+	if _, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		f.Fd(),
+		nvmeAdminCmd,
+		uintptr(unsafe.Pointer(cmd))); errno != 0 {
+		return "", goof.WithFieldE(
+			"device", dev, "error identifying nvme controller", errno)
+	}
+
+	bdev := buf[nvmeVendorOffset : nvmeVendorOffset+nvmeBdevSize]
+	return strings.TrimSpace(strings.Trim(string(bdev), "\x00")), nil
+}
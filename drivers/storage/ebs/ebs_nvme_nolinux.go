@@ -0,0 +1,13 @@
+// +build !linux
+
+package ebs
+
+import (
+	"github.com/akutz/goof"
+)
+
+// NVMeDeviceName returns the name of the device with which the EBS volume
+// that an NVMe device presents was attached, such as "/dev/xvdf".
+func NVMeDeviceName(dev string) (string, error) {
+	return "", goof.New("nvme device names require linux")
+}
@@ -0,0 +1,176 @@
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/drivers/storage/ebs"
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+const (
+	// ebsModel is the model of the NVMe devices that present EBS volumes.
+	ebsModel = "Amazon Elastic Block Store"
+
+	devicePrefix = "/dev/xvd"
+)
+
+var (
+	nvmeRX = regexp.MustCompile(`^nvme\d+n\d+$`)
+
+	// deviceLetters are the letters of the devices that EBS volumes are
+	// attached as.
+	deviceLetters = "fghijklmnop"
+)
+
+// driver is the storage executor for the ebs storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(ebs.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return ebs.Name
+}
+
+// InstanceID returns the ID of the EC2 instance from the instance metadata
+// service.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	md := ebsclient.NewMetadata(ebs.MetadataURL(d.config))
+	ident, err := md.InstanceIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	iid := &types.InstanceID{ID: ident.InstanceID, Driver: ebs.Name}
+	if err := iid.MarshalMetadata(ident); err != nil {
+		return nil, err
+	}
+
+	return iid, nil
+}
+
+// NextDevice returns the first device name with which a volume is not
+// attached.
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+
+	devs, err := d.localDevices()
+	if err != nil {
+		return "", err
+	}
+
+	for _, c := range deviceLetters {
+		name := fmt.Sprintf("%s%c", devicePrefix, c)
+		if _, ok := devs[name]; !ok {
+			return name, nil
+		}
+	}
+
+	return "", goof.New("no available device")
+}
+
+// LocalDevices returns a map of the names with which EBS volumes were
+// attached and the paths to their devices. Volumes that NVMe devices present
+// are also mapped by their IDs.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	devs, err := d.localDevices()
+	if err != nil {
+		return nil, err
+	}
+	return &types.LocalDevices{Driver: ebs.Name, DeviceMap: devs}, nil
+}
+
+func (d *driver) localDevices() (map[string]string, error) {
+	sysBlock := ebs.SysBlockPath(d.config)
+
+	devs := map[string]string{}
+
+	fis, err := ioutil.ReadDir(sysBlock)
+	if err != nil {
+		// there are no block devices to read on systems without sysfs
+		if os.IsNotExist(err) {
+			return devs, nil
+		}
+		return nil, err
+	}
+
+	for _, fi := range fis {
+		name := fi.Name()
+		dev := path.Join("/dev", name)
+
+		switch {
+		case strings.HasPrefix(name, "xvd"), strings.HasPrefix(name, "sd"):
+			devs[DeviceName(name)] = dev
+
+		case nvmeRX.MatchString(name):
+			devDir := path.Join(sysBlock, name, "device")
+			if readAttr(devDir, "model") != ebsModel {
+				continue
+			}
+			if serial := readAttr(devDir, "serial"); serial != "" {
+				devs[VolumeID(serial)] = dev
+			}
+			if bdev, err := ebs.NVMeDeviceName(dev); err == nil && bdev != "" {
+				devs[DeviceName(bdev)] = dev
+			}
+		}
+	}
+
+	return devs, nil
+}
+
+// DeviceName returns the name of a device as the xvd device name with which
+// EBS attaches it, such as "/dev/xvdf" for "sdf".
+func DeviceName(name string) string {
+	name = strings.TrimPrefix(name, "/dev/")
+	if strings.HasPrefix(name, "sd") {
+		name = "xvd" + name[2:]
+	}
+	return "/dev/" + name
+}
+
+// VolumeID returns the ID of the EBS volume that an NVMe device with a
+// serial number, such as "vol0123456789abcdef0", presents.
+func VolumeID(serial string) string {
+	if strings.HasPrefix(serial, "vol") && !strings.HasPrefix(serial, "vol-") {
+		return "vol-" + serial[3:]
+	}
+	return serial
+}
+
+func readAttr(dir, name string) string {
+	buf, err := ioutil.ReadFile(path.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(buf))
+}
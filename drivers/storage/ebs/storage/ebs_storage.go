@@ -0,0 +1,828 @@
+package storage
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
+	"github.com/emccode/libstorage/drivers/storage/ebs"
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+const (
+	// defaultSize is the size, in GiB, of a volume that is created without
+	// a size.
+	defaultSize = 1
+
+	volumeAvailable  = "available"
+	volumeInUse      = "in-use"
+	attachAttached   = "attached"
+	snapshotComplete = "completed"
+	snapshotError    = "error"
+)
+
+// driver is the storage driver for AWS EBS volumes. A volume's name is the
+// value of its Name tag, and its other tags are its fields.
+type driver struct {
+	sync.Mutex
+	config   gofig.Config
+	region   string
+	creds    ebsclient.CredentialsFunc
+	client   *ebsclient.Client
+	timeout  time.Duration
+	interval time.Duration
+}
+
+func init() {
+	registry.RegisterStorageDriver(ebs.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return ebs.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.region = ebs.Region(config)
+	d.timeout = ebs.StatusTimeout(config)
+	d.interval = ebs.StatusInterval(config)
+
+	md := ebsclient.NewMetadata(ebs.MetadataURL(config))
+
+	if d.region == "" {
+		// the server may run on an instance in the region it manages
+		if az, err := md.Get("placement/availability-zone"); err == nil {
+			d.region = ebsclient.RegionOf(az)
+		}
+	}
+
+	fields := log.Fields{
+		"region":   d.region,
+		"endpoint": ebs.Endpoint(config, d.region),
+	}
+
+	if d.region == "" {
+		return goof.WithFields(fields, "ebs region required")
+	}
+
+	switch {
+	case ebs.AccessKey(config) != "":
+		fields["credentials"] = "config"
+		d.creds = ebsclient.StaticCredentials(
+			ebs.AccessKey(config), ebs.SecretKey(config), "")
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "":
+		fields["credentials"] = "environment"
+		d.creds = ebsclient.StaticCredentials(
+			os.Getenv("AWS_ACCESS_KEY_ID"),
+			os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			os.Getenv("AWS_SESSION_TOKEN"))
+	default:
+		fields["credentials"] = "instance role"
+		d.creds = md.Credentials()
+	}
+
+	d.client = d.regionClient(d.region)
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return &types.NextDeviceInfo{
+		Ignore:  false,
+		Prefix:  "xvd",
+		Pattern: "[f-p]",
+	}, nil
+}
+
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots:   true,
+		Copy:        true,
+		Resize:      true,
+		IOPS:        true,
+		VolumeTypes: []string{"standard", "gp2", "io1", "st1", "sc1"},
+		MaxSize:     16384,
+	}, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	if iid.ID != "" {
+		return &types.Instance{
+			InstanceID: iid,
+			Region:     d.region,
+		}, nil
+	}
+
+	ident := &ebsclient.InstanceIdentity{}
+	if err := iid.UnmarshalMetadata(ident); err != nil {
+		return nil, err
+	}
+
+	return &types.Instance{
+		InstanceID: &types.InstanceID{
+			ID:     ident.InstanceID,
+			Driver: iid.Driver,
+		},
+		Region: ident.Region,
+	}, nil
+}
+
+// Volumes returns all volumes or a filtered list of volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	ec2vols, err := d.client.DescribeVolumes(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := opts != nil && opts.Attachments
+
+	vols := []*types.Volume{}
+	for _, v := range ec2vols {
+		vol := toVolume(v, attachments)
+		if opts != nil && opts.Filter != nil {
+			ok, err := filters.Match(opts.Filter, vol)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		vols = append(vols, vol)
+	}
+
+	return utils.SortVolumeByID(vols), nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return toVolume(v, opts != nil && opts.Attachments), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	cvo, err := d.createVolumeOpts(ctx, opts, "")
+	if err != nil {
+		return nil, err
+	}
+	if cvo.Size == 0 {
+		cvo.Size = defaultSize
+	}
+
+	return d.createVolume(cvo, tags(name, opts.Opts))
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	snap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the volume is created in the availability zone of the snapshot's
+	// volume if the request does not have an instance
+	az := ""
+	if v, err := d.getVolume(snap.VolumeID); err == nil {
+		az = v.AvailabilityZone
+	}
+
+	cvo, err := d.createVolumeOpts(ctx, opts, az)
+	if err != nil {
+		return nil, err
+	}
+	cvo.SnapshotID = snapshotID
+
+	return d.createVolume(cvo, tags(volumeName, opts.Opts))
+}
+
+// VolumeCopy copies an existing volume and its tags. EBS cannot copy a
+// volume, so the copy is created from a temporary snapshot of the volume.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := d.client.CreateSnapshot(
+		volumeID, "libStorage copy of "+volumeID+" to "+volumeName)
+	if err != nil {
+		return nil, err
+	}
+	defer d.client.DeleteSnapshot(snap.SnapshotID)
+
+	if _, err := d.waitSnapshot(snap.SnapshotID); err != nil {
+		return nil, err
+	}
+
+	vtags := tags(volumeName, opts)
+	for _, t := range v.Tags {
+		if t.Key != ebs.NameTag && !hasTag(vtags, t.Key) {
+			vtags = append(vtags, t)
+		}
+	}
+
+	return d.createVolume(&ebsclient.CreateVolumeOpts{
+		AvailabilityZone: v.AvailabilityZone,
+		SnapshotID:       snap.SnapshotID,
+		Size:             v.Size,
+		VolumeType:       v.VolumeType,
+		IOPS:             iops(v.VolumeType, v.IOPS),
+		Encrypted:        v.Encrypted,
+		KMSKeyID:         v.KMSKeyID,
+	}, vtags)
+}
+
+// VolumeResize grows a volume. EBS cannot shrink a volume.
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Size < v.Size {
+		return nil, goof.WithFields(goof.Fields{
+			"volumeID": volumeID,
+			"size":     v.Size,
+			"newSize":  opts.Size,
+		}, "cannot shrink volume")
+	}
+
+	if opts.Size > v.Size {
+		if err := d.client.ModifyVolume(volumeID, opts.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.VolumeInspect(ctx, volumeID, &types.VolumeInspectOpts{})
+}
+
+// VolumeSnapshot snapshots a volume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if _, err := d.getVolume(volumeID); err != nil {
+		return nil, err
+	}
+
+	snap, err := d.client.CreateSnapshot(volumeID, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.tagSnapshot(d.client, snap.SnapshotID, snapshotName, opts)
+}
+
+// VolumeRemove removes a volume.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	if err := d.client.DeleteVolume(volumeID); err != nil {
+		return notFound(err, volumeID)
+	}
+	return nil
+}
+
+// VolumeAttach attaches a volume to the instance as the next available
+// device. The attach token is the device's name, which is how the executor
+// reports the device.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	if opts.NextDevice == nil || *opts.NextDevice == "" {
+		return nil, "", goof.WithField(
+			"volumeID", volumeID, "next device required")
+	}
+	device := *opts.NextDevice
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	instanceID := inst.InstanceID.ID
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, att := range v.Attachments {
+		fields := goof.Fields{
+			"volumeID":   volumeID,
+			"instanceID": att.InstanceID,
+		}
+		if att.InstanceID == instanceID {
+			return nil, "", goof.WithFields(
+				fields, "volume already attached to instance")
+		}
+		if !opts.Force {
+			return nil, "", goof.WithFields(
+				fields, "volume already attached to another instance")
+		}
+		if _, err := d.client.DetachVolume(
+			volumeID, att.InstanceID, true); err != nil {
+			return nil, "", err
+		}
+	}
+	if len(v.Attachments) > 0 {
+		if _, err := d.waitVolume(volumeID, isAvailable); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if _, err := d.client.AttachVolume(
+		volumeID, instanceID, device); err != nil {
+		return nil, "", err
+	}
+
+	v, err = d.waitVolume(volumeID, func(v *ebsclient.Volume) bool {
+		for _, att := range v.Attachments {
+			if att.InstanceID == instanceID && att.State == attachAttached {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return toVolume(v, true), device, nil
+}
+
+// VolumeDetach detaches a volume from the instance.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	instanceID := inst.InstanceID.ID
+
+	d.Lock()
+	defer d.Unlock()
+
+	v, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	attached := func(v *ebsclient.Volume) bool {
+		for _, att := range v.Attachments {
+			if att.InstanceID == instanceID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if attached(v) {
+		if _, err := d.client.DetachVolume(
+			volumeID, instanceID, opts.Force); err != nil {
+			return nil, err
+		}
+		v, err = d.waitVolume(volumeID, func(v *ebsclient.Volume) bool {
+			return !attached(v)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return toVolume(v, false), nil
+}
+
+// Snapshots returns all snapshots or a filtered list of snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	filter, err := filters.FromStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ec2snaps, err := d.client.DescribeSnapshots(nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []*types.Snapshot{}
+	for _, s := range ec2snaps {
+		snap := toSnapshot(s)
+		if filter != nil {
+			ok, err := filters.Match(filter, snap)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	s, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	return toSnapshot(s), nil
+}
+
+// SnapshotCopy copies an existing snapshot. The destination ID is the region
+// to which the snapshot is copied, or an empty string for the driver's
+// region.
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if _, err := d.getSnapshot(snapshotID); err != nil {
+		return nil, err
+	}
+
+	dst := d.client
+	if destinationID != "" && destinationID != d.region {
+		dst = d.regionClient(destinationID)
+	}
+
+	id, err := dst.CopySnapshot(d.region, snapshotID, snapshotName)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.tagSnapshot(dst, id, snapshotName, opts)
+}
+
+// SnapshotRemove removes a snapshot.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	if err := d.client.DeleteSnapshot(snapshotID); err != nil {
+		return notFound(err, snapshotID)
+	}
+	return nil
+}
+
+func (d *driver) regionClient(region string) *ebsclient.Client {
+	return ebsclient.New(ebs.Endpoint(d.config, region), region, d.creds)
+}
+
+// createVolumeOpts returns the options for creating a volume. A volume is
+// created in the instance's availability zone, or else in the default
+// availability zone, if the options do not have an availability zone.
+func (d *driver) createVolumeOpts(
+	ctx types.Context,
+	opts *types.VolumeCreateOpts,
+	defaultAZ string) (*ebsclient.CreateVolumeOpts, error) {
+
+	cvo := &ebsclient.CreateVolumeOpts{
+		VolumeType: ebs.VolumeType(d.config),
+		Encrypted:  ebs.Encrypted(d.config),
+		KMSKeyID:   ebs.KMSKeyID(d.config),
+	}
+
+	if opts.Size != nil {
+		cvo.Size = *opts.Size
+	}
+	if opts.Type != nil && *opts.Type != "" {
+		cvo.VolumeType = *opts.Type
+	}
+	if opts.IOPS != nil {
+		cvo.IOPS = iops(cvo.VolumeType, *opts.IOPS)
+	}
+
+	if opts.AvailabilityZone != nil && *opts.AvailabilityZone != "" {
+		cvo.AvailabilityZone = *opts.AvailabilityZone
+		return cvo, nil
+	}
+
+	if _, ok := context.InstanceID(ctx); ok {
+		inst, err := d.InstanceInspect(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		insts, err := d.client.DescribeInstances(
+			[]string{inst.InstanceID.ID})
+		if err != nil {
+			return nil, err
+		}
+		if len(insts) > 0 {
+			cvo.AvailabilityZone = insts[0].AvailabilityZone
+			return cvo, nil
+		}
+	}
+
+	if defaultAZ != "" {
+		cvo.AvailabilityZone = defaultAZ
+		return cvo, nil
+	}
+
+	return nil, goof.New("availability zone required")
+}
+
+// createVolume creates a volume, tags it, and waits for it to become
+// available.
+func (d *driver) createVolume(
+	cvo *ebsclient.CreateVolumeOpts,
+	vtags []*ebsclient.Tag) (*types.Volume, error) {
+
+	v, err := d.client.CreateVolume(cvo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.client.CreateTags(v.VolumeID, vtags); err != nil {
+		return nil, err
+	}
+
+	if v, err = d.waitVolume(v.VolumeID, isAvailable); err != nil {
+		return nil, err
+	}
+
+	return toVolume(v, false), nil
+}
+
+// tagSnapshot tags a snapshot with its name and fields and returns it.
+func (d *driver) tagSnapshot(
+	c *ebsclient.Client,
+	snapshotID, name string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if err := c.CreateTags(snapshotID, tags(name, opts)); err != nil {
+		return nil, err
+	}
+
+	snaps, err := c.DescribeSnapshots([]string{snapshotID}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(snaps) == 0 {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+	return toSnapshot(snaps[0]), nil
+}
+
+func (d *driver) getVolume(volumeID string) (*ebsclient.Volume, error) {
+	vols, err := d.client.DescribeVolumes([]string{volumeID}, nil)
+	if err != nil {
+		return nil, notFound(err, volumeID)
+	}
+	if len(vols) == 0 {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	return vols[0], nil
+}
+
+func (d *driver) getSnapshot(snapshotID string) (*ebsclient.Snapshot, error) {
+	snaps, err := d.client.DescribeSnapshots([]string{snapshotID}, nil)
+	if err != nil {
+		return nil, notFound(err, snapshotID)
+	}
+	if len(snaps) == 0 {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+	return snaps[0], nil
+}
+
+// waitVolume waits for a volume to satisfy a condition.
+func (d *driver) waitVolume(
+	volumeID string,
+	cond func(*ebsclient.Volume) bool) (*ebsclient.Volume, error) {
+
+	deadline := time.Now().Add(d.timeout)
+	for {
+		v, err := d.getVolume(volumeID)
+		if err != nil {
+			return nil, err
+		}
+		if cond(v) {
+			return v, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"state":    v.State,
+				"timeout":  d.timeout,
+			}, "timed out waiting for volume")
+		}
+		time.Sleep(d.interval)
+	}
+}
+
+// waitSnapshot waits for a snapshot to complete.
+func (d *driver) waitSnapshot(snapshotID string) (*ebsclient.Snapshot, error) {
+	deadline := time.Now().Add(d.timeout)
+	for {
+		s, err := d.getSnapshot(snapshotID)
+		if err != nil {
+			return nil, err
+		}
+		switch s.State {
+		case snapshotComplete:
+			return s, nil
+		case snapshotError:
+			return nil, goof.WithField(
+				"snapshotID", snapshotID, "snapshot failed")
+		}
+		if time.Now().After(deadline) {
+			return nil, goof.WithFields(goof.Fields{
+				"snapshotID": snapshotID,
+				"state":      s.State,
+				"timeout":    d.timeout,
+			}, "timed out waiting for snapshot")
+		}
+		time.Sleep(d.interval)
+	}
+}
+
+func isAvailable(v *ebsclient.Volume) bool {
+	return v.State == volumeAvailable && len(v.Attachments) == 0
+}
+
+// iops returns the provisioned IOPS of a volume of a type. Only io1 volumes
+// accept provisioned IOPS.
+func iops(volumeType string, n int64) int64 {
+	if volumeType != "io1" {
+		return 0
+	}
+	return n
+}
+
+// notFound returns a not found error if an error is the EC2 error for a
+// missing resource.
+func notFound(err error, id string) error {
+	if ebsclient.IsNotFound(err) {
+		return utils.NewNotFoundError(id)
+	}
+	return err
+}
+
+func tags(name string, opts types.Store) []*ebsclient.Tag {
+	tags := []*ebsclient.Tag{}
+	if name != "" {
+		tags = append(tags, &ebsclient.Tag{Key: ebs.NameTag, Value: name})
+	}
+	if opts == nil {
+		return tags
+	}
+	if customFields := opts.GetStore("opts"); customFields != nil {
+		for _, k := range customFields.Keys() {
+			if k == ebs.NameTag {
+				continue
+			}
+			tags = append(tags, &ebsclient.Tag{
+				Key:   k,
+				Value: customFields.GetString(k),
+			})
+		}
+	}
+	return tags
+}
+
+func hasTag(tags []*ebsclient.Tag, key string) bool {
+	for _, t := range tags {
+		if t.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func toVolume(v *ebsclient.Volume, attachments bool) *types.Volume {
+	vol := &types.Volume{
+		ID:               v.VolumeID,
+		Size:             v.Size,
+		AvailabilityZone: v.AvailabilityZone,
+		IOPS:             v.IOPS,
+		Type:             v.VolumeType,
+		Status:           v.State,
+		Fields:           map[string]string{},
+	}
+
+	for _, t := range v.Tags {
+		if t.Key == ebs.NameTag {
+			vol.Name = t.Value
+			continue
+		}
+		vol.Fields[t.Key] = t.Value
+	}
+
+	if attachments {
+		for _, att := range v.Attachments {
+			vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+				VolumeID: att.VolumeID,
+				InstanceID: &types.InstanceID{
+					ID:     att.InstanceID,
+					Driver: ebs.Name,
+				},
+				DeviceName: att.Device,
+				Status:     att.State,
+			})
+		}
+	}
+
+	return vol
+}
+
+func toSnapshot(s *ebsclient.Snapshot) *types.Snapshot {
+	snap := &types.Snapshot{
+		ID:          s.SnapshotID,
+		VolumeID:    s.VolumeID,
+		VolumeSize:  s.VolumeSize,
+		Description: s.Description,
+		StartTime:   s.StartTime.Unix(),
+		Status:      s.State,
+		Fields:      map[string]string{},
+	}
+
+	for _, t := range s.Tags {
+		if t.Key == ebs.NameTag {
+			snap.Name = t.Value
+			continue
+		}
+		snap.Fields[t.Key] = t.Value
+	}
+
+	return snap
+}
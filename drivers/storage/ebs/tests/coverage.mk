@@ -0,0 +1,2 @@
+EBS_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/ebs
+TEST_COVERPKG_./drivers/storage/ebs/tests := $(EBS_COVERPKG),$(EBS_COVERPKG)/executor
@@ -0,0 +1,518 @@
+package ebs
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+const (
+	testRoleAccessKey = "role-access-key"
+	testRoleSecretKey = "role-secret-key"
+	testMetadataToken = "metadata-token"
+)
+
+// ec2Server is a stand-in for the EC2 API of every region and for the
+// instance metadata service of one instance. It checks that requests are
+// signed with the configured or role credentials.
+//
+// Resources become ready one describe after they change, so the driver
+// waits on them as it does on EC2.
+type ec2Server struct {
+	sync.Mutex
+	*httptest.Server
+
+	instanceID string
+	az         string
+	secretKeys map[string]string
+	regions    map[string]*ec2Region
+	lastID     int
+
+	// accessKeys are the access keys with which requests were signed.
+	accessKeys map[string]bool
+}
+
+type ec2Region struct {
+	vols  map[string]*ebsclient.Volume
+	snaps map[string]*ebsclient.Snapshot
+}
+
+type ec2Error struct {
+	code    string
+	message string
+}
+
+func newEC2Server(instanceID, az string) *ec2Server {
+	s := &ec2Server{
+		instanceID: instanceID,
+		az:         az,
+		secretKeys: map[string]string{
+			testAccessKey:     testSecretKey,
+			testRoleAccessKey: testRoleSecretKey,
+		},
+		regions:    map[string]*ec2Region{},
+		accessKeys: map[string]bool{},
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+func (s *ec2Server) region(name string) *ec2Region {
+	r, ok := s.regions[name]
+	if !ok {
+		r = &ec2Region{
+			vols:  map[string]*ebsclient.Volume{},
+			snaps: map[string]*ebsclient.Snapshot{},
+		}
+		s.regions[name] = r
+	}
+	return r
+}
+
+func (s *ec2Server) newID(prefix string) string {
+	s.lastID++
+	return fmt.Sprintf("%s-%017x", prefix, s.lastID)
+}
+
+// attach attaches a volume to an instance as if another client had.
+func (s *ec2Server) attach(region, volumeID, instanceID string) {
+	s.Lock()
+	defer s.Unlock()
+	v := s.region(region).vols[volumeID]
+	v.State = "in-use"
+	v.Attachments = append(v.Attachments, &ebsclient.Attachment{
+		VolumeID:   volumeID,
+		InstanceID: instanceID,
+		Device:     "/dev/xvdz",
+		State:      "attached",
+	})
+}
+
+func (s *ec2Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/latest/") {
+		s.serveMetadata(w, r)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	region, err := s.verify(r, body)
+	if err != nil {
+		writeError(w, http.StatusForbidden, &ec2Error{
+			"AuthFailure", err.Error()})
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	action := r.PostForm.Get("Action")
+	res, e := s.do(region, action, r.PostForm)
+	if e != nil {
+		writeError(w, http.StatusBadRequest, e)
+		return
+	}
+
+	buf, err := xml.Marshal(res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(buf)
+}
+
+// verify checks the signature of a request and returns the region in its
+// credential scope.
+func (s *ec2Server) verify(r *http.Request, body []byte) (string, error) {
+	auth := r.Header.Get("Authorization")
+	i := strings.Index(auth, "Credential=")
+	if i < 0 {
+		return "", fmt.Errorf("missing credential")
+	}
+	scope := strings.Split(strings.SplitN(auth[i+11:], ",", 2)[0], "/")
+	if len(scope) != 5 {
+		return "", fmt.Errorf("invalid credential scope")
+	}
+	accessKey, region := scope[0], scope[2]
+
+	secretKey, ok := s.secretKeys[accessKey]
+	if !ok {
+		return "", fmt.Errorf("unknown access key %s", accessKey)
+	}
+
+	t, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(
+		r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", r.Header.Get("Content-Type"))
+	ebsclient.Sign(req, body, &ebsclient.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		SessionToken:    r.Header.Get("X-Amz-Security-Token"),
+	}, region, "ec2", t)
+	if req.Header.Get("Authorization") != auth {
+		return "", fmt.Errorf("signature does not match")
+	}
+
+	s.Lock()
+	s.accessKeys[accessKey] = true
+	s.Unlock()
+
+	return region, nil
+}
+
+func (s *ec2Server) serveMetadata(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "PUT" && r.URL.Path == "/latest/api/token" {
+		fmt.Fprint(w, testMetadataToken)
+		return
+	}
+	if r.Header.Get("X-aws-ec2-metadata-token") != testMetadataToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/latest/meta-data/") {
+	case "instance-id":
+		fmt.Fprint(w, s.instanceID)
+	case "placement/availability-zone":
+		fmt.Fprint(w, s.az)
+	case "iam/security-credentials/":
+		fmt.Fprint(w, "libstorage\n")
+	case "iam/security-credentials/libstorage":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"AccessKeyId":     testRoleAccessKey,
+			"SecretAccessKey": testRoleSecretKey,
+			"Token":           "role-token",
+			"Expiration":      time.Now().Add(time.Hour),
+		})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *ec2Server) do(
+	region, action string, form map[string][]string) (interface{}, *ec2Error) {
+
+	get := func(k string) string {
+		if v, ok := form[k]; ok && len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	list := func(prefix string) []string {
+		vals := []string{}
+		for i := 1; get(fmt.Sprintf("%s.%d", prefix, i)) != ""; i++ {
+			vals = append(vals, get(fmt.Sprintf("%s.%d", prefix, i)))
+		}
+		return vals
+	}
+
+	reg := s.region(region)
+	name := xml.Name{Local: action + "Response"}
+
+	switch action {
+
+	case "DescribeVolumes":
+		vols := []*ebsclient.Volume{}
+		ids := list("VolumeId")
+		if len(ids) == 0 {
+			for id := range reg.vols {
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range ids {
+			v, ok := reg.vols[id]
+			if !ok {
+				return nil, volumeNotFound(id)
+			}
+			vols = append(vols, describeVolume(v))
+		}
+		return &struct {
+			XMLName xml.Name
+			Volumes []*ebsclient.Volume `xml:"volumeSet>item"`
+		}{name, vols}, nil
+
+	case "CreateVolume":
+		v := &ebsclient.Volume{
+			VolumeID:         s.newID("vol"),
+			AvailabilityZone: get("AvailabilityZone"),
+			SnapshotID:       get("SnapshotId"),
+			VolumeType:       get("VolumeType"),
+			State:            "creating",
+			CreateTime:       time.Now().UTC(),
+		}
+		v.Size, _ = strconv.ParseInt(get("Size"), 10, 64)
+		v.IOPS, _ = strconv.ParseInt(get("Iops"), 10, 64)
+		if v.AvailabilityZone == "" {
+			return nil, &ec2Error{"MissingParameter", "AvailabilityZone"}
+		}
+		if v.SnapshotID != "" {
+			snap, ok := reg.snaps[v.SnapshotID]
+			if !ok {
+				return nil, snapshotNotFound(v.SnapshotID)
+			}
+			if v.Size == 0 {
+				v.Size = snap.VolumeSize
+			}
+		}
+		reg.vols[v.VolumeID] = v
+		return &struct {
+			XMLName xml.Name
+			*ebsclient.Volume
+		}{name, v}, nil
+
+	case "DeleteVolume":
+		v, ok := reg.vols[get("VolumeId")]
+		if !ok {
+			return nil, volumeNotFound(get("VolumeId"))
+		}
+		if len(v.Attachments) > 0 {
+			return nil, &ec2Error{"VolumeInUse", v.VolumeID}
+		}
+		delete(reg.vols, v.VolumeID)
+
+	case "ModifyVolume":
+		v, ok := reg.vols[get("VolumeId")]
+		if !ok {
+			return nil, volumeNotFound(get("VolumeId"))
+		}
+		v.Size, _ = strconv.ParseInt(get("Size"), 10, 64)
+
+	case "AttachVolume":
+		v, ok := reg.vols[get("VolumeId")]
+		if !ok {
+			return nil, volumeNotFound(get("VolumeId"))
+		}
+		if v.State != "available" {
+			return nil, &ec2Error{"IncorrectState", v.VolumeID}
+		}
+		att := &ebsclient.Attachment{
+			VolumeID:   v.VolumeID,
+			InstanceID: get("InstanceId"),
+			Device:     get("Device"),
+			State:      "attaching",
+		}
+		v.State = "in-use"
+		v.Attachments = append(v.Attachments, att)
+		return &struct {
+			XMLName xml.Name
+			*ebsclient.Attachment
+		}{name, att}, nil
+
+	case "DetachVolume":
+		v, ok := reg.vols[get("VolumeId")]
+		if !ok {
+			return nil, volumeNotFound(get("VolumeId"))
+		}
+		for _, att := range v.Attachments {
+			if att.InstanceID == get("InstanceId") {
+				att.State = "detaching"
+				return &struct {
+					XMLName xml.Name
+					*ebsclient.Attachment
+				}{name, att}, nil
+			}
+		}
+		return nil, &ec2Error{"IncorrectState", v.VolumeID}
+
+	case "DescribeSnapshots":
+		snaps := []*ebsclient.Snapshot{}
+		ids := list("SnapshotId")
+		if len(ids) == 0 {
+			for id := range reg.snaps {
+				ids = append(ids, id)
+			}
+		}
+		for _, id := range ids {
+			snap, ok := reg.snaps[id]
+			if !ok {
+				return nil, snapshotNotFound(id)
+			}
+			snaps = append(snaps, describeSnapshot(snap))
+		}
+		return &struct {
+			XMLName   xml.Name
+			Snapshots []*ebsclient.Snapshot `xml:"snapshotSet>item"`
+		}{name, snaps}, nil
+
+	case "CreateSnapshot":
+		v, ok := reg.vols[get("VolumeId")]
+		if !ok {
+			return nil, volumeNotFound(get("VolumeId"))
+		}
+		snap := &ebsclient.Snapshot{
+			SnapshotID:  s.newID("snap"),
+			VolumeID:    v.VolumeID,
+			VolumeSize:  v.Size,
+			Description: get("Description"),
+			State:       "pending",
+			StartTime:   time.Now().UTC(),
+		}
+		reg.snaps[snap.SnapshotID] = snap
+		return &struct {
+			XMLName xml.Name
+			*ebsclient.Snapshot
+		}{name, snap}, nil
+
+	case "CopySnapshot":
+		src, ok := s.region(get("SourceRegion")).snaps[get("SourceSnapshotId")]
+		if !ok {
+			return nil, snapshotNotFound(get("SourceSnapshotId"))
+		}
+		snap := &ebsclient.Snapshot{
+			SnapshotID:  s.newID("snap"),
+			VolumeID:    src.VolumeID,
+			VolumeSize:  src.VolumeSize,
+			Description: get("Description"),
+			State:       "pending",
+			StartTime:   time.Now().UTC(),
+		}
+		reg.snaps[snap.SnapshotID] = snap
+		return &struct {
+			XMLName    xml.Name
+			SnapshotID string `xml:"snapshotId"`
+		}{name, snap.SnapshotID}, nil
+
+	case "DeleteSnapshot":
+		if _, ok := reg.snaps[get("SnapshotId")]; !ok {
+			return nil, snapshotNotFound(get("SnapshotId"))
+		}
+		delete(reg.snaps, get("SnapshotId"))
+
+	case "CreateTags":
+		id := get("ResourceId.1")
+		var tags *[]*ebsclient.Tag
+		if v, ok := reg.vols[id]; ok {
+			tags = &v.Tags
+		} else if snap, ok := reg.snaps[id]; ok {
+			tags = &snap.Tags
+		} else {
+			return nil, &ec2Error{"InvalidID", id}
+		}
+		for i := 1; get(fmt.Sprintf("Tag.%d.Key", i)) != ""; i++ {
+			*tags = setTag(*tags,
+				get(fmt.Sprintf("Tag.%d.Key", i)),
+				get(fmt.Sprintf("Tag.%d.Value", i)))
+		}
+
+	case "DescribeInstances":
+		for _, id := range list("InstanceId") {
+			if id != s.instanceID {
+				return nil, &ec2Error{"InvalidInstanceID.NotFound", id}
+			}
+		}
+		type reservation struct {
+			Instances []*ebsclient.Instance `xml:"instancesSet>item"`
+		}
+		return &struct {
+			XMLName      xml.Name
+			Reservations []*reservation `xml:"reservationSet>item"`
+		}{name, []*reservation{{[]*ebsclient.Instance{{
+			InstanceID:       s.instanceID,
+			AvailabilityZone: s.az,
+			State:            "running",
+		}}}}}, nil
+
+	default:
+		return nil, &ec2Error{"InvalidAction", action}
+	}
+
+	return &struct {
+		XMLName xml.Name
+		Return  bool `xml:"return"`
+	}{name, true}, nil
+}
+
+// describeVolume advances a volume to its next state and returns a copy of
+// it.
+func describeVolume(v *ebsclient.Volume) *ebsclient.Volume {
+	if v.State == "creating" {
+		v.State = "available"
+	}
+	atts := []*ebsclient.Attachment{}
+	for _, att := range v.Attachments {
+		switch att.State {
+		case "attaching":
+			att.State = "attached"
+		case "detaching":
+			continue
+		}
+		atts = append(atts, att)
+	}
+	v.Attachments = atts
+	if len(atts) == 0 && v.State == "in-use" {
+		v.State = "available"
+	}
+
+	c := *v
+	return &c
+}
+
+// describeSnapshot advances a snapshot to its next state and returns a copy
+// of it.
+func describeSnapshot(s *ebsclient.Snapshot) *ebsclient.Snapshot {
+	if s.State == "pending" {
+		s.State = "completed"
+		s.Progress = "100%"
+	}
+	c := *s
+	return &c
+}
+
+func setTag(tags []*ebsclient.Tag, k, v string) []*ebsclient.Tag {
+	for _, t := range tags {
+		if t.Key == k {
+			t.Value = v
+			return tags
+		}
+	}
+	return append(tags, &ebsclient.Tag{Key: k, Value: v})
+}
+
+func volumeNotFound(id string) *ec2Error {
+	return &ec2Error{
+		"InvalidVolume.NotFound",
+		fmt.Sprintf("The volume '%s' does not exist.", id)}
+}
+
+func snapshotNotFound(id string) *ec2Error {
+	return &ec2Error{
+		"InvalidSnapshot.NotFound",
+		fmt.Sprintf("The snapshot '%s' does not exist.", id)}
+}
+
+func writeError(w http.ResponseWriter, status int, e *ec2Error) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors>`+
+		`<RequestID>%d</RequestID></Response>`,
+		e.code, e.message, time.Now().UnixNano())
+}
@@ -0,0 +1,393 @@
+package ebs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+
+	// load the driver
+	"github.com/emccode/libstorage/drivers/storage/ebs"
+	ebsx "github.com/emccode/libstorage/drivers/storage/ebs/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/ebs/storage"
+)
+
+const (
+	testAccessKey  = "access-key"
+	testSecretKey  = "secret-key"
+	testInstanceID = "i-0123456789abcdef0"
+	testRegion     = "us-east-1"
+	testAZ         = "us-east-1a"
+	testCopyRegion = "us-west-2"
+)
+
+var (
+	testDirs     []string
+	testDirsLock = &sync.Mutex{}
+)
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	for _, d := range testDirs {
+		os.RemoveAll(d)
+	}
+	os.Exit(ec)
+}
+
+// newTestConfig returns a new EC2 stand-in and the configuration of a driver
+// that uses it. The driver's block devices are read from a fake sysfs.
+func newTestConfig(t *testing.T, creds bool) (*ec2Server, []byte) {
+	d, err := ioutil.TempDir("", "libstorage-ebs")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	func() {
+		testDirsLock.Lock()
+		defer testDirsLock.Unlock()
+		testDirs = append(testDirs, d)
+	}()
+
+	// xvda is the root device, sdf was attached as xvdf, and nvme1n1
+	// presents vol-0abc. the NVMe device's attached name cannot be read
+	// from a fake sysfs.
+	sysBlock := path.Join(d, "block")
+	for _, dev := range []string{"xvda", "sdf", "loop0", "nvme0n1"} {
+		assert.NoError(t, os.MkdirAll(path.Join(sysBlock, dev), 0755))
+	}
+	nvme := path.Join(sysBlock, "nvme1n1", "device")
+	assert.NoError(t, os.MkdirAll(nvme, 0755))
+	assert.NoError(t, ioutil.WriteFile(path.Join(nvme, "model"),
+		[]byte("Amazon Elastic Block Store              \n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(path.Join(nvme, "serial"),
+		[]byte("vol0abc\n"), 0644))
+
+	s := newEC2Server(testInstanceID, testAZ)
+
+	config := fmt.Sprintf(`
+ebs:
+  endpoint:       %[1]s
+  metadataURL:    %[1]s
+  statusInterval: 10ms
+  statusTimeout:  10s
+  sysBlockPath:   %[2]s
+`, s.URL, sysBlock)
+	if creds {
+		config += fmt.Sprintf(`
+  region:         %s
+  accessKey:      %s
+  secretKey:      %s
+`, testRegion, testAccessKey, testSecretKey)
+	}
+
+	return s, []byte(config)
+}
+
+func TestServices(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		if assert.Contains(t, reply, ebs.Name) {
+			assert.Equal(t, types.Block, reply[ebs.Name].Driver.Type)
+			assert.Equal(t, "xvd", reply[ebs.Name].Driver.NextDevice.Prefix)
+		}
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestInstanceRoleCredentials(t *testing.T) {
+	s, tc := newTestConfig(t, false)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vols, err := client.API().VolumesByService(nil, ebs.Name, false)
+		assert.NoError(t, err)
+		assert.Len(t, vols, 0)
+		assert.True(t, s.accessKeys[testRoleAccessKey])
+		assert.False(t, s.accessKeys[testAccessKey])
+		assert.Contains(t, s.regions, testRegion)
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestExecutor(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, ebs.Name)
+		iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+		if assert.NoError(t, err) {
+			assert.Equal(t, testInstanceID, iid.ID)
+		}
+
+		ld, err := client.Executor().LocalDevices(
+			ctx, &types.LocalDevicesOpts{
+				ScanType: types.DeviceScanQuick,
+				Opts:     utils.NewStore(),
+			})
+		if assert.NoError(t, err) {
+			assert.Equal(t, map[string]string{
+				"/dev/xvda": "/dev/xvda",
+				"/dev/xvdf": "/dev/sdf",
+				"vol-0abc":  "/dev/nvme1n1",
+			}, ld.DeviceMap)
+		}
+
+		next, err := client.Executor().NextDevice(ctx, utils.NewStore())
+		if assert.NoError(t, err) {
+			assert.Equal(t, "/dev/xvdg", next)
+		}
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestVolumeCreateUnsupported(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		assertUnsupported := func(msg string, size int64, volType string) {
+			_, err := client.API().VolumeCreate(
+				nil, ebs.Name, &types.VolumeCreateRequest{
+					Name: "vol1",
+					Size: &size,
+					Type: &volType,
+				})
+			if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+				assert.Equal(
+					t, http.StatusNotImplemented, httpErr.Status())
+				assert.Equal(t, msg, httpErr.Error())
+			}
+		}
+		assertUnsupported(
+			"service ebs does not support volumeType gp9", 2, "gp9")
+		assertUnsupported(
+			"service ebs does not support maxSize 16385", 16385, "gp2")
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestDeviceNames(t *testing.T) {
+	assert.Equal(t, "/dev/xvdf", ebsx.DeviceName("sdf"))
+	assert.Equal(t, "/dev/xvdf", ebsx.DeviceName("/dev/sdf"))
+	assert.Equal(t, "/dev/xvdg", ebsx.DeviceName("xvdg"))
+	assert.Equal(t, "vol-0abc", ebsx.VolumeID("vol0abc"))
+	assert.Equal(t, "vol-0abc", ebsx.VolumeID("vol-0abc"))
+}
+
+func TestVolumeLifecycle(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(2)
+		vol, err := client.API().VolumeCreate(
+			nil, ebs.Name, &types.VolumeCreateRequest{
+				Name: "vol1",
+				Size: &size,
+				Opts: map[string]interface{}{"owner": "libstorage"},
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "vol1", vol.Name)
+		assert.Equal(t, size, vol.Size)
+		assert.Equal(t, testAZ, vol.AvailabilityZone)
+		assert.Equal(t, "gp2", vol.Type)
+		assert.Equal(t, "available", vol.Status)
+		assert.Equal(t, "libstorage", vol.Fields["owner"])
+
+		resized, err := client.API().VolumeResize(
+			nil, ebs.Name, vol.ID, &types.VolumeResizeRequest{Size: 4})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), resized.Size)
+		}
+		_, err = client.API().VolumeResize(
+			nil, ebs.Name, vol.ID, &types.VolumeResizeRequest{Size: 1})
+		assert.Error(t, err)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "snap1", snap.Name)
+		assert.Equal(t, vol.ID, snap.VolumeID)
+		assert.Equal(t, int64(4), snap.VolumeSize)
+
+		vol2, err := client.API().VolumeCreateFromSnapshot(
+			nil, ebs.Name, snap.ID, &types.VolumeCreateRequest{Name: "vol2"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol2", vol2.Name)
+			assert.Equal(t, int64(4), vol2.Size)
+		}
+
+		// a copy is created from a temporary snapshot that is removed
+		vol3, err := client.API().VolumeCopy(
+			nil, ebs.Name, vol.ID, &types.VolumeCopyRequest{VolumeName: "vol3"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol3", vol3.Name)
+			assert.Equal(t, int64(4), vol3.Size)
+			assert.Equal(t, "libstorage", vol3.Fields["owner"])
+		}
+
+		snaps, err := client.API().SnapshotsByService(nil, ebs.Name)
+		if assert.NoError(t, err) {
+			assert.Len(t, snaps, 1)
+		}
+
+		vols, err := client.API().VolumesByService(nil, ebs.Name, false)
+		if assert.NoError(t, err) {
+			assert.Len(t, vols, 3)
+		}
+
+		assert.NoError(t, client.API().SnapshotRemove(nil, ebs.Name, snap.ID))
+		assert.Error(t, client.API().SnapshotRemove(nil, ebs.Name, snap.ID))
+
+		for _, v := range []*types.Volume{vol, vol2, vol3} {
+			assert.NoError(t, client.API().VolumeRemove(nil, ebs.Name, v.ID))
+		}
+		_, err = client.API().VolumeInspect(nil, ebs.Name, vol.ID, false)
+		assert.Error(t, err)
+		assert.Error(t, client.API().VolumeRemove(nil, ebs.Name, vol.ID))
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestSnapshotCopyRegion(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, ebs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		snapCopy, err := client.API().SnapshotCopy(
+			nil, ebs.Name, snap.ID,
+			&types.SnapshotCopyRequest{
+				SnapshotName:  "snap2",
+				DestinationID: testCopyRegion,
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.NotEqual(t, snap.ID, snapCopy.ID)
+		assert.Equal(t, "snap2", snapCopy.Name)
+		assert.Equal(t, vol.ID, snapCopy.VolumeID)
+
+		// the copy is in the destination region, not the driver's region
+		snaps, err := client.API().SnapshotsByService(nil, ebs.Name)
+		if assert.NoError(t, err) && assert.Len(t, snaps, 1) {
+			assert.Contains(t, snaps, snap.ID)
+		}
+		if assert.Contains(t, s.regions, testCopyRegion) {
+			assert.Contains(t, s.regions[testCopyRegion].snaps, snapCopy.ID)
+		}
+
+		_, err = client.API().SnapshotCopy(
+			nil, ebs.Name, "snap-missing",
+			&types.SnapshotCopyRequest{DestinationID: testCopyRegion})
+		assert.Error(t, err)
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestVolumeAttach(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, ebs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		nextDevice := "/dev/xvdg"
+		vol, attTokn, err := client.API().VolumeAttach(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeAttachRequest{NextDeviceName: &nextDevice})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, nextDevice, attTokn)
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, testInstanceID, vol.Attachments[0].InstanceID.ID)
+			assert.Equal(t, nextDevice, vol.Attachments[0].DeviceName)
+			assert.Equal(t, "attached", vol.Attachments[0].Status)
+		}
+
+		_, _, err = client.API().VolumeAttach(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeAttachRequest{NextDeviceName: &nextDevice})
+		assert.Error(t, err)
+
+		vol, err = client.API().VolumeDetach(
+			nil, ebs.Name, vol.ID, &types.VolumeDetachRequest{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "available", vol.Status)
+		}
+
+		vol, err = client.API().VolumeInspect(nil, ebs.Name, vol.ID, true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 0)
+		}
+
+		// a volume that is attached to another instance is only attached
+		// to this instance if the attach is forced
+		s.attach(testRegion, vol.ID, "i-other")
+		_, _, err = client.API().VolumeAttach(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeAttachRequest{NextDeviceName: &nextDevice})
+		assert.Error(t, err)
+
+		vol, _, err = client.API().VolumeAttach(
+			nil, ebs.Name, vol.ID,
+			&types.VolumeAttachRequest{
+				NextDeviceName: &nextDevice,
+				Force:          true,
+			})
+		if assert.NoError(t, err) && assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, testInstanceID, vol.Attachments[0].InstanceID.ID)
+		}
+
+		assert.Error(t, client.API().VolumeRemove(nil, ebs.Name, vol.ID))
+		_, err = client.API().VolumeDetach(
+			nil, ebs.Name, vol.ID, &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.NoError(t, client.API().VolumeRemove(nil, ebs.Name, vol.ID))
+	}
+	apitests.Run(t, ebs.Name, tc, tf)
+}
+
+func TestConformance(t *testing.T) {
+	s, tc := newTestConfig(t, true)
+	defer s.Close()
+	rep := (&conformance.Suite{
+		Driver: ebs.Name,
+		Config: tc,
+	}).Run(t)
+	assert.Empty(t, rep.Unsupported)
+}
@@ -2,6 +2,8 @@ package isilon
 
 import (
 	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const (
@@ -14,16 +16,16 @@ func init() {
 }
 
 func registerConfig() {
-	r := gofig.NewRegistration("Isilon")
+	r := apiconfig.NewRegistration("Isilon")
 	r.Key(gofig.String, "", "", "", "isilon.endpoint")
 	r.Key(gofig.Bool, "", false, "", "isilon.insecure")
 	r.Key(gofig.String, "", "", "", "isilon.userName")
 	r.Key(gofig.String, "", "", "", "isilon.group")
-	r.Key(gofig.String, "", "", "", "isilon.password")
+	r.Key(gofig.SecureString, "", "", "", "isilon.password")
 	r.Key(gofig.String, "", "", "", "isilon.volumePath")
 	r.Key(gofig.String, "", "", "", "isilon.nfsHost")
 	r.Key(gofig.String, "", "", "", "isilon.dataSubnet")
 	r.Key(gofig.Bool, "", false, "", "isilon.quotas")
 	r.Key(gofig.Bool, "", false, "", "isilon.sharedMounts")
-	gofig.Register(r)
+	apiconfig.Register(r)
 }
@@ -118,6 +118,13 @@ func createInstanceID(clients []string) string {
 	return strings.Join(clients, idDelimiter)
 }
 
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{MultiAttach: d.sharedMounts()}, nil
+}
+
 // InstanceInspect returns an instance.
 func (d *driver) InstanceInspect(
 	ctx types.Context,
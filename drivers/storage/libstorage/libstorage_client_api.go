@@ -65,6 +65,12 @@ func (c *client) ServiceInspect(
 	return c.APIClient.ServiceInspect(ctx, service)
 }
 
+func (c *client) ServiceProfiles(
+	ctx types.Context, service string) (types.VolumeProfileMap, error) {
+
+	return c.APIClient.ServiceProfiles(c.requireCtx(ctx), service)
+}
+
 func (c *client) Volumes(
 	ctx types.Context,
 	attachments bool) (types.ServiceVolumeMap, error) {
@@ -95,6 +101,38 @@ func (c *client) VolumesByService(
 	return c.APIClient.VolumesByService(ctx, service, attachments)
 }
 
+func (c *client) VolumesPage(
+	ctx types.Context,
+	attachments bool,
+	page *types.PageRequest) (types.ServiceVolumeMap, string, error) {
+
+	ctx = c.requireCtx(ctx)
+
+	ctxA, err := c.withAllLocalDevices(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx = c.withAllInstanceIDs(ctxA)
+
+	return c.APIClient.VolumesPage(ctx, attachments, page)
+}
+
+func (c *client) VolumesByServicePage(
+	ctx types.Context,
+	service string,
+	attachments bool,
+	page *types.PageRequest) (types.VolumeMap, string, error) {
+
+	ctx = c.withInstanceID(c.requireCtx(ctx), service)
+	ctxA, err := c.withAllLocalDevices(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	ctx = ctxA
+
+	return c.APIClient.VolumesByServicePage(ctx, service, attachments, page)
+}
+
 func (c *client) VolumeInspect(
 	ctx types.Context,
 	service, volumeID string,
@@ -110,6 +148,21 @@ func (c *client) VolumeInspect(
 	return c.APIClient.VolumeInspect(ctx, service, volumeID, attachments)
 }
 
+func (c *client) VolumeInspectByName(
+	ctx types.Context,
+	service, volumeName string,
+	attachments bool) (*types.Volume, error) {
+
+	ctx = c.withInstanceID(c.requireCtx(ctx), service)
+	ctxA, err := c.withAllLocalDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ctxA
+
+	return c.APIClient.VolumeInspectByName(ctx, service, volumeName, attachments)
+}
+
 func (c *client) VolumeCreate(
 	ctx types.Context,
 	service string,
@@ -209,6 +262,21 @@ func (c *client) VolumeCopy(
 	return vol, nil
 }
 
+func (c *client) VolumeResize(
+	ctx types.Context,
+	service, volumeID string,
+	request *types.VolumeResizeRequest) (*types.Volume, error) {
+
+	ctx = c.withInstanceID(c.requireCtx(ctx), service)
+	ctxA, err := c.withAllLocalDevices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = ctxA
+
+	return c.APIClient.VolumeResize(ctx, service, volumeID, request)
+}
+
 func (c *client) VolumeRemove(
 	ctx types.Context,
 	service, volumeID string) error {
@@ -344,6 +412,23 @@ func (c *client) SnapshotsByService(
 	return c.APIClient.SnapshotsByService(ctx, service)
 }
 
+func (c *client) SnapshotsPage(
+	ctx types.Context,
+	page *types.PageRequest) (types.ServiceSnapshotMap, string, error) {
+
+	ctx = c.requireCtx(ctx)
+	return c.APIClient.SnapshotsPage(ctx, page)
+}
+
+func (c *client) SnapshotsByServicePage(
+	ctx types.Context,
+	service string,
+	page *types.PageRequest) (types.SnapshotMap, string, error) {
+
+	ctx = c.requireCtx(ctx).WithValue(context.ServiceKey, service)
+	return c.APIClient.SnapshotsByServicePage(ctx, service, page)
+}
+
 func (c *client) SnapshotInspect(
 	ctx types.Context,
 	service, snapshotID string) (*types.Snapshot, error) {
@@ -369,6 +454,20 @@ func (c *client) SnapshotCopy(
 	return c.APIClient.SnapshotCopy(ctx, service, snapshotID, request)
 }
 
+func (c *client) Tasks(
+	ctx types.Context) (map[string]*types.Task, error) {
+
+	ctx = c.requireCtx(ctx)
+	return c.APIClient.Tasks(ctx)
+}
+
+func (c *client) TaskInspect(
+	ctx types.Context, taskID int) (*types.Task, error) {
+
+	ctx = c.requireCtx(ctx)
+	return c.APIClient.TaskInspect(ctx, taskID)
+}
+
 func (c *client) Executors(
 	ctx types.Context) (map[string]*types.ExecutorInfo, error) {
 
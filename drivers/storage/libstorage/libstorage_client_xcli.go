@@ -13,6 +13,7 @@ import (
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/tracing"
 )
 
 func (c *client) InstanceID(
@@ -202,7 +203,7 @@ func unmarshalLocalDevices(
 }
 
 func (c *client) runExecutor(
-	ctx types.Context, args ...string) ([]byte, error) {
+	ctx types.Context, args ...string) (out []byte, err error) {
 
 	if c.isController() {
 		return nil, utils.NewUnsupportedForClientTypeError(
@@ -221,6 +222,10 @@ func (c *client) runExecutor(
 		}
 	}()
 
+	ctx, span := tracing.StartSpan(ctx, "executor")
+	span.SetAttribute("args", strings.Join(args, " "))
+	defer func() { span.Finish(err) }()
+
 	cmd := exec.Command(types.LSX.String(), args...)
 	cmd.Env = os.Environ()
 
@@ -229,6 +234,7 @@ func (c *client) runExecutor(
 		// ctx.WithField("value", cev).Debug("set executor env var")
 		cmd.Env = append(cmd.Env, cev)
 	}
+	cmd.Env = append(cmd.Env, tracing.EnvVar(ctx))
 
 	return cmd.Output()
 }
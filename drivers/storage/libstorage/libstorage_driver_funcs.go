@@ -119,6 +119,7 @@ func (d *driver) VolumeCreate(
 		IOPS:             opts.IOPS,
 		Size:             opts.Size,
 		Type:             opts.Type,
+		Profile:          opts.Profile,
 		Opts:             opts.Opts.Map(),
 	}
 
@@ -167,6 +168,25 @@ func (d *driver) VolumeCopy(
 	return d.client.VolumeCopy(ctx, serviceName, volumeID, req)
 }
 
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	ctx = d.requireCtx(ctx)
+	serviceName, ok := context.ServiceName(ctx)
+	if !ok {
+		return nil, goof.New("missing service name")
+	}
+
+	req := &types.VolumeResizeRequest{
+		Size: opts.Size,
+		Opts: opts.Opts.Map(),
+	}
+
+	return d.client.VolumeResize(ctx, serviceName, volumeID, req)
+}
+
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
 	volumeID, snapshotName string,
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/drivers/storage/lvm"
+)
+
+// driver is the storage executor for the lvm storage driver.
+type driver struct {
+	config gofig.Config
+	vg     string
+}
+
+func init() {
+	registry.RegisterStorageExecutor(lvm.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.vg = lvm.VolumeGroup(config)
+	return nil
+}
+
+func (d *driver) Name() string {
+	return lvm.Name
+}
+
+// InstanceID returns the local system's InstanceID.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+
+	iid := &types.InstanceID{Driver: lvm.Name}
+	if err := iid.MarshalMetadata(hostName); err != nil {
+		return nil, err
+	}
+
+	return iid, nil
+}
+
+// NextDevice returns the next available device (not implemented).
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns a map of the IDs of the active volumes and the paths
+// to their devices.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	lvs, err := lvm.LVs(d.vg)
+	if err != nil {
+		return nil, err
+	}
+
+	localDevs := map[string]string{}
+	for _, lv := range lvs {
+		if lv.Active && lv.HasTag(lvm.VolumeTag) {
+			localDevs[lv.Name] = lvm.DevicePath(lv.VG, lv.Name)
+		}
+	}
+
+	return &types.LocalDevices{Driver: lvm.Name, DeviceMap: localDevs}, nil
+}
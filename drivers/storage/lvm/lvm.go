@@ -0,0 +1,50 @@
+package lvm
+
+import (
+	"path"
+
+	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// Name is the name of the storage driver
+	Name = "lvm"
+
+	// VolumeTag is the LVM tag of the logical volumes that are libStorage
+	// volumes.
+	VolumeTag = "libstorage_volume"
+
+	// SnapshotTag is the LVM tag of the logical volumes that are libStorage
+	// snapshots.
+	SnapshotTag = "libstorage_snapshot"
+)
+
+func init() {
+	registerConfig()
+}
+
+func registerConfig() {
+	r := apiconfig.NewRegistration("LVM")
+	r.Key(gofig.String, "", "", "", "lvm.volumeGroup")
+	r.Key(gofig.String, "", "thinpool", "", "lvm.thinPool")
+	apiconfig.Register(r)
+}
+
+// VolumeGroup returns the name of the volume group in which the volumes are
+// created.
+func VolumeGroup(config gofig.Config) string {
+	return config.GetString("lvm.volumeGroup")
+}
+
+// ThinPool returns the name of the thin pool in the volume group from which
+// the volumes are allocated.
+func ThinPool(config gofig.Config) string {
+	return config.GetString("lvm.thinPool")
+}
+
+// DevicePath returns the path to the device of an active logical volume.
+func DevicePath(vg, lv string) string {
+	return path.Join("/dev", vg, lv)
+}
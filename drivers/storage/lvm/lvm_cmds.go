@@ -0,0 +1,224 @@
+package lvm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/goof"
+)
+
+const (
+	gibibyte = 1 << 30
+
+	lvsSeparator  = "|"
+	lvsTimeFormat = "2006-01-02 15:04:05 -0700"
+)
+
+// lvsFields are the fields of the lvs report parsed by ParseLVs, in order.
+var lvsFields = []string{
+	"lv_name", "vg_name", "pool_lv", "origin", "lv_size", "lv_attr",
+	"lv_tags", "lv_time",
+}
+
+// LV is a logical volume.
+type LV struct {
+
+	// Name is the name of the logical volume.
+	Name string
+
+	// VG is the name of the volume group to which the logical volume belongs.
+	VG string
+
+	// Pool is the name of the thin pool from which the logical volume is
+	// allocated.
+	Pool string
+
+	// Origin is the name of the logical volume of which the logical volume
+	// is a snapshot.
+	Origin string
+
+	// Size is the size of the logical volume in bytes.
+	Size int64
+
+	// Active indicates whether or not the logical volume is active, and thus
+	// has a device.
+	Active bool
+
+	// Tags are the logical volume's tags.
+	Tags []string
+
+	// Created is the time at which the logical volume was created.
+	Created time.Time
+}
+
+// HasTag returns a flag indicating whether or not the logical volume has the
+// specified tag.
+func (lv *LV) HasTag(tag string) bool {
+	for _, t := range lv.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// SizeGiB returns the size of the logical volume in GiB, rounded up.
+func (lv *LV) SizeGiB() int64 {
+	return (lv.Size + gibibyte - 1) / gibibyte
+}
+
+// LVs returns the logical volumes in a volume group.
+func LVs(vg string) ([]*LV, error) {
+	out, err := run(
+		"lvs",
+		"--noheadings",
+		"--nosuffix",
+		"--units", "b",
+		"--separator", lvsSeparator,
+		"--options", strings.Join(lvsFields, ","),
+		vg)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLVs(out)
+}
+
+// ParseLVs parses the report that the lvs command prints for the fields
+// lv_name, vg_name, pool_lv, origin, lv_size, lv_attr, lv_tags, and lv_time,
+// separated by the "|" character, without headings or size suffixes.
+func ParseLVs(out []byte) ([]*LV, error) {
+	lvs := []*LV{}
+	scn := bufio.NewScanner(bytes.NewReader(out))
+	for scn.Scan() {
+		line := strings.TrimSpace(scn.Text())
+		if line == "" {
+			continue
+		}
+		f := strings.Split(line, lvsSeparator)
+		if len(f) != len(lvsFields) {
+			return nil, goof.WithField("line", line, "invalid lvs output")
+		}
+		size, err := strconv.ParseInt(f[4], 10, 64)
+		if err != nil {
+			return nil, goof.WithFieldE("line", line, "invalid lvs size", err)
+		}
+		lv := &LV{
+			Name:   f[0],
+			VG:     f[1],
+			Pool:   f[2],
+			Origin: f[3],
+			Size:   size,
+			Active: len(f[5]) > 4 && f[5][4] == 'a',
+		}
+		if f[6] != "" {
+			lv.Tags = strings.Split(f[6], ",")
+		}
+		if t, err := time.Parse(lvsTimeFormat, f[7]); err == nil {
+			lv.Created = t
+		}
+		lvs = append(lvs, lv)
+	}
+	return lvs, scn.Err()
+}
+
+// LVCreate creates a thin logical volume with a size of the specified number
+// of GiB. The logical volume is inactive. A logical volume that cannot be
+// deactivated is removed.
+func LVCreate(vg, pool, name, tag string, size int64) error {
+	if _, err := run(
+		"lvcreate",
+		"--thin",
+		"--virtualsize", sizeArg(size),
+		"--name", name,
+		"--addtag", tag,
+		vg+"/"+pool); err != nil {
+		return err
+	}
+	return deactivateCreated(vg, name)
+}
+
+// LVSnapshot creates a thin snapshot of a logical volume. A snapshot that
+// is to be used as a volume is created without the activation skip flag so
+// that it can be activated like any other volume. The snapshot is inactive.
+// A snapshot that cannot be deactivated is removed.
+func LVSnapshot(vg, origin, name, tag string, skipActivation bool) error {
+	skip := "n"
+	if skipActivation {
+		skip = "y"
+	}
+	if _, err := run(
+		"lvcreate",
+		"--snapshot",
+		"--setactivationskip", skip,
+		"--name", name,
+		"--addtag", tag,
+		vg+"/"+origin); err != nil {
+		return err
+	}
+	return deactivateCreated(vg, name)
+}
+
+// deactivateCreated deactivates a logical volume that was just created, and
+// removes it if it cannot be deactivated so that it is not left behind.
+func deactivateCreated(vg, name string) error {
+	err := LVDeactivate(vg, name)
+	if err == nil {
+		return nil
+	}
+	if rerr := LVRemove(vg, name); rerr != nil {
+		log.WithError(rerr).WithField("name", name).Warn(
+			"error removing logical volume")
+	}
+	return err
+}
+
+// LVExtend extends a logical volume to a size of the specified number of
+// GiB.
+func LVExtend(vg, name string, size int64) error {
+	_, err := run("lvextend", "--size", sizeArg(size), vg+"/"+name)
+	return err
+}
+
+// LVActivate activates a logical volume, which creates its device.
+func LVActivate(vg, name string) error {
+	_, err := run(
+		"lvchange", "--activate", "y", "--ignoreactivationskip", vg+"/"+name)
+	return err
+}
+
+// LVDeactivate deactivates a logical volume, which removes its device.
+func LVDeactivate(vg, name string) error {
+	_, err := run("lvchange", "--activate", "n", vg+"/"+name)
+	return err
+}
+
+// LVRemove removes a logical volume.
+func LVRemove(vg, name string) error {
+	_, err := run("lvremove", "--force", vg+"/"+name)
+	return err
+}
+
+func sizeArg(size int64) string {
+	return fmt.Sprintf("%dG", size)
+}
+
+func run(name string, args ...string) ([]byte, error) {
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command(name, args...)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, goof.WithFieldsE(goof.Fields{
+			"cmd":    name,
+			"args":   strings.Join(args, " "),
+			"stderr": strings.TrimSpace(stderr.String()),
+		}, "error running lvm command", err)
+	}
+	return out, nil
+}
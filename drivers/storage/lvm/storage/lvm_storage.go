@@ -0,0 +1,499 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/drivers/storage/lvm"
+)
+
+const (
+	// defaultSize is the size, in GiB, of a volume that is created without
+	// a size.
+	defaultSize = 1
+)
+
+// lvNameRX matches the names that LVM allows for logical volumes.
+var lvNameRX = regexp.MustCompile(`^[a-zA-Z0-9+_.][a-zA-Z0-9+_.-]*$`)
+
+// driver is the storage driver for logical volumes in a local LVM volume
+// group. The volumes are thin logical volumes allocated from a thin pool,
+// and the snapshots are thin snapshots of the volumes. A volume's ID and name
+// are the name of its logical volume. A volume is attached to the server's
+// host, the only instance that can access it, when its logical volume is
+// active.
+type driver struct {
+	sync.Mutex
+	config gofig.Config
+	vg     string
+	pool   string
+	iid    *types.InstanceID
+}
+
+func init() {
+	registry.RegisterStorageDriver(lvm.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return lvm.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.vg = lvm.VolumeGroup(config)
+	d.pool = lvm.ThinPool(config)
+
+	fields := log.Fields{
+		"volumeGroup": d.vg,
+		"thinPool":    d.pool,
+	}
+
+	if d.vg == "" {
+		return goof.WithFields(fields, "lvm volume group required")
+	}
+
+	hostname, err := utils.HostName()
+	if err != nil {
+		return err
+	}
+	d.iid = &types.InstanceID{ID: hostname, Driver: lvm.Name}
+
+	if _, err := lvm.LVs(d.vg); err != nil {
+		return goof.WithFieldsE(fields, "error listing logical volumes", err)
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Block, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return &types.NextDeviceInfo{Ignore: true}, nil
+}
+
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots: true,
+		Copy:      true,
+		Resize:    true,
+	}, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	if iid.ID != "" {
+		return &types.Instance{InstanceID: iid}, nil
+	}
+
+	var hostname string
+	if err := iid.UnmarshalMetadata(&hostname); err != nil {
+		return nil, err
+	}
+
+	return &types.Instance{
+		Name: hostname,
+		InstanceID: &types.InstanceID{
+			ID:     hostname,
+			Driver: iid.Driver,
+		},
+	}, nil
+}
+
+// Volumes returns all volumes or a filtered list of volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	lvs, err := lvm.LVs(d.vg)
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := opts != nil && opts.Attachments
+
+	vols := []*types.Volume{}
+	for _, lv := range lvs {
+		if lv.HasTag(lvm.VolumeTag) {
+			vols = append(vols, d.toVolume(lv, attachments))
+		}
+	}
+	return vols, nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	lv, err := d.getLV(volumeID, lvm.VolumeTag)
+	if err != nil {
+		return nil, err
+	}
+	return d.toVolume(lv, opts != nil && opts.Attachments), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	size := int64(defaultSize)
+	if opts.Size != nil && *opts.Size > 0 {
+		size = *opts.Size
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if err := lvm.LVCreate(
+		d.vg, d.pool, name, lvm.VolumeTag, size); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, name, &types.VolumeInspectOpts{})
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := validateName(volumeName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	snap, err := d.getLV(snapshotID, lvm.SnapshotTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := lvm.LVSnapshot(
+		d.vg, snap.Name, volumeName, lvm.VolumeTag, false); err != nil {
+		return nil, err
+	}
+
+	if opts.Size != nil && *opts.Size > snap.SizeGiB() {
+		if err := lvm.LVExtend(d.vg, volumeName, *opts.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.VolumeInspect(ctx, volumeName, &types.VolumeInspectOpts{})
+}
+
+// VolumeCopy copies an existing volume.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	if err := validateName(volumeName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(volumeID, lvm.VolumeTag); err != nil {
+		return nil, err
+	}
+
+	if err := lvm.LVSnapshot(
+		d.vg, volumeID, volumeName, lvm.VolumeTag, false); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeName, &types.VolumeInspectOpts{})
+}
+
+// VolumeResize extends a volume. A volume cannot be shrunk because reducing
+// a logical volume discards the data beyond its new size.
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	lv, err := d.getLV(volumeID, lvm.VolumeTag)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Size < lv.SizeGiB() {
+		return nil, utils.NewBadRequestErr(
+			fmt.Sprintf("cannot shrink volume %s from %dGiB to %dGiB",
+				volumeID, lv.SizeGiB(), opts.Size),
+			goof.Fields{
+				"volumeID": volumeID,
+				"size":     lv.SizeGiB(),
+				"newSize":  opts.Size,
+			}, nil)
+	}
+
+	if opts.Size > lv.SizeGiB() {
+		if err := lvm.LVExtend(d.vg, volumeID, opts.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.VolumeInspect(ctx, volumeID, &types.VolumeInspectOpts{})
+}
+
+// VolumeSnapshot snapshots a volume.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if err := validateName(snapshotName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(volumeID, lvm.VolumeTag); err != nil {
+		return nil, err
+	}
+
+	if err := lvm.LVSnapshot(
+		d.vg, volumeID, snapshotName, lvm.SnapshotTag, true); err != nil {
+		return nil, err
+	}
+
+	return d.SnapshotInspect(ctx, snapshotName, opts)
+}
+
+// VolumeRemove removes a volume.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(volumeID, lvm.VolumeTag); err != nil {
+		return err
+	}
+	return lvm.LVRemove(d.vg, volumeID)
+}
+
+// VolumeAttach activates a volume's logical volume. The attach token is the
+// volume's ID, which is how the executor reports the volume's device.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(volumeID, lvm.VolumeTag); err != nil {
+		return nil, "", err
+	}
+
+	if err := lvm.LVActivate(d.vg, volumeID); err != nil {
+		return nil, "", err
+	}
+
+	vol, err := d.VolumeInspect(
+		ctx, volumeID, &types.VolumeInspectOpts{Attachments: true})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return vol, volumeID, nil
+}
+
+// VolumeDetach deactivates a volume's logical volume.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(volumeID, lvm.VolumeTag); err != nil {
+		return nil, err
+	}
+
+	if err := lvm.LVDeactivate(d.vg, volumeID); err != nil {
+		return nil, err
+	}
+
+	return d.VolumeInspect(ctx, volumeID, &types.VolumeInspectOpts{})
+}
+
+// Snapshots returns all snapshots or a filtered list of snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	lvs, err := lvm.LVs(d.vg)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps := []*types.Snapshot{}
+	for _, lv := range lvs {
+		if lv.HasTag(lvm.SnapshotTag) {
+			snaps = append(snaps, toSnapshot(lv))
+		}
+	}
+	return snaps, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	lv, err := d.getLV(snapshotID, lvm.SnapshotTag)
+	if err != nil {
+		return nil, err
+	}
+	return toSnapshot(lv), nil
+}
+
+// SnapshotCopy copies an existing snapshot. The copy is a thin snapshot of
+// the snapshot in the same volume group, so the destination ID is ignored.
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	if err := validateName(snapshotName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(snapshotID, lvm.SnapshotTag); err != nil {
+		return nil, err
+	}
+
+	if err := lvm.LVSnapshot(
+		d.vg, snapshotID, snapshotName, lvm.SnapshotTag, true); err != nil {
+		return nil, err
+	}
+
+	return d.SnapshotInspect(ctx, snapshotName, opts)
+}
+
+// SnapshotRemove removes a snapshot.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getLV(snapshotID, lvm.SnapshotTag); err != nil {
+		return err
+	}
+	return lvm.LVRemove(d.vg, snapshotID)
+}
+
+// getLV returns the logical volume with the specified name and tag.
+func (d *driver) getLV(name, tag string) (*lvm.LV, error) {
+	lvs, err := lvm.LVs(d.vg)
+	if err != nil {
+		return nil, err
+	}
+	for _, lv := range lvs {
+		if lv.Name == name && lv.HasTag(tag) {
+			return lv, nil
+		}
+	}
+	return nil, utils.NewNotFoundError(name)
+}
+
+func (d *driver) toVolume(lv *lvm.LV, attachments bool) *types.Volume {
+
+	vol := &types.Volume{
+		ID:     lv.Name,
+		Name:   lv.Name,
+		Size:   lv.SizeGiB(),
+		Type:   "thin",
+		Status: "inactive",
+	}
+
+	if lv.Active {
+		vol.Status = "active"
+	}
+
+	if attachments && lv.Active {
+		vol.Attachments = []*types.VolumeAttachment{{
+			VolumeID:   lv.Name,
+			InstanceID: d.iid,
+			DeviceName: lvm.DevicePath(lv.VG, lv.Name),
+			Status:     "attached",
+		}}
+	}
+
+	return vol
+}
+
+func toSnapshot(lv *lvm.LV) *types.Snapshot {
+	return &types.Snapshot{
+		ID:         lv.Name,
+		Name:       lv.Name,
+		VolumeID:   lv.Origin,
+		VolumeSize: lv.SizeGiB(),
+		StartTime:  lv.Created.Unix(),
+		Status:     "available",
+	}
+}
+
+func validateName(name string) error {
+	if !lvNameRX.MatchString(name) || name == "." || name == ".." {
+		return goof.WithField("name", name, "invalid logical volume name")
+	}
+	return nil
+}
@@ -0,0 +1,2 @@
+LVM_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/lvm
+TEST_COVERPKG_./drivers/storage/lvm/tests := $(LVM_COVERPKG),$(LVM_COVERPKG)/executor
@@ -0,0 +1,306 @@
+package lvm
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+
+	// load the driver
+	"github.com/emccode/libstorage/drivers/storage/lvm"
+	_ "github.com/emccode/libstorage/drivers/storage/lvm/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/lvm/storage"
+)
+
+var (
+	// volumeGroup is the name of the volume group in which the tests create
+	// volumes. The tests create and remove the volume group on a loop device
+	// unless the environment variable LVM_VOLUMEGROUP names an existing
+	// volume group with a thin pool named "thinpool".
+	volumeGroup = os.Getenv("LVM_VOLUMEGROUP")
+
+	testImage string
+	testLoop  string
+)
+
+const lvsOutput = `
+  vol1|libstorage|thinpool||1073741824|Vwi-a-tz--|libstorage_volume|2016-08-01 10:00:00 +0000
+  snap1|libstorage|thinpool|vol1|1073741824|Vwi---tz-k|libstorage_snapshot|2016-08-01 10:05:00 +0000
+  thinpool|libstorage|||4294967296|twi-aotz--||2016-08-01 09:00:00 +0000
+`
+
+func skipTests() bool {
+	noTest, _ := strconv.ParseBool(os.Getenv("TEST_SKIP_LVM"))
+	return noTest || volumeGroup == ""
+}
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	setupVolumeGroup()
+	ec := m.Run()
+	teardownVolumeGroup()
+	os.Exit(ec)
+}
+
+// setupVolumeGroup creates a volume group with a thin pool on a loop device
+// if the tests run as root on a host with the LVM tools.
+func setupVolumeGroup() {
+	if volumeGroup != "" || os.Geteuid() != 0 ||
+		!gotil.FileExists("/dev/loop-control") {
+		return
+	}
+	for _, c := range []string{"losetup", "pvcreate", "vgcreate", "lvcreate"} {
+		if _, err := exec.LookPath(c); err != nil {
+			return
+		}
+	}
+
+	f, err := ioutil.TempFile("", "libstorage-lvm")
+	if err != nil {
+		return
+	}
+	testImage = f.Name()
+	err = f.Truncate(1 << 30)
+	f.Close()
+	if err != nil {
+		teardownVolumeGroup()
+		return
+	}
+
+	out, err := exec.Command("losetup", "--find", "--show", testImage).Output()
+	if err != nil {
+		teardownVolumeGroup()
+		return
+	}
+	testLoop = strings.TrimSpace(string(out))
+
+	uuid, _ := types.NewUUID()
+	vg := "libstorage" + strings.Split(uuid.String(), "-")[0]
+	for _, args := range [][]string{
+		{"pvcreate", testLoop},
+		{"vgcreate", vg, testLoop},
+		{"lvcreate", "--type", "thin-pool", "--size", "512M",
+			"--name", "thinpool", vg},
+	} {
+		if err := exec.Command(args[0], args[1:]...).Run(); err != nil {
+			volumeGroup = vg
+			teardownVolumeGroup()
+			return
+		}
+	}
+	volumeGroup = vg
+}
+
+func teardownVolumeGroup() {
+	if testLoop == "" && testImage == "" {
+		return
+	}
+	if testLoop != "" {
+		exec.Command("vgremove", "--force", volumeGroup).Run()
+		exec.Command("pvremove", "--force", testLoop).Run()
+		exec.Command("losetup", "--detach", testLoop).Run()
+	}
+	if testImage != "" {
+		os.Remove(testImage)
+	}
+	volumeGroup, testImage, testLoop = "", "", ""
+}
+
+func newTestConfig() []byte {
+	return []byte(fmt.Sprintf(`
+lvm:
+  volumeGroup: %s
+  thinPool:    thinpool
+`, volumeGroup))
+}
+
+func TestParseLVs(t *testing.T) {
+	lvs, err := lvm.ParseLVs([]byte(lvsOutput))
+	if !assert.NoError(t, err) || !assert.Len(t, lvs, 3) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "vol1", lvs[0].Name)
+	assert.Equal(t, "libstorage", lvs[0].VG)
+	assert.Equal(t, "thinpool", lvs[0].Pool)
+	assert.Equal(t, "", lvs[0].Origin)
+	assert.Equal(t, int64(1), lvs[0].SizeGiB())
+	assert.True(t, lvs[0].Active)
+	assert.True(t, lvs[0].HasTag(lvm.VolumeTag))
+	assert.Equal(t, int64(1470045600), lvs[0].Created.Unix())
+
+	assert.Equal(t, "vol1", lvs[1].Origin)
+	assert.False(t, lvs[1].Active)
+	assert.True(t, lvs[1].HasTag(lvm.SnapshotTag))
+	assert.False(t, lvs[1].HasTag(lvm.VolumeTag))
+
+	assert.Equal(t, int64(4), lvs[2].SizeGiB())
+	assert.Len(t, lvs[2].Tags, 0)
+
+	_, err = lvm.ParseLVs([]byte("vol1|libstorage\n"))
+	assert.Error(t, err)
+}
+
+func TestInstanceID(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	hostname, err := utils.HostName()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	apitests.Run(
+		t, lvm.Name, newTestConfig(),
+		func(config gofig.Config, client types.Client, t *testing.T) {
+			ctx := context.Background().WithValue(context.ServiceKey, lvm.Name)
+			iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+			assert.NoError(t, err)
+			if assert.NotNil(t, iid) {
+				assert.Equal(t, hostname, iid.ID)
+			}
+		})
+}
+
+func TestServices(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		if assert.Contains(t, reply, lvm.Name) {
+			assert.Equal(t, types.Block, reply[lvm.Name].Driver.Type)
+		}
+	}
+	apitests.Run(t, lvm.Name, newTestConfig(), tf)
+}
+
+func TestVolumeLifecycle(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, lvm.Name)
+		localDevices := func() map[string]string {
+			ld, err := client.Executor().LocalDevices(
+				ctx, &types.LocalDevicesOpts{
+					ScanType: types.DeviceScanQuick,
+					Opts:     utils.NewStore(),
+				})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			return ld.DeviceMap
+		}
+
+		vol, err := client.API().VolumeCreate(
+			nil, lvm.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "vol1", vol.ID)
+		assert.Equal(t, "vol1", vol.Name)
+		assert.Equal(t, int64(1), vol.Size)
+
+		_, err = client.API().VolumeCreate(
+			nil, lvm.Name, &types.VolumeCreateRequest{Name: "vol/1"})
+		assert.Error(t, err)
+
+		vol, err = client.API().VolumeInspect(nil, lvm.Name, "vol1", false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol1", vol.ID)
+		}
+
+		vol, err = client.API().VolumeResize(
+			nil, lvm.Name, "vol1", &types.VolumeResizeRequest{Size: 2})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(2), vol.Size)
+		}
+		_, err = client.API().VolumeResize(
+			nil, lvm.Name, "vol1", &types.VolumeResizeRequest{Size: 1})
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 400, httpErr.Status())
+		}
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, lvm.Name, "vol1",
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "snap1", snap.ID)
+		assert.Equal(t, "vol1", snap.VolumeID)
+
+		vols, err := client.API().VolumesByService(nil, lvm.Name, false)
+		assert.NoError(t, err)
+		assert.Len(t, vols, 1)
+
+		vol2, err := client.API().VolumeCreateFromSnapshot(
+			nil, lvm.Name, "snap1", &types.VolumeCreateRequest{Name: "vol2"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol2", vol2.ID)
+		}
+
+		vol3, err := client.API().VolumeCopy(
+			nil, lvm.Name, "vol1", &types.VolumeCopyRequest{VolumeName: "vol3"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol3", vol3.ID)
+		}
+
+		vol, attTokn, err := client.API().VolumeAttach(
+			nil, lvm.Name, "vol1", &types.VolumeAttachRequest{})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol1", attTokn)
+			dev := lvm.DevicePath(volumeGroup, "vol1")
+			if assert.Len(t, vol.Attachments, 1) {
+				assert.Equal(t, dev, vol.Attachments[0].DeviceName)
+			}
+			assert.Equal(t, dev, localDevices()["vol1"])
+		}
+
+		_, err = client.API().VolumeDetach(
+			nil, lvm.Name, "vol1", &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.NotContains(t, localDevices(), "vol1")
+
+		assert.NoError(t, client.API().SnapshotRemove(nil, lvm.Name, "snap1"))
+		for _, id := range []string{"vol1", "vol2", "vol3"} {
+			assert.NoError(t, client.API().VolumeRemove(nil, lvm.Name, id))
+		}
+
+		_, err = client.API().VolumeInspect(nil, lvm.Name, "vol1", false)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, lvm.Name, newTestConfig(), tf)
+}
+
+func TestConformance(t *testing.T) {
+	if skipTests() {
+		t.SkipNow()
+	}
+
+	rep := (&conformance.Suite{
+		Driver: lvm.Name,
+		Config: newTestConfig(),
+	}).Run(t)
+	assert.Empty(t, rep.Unsupported)
+}
@@ -3,8 +3,6 @@
 package executor
 
 import (
-	"encoding/json"
-
 	"github.com/akutz/gofig"
 
 	"github.com/emccode/libstorage/api/registry"
@@ -83,18 +81,12 @@ func (d *Executor) LocalDevices(
 
 // GetInstanceID gets the mock instance ID.
 func GetInstanceID() *types.InstanceID {
-	return &types.InstanceID{
-		ID:       "12345",
-		Metadata: instanceIDMetadata(),
-	}
-}
-
-func instanceIDMetadata() json.RawMessage {
-	metadata, _ := json.Marshal(map[string]interface{}{
+	iid := &types.InstanceID{ID: "12345", Driver: Name}
+	iid.MarshalMetadata(map[string]interface{}{
 		"min":     0,
 		"max":     10,
 		"rad":     "cool",
 		"totally": "tubular",
 	})
-	return metadata
+	return iid
 }
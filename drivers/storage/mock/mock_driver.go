@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 
 	"github.com/emccode/libstorage/api/context"
@@ -91,6 +92,26 @@ func newDriver() types.StorageDriver {
 	return d
 }
 
+// Init initializes the driver and puts the fault plan that is defined in the
+// config into effect for the driver's service.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	if err := d.Executor.Init(ctx, config); err != nil {
+		return err
+	}
+
+	plan, err := readFaults(config)
+	if err != nil {
+		return err
+	}
+	if plan == nil {
+		return nil
+	}
+
+	server, _ := context.Server(ctx)
+	service, _ := context.ServiceName(ctx)
+	return SetFaults(server, service, plan)
+}
+
 func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
 	return types.Block, nil
 }
@@ -100,9 +121,25 @@ func (d *driver) NextDeviceInfo(
 	return d.nextDeviceInfo, nil
 }
 
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots: true,
+		Copy:      true,
+		IOPS:      true,
+	}, nil
+}
+
 func (d *driver) InstanceInspect(
 	ctx types.Context,
 	opts types.Store) (*types.Instance, error) {
+
+	if err := d.fault(ctx, "instanceInspect", Name); err != nil {
+		return nil, err
+	}
+
 	iid, _ := d.InstanceID(ctx, opts)
 	return &types.Instance{Name: "mockInstance", InstanceID: iid}, nil
 }
@@ -111,6 +148,10 @@ func (d *driver) Volumes(
 	ctx types.Context,
 	opts *types.VolumesOpts) ([]*types.Volume, error) {
 
+	if err := d.fault(ctx, "volumes", Name); err != nil {
+		return nil, err
+	}
+
 	xiid := executor.GetInstanceID()
 
 	if serviceName, ok := context.ServiceName(ctx); ok && serviceName == Name {
@@ -159,6 +200,10 @@ func (d *driver) VolumeInspect(
 	volumeID string,
 	opts *types.VolumeInspectOpts) (*types.Volume, error) {
 
+	if err := d.fault(ctx, "volumeInspect", volumeID); err != nil {
+		return nil, err
+	}
+
 	for _, v := range d.volumes {
 		if strings.ToLower(v.ID) == strings.ToLower(volumeID) {
 			return v, nil
@@ -172,6 +217,10 @@ func (d *driver) VolumeCreate(
 	name string,
 	opts *types.VolumeCreateOpts) (*types.Volume, error) {
 
+	if err := d.fault(ctx, "volumeCreate", name); err != nil {
+		return nil, err
+	}
+
 	if name == "Volume 010" {
 		return nil, goof.WithFieldE(
 			"iops", opts.IOPS,
@@ -223,6 +272,11 @@ func (d *driver) VolumeCreateFromSnapshot(
 	snapshotID, volumeName string,
 	opts *types.VolumeCreateOpts) (*types.Volume, error) {
 
+	if err := d.fault(
+		ctx, "volumeCreateFromSnapshot", snapshotID); err != nil {
+		return nil, err
+	}
+
 	s, err := d.SnapshotInspect(ctx, snapshotID, nil)
 	if err != nil {
 		return nil, err
@@ -260,6 +314,9 @@ func (d *driver) VolumeCreateFromSnapshot(
 	if opts.Opts.IsSet("priority") {
 		volume.Fields["priority"] = opts.Opts.GetString("priority")
 	}
+
+	d.volumes = append(d.volumes, volume)
+
 	return volume, nil
 }
 
@@ -268,6 +325,10 @@ func (d *driver) VolumeCopy(
 	volumeID, volumeName string,
 	opts types.Store) (*types.Volume, error) {
 
+	if err := d.fault(ctx, "volumeCopy", volumeID); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID":   volumeID,
 		"volumeName": volumeName,
@@ -283,6 +344,10 @@ func (d *driver) VolumeCopy(
 		}
 	}
 
+	if ogvol == nil {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
 	volume := &types.Volume{
 		Name:             volumeName,
 		ID:               fmt.Sprintf("vol-%03d", lenVols+1),
@@ -307,11 +372,19 @@ func (d *driver) VolumeSnapshot(
 	volumeID, snapshotName string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.fault(ctx, "volumeSnapshot", volumeID); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID":     volumeID,
 		"snapshotName": snapshotName,
 	}).Debug("mockDriver.VolumeSnapshot")
 
+	if _, err := d.VolumeInspect(ctx, volumeID, nil); err != nil {
+		return nil, err
+	}
+
 	lenSnaps := len(d.snapshots)
 
 	snapshot := &types.Snapshot{
@@ -331,6 +404,10 @@ func (d *driver) VolumeRemove(
 	volumeID string,
 	opts types.Store) error {
 
+	if err := d.fault(ctx, "volumeRemove", volumeID); err != nil {
+		return err
+	}
+
 	ctx.WithFields(log.Fields{
 		"volumeID": volumeID,
 	}).Debug("mockDriver.VolumeRemove")
@@ -359,6 +436,10 @@ func (d *driver) VolumeAttach(
 	volumeID string,
 	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
 
+	if err := d.fault(ctx, "volumeAttach", volumeID); err != nil {
+		return nil, "", err
+	}
+
 	var modVol *types.Volume
 	for _, vol := range d.volumes {
 		if vol.ID == volumeID {
@@ -367,9 +448,18 @@ func (d *driver) VolumeAttach(
 		}
 	}
 
+	if modVol == nil {
+		return nil, "", utils.NewNotFoundError(volumeID)
+	}
+
+	nextDevice := ""
+	if opts.NextDevice != nil {
+		nextDevice = *opts.NextDevice
+	}
+
 	modVol.Attachments = []*types.VolumeAttachment{
 		&types.VolumeAttachment{
-			DeviceName: *opts.NextDevice,
+			DeviceName: nextDevice,
 			MountPoint: "",
 			InstanceID: context.MustInstanceID(ctx),
 			Status:     "attached",
@@ -385,6 +475,10 @@ func (d *driver) VolumeDetach(
 	volumeID string,
 	opts *types.VolumeDetachOpts) (*types.Volume, error) {
 
+	if err := d.fault(ctx, "volumeDetach", volumeID); err != nil {
+		return nil, err
+	}
+
 	var modVol *types.Volume
 	for _, vol := range d.volumes {
 		if vol.ID == volumeID {
@@ -393,6 +487,10 @@ func (d *driver) VolumeDetach(
 		}
 	}
 
+	if modVol == nil {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+
 	modVol.Attachments = nil
 
 	return modVol, nil
@@ -403,6 +501,10 @@ func (d *driver) VolumeDetachAll(
 	volumeID string,
 	opts types.Store) error {
 
+	if err := d.fault(ctx, "volumeDetachAll", volumeID); err != nil {
+		return err
+	}
+
 	for _, vol := range d.volumes {
 		vol.Attachments = nil
 	}
@@ -414,6 +516,10 @@ func (d *driver) Snapshots(
 	ctx types.Context,
 	opts types.Store) ([]*types.Snapshot, error) {
 
+	if err := d.fault(ctx, "snapshots", Name); err != nil {
+		return nil, err
+	}
+
 	return d.snapshots, nil
 }
 
@@ -422,12 +528,16 @@ func (d *driver) SnapshotInspect(
 	snapshotID string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.fault(ctx, "snapshotInspect", snapshotID); err != nil {
+		return nil, err
+	}
+
 	for _, v := range d.snapshots {
 		if strings.ToLower(v.ID) == strings.ToLower(snapshotID) {
 			return v, nil
 		}
 	}
-	return nil, goof.New("invalid snapshot id")
+	return nil, utils.NewNotFoundError(snapshotID)
 }
 
 func (d *driver) SnapshotCopy(
@@ -435,6 +545,10 @@ func (d *driver) SnapshotCopy(
 	snapshotID, snapshotName, destinationID string,
 	opts types.Store) (*types.Snapshot, error) {
 
+	if err := d.fault(ctx, "snapshotCopy", snapshotID); err != nil {
+		return nil, err
+	}
+
 	ctx.WithFields(log.Fields{
 		"snapshotID":    snapshotID,
 		"snapshotName":  snapshotName,
@@ -451,6 +565,10 @@ func (d *driver) SnapshotCopy(
 		}
 	}
 
+	if ogsnap == nil {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+
 	snapshot := &types.Snapshot{
 		Name:     snapshotName,
 		ID:       fmt.Sprintf("snap-%03d", lenSnaps+1),
@@ -472,6 +590,10 @@ func (d *driver) SnapshotRemove(
 	snapshotID string,
 	opts types.Store) error {
 
+	if err := d.fault(ctx, "snapshotRemove", snapshotID); err != nil {
+		return err
+	}
+
 	ctx.WithFields(log.Fields{
 		"snapshotID": snapshotID,
 	}).Debug("mockDriver.SnapshotRemove")
@@ -0,0 +1,313 @@
+// +build mock
+
+package mock
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+const (
+	// ConfigFaults is the config key for the mock driver's fault plan.
+	ConfigFaults = Name + ".faults"
+
+	// FaultAll is the key of the fault that applies to the operations that
+	// do not have a fault of their own.
+	FaultAll = "all"
+
+	// FaultErrNotFound is the Fault.Error value that injects a not found
+	// error.
+	FaultErrNotFound = "notFound"
+
+	// FaultErrNotImplemented is the Fault.Error value that injects a not
+	// implemented error.
+	FaultErrNotImplemented = "notImplemented"
+
+	// FaultErrDuplicateName is the Fault.Error value that injects a duplicate
+	// name error.
+	FaultErrDuplicateName = "duplicateName"
+
+	defaultFaultMessage = "injected fault"
+)
+
+// Fault describes how the mock driver misbehaves when an operation is
+// invoked.
+type Fault struct {
+
+	// Error is the error that a faulted call returns. The values notFound,
+	// notImplemented and duplicateName inject the matching libStorage errors,
+	// and any other value is the message of a generic error.
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+
+	// Rate is the probability, from 0 to 1, that a faulted call returns an
+	// error. A zero rate means that every faulted call returns the Error, if
+	// one is set.
+	Rate float64 `json:"rate,omitempty" yaml:"rate,omitempty"`
+
+	// Latency is the duration by which a faulted call is delayed.
+	Latency string `json:"latency,omitempty" yaml:"latency,omitempty"`
+
+	// Jitter is the upper bound of a random duration that is added to the
+	// Latency.
+	Jitter string `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+
+	// Hang causes a faulted call to block until its context is cancelled or
+	// the fault plan is replaced or cleared.
+	Hang bool `json:"hang,omitempty" yaml:"hang,omitempty"`
+
+	// Skip is the number of calls that are let through before the fault
+	// applies.
+	Skip int `json:"skip,omitempty" yaml:"skip,omitempty"`
+
+	// Count is the maximum number of faulted calls. Zero is unlimited.
+	Count int `json:"count,omitempty" yaml:"count,omitempty"`
+
+	latency time.Duration
+	jitter  time.Duration
+}
+
+// FaultPlan is a map of faults keyed by the names of the driver's operations,
+// such as volumeInspect or volumeAttach. The key all applies to every
+// operation without a fault of its own.
+type FaultPlan map[string]*Fault
+
+// faultState is a plan that is in effect for a service.
+type faultState struct {
+	plan    FaultPlan
+	calls   map[string]int
+	release chan struct{}
+}
+
+var (
+	faults    = map[string]*faultState{}
+	faultsRWL = &sync.RWMutex{}
+)
+
+func faultsKey(server, service string) string {
+	return strings.ToLower(fmt.Sprintf("%s/%s", server, service))
+}
+
+// SetFaults puts a fault plan into effect for a service of a server. The
+// calls that are hung by the previous plan are released.
+func SetFaults(server, service string, plan FaultPlan) error {
+
+	state := &faultState{
+		plan:    FaultPlan{},
+		calls:   map[string]int{},
+		release: make(chan struct{}),
+	}
+
+	for op, f := range plan {
+		if f == nil {
+			continue
+		}
+		nf := *f
+		if err := nf.init(); err != nil {
+			return goof.WithFieldE("operation", op, "invalid fault", err)
+		}
+		state.plan[strings.ToLower(op)] = &nf
+	}
+
+	faultsRWL.Lock()
+	defer faultsRWL.Unlock()
+
+	key := faultsKey(server, service)
+	if old, ok := faults[key]; ok {
+		close(old.release)
+	}
+	faults[key] = state
+	return nil
+}
+
+// Faults returns the fault plan that is in effect for a service of a server.
+func Faults(server, service string) FaultPlan {
+
+	faultsRWL.RLock()
+	defer faultsRWL.RUnlock()
+
+	plan := FaultPlan{}
+	if state, ok := faults[faultsKey(server, service)]; ok {
+		for op, f := range state.plan {
+			nf := *f
+			plan[op] = &nf
+		}
+	}
+	return plan
+}
+
+// ClearFaults removes the fault plan of a service of a server and releases
+// the calls that it hung.
+func ClearFaults(server, service string) {
+
+	faultsRWL.Lock()
+	defer faultsRWL.Unlock()
+
+	key := faultsKey(server, service)
+	if state, ok := faults[key]; ok {
+		close(state.release)
+		delete(faults, key)
+	}
+}
+
+// readFaults reads a fault plan from the config. A nil plan is returned if
+// the config does not have one.
+func readFaults(config gofig.Config) (FaultPlan, error) {
+
+	cfg, ok := config.Get(ConfigFaults).(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	plan := FaultPlan{}
+	for op := range cfg {
+		op = strings.ToLower(op)
+		scope := fmt.Sprintf("%s.%s", ConfigFaults, op)
+
+		f := &Fault{
+			Error:   config.GetString(scope + ".error"),
+			Latency: config.GetString(scope + ".latency"),
+			Jitter:  config.GetString(scope + ".jitter"),
+			Hang:    config.GetBool(scope + ".hang"),
+			Skip:    config.GetInt(scope + ".skip"),
+			Count:   config.GetInt(scope + ".count"),
+		}
+		if v := config.GetString(scope + ".rate"); v != "" {
+			rate, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, goof.WithFields(goof.Fields{
+					"operation": op,
+					"rate":      v,
+				}, "invalid fault rate")
+			}
+			f.Rate = rate
+		}
+		plan[op] = f
+	}
+	return plan, nil
+}
+
+func (f *Fault) init() error {
+	if f.Rate < 0 || f.Rate > 1 {
+		return goof.WithField("rate", f.Rate, "invalid rate")
+	}
+	if f.Skip < 0 || f.Count < 0 {
+		return goof.WithFields(goof.Fields{
+			"skip":  f.Skip,
+			"count": f.Count,
+		}, "invalid skip or count")
+	}
+	var err error
+	if f.Latency != "" {
+		if f.latency, err = time.ParseDuration(f.Latency); err != nil {
+			return err
+		}
+	}
+	if f.Jitter != "" {
+		if f.jitter, err = time.ParseDuration(f.Jitter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// err returns the error that a faulted call returns, if any.
+func (f *Fault) err(resourceID string) error {
+	if f.Error == "" && f.Rate == 0 {
+		return nil
+	}
+	if f.Rate > 0 && f.Rate < 1 && rand.Float64() >= f.Rate {
+		return nil
+	}
+	switch f.Error {
+	case FaultErrNotFound:
+		return utils.NewNotFoundError(resourceID)
+	case FaultErrNotImplemented:
+		return types.ErrNotImplemented
+	case FaultErrDuplicateName:
+		return utils.NewDuplicateNameErr(resourceID)
+	case "":
+		return goof.New(defaultFaultMessage)
+	default:
+		return goof.New(f.Error)
+	}
+}
+
+// nextFault returns the fault that applies to a call of an operation and
+// the channel that is closed when the call should be released from a hang.
+// A nil fault is returned if the call is not faulted.
+func nextFault(ctx types.Context, op string) (*Fault, <-chan struct{}) {
+
+	server, _ := context.Server(ctx)
+	service, _ := context.ServiceName(ctx)
+
+	faultsRWL.Lock()
+	defer faultsRWL.Unlock()
+
+	state, ok := faults[faultsKey(server, service)]
+	if !ok {
+		return nil, nil
+	}
+
+	op = strings.ToLower(op)
+	f, ok := state.plan[op]
+	if !ok {
+		if f, ok = state.plan[FaultAll]; !ok {
+			return nil, nil
+		}
+	}
+
+	state.calls[op]++
+	n := state.calls[op]
+	if n <= f.Skip || (f.Count > 0 && n > f.Skip+f.Count) {
+		return nil, nil
+	}
+	return f, state.release
+}
+
+// fault applies the fault plan of the context's service to a call of an
+// operation. The returned error is the one the call should return.
+func (d *driver) fault(ctx types.Context, op, resourceID string) error {
+
+	f, release := nextFault(ctx, op)
+	if f == nil {
+		return nil
+	}
+
+	delay := f.latency
+	if f.jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(f.jitter)))
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if f.Hang {
+		ctx.WithField("operation", op).Debug("mockDriver hanging call")
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return f.err(resourceID)
+}
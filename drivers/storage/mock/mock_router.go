@@ -0,0 +1,144 @@
+// +build mock
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/server/handlers"
+	"github.com/emccode/libstorage/api/server/httputils"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+func init() {
+	registry.RegisterRouter(&router{})
+}
+
+// router is the control router for the fault plans of the mock services.
+type router struct {
+	routes []types.Route
+}
+
+func (r *router) Name() string {
+	return "mock-router"
+}
+
+func (r *router) Init(config gofig.Config) {
+	r.initRoutes()
+}
+
+// Routes returns the available routes.
+func (r *router) Routes() []types.Route {
+	return r.routes
+}
+
+func (r *router) initRoutes() {
+
+	r.routes = []types.Route{
+
+		// GET
+		httputils.NewGetRoute(
+			"mockFaults",
+			"/mock/faults/{service}",
+			r.faults,
+			handlers.NewServiceValidator()).Doc(&types.RouteDoc{
+			Summary: "Get the fault plan of a mock service",
+		}),
+
+		// POST
+		httputils.NewPostRoute(
+			"mockFaultsSet",
+			"/mock/faults/{service}",
+			r.faultsSet,
+			handlers.NewServiceValidator()).Doc(&types.RouteDoc{
+			Summary: "Replace the fault plan of a mock service",
+		}),
+
+		// DELETE
+		httputils.NewDeleteRoute(
+			"mockFaultsClear",
+			"/mock/faults/{service}",
+			r.faultsClear,
+			handlers.NewServiceValidator()).Doc(&types.RouteDoc{
+			Summary: "Clear the fault plan of a mock service",
+			Status:  http.StatusNoContent,
+		}),
+	}
+}
+
+func (r *router) faults(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	server, service, err := mockService(ctx)
+	if err != nil {
+		return err
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, Faults(server, service))
+	return nil
+}
+
+func (r *router) faultsSet(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	server, service, err := mockService(ctx)
+	if err != nil {
+		return err
+	}
+
+	plan := FaultPlan{}
+	if err := json.NewDecoder(req.Body).Decode(&plan); err != nil {
+		return utils.NewBadRequestErr(
+			fmt.Sprintf("invalid fault plan: %v", err), nil, err)
+	}
+	if err := SetFaults(server, service, plan); err != nil {
+		return utils.NewBadRequestErr(
+			fmt.Sprintf("invalid fault plan: %v", err), nil, err)
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, Faults(server, service))
+	return nil
+}
+
+func (r *router) faultsClear(
+	ctx types.Context,
+	w http.ResponseWriter,
+	req *http.Request,
+	store types.Store) error {
+
+	server, service, err := mockService(ctx)
+	if err != nil {
+		return err
+	}
+
+	ClearFaults(server, service)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// mockService returns the names of the context's server and service, or an
+// ErrBadRequest error if the service's driver is not the mock driver.
+func mockService(ctx types.Context) (string, string, error) {
+	service := context.MustService(ctx)
+	if service.Driver().Name() != Name {
+		return "", "", utils.NewBadRequestErr(
+			fmt.Sprintf("service %s is not a mock service", service.Name()),
+			goof.Fields{"service": service.Name()}, nil)
+	}
+	server, _ := context.Server(ctx)
+	return server, service.Name(), nil
+}
@@ -4,9 +4,13 @@ package mock
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
@@ -16,6 +20,7 @@ import (
 	"github.com/emccode/libstorage/api/server"
 	"github.com/emccode/libstorage/api/server/executors"
 	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
 
@@ -95,8 +100,7 @@ func TestClient(t *testing.T) {
 
 func TestInstanceID(t *testing.T) {
 	iid := mockx.GetInstanceID()
-	iid.Formatted = true
-	iid.Driver = mock.Name
+	iid.DeleteMetadata()
 	apitests.Run(
 		t, mock.Name, nil,
 		(&apitests.InstanceIDTest{
@@ -107,8 +111,6 @@ func TestInstanceID(t *testing.T) {
 
 func TestInstance(t *testing.T) {
 	iid := mockx.GetInstanceID()
-	iid.Formatted = true
-	iid.Driver = mock.Name
 	apitests.Run(
 		t, mock.Name, nil,
 		(&apitests.InstanceTest{
@@ -151,6 +153,11 @@ func TestServiceInpspect(t *testing.T) {
 		assert.True(t, reply.Driver.NextDevice.Ignore)
 		assert.Equal(t, "xvd", reply.Driver.NextDevice.Prefix)
 		assert.Equal(t, `\w`, reply.Driver.NextDevice.Pattern)
+		if assert.NotNil(t, reply.Driver.Capabilities) {
+			assert.True(t, reply.Driver.Capabilities.Snapshots)
+			assert.True(t, reply.Driver.Capabilities.Copy)
+			assert.False(t, reply.Driver.Capabilities.Resize)
+		}
 	}
 	apitests.Run(t, mock.Name, configYAML, tf)
 }
@@ -318,6 +325,20 @@ func TestVolumeCreateWithError(t *testing.T) {
 	apitests.Run(t, mock.Name, configYAML, tf)
 }
 
+func TestVolumeResizeUnsupported(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeResize(nil, mock.Name, "vol-000",
+			&types.VolumeResizeRequest{Size: 20})
+		assert.Error(t, err)
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, http.StatusNotImplemented, httpErr.Status())
+			assert.Equal(t,
+				"service mock does not support resize", httpErr.Error())
+		}
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
+
 func TestVolumeRemove(t *testing.T) {
 
 	tf1 := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -579,6 +600,14 @@ func TestVolumeDetachAll(t *testing.T) {
 	apitests.Run(t, mock.Name, configYAML, tf)
 }
 
+func TestConformance(t *testing.T) {
+	rep := (&conformance.Suite{
+		Driver: mock.Name,
+		Config: configYAML,
+	}).Run(t)
+	assert.Equal(t, []string{conformance.CapResize}, rep.Unsupported)
+}
+
 func TestExecutors(t *testing.T) {
 	apitests.Run(t, mock.Name, configYAML, apitests.TestExecutors)
 }
@@ -598,3 +627,223 @@ func TestExecutorGet(t *testing.T) {
 		apitests.TestGetExecutorDarwin)
 	//apitests.TestGetExecutorWindows)
 }
+
+var faultsConfigYAML = []byte(`
+libstorage:
+  driver: mock
+  server:
+    services:
+      mock2:
+        mock:
+          faults:
+            volumes:
+              error: backend unavailable
+      mock3:
+mock:
+  faults:
+    volumeInspect:
+      error: notFound
+`)
+
+func serverName(t *testing.T, client types.Client) string {
+	_, err := client.API().Root(nil)
+	assert.NoError(t, err)
+	return client.API().ServerName()
+}
+
+func TestFaultsConfig(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		_, err := client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.Error(t, err)
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 404, httpErr.Status())
+		}
+
+		vols, err := client.API().VolumesByService(nil, mock.Name, false)
+		assert.NoError(t, err)
+		assert.Len(t, vols, 3)
+
+		_, err = client.API().VolumesByService(nil, "mock2", false)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "backend unavailable")
+
+		_, err = client.API().Volumes(nil, false)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "batch processing error")
+	}
+	apitests.Run(t, mock.Name, faultsConfigYAML, tf)
+}
+
+func TestFaultsSkipCount(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		server := serverName(t, client)
+		assert.NoError(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumeDetach": &mock.Fault{
+				Error: "detach failed",
+				Skip:  1,
+				Count: 1,
+			},
+		}))
+		defer mock.ClearFaults(server, mock.Name)
+
+		request := &types.VolumeDetachRequest{}
+
+		// the second detach of the batch fails
+		_, err := client.API().VolumeDetachAllForService(
+			nil, mock.Name, request)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "batch processing error")
+
+		// the fault's count is exhausted
+		_, err = client.API().VolumeDetachAllForService(
+			nil, mock.Name, request)
+		assert.NoError(t, err)
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
+
+func TestFaultsRate(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		server := serverName(t, client)
+		assert.NoError(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumeCreate": &mock.Fault{
+				Error: mock.FaultErrDuplicateName,
+				Rate:  1,
+			},
+			"all": &mock.Fault{Rate: 0},
+		}))
+		defer mock.ClearFaults(server, mock.Name)
+
+		_, err := client.API().VolumeCreate(
+			nil, mock.Name, &types.VolumeCreateRequest{Name: "Volume 100"})
+		assert.Error(t, err)
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 409, httpErr.Status())
+		}
+
+		_, err = client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.NoError(t, err)
+
+		assert.Error(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumes": &mock.Fault{Rate: 2},
+		}))
+		assert.Error(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumes": &mock.Fault{Latency: "soon"},
+		}))
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
+
+func TestFaultsLatency(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		server := serverName(t, client)
+		assert.NoError(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumes": &mock.Fault{Latency: "200ms", Jitter: "100ms"},
+		}))
+		defer mock.ClearFaults(server, mock.Name)
+
+		start := time.Now()
+		_, err := client.API().VolumesByService(nil, mock.Name, false)
+		assert.NoError(t, err)
+		assert.True(t, time.Since(start) >= 200*time.Millisecond)
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
+
+var timeoutConfigYAML = []byte(`
+libstorage:
+  driver: mock
+  server:
+    tasks:
+      exeTimeout: 200ms
+`)
+
+func TestFaultsHang(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		server := serverName(t, client)
+		assert.NoError(t, mock.SetFaults(server, mock.Name, mock.FaultPlan{
+			"volumeInspect": &mock.Fault{Hang: true},
+		}))
+
+		_, err := client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.Error(t, err)
+		// the request times out with the task that is still running
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.EqualValues(
+				t, types.TaskStateRunning, httpErr.Fields()["state"])
+		}
+
+		// clearing the plan releases the hung call
+		mock.ClearFaults(server, mock.Name)
+		_, err = client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.NoError(t, err)
+	}
+	apitests.Run(t, mock.Name, timeoutConfigYAML, tf)
+}
+
+func TestFaultsRoute(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+
+		// the route is exercised with a plain HTTP client
+		host := config.GetString("libstorage.host")
+		if !strings.HasPrefix(host, "tcp://") ||
+			config.Get("libstorage.client.tls") != nil {
+			return
+		}
+		url := fmt.Sprintf(
+			"http://%s/mock/faults/%s",
+			strings.TrimPrefix(host, "tcp://"), mock.Name)
+
+		for _, body := range []string{
+			`{"volumeInspect":`,
+			`{"volumeInspect":{"rate":2}}`,
+		} {
+			res, err := http.Post(
+				url, "application/json", strings.NewReader(body))
+			if !assert.NoError(t, err) {
+				return
+			}
+			res.Body.Close()
+			assert.Equal(t, 400, res.StatusCode, body)
+		}
+
+		res, err := http.Post(url, "application/json", strings.NewReader(
+			`{"volumeInspect":{"error":"notFound"}}`))
+		if !assert.NoError(t, err) {
+			return
+		}
+		res.Body.Close()
+		assert.Equal(t, 200, res.StatusCode)
+
+		res, err = http.Get(url)
+		if !assert.NoError(t, err) {
+			return
+		}
+		plan := mock.FaultPlan{}
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&plan))
+		res.Body.Close()
+		if assert.NotNil(t, plan["volumeinspect"]) {
+			assert.Equal(t, mock.FaultErrNotFound, plan["volumeinspect"].Error)
+		}
+
+		_, err = client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.Error(t, err)
+
+		req, _ := http.NewRequest("DELETE", url, nil)
+		res, err = http.DefaultClient.Do(req)
+		if !assert.NoError(t, err) {
+			return
+		}
+		res.Body.Close()
+		assert.Equal(t, 204, res.StatusCode)
+
+		_, err = client.API().VolumeInspect(nil, mock.Name, "vol-000", false)
+		assert.NoError(t, err)
+	}
+	apitests.Run(t, mock.Name, configYAML, tf)
+}
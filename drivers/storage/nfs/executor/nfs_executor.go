@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/drivers/storage/nfs"
+)
+
+// driver is the storage executor for the nfs storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(nfs.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return nfs.Name
+}
+
+// InstanceID returns the local system's InstanceID.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+
+	iid := &types.InstanceID{Driver: nfs.Name}
+	if err := iid.MarshalMetadata(hostName); err != nil {
+		return nil, err
+	}
+
+	return iid, nil
+}
+
+// NextDevice returns the next available device (not implemented).
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns a map of the NFS exports that are mounted on the
+// local system and the paths at which they are mounted.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	localDevs, err := utils.LocalMounts(isNFSMount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.LocalDevices{Driver: nfs.Name, DeviceMap: localDevs}, nil
+}
+
+// isNFSMount returns a flag indicating whether or not an entry of the mount
+// table is an NFS file system.
+func isNFSMount(source, target, fsType string) bool {
+	return strings.HasPrefix(fsType, "nfs")
+}
@@ -0,0 +1,76 @@
+package nfs
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// Name is the name of the storage driver
+	Name = "nfs"
+)
+
+func init() {
+	registerConfig()
+}
+
+func registerConfig() {
+	defaultStateDir := types.Lib.Join("nfs")
+	r := apiconfig.NewRegistration("NFS")
+	r.Key(gofig.String, "", "", "", "nfs.exportRoot")
+	r.Key(gofig.String, "", "", "", "nfs.exportPath")
+	r.Key(gofig.String, "", "", "", "nfs.host")
+	r.Key(gofig.String, "", defaultStateDir, "", "nfs.stateDir")
+	r.Key(gofig.Bool, "", false, "", "nfs.quotas")
+	r.Key(gofig.Bool, "", false, "", "nfs.sharedMounts")
+	apiconfig.Register(r)
+}
+
+// ExportRoot returns the path to the local directory that is exported and
+// in which the volumes are created.
+func ExportRoot(config gofig.Config) string {
+	return config.GetString("nfs.exportRoot")
+}
+
+// ExportPath returns the path at which the NFS server exports the export
+// root. It is the export root if it is not configured.
+func ExportPath(config gofig.Config) string {
+	if p := config.GetString("nfs.exportPath"); p != "" {
+		return p
+	}
+	return ExportRoot(config)
+}
+
+// Host returns the name or address of the NFS server, or an empty string if
+// the NFS server is the host on which the storage driver runs.
+func Host(config gofig.Config) string {
+	return config.GetString("nfs.host")
+}
+
+// StateDir returns the path to the directory in which the driver keeps the
+// volume records and the snapshots.
+func StateDir(config gofig.Config) string {
+	return config.GetString("nfs.stateDir")
+}
+
+// Quotas returns a flag indicating whether or not the size of a volume is
+// enforced with an XFS project quota.
+func Quotas(config gofig.Config) bool {
+	return config.GetBool("nfs.quotas")
+}
+
+// SharedMounts returns a flag indicating whether or not a volume can be
+// attached to more than one instance at a time.
+func SharedMounts(config gofig.Config) bool {
+	return config.GetBool("nfs.sharedMounts")
+}
+
+// DeviceName returns the device name with which an instance mounts a volume.
+func DeviceName(host, exportPath, volumeID string) string {
+	return fmt.Sprintf("%s:%s", host, path.Join(exportPath, volumeID))
+}
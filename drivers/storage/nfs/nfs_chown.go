@@ -0,0 +1,22 @@
+// +build !windows
+
+package nfs
+
+import (
+	"os"
+	"syscall"
+)
+
+// chown gives a copy the owner of the original. An unprivileged process
+// cannot change owners, so a failure is ignored.
+func chown(p string, info os.FileInfo, link bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+	if link {
+		os.Lchown(p, int(st.Uid), int(st.Gid))
+		return
+	}
+	os.Chown(p, int(st.Uid), int(st.Gid))
+}
@@ -0,0 +1,9 @@
+package nfs
+
+import (
+	"os"
+)
+
+// chown does nothing on Windows, where files do not have Unix owners.
+func chown(p string, info os.FileInfo, link bool) {
+}
@@ -0,0 +1,101 @@
+package nfs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/akutz/goof"
+)
+
+// CopyDir copies a directory tree. The copies of the directories, files,
+// and symbolic links keep the modes and modification times of the
+// originals, and their owners as well if the process is privileged to
+// change owners. The destination directory must not exist.
+func CopyDir(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return goof.WithField("path", dst, "copy destination exists")
+	}
+
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		return copyEntry(p, filepath.Join(dst, rel), info)
+	})
+	if err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"src": src,
+			"dst": dst,
+		}, "error copying directory", err)
+	}
+
+	// the modification times of the directories are set after the walk
+	// because copying their contents changes them
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(
+			filepath.Join(dst, rel), info.ModTime(), info.ModTime())
+	})
+}
+
+func copyEntry(src, dst string, info os.FileInfo) error {
+	mode := info.Mode()
+	switch {
+	case mode.IsDir():
+		if err := os.Mkdir(dst, mode.Perm()); err != nil {
+			return err
+		}
+	case mode&os.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		if err := os.Symlink(target, dst); err != nil {
+			return err
+		}
+		chown(dst, info, true)
+		return nil
+	case mode.IsRegular():
+		if err := copyFile(src, dst, mode.Perm()); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dst, info.ModTime(), info.ModTime()); err != nil {
+			return err
+		}
+	default:
+		// sockets, pipes, and devices are not copied
+		return nil
+	}
+	chown(dst, info, false)
+	return os.Chmod(dst, mode.Perm()|mode&(os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	s, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	d, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(d, s); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,47 @@
+package nfs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/akutz/goof"
+)
+
+// SetQuota makes a directory tree an XFS project and limits the blocks that
+// the project can use to the specified number of GiB. A size of zero removes
+// the limit.
+func SetQuota(dir string, projectID, size int64) error {
+	return xfsQuota(dir,
+		fmt.Sprintf("project -s -p %s %d", dir, projectID),
+		fmt.Sprintf("limit -p bhard=%dg %d", size, projectID))
+}
+
+// ClearQuota removes the limit of an XFS project and clears the project from
+// a directory tree.
+func ClearQuota(dir string, projectID int64) error {
+	return xfsQuota(dir,
+		fmt.Sprintf("limit -p bhard=0 %d", projectID),
+		fmt.Sprintf("project -C -p %s %d", dir, projectID))
+}
+
+func xfsQuota(dir string, cmds ...string) error {
+	args := []string{"-x"}
+	for _, c := range cmds {
+		args = append(args, "-c", c)
+	}
+	args = append(args, dir)
+
+	stderr := &bytes.Buffer{}
+	cmd := exec.Command("xfs_quota", args...)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return goof.WithFieldsE(goof.Fields{
+			"dir":    dir,
+			"cmds":   strings.Join(cmds, "; "),
+			"stderr": strings.TrimSpace(stderr.String()),
+		}, "error setting quota", err)
+	}
+	return nil
+}
@@ -0,0 +1,675 @@
+package storage
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
+	"github.com/emccode/libstorage/drivers/storage/nfs"
+)
+
+const (
+	// firstProjectID is the XFS project ID of the first volume whose size is
+	// enforced with a project quota.
+	firstProjectID = 10000
+)
+
+// volNameRX matches the names of volumes, which are the names of their
+// directories in the export root.
+var volNameRX = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// driver is the storage driver for a directory tree that a NFS server
+// exports. A volume is a directory in the export root, and its ID and name
+// are the name of the directory. A snapshot is a copy of a volume's directory
+// that is kept outside of the export root. Instances mount the volumes with
+// NFS, so attaching a volume only records the instance's attachment.
+type driver struct {
+	sync.Mutex
+	config       gofig.Config
+	root         string
+	exportPath   string
+	host         string
+	volPath      string
+	snapPath     string
+	quotas       bool
+	sharedMounts bool
+}
+
+func init() {
+	registry.RegisterStorageDriver(nfs.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return nfs.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.root = nfs.ExportRoot(config)
+	d.exportPath = nfs.ExportPath(config)
+	d.host = nfs.Host(config)
+	d.volPath = path.Join(nfs.StateDir(config), "vol")
+	d.snapPath = path.Join(nfs.StateDir(config), "snap")
+	d.quotas = nfs.Quotas(config)
+	d.sharedMounts = nfs.SharedMounts(config)
+
+	if d.host == "" {
+		hostname, err := utils.HostName()
+		if err != nil {
+			return err
+		}
+		d.host = hostname
+	}
+
+	fields := log.Fields{
+		"exportRoot":   d.root,
+		"exportPath":   d.exportPath,
+		"host":         d.host,
+		"stateDir":     nfs.StateDir(config),
+		"quotas":       d.quotas,
+		"sharedMounts": d.sharedMounts,
+	}
+
+	if d.root == "" {
+		return goof.WithFields(fields, "nfs export root required")
+	}
+	if info, err := os.Stat(d.root); err != nil || !info.IsDir() {
+		return goof.WithFieldsE(fields, "invalid nfs export root", err)
+	}
+
+	for _, p := range []string{d.volPath, d.snapPath} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			return goof.WithFieldsE(fields, "error creating state dir", err)
+		}
+	}
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.NAS, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return &types.NextDeviceInfo{Ignore: true}, nil
+}
+
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots:   true,
+		Copy:        true,
+		Resize:      true,
+		MultiAttach: d.sharedMounts,
+	}, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	if iid.ID != "" {
+		return &types.Instance{InstanceID: iid}, nil
+	}
+
+	var hostname string
+	if err := iid.UnmarshalMetadata(&hostname); err != nil {
+		return nil, err
+	}
+
+	return &types.Instance{
+		Name: hostname,
+		InstanceID: &types.InstanceID{
+			ID:     hostname,
+			Driver: iid.Driver,
+		},
+	}, nil
+}
+
+// Volumes returns all volumes or a filtered list of volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	names, err := d.volumeNames()
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := opts != nil && opts.Attachments
+
+	vols := []*types.Volume{}
+	for _, name := range names {
+		rec, err := d.readVolume(name)
+		if err != nil {
+			return nil, err
+		}
+		vol := d.toVolume(rec, attachments)
+		if opts != nil && opts.Filter != nil {
+			ok, err := filters.Match(opts.Filter, vol)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		vols = append(vols, vol)
+	}
+
+	return utils.SortVolumeByID(vols), nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	return d.toVolume(rec, opts != nil && opts.Attachments), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec := newVolumeRecord(name, opts.Opts)
+	if opts.Size != nil {
+		rec.Volume.Size = *opts.Size
+	}
+	if opts.Type != nil {
+		rec.Volume.Type = *opts.Type
+	}
+
+	if err := os.Mkdir(d.volDirPath(name), 0755); err != nil {
+		return nil, goof.WithFieldE("name", name, "error creating volume", err)
+	}
+
+	if err := d.initVolume(rec); err != nil {
+		os.RemoveAll(d.volDirPath(name))
+		return nil, err
+	}
+
+	return d.toVolume(rec, false), nil
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	if err := validateName(volumeName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	snap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := newVolumeRecord(volumeName, opts.Opts)
+	rec.Volume.Size = snap.VolumeSize
+	if opts.Size != nil {
+		rec.Volume.Size = *opts.Size
+	}
+	if opts.Type != nil {
+		rec.Volume.Type = *opts.Type
+	}
+
+	return d.copyVolume(d.snapDirPath(snap.ID), rec)
+}
+
+// VolumeCopy copies an existing volume.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	if err := validateName(volumeName); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	ogRec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rec := newVolumeRecord(volumeName, opts)
+	rec.Volume.Size = ogRec.Volume.Size
+	rec.Volume.Type = ogRec.Volume.Type
+	for k, v := range ogRec.Volume.Fields {
+		if _, ok := rec.Volume.Fields[k]; !ok {
+			rec.Volume.Fields[k] = v
+		}
+	}
+
+	return d.copyVolume(d.volDirPath(volumeID), rec)
+}
+
+// VolumeResize changes the size of a volume. The size is enforced only if
+// quotas are enabled, so a volume can be shrunk as long as its data fits.
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	rec.Volume.Size = opts.Size
+	if err := d.initVolume(rec); err != nil {
+		return nil, err
+	}
+
+	return d.toVolume(rec, false), nil
+}
+
+// VolumeSnapshot snapshots a volume by copying its directory.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := newSnapshot(rec.Volume.ID, rec.Volume.Size, snapshotName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.copySnapshot(d.volDirPath(volumeID), snap)
+}
+
+// VolumeRemove removes a volume and its data.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return err
+	}
+
+	if rec.ProjectID > 0 {
+		if err := nfs.ClearQuota(
+			d.volDirPath(volumeID), rec.ProjectID); err != nil {
+			return err
+		}
+	}
+
+	if err := os.RemoveAll(d.volDirPath(volumeID)); err != nil {
+		return goof.WithFieldE("volumeID", volumeID, "error removing volume", err)
+	}
+	if err := os.Remove(d.volRecordPath(volumeID)); err != nil &&
+		!os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// VolumeAttach records the attachment of a volume to an instance. The
+// attachment's device name is the volume's NFS export, which the instance
+// mounts. The volume does not appear as a local device until it is mounted,
+// so the attach token is empty.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	iid := inst.InstanceID
+
+	atts := []*types.VolumeAttachment{}
+	for _, att := range rec.Volume.Attachments {
+		if att.InstanceID.ID == iid.ID {
+			if opts.Force {
+				continue
+			}
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID":   volumeID,
+				"instanceID": iid.ID,
+			}, "volume already attached to instance")
+		}
+		if !d.sharedMounts && !opts.Force {
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID":   volumeID,
+				"instanceID": att.InstanceID.ID,
+			}, "volume already attached to another instance")
+		}
+		if d.sharedMounts {
+			atts = append(atts, att)
+		}
+	}
+
+	rec.Volume.Attachments = append(atts, &types.VolumeAttachment{
+		VolumeID:   volumeID,
+		InstanceID: iid,
+		DeviceName: d.deviceName(volumeID),
+		Status:     "attached",
+	})
+	if err := d.writeVolume(rec); err != nil {
+		return nil, "", err
+	}
+
+	return d.toVolume(rec, true), "", nil
+}
+
+// VolumeDetach removes the attachment of a volume to an instance.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	rec, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	atts := []*types.VolumeAttachment{}
+	for _, att := range rec.Volume.Attachments {
+		if att.InstanceID.ID != inst.InstanceID.ID {
+			atts = append(atts, att)
+		}
+	}
+
+	if len(atts) != len(rec.Volume.Attachments) {
+		rec.Volume.Attachments = atts
+		if err := d.writeVolume(rec); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.toVolume(rec, false), nil
+}
+
+// Snapshots returns all snapshots or a filtered list of snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	filter, err := filters.FromStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps, err := d.readSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		return snaps, nil
+	}
+
+	matched := []*types.Snapshot{}
+	for _, s := range snaps {
+		ok, err := filters.Match(filter, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	return d.getSnapshot(snapshotID)
+}
+
+// SnapshotCopy copies an existing snapshot. The copy is kept with the other
+// snapshots, so the destination ID is ignored.
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	ogSnap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := newSnapshot(
+		ogSnap.VolumeID, ogSnap.VolumeSize, snapshotName, opts)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range ogSnap.Fields {
+		if _, ok := snap.Fields[k]; !ok {
+			snap.Fields[k] = v
+		}
+	}
+
+	return d.copySnapshot(d.snapDirPath(snapshotID), snap)
+}
+
+// SnapshotRemove removes a snapshot and its data.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getSnapshot(snapshotID); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(d.snapDirPath(snapshotID)); err != nil {
+		return goof.WithFieldE(
+			"snapshotID", snapshotID, "error removing snapshot", err)
+	}
+	return os.Remove(d.snapRecordPath(snapshotID))
+}
+
+// initVolume enforces a volume's size with a project quota if quotas are
+// enabled and writes the volume's record.
+func (d *driver) initVolume(rec *volumeRecord) error {
+	if d.quotas {
+		if rec.ProjectID == 0 {
+			pid, err := d.nextProjectID()
+			if err != nil {
+				return err
+			}
+			rec.ProjectID = pid
+		}
+		if err := nfs.SetQuota(
+			d.volDirPath(rec.Volume.ID),
+			rec.ProjectID,
+			rec.Volume.Size); err != nil {
+			return err
+		}
+	}
+	return d.writeVolume(rec)
+}
+
+// copyVolume creates a volume from a copy of a directory.
+func (d *driver) copyVolume(
+	src string, rec *volumeRecord) (*types.Volume, error) {
+
+	dst := d.volDirPath(rec.Volume.ID)
+	if err := nfs.CopyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return nil, err
+	}
+
+	if err := d.initVolume(rec); err != nil {
+		os.RemoveAll(dst)
+		return nil, err
+	}
+
+	return d.toVolume(rec, false), nil
+}
+
+// copySnapshot creates a snapshot from a copy of a directory.
+func (d *driver) copySnapshot(
+	src string, snap *types.Snapshot) (*types.Snapshot, error) {
+
+	dst := d.snapDirPath(snap.ID)
+	if err := nfs.CopyDir(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return nil, err
+	}
+
+	if err := d.writeSnapshot(snap); err != nil {
+		os.RemoveAll(dst)
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+func (d *driver) deviceName(volumeID string) string {
+	return nfs.DeviceName(d.host, d.exportPath, volumeID)
+}
+
+func (d *driver) toVolume(rec *volumeRecord, attachments bool) *types.Volume {
+	vol := *rec.Volume
+	if !attachments {
+		vol.Attachments = nil
+	}
+	return &vol
+}
+
+func newVolumeRecord(name string, opts types.Store) *volumeRecord {
+	vol := &types.Volume{
+		ID:     name,
+		Name:   name,
+		Fields: map[string]string{},
+	}
+	if opts != nil {
+		if customFields := opts.GetStore("opts"); customFields != nil {
+			for _, k := range customFields.Keys() {
+				vol.Fields[k] = customFields.GetString(k)
+			}
+		}
+	}
+	return &volumeRecord{Volume: vol}
+}
+
+func newSnapshot(
+	volumeID string,
+	volumeSize int64,
+	name string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	uuid, err := types.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &types.Snapshot{
+		ID:         uuid.String(),
+		Name:       name,
+		VolumeID:   volumeID,
+		VolumeSize: volumeSize,
+		StartTime:  time.Now().Unix(),
+		Status:     "available",
+		Fields:     map[string]string{},
+	}
+	if opts != nil {
+		if customFields := opts.GetStore("opts"); customFields != nil {
+			for _, k := range customFields.Keys() {
+				snap.Fields[k] = customFields.GetString(k)
+			}
+		}
+	}
+	return snap, nil
+}
+
+func validateName(name string) error {
+	if !volNameRX.MatchString(name) {
+		return goof.WithField("name", name, "invalid volume name")
+	}
+	return nil
+}
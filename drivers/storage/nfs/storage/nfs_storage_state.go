@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+)
+
+// volumeRecord is the record of a volume that the driver keeps in its state
+// directory. A directory in the export root without a record is a volume
+// without a size.
+type volumeRecord struct {
+	Volume *types.Volume `json:"volume"`
+
+	// ProjectID is the ID of the XFS project whose quota enforces the
+	// volume's size, or zero if the volume does not have a quota.
+	ProjectID int64 `json:"projectID,omitempty"`
+}
+
+func (d *driver) volDirPath(volumeID string) string {
+	return path.Join(d.root, volumeID)
+}
+
+func (d *driver) volRecordPath(volumeID string) string {
+	return path.Join(d.volPath, volumeID+".json")
+}
+
+func (d *driver) snapDirPath(snapshotID string) string {
+	return path.Join(d.snapPath, snapshotID)
+}
+
+func (d *driver) snapRecordPath(snapshotID string) string {
+	return path.Join(d.snapPath, snapshotID+".json")
+}
+
+// volumeNames returns the names of the directories in the export root that
+// are volumes.
+func (d *driver) volumeNames() ([]string, error) {
+	infos, err := ioutil.ReadDir(d.root)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, info := range infos {
+		if info.IsDir() && volNameRX.MatchString(info.Name()) {
+			names = append(names, info.Name())
+		}
+	}
+	return names, nil
+}
+
+// getVolume returns the record of a volume, or a not found error if the
+// volume's directory does not exist.
+func (d *driver) getVolume(volumeID string) (*volumeRecord, error) {
+	if !volNameRX.MatchString(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	info, err := os.Stat(d.volDirPath(volumeID))
+	if err != nil || !info.IsDir() {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	return d.readVolume(volumeID)
+}
+
+func (d *driver) readVolume(volumeID string) (*volumeRecord, error) {
+	rec := &volumeRecord{}
+	if err := readJSON(d.volRecordPath(volumeID), rec); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if rec.Volume == nil {
+		rec.Volume = &types.Volume{}
+	}
+	rec.Volume.ID = volumeID
+	rec.Volume.Name = volumeID
+	return rec, nil
+}
+
+func (d *driver) writeVolume(rec *volumeRecord) error {
+	return writeJSON(d.volRecordPath(rec.Volume.ID), rec)
+}
+
+// nextProjectID returns an XFS project ID that no volume uses.
+func (d *driver) nextProjectID() (int64, error) {
+	paths, err := filepath.Glob(path.Join(d.volPath, "*.json"))
+	if err != nil {
+		return 0, err
+	}
+	pid := int64(firstProjectID)
+	for _, p := range paths {
+		rec := &volumeRecord{}
+		if err := readJSON(p, rec); err != nil {
+			return 0, err
+		}
+		if rec.ProjectID >= pid {
+			pid = rec.ProjectID + 1
+		}
+	}
+	return pid, nil
+}
+
+func (d *driver) getSnapshot(snapshotID string) (*types.Snapshot, error) {
+	if strings.ContainsAny(snapshotID, `/\`) {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+	snap := &types.Snapshot{}
+	if err := readJSON(d.snapRecordPath(snapshotID), snap); err != nil {
+		if os.IsNotExist(err) {
+			return nil, utils.NewNotFoundError(snapshotID)
+		}
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (d *driver) readSnapshots() ([]*types.Snapshot, error) {
+	paths, err := filepath.Glob(path.Join(d.snapPath, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	snaps := []*types.Snapshot{}
+	for _, p := range paths {
+		snap := &types.Snapshot{}
+		if err := readJSON(p, snap); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+func (d *driver) writeSnapshot(snap *types.Snapshot) error {
+	return writeJSON(d.snapRecordPath(snap.ID), snap)
+}
+
+func readJSON(p string, v interface{}) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
+
+func writeJSON(p string, v interface{}) error {
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
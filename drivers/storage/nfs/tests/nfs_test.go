@@ -0,0 +1,295 @@
+package nfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/gotil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+
+	// load the driver
+	"github.com/emccode/libstorage/drivers/storage/nfs"
+	_ "github.com/emccode/libstorage/drivers/storage/nfs/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/nfs/storage"
+)
+
+const (
+	testHost       = "nfs.example.com"
+	testExportPath = "/exports/libstorage"
+)
+
+var (
+	testDirs     []string
+	testDirsLock = &sync.Mutex{}
+)
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	for _, d := range testDirs {
+		os.RemoveAll(d)
+	}
+	os.Exit(ec)
+}
+
+// newTestConfig returns the configuration of a driver whose export root and
+// state directory are in a new temporary directory, and the path to the
+// export root.
+func newTestConfig(t *testing.T) ([]byte, string) {
+	d, err := ioutil.TempDir("", "libstorage-nfs")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	func() {
+		testDirsLock.Lock()
+		defer testDirsLock.Unlock()
+		testDirs = append(testDirs, d)
+	}()
+
+	root := path.Join(d, "export")
+	if !assert.NoError(t, os.Mkdir(root, 0755)) {
+		t.FailNow()
+	}
+
+	return []byte(fmt.Sprintf(`
+nfs:
+  exportRoot: %s
+  exportPath: %s
+  host:       %s
+  stateDir:   %s
+`, root, testExportPath, testHost, path.Join(d, "state"))), root
+}
+
+func TestServices(t *testing.T) {
+	tc, _ := newTestConfig(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		if assert.Contains(t, reply, nfs.Name) {
+			assert.Equal(t, types.NAS, reply[nfs.Name].Driver.Type)
+		}
+	}
+	apitests.Run(t, nfs.Name, tc, tf)
+}
+
+func TestInstanceID(t *testing.T) {
+	hostname, err := utils.HostName()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tc, _ := newTestConfig(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, nfs.Name)
+		iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+		assert.NoError(t, err)
+		if assert.NotNil(t, iid) {
+			assert.Equal(t, hostname, iid.ID)
+		}
+
+		ld, err := client.Executor().LocalDevices(
+			ctx, &types.LocalDevicesOpts{
+				ScanType: types.DeviceScanQuick,
+				Opts:     utils.NewStore(),
+			})
+		if assert.NoError(t, err) {
+			assert.Equal(t, nfs.Name, ld.Driver)
+		}
+	}
+	apitests.Run(t, nfs.Name, tc, tf)
+}
+
+func TestVolumes(t *testing.T) {
+	tc, root := newTestConfig(t)
+
+	// a directory that the driver did not create is a volume without a
+	// size, and entries that are not valid volume names are ignored
+	assert.NoError(t, os.Mkdir(path.Join(root, "existing"), 0755))
+	assert.NoError(t, os.Mkdir(path.Join(root, ".hidden"), 0755))
+	assert.NoError(t, os.Mkdir(path.Join(root, "lost+found"), 0755))
+	assert.NoError(t, ioutil.WriteFile(path.Join(root, "file"), nil, 0644))
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vols, err := client.API().VolumesByService(nil, nfs.Name, false)
+		if assert.NoError(t, err) && assert.Len(t, vols, 1) {
+			assert.Equal(t, "existing", vols["existing"].ID)
+			assert.Equal(t, "existing", vols["existing"].Name)
+			assert.Equal(t, int64(0), vols["existing"].Size)
+		}
+
+		_, err = client.API().VolumeInspect(nil, nfs.Name, ".hidden", false)
+		assert.Error(t, err)
+		_, err = client.API().VolumeInspect(nil, nfs.Name, "file", false)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, nfs.Name, tc, tf)
+}
+
+func TestVolumeLifecycle(t *testing.T) {
+	tc, root := newTestConfig(t)
+	data := []byte("libstorage")
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(2)
+		vol, err := client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{
+				Name: "vol1",
+				Size: &size,
+				Opts: map[string]interface{}{"owner": "libstorage"},
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "vol1", vol.ID)
+		assert.Equal(t, "vol1", vol.Name)
+		assert.Equal(t, size, vol.Size)
+		assert.Equal(t, "libstorage", vol.Fields["owner"])
+		assert.True(t, gotil.FileExists(path.Join(root, "vol1")))
+
+		_, err = client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		assert.Error(t, err)
+		_, err = client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "../vol1"})
+		assert.Error(t, err)
+
+		assert.NoError(t, ioutil.WriteFile(
+			path.Join(root, "vol1", "data"), data, 0600))
+
+		vol, err = client.API().VolumeResize(
+			nil, nfs.Name, "vol1", &types.VolumeResizeRequest{Size: 4})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), vol.Size)
+		}
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, nfs.Name, "vol1",
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "snap1", snap.Name)
+		assert.Equal(t, "vol1", snap.VolumeID)
+		assert.Equal(t, int64(4), snap.VolumeSize)
+
+		// the snapshot is not changed by changes to the volume
+		assert.NoError(t, ioutil.WriteFile(
+			path.Join(root, "vol1", "data"), []byte("changed"), 0600))
+
+		snapCopy, err := client.API().SnapshotCopy(
+			nil, nfs.Name, snap.ID,
+			&types.SnapshotCopyRequest{SnapshotName: "snap2"})
+		if assert.NoError(t, err) {
+			assert.NotEqual(t, snap.ID, snapCopy.ID)
+			assert.Equal(t, "vol1", snapCopy.VolumeID)
+		}
+
+		snaps, err := client.API().SnapshotsByService(nil, nfs.Name)
+		if assert.NoError(t, err) {
+			assert.Len(t, snaps, 2)
+		}
+
+		vol2, err := client.API().VolumeCreateFromSnapshot(
+			nil, nfs.Name, snap.ID, &types.VolumeCreateRequest{Name: "vol2"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol2", vol2.ID)
+			assert.Equal(t, int64(4), vol2.Size)
+			assertFileData(t, path.Join(root, "vol2", "data"), data)
+		}
+
+		vol3, err := client.API().VolumeCopy(
+			nil, nfs.Name, "vol1", &types.VolumeCopyRequest{VolumeName: "vol3"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, "vol3", vol3.ID)
+			assert.Equal(t, "libstorage", vol3.Fields["owner"])
+			assertFileData(
+				t, path.Join(root, "vol3", "data"), []byte("changed"))
+		}
+
+		for _, id := range []string{snap.ID, snapCopy.ID} {
+			assert.NoError(t, client.API().SnapshotRemove(nil, nfs.Name, id))
+		}
+		assert.Error(t, client.API().SnapshotRemove(nil, nfs.Name, snap.ID))
+
+		for _, id := range []string{"vol1", "vol2", "vol3"} {
+			assert.NoError(t, client.API().VolumeRemove(nil, nfs.Name, id))
+			assert.False(t, gotil.FileExists(path.Join(root, id)))
+		}
+
+		_, err = client.API().VolumeInspect(nil, nfs.Name, "vol1", false)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, nfs.Name, tc, tf)
+}
+
+func TestVolumeAttach(t *testing.T) {
+	tc, _ := newTestConfig(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, nfs.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		vol, attTokn, err := client.API().VolumeAttach(
+			nil, nfs.Name, "vol1", &types.VolumeAttachRequest{})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "", attTokn)
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t,
+				testHost+":"+testExportPath+"/vol1",
+				vol.Attachments[0].DeviceName)
+		}
+
+		_, _, err = client.API().VolumeAttach(
+			nil, nfs.Name, "vol1", &types.VolumeAttachRequest{})
+		assert.Error(t, err)
+
+		vol, err = client.API().VolumeInspect(nil, nfs.Name, "vol1", true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 1)
+		}
+
+		_, err = client.API().VolumeDetach(
+			nil, nfs.Name, "vol1", &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+
+		vol, err = client.API().VolumeInspect(nil, nfs.Name, "vol1", true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 0)
+		}
+
+		assert.NoError(t, client.API().VolumeRemove(nil, nfs.Name, "vol1"))
+	}
+	apitests.Run(t, nfs.Name, tc, tf)
+}
+
+func TestConformance(t *testing.T) {
+	tc, _ := newTestConfig(t)
+	rep := (&conformance.Suite{
+		Driver: nfs.Name,
+		Config: tc,
+	}).Run(t)
+	assert.Empty(t, rep.Unsupported)
+}
+
+func assertFileData(t *testing.T, p string, data []byte) {
+	buf, err := ioutil.ReadFile(p)
+	if assert.NoError(t, err) {
+		assert.Equal(t, data, buf)
+	}
+}
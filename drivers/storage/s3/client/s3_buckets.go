@@ -0,0 +1,129 @@
+package client
+
+import (
+	"encoding/xml"
+	"net/url"
+	"time"
+)
+
+// Bucket is an S3 bucket.
+type Bucket struct {
+	Name         string    `xml:"Name"`
+	CreationDate time.Time `xml:"CreationDate"`
+}
+
+type listBucketsResult struct {
+	Buckets []*Bucket `xml:"Buckets>Bucket"`
+}
+
+type createBucketConfiguration struct {
+	XMLName            xml.Name `xml:"CreateBucketConfiguration"`
+	LocationConstraint string   `xml:"LocationConstraint"`
+}
+
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration"`
+	Status  string   `xml:"Status"`
+}
+
+type tag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []*tag   `xml:"TagSet>Tag"`
+}
+
+// Buckets returns the buckets that the client's credentials own.
+func (c *Client) Buckets() ([]*Bucket, error) {
+	res := &listBucketsResult{}
+	if _, _, err := c.do(&request{method: "GET"}, res); err != nil {
+		return nil, err
+	}
+	return res.Buckets, nil
+}
+
+// CreateBucket creates a bucket in the client's region.
+func (c *Client) CreateBucket(bucket string) error {
+	r := &request{method: "PUT", bucket: bucket}
+
+	// a bucket is created in the default region without a location
+	// constraint, which the default region rejects
+	if c.region != "" && c.region != "us-east-1" {
+		body, err := xml.Marshal(&createBucketConfiguration{
+			LocationConstraint: c.region,
+		})
+		if err != nil {
+			return err
+		}
+		r.body = body
+	}
+
+	_, _, err := c.do(r, nil)
+	return err
+}
+
+// DeleteBucket deletes a bucket, which must be empty.
+func (c *Client) DeleteBucket(bucket string) error {
+	_, _, err := c.do(&request{method: "DELETE", bucket: bucket}, nil)
+	return err
+}
+
+// EnableVersioning enables the versioning of a bucket's objects.
+func (c *Client) EnableVersioning(bucket string) error {
+	body, err := xml.Marshal(&versioningConfiguration{Status: "Enabled"})
+	if err != nil {
+		return err
+	}
+	_, _, err = c.do(&request{
+		method: "PUT",
+		bucket: bucket,
+		query:  url.Values{"versioning": {""}},
+		body:   body,
+	}, nil)
+	return err
+}
+
+// BucketTags returns the tags of a bucket.
+func (c *Client) BucketTags(bucket string) (map[string]string, error) {
+	res := &tagging{}
+	_, _, err := c.do(&request{
+		method: "GET",
+		bucket: bucket,
+		query:  url.Values{"tagging": {""}},
+	}, res)
+	if err != nil {
+		// a bucket without tags has no tag set
+		if IsCode(err, "NoSuchTagSet") {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+
+	tags := map[string]string{}
+	for _, t := range res.TagSet {
+		tags[t.Key] = t.Value
+	}
+	return tags, nil
+}
+
+// PutBucketTags replaces the tags of a bucket.
+func (c *Client) PutBucketTags(bucket string, tags map[string]string) error {
+	t := &tagging{TagSet: []*tag{}}
+	for k, v := range tags {
+		t.TagSet = append(t.TagSet, &tag{Key: k, Value: v})
+	}
+	body, err := xml.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, _, err = c.do(&request{
+		method: "PUT",
+		bucket: bucket,
+		query:  url.Values{"tagging": {""}},
+		body:   body,
+	}, nil)
+	return err
+}
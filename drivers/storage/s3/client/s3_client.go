@@ -0,0 +1,207 @@
+package client
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/akutz/goof"
+
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+const service = "s3"
+
+// Client is a client for an S3-compatible API. It addresses buckets by path,
+// which every S3-compatible server supports, and signs requests with AWS
+// Signature Version 4.
+type Client struct {
+	endpoint *url.URL
+	region   string
+	creds    ebsclient.CredentialsFunc
+	client   *http.Client
+}
+
+// New returns a client for the S3 API at an endpoint.
+func New(
+	endpoint, region string,
+	creds ebsclient.CredentialsFunc) (*Client, error) {
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, goof.WithFieldE(
+			"endpoint", endpoint, "invalid s3 endpoint", err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, goof.WithField(
+			"endpoint", endpoint, "invalid s3 endpoint")
+	}
+	return &Client{
+		endpoint: u,
+		region:   region,
+		creds:    creds,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// Endpoint returns the URL of the S3 API.
+func (c *Client) Endpoint() *url.URL {
+	return c.endpoint
+}
+
+// Region returns the region of the client.
+func (c *Client) Region() string {
+	return c.region
+}
+
+// Error is an error that the S3 API returned.
+type Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	Resource   string `xml:"Resource"`
+	RequestID  string `xml:"RequestId"`
+}
+
+// Error returns the error's message.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// IsNotFound returns a flag indicating whether or not an error is the error
+// that the S3 API returns for a bucket or an object that does not exist.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// IsCode returns a flag indicating whether or not an error is an error that
+// the S3 API returned with a code.
+func IsCode(err error, code string) bool {
+	e, ok := err.(*Error)
+	return ok && e.Code == code
+}
+
+// request is a request for a bucket or an object.
+type request struct {
+	method string
+	bucket string
+	key    string
+	query  url.Values
+	header http.Header
+	body   []byte
+}
+
+// do sends a request and returns the response's headers and body. The body
+// is decoded into out if out is not nil.
+func (c *Client) do(r *request, out interface{}) (http.Header, []byte, error) {
+	p := "/"
+	if r.bucket != "" {
+		p += r.bucket
+		if r.key != "" {
+			p += "/" + r.key
+		}
+	}
+
+	u := *c.endpoint
+	u.Path = strings.TrimSuffix(u.Path, "/") + p
+	u.RawPath = strings.TrimSuffix(escapePath(c.endpoint.Path), "/") +
+		escapePath(p)
+	u.RawQuery = ""
+	if r.query != nil {
+		u.RawQuery = r.query.Encode()
+	}
+
+	req, err := http.NewRequest(r.method, u.String(), bytes.NewReader(r.body))
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	if r.body != nil {
+		sum := md5.Sum(r.body)
+		req.Header.Set(
+			"Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	sum := sha256.Sum256(r.body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+
+	creds, err := c.creds()
+	if err != nil {
+		return nil, nil, goof.WithError("error getting s3 credentials", err)
+	}
+	ebsclient.Sign(req, r.body, creds, c.region, service, time.Now())
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, goof.WithFieldsE(goof.Fields{
+			"method": r.method,
+			"bucket": r.bucket,
+			"key":    r.key,
+		}, "error sending s3 request", err)
+	}
+	defer res.Body.Close()
+
+	buf, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		e := &Error{}
+		if err := xml.Unmarshal(buf, e); err != nil || e.Code == "" {
+			e.Code = http.StatusText(res.StatusCode)
+			e.Message = strings.TrimSpace(string(buf))
+		}
+		e.StatusCode = res.StatusCode
+		return nil, nil, e
+	}
+
+	// a copy can fail after the response's status is sent, in which case
+	// the error is in the response's body
+	if r.header.Get("X-Amz-Copy-Source") != "" &&
+		bytes.Contains(buf, []byte("<Error>")) {
+		e := &Error{}
+		if err := xml.Unmarshal(buf, e); err == nil && e.Code != "" {
+			e.StatusCode = http.StatusInternalServerError
+			return nil, nil, e
+		}
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(buf, out); err != nil {
+			return nil, nil, goof.WithFieldsE(goof.Fields{
+				"method": r.method,
+				"bucket": r.bucket,
+				"key":    r.key,
+			}, "error decoding s3 response", err)
+		}
+	}
+	return res.Header, buf, nil
+}
+
+// escapePath encodes the segments of a path as RFC 3986 requires.
+func escapePath(p string) string {
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' ||
+			'0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' ||
+			c == '~' || c == '/' {
+			buf.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(buf, "%%%02X", c)
+	}
+	return buf.String()
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc) (*Client, func()) {
+	ts := httptest.NewServer(h)
+	c, err := New(ts.URL, "us-east-1",
+		ebsclient.StaticCredentials("access", "secret", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, ts.Close
+}
+
+func TestEscapePath(t *testing.T) {
+	act := escapePath("/bucket/a b/c+d~e")
+	if act != "/bucket/a%20b/c%2Bd~e" {
+		t.Fatalf("invalid path: %s", act)
+	}
+}
+
+func TestRequestPath(t *testing.T) {
+	c, stop := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/bucket/a%20b/c%2Bd" {
+			t.Fatalf("invalid path: %s", r.URL.EscapedPath())
+		}
+		if r.Header.Get("X-Amz-Content-Sha256") == "" {
+			t.Fatal("missing payload hash")
+		}
+		fmt.Fprint(w, "data")
+	})
+	defer stop()
+
+	buf, err := c.GetObject("bucket", "a b/c+d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != "data" {
+		t.Fatalf("invalid data: %s", buf)
+	}
+}
+
+func TestError(t *testing.T) {
+	c, stop := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?>
+<Error><Code>NoSuchTagSet</Code><Message>The TagSet does not exist</Message>
+</Error>`)
+	})
+	defer stop()
+
+	_, err := c.GetObject("bucket", "key")
+	if !IsNotFound(err) || !IsCode(err, "NoSuchTagSet") {
+		t.Fatalf("invalid error: %v", err)
+	}
+
+	// a bucket without tags has no tag set
+	tags, err := c.BucketTags("bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 0 {
+		t.Fatalf("invalid tags: %v", tags)
+	}
+}
+
+func TestCopyError(t *testing.T) {
+	c, stop := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Copy-Source") != "/src/a%20b?versionId=v1" {
+			t.Fatalf("invalid copy source: %s",
+				r.Header.Get("X-Amz-Copy-Source"))
+		}
+		fmt.Fprint(w, `<Error><Code>InternalError</Code>
+<Message>We encountered an internal error.</Message></Error>`)
+	})
+	defer stop()
+
+	err := c.CopyObject("dst", "key", "src", "a b", "v1")
+	if !IsCode(err, "InternalError") {
+		t.Fatalf("invalid error: %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Object is an object in an S3 bucket.
+type Object struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// ObjectVersion is a version of an object in a versioned S3 bucket, or a
+// delete marker.
+type ObjectVersion struct {
+	Key          string    `xml:"Key"`
+	VersionID    string    `xml:"VersionId"`
+	IsLatest     bool      `xml:"IsLatest"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+	DeleteMarker bool      `xml:"-"`
+}
+
+type listObjectsResult struct {
+	Contents              []*Object `xml:"Contents"`
+	CommonPrefixes        []string  `xml:"CommonPrefixes>Prefix"`
+	IsTruncated           bool      `xml:"IsTruncated"`
+	NextContinuationToken string    `xml:"NextContinuationToken"`
+}
+
+type listVersionsResult struct {
+	Versions            []*ObjectVersion `xml:"Version"`
+	DeleteMarkers       []*ObjectVersion `xml:"DeleteMarker"`
+	IsTruncated         bool             `xml:"IsTruncated"`
+	NextKeyMarker       string           `xml:"NextKeyMarker"`
+	NextVersionIDMarker string           `xml:"NextVersionIdMarker"`
+}
+
+// Objects returns the objects in a bucket whose keys begin with a prefix. If
+// the delimiter is not empty then the objects whose keys contain the
+// delimiter after the prefix are not returned, and the distinct parts of
+// their keys up to the delimiter are returned instead.
+func (c *Client) Objects(
+	bucket, prefix, delimiter string) ([]*Object, []string, error) {
+
+	objs := []*Object{}
+	prefixes := []string{}
+
+	q := url.Values{"list-type": {"2"}}
+	if prefix != "" {
+		q.Set("prefix", prefix)
+	}
+	if delimiter != "" {
+		q.Set("delimiter", delimiter)
+	}
+
+	for {
+		res := &listObjectsResult{}
+		_, _, err := c.do(&request{
+			method: "GET",
+			bucket: bucket,
+			query:  q,
+		}, res)
+		if err != nil {
+			return nil, nil, err
+		}
+		objs = append(objs, res.Contents...)
+		prefixes = append(prefixes, res.CommonPrefixes...)
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return objs, prefixes, nil
+		}
+		q.Set("continuation-token", res.NextContinuationToken)
+	}
+}
+
+// ObjectVersions returns the versions and the delete markers of the objects
+// in a bucket whose keys begin with a prefix.
+func (c *Client) ObjectVersions(
+	bucket, prefix string) ([]*ObjectVersion, error) {
+
+	vers := []*ObjectVersion{}
+
+	q := url.Values{"versions": {""}}
+	if prefix != "" {
+		q.Set("prefix", prefix)
+	}
+
+	for {
+		res := &listVersionsResult{}
+		_, _, err := c.do(&request{
+			method: "GET",
+			bucket: bucket,
+			query:  q,
+		}, res)
+		if err != nil {
+			return nil, err
+		}
+		vers = append(vers, res.Versions...)
+		for _, m := range res.DeleteMarkers {
+			m.DeleteMarker = true
+			vers = append(vers, m)
+		}
+		if !res.IsTruncated {
+			return vers, nil
+		}
+		q.Set("key-marker", res.NextKeyMarker)
+		q.Set("version-id-marker", res.NextVersionIDMarker)
+	}
+}
+
+// GetObject returns the data of an object.
+func (c *Client) GetObject(bucket, key string) ([]byte, error) {
+	_, buf, err := c.do(&request{
+		method: "GET",
+		bucket: bucket,
+		key:    key,
+	}, nil)
+	return buf, err
+}
+
+// PutObject creates or replaces an object.
+func (c *Client) PutObject(bucket, key, contentType string, data []byte) error {
+	h := http.Header{}
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	if data == nil {
+		data = []byte{}
+	}
+	_, _, err := c.do(&request{
+		method: "PUT",
+		bucket: bucket,
+		key:    key,
+		header: h,
+		body:   data,
+	}, nil)
+	return err
+}
+
+// CopyObject copies a version of an object, or its current version if the
+// version ID is empty. Objects larger than 5 GiB cannot be copied this way.
+func (c *Client) CopyObject(
+	dstBucket, dstKey, srcBucket, srcKey, srcVersionID string) error {
+
+	src := escapePath("/" + srcBucket + "/" + srcKey)
+	if srcVersionID != "" {
+		src += "?versionId=" + url.QueryEscape(srcVersionID)
+	}
+	h := http.Header{}
+	h.Set("X-Amz-Copy-Source", src)
+
+	_, _, err := c.do(&request{
+		method: "PUT",
+		bucket: dstBucket,
+		key:    dstKey,
+		header: h,
+	}, nil)
+	return err
+}
+
+// DeleteObject deletes a version of an object. If the version ID is empty
+// then the object is deleted, which in a versioned bucket adds a delete
+// marker and keeps the object's versions.
+func (c *Client) DeleteObject(bucket, key, versionID string) error {
+	var q url.Values
+	if versionID != "" {
+		q = url.Values{"versionId": {versionID}}
+	}
+	_, _, err := c.do(&request{
+		method: "DELETE",
+		bucket: bucket,
+		key:    key,
+		query:  q,
+	}, nil)
+	return err
+}
@@ -0,0 +1,80 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/akutz/gofig"
+
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/drivers/storage/s3"
+)
+
+// driver is the storage executor for the s3 storage driver.
+type driver struct {
+	config gofig.Config
+}
+
+func init() {
+	registry.RegisterStorageExecutor(s3.Name, newDriver)
+}
+
+func newDriver() types.StorageExecutor {
+	return &driver{}
+}
+
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	return nil
+}
+
+func (d *driver) Name() string {
+	return s3.Name
+}
+
+// InstanceID returns the local system's InstanceID.
+func (d *driver) InstanceID(
+	ctx types.Context,
+	opts types.Store) (*types.InstanceID, error) {
+
+	hostName, err := utils.HostName()
+	if err != nil {
+		return nil, err
+	}
+
+	iid := &types.InstanceID{Driver: s3.Name}
+	if err := iid.MarshalMetadata(hostName); err != nil {
+		return nil, err
+	}
+
+	return iid, nil
+}
+
+// NextDevice returns the next available device (not implemented).
+func (d *driver) NextDevice(
+	ctx types.Context,
+	opts types.Store) (string, error) {
+	return "", types.ErrNotImplemented
+}
+
+// LocalDevices returns a map of the buckets that are mounted on the local
+// system with a FUSE helper and the paths at which they are mounted.
+func (d *driver) LocalDevices(
+	ctx types.Context,
+	opts *types.LocalDevicesOpts) (*types.LocalDevices, error) {
+
+	localDevs, err := utils.LocalMounts(isBucketMount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.LocalDevices{Driver: s3.Name, DeviceMap: localDevs}, nil
+}
+
+// isBucketMount returns a flag indicating whether or not an entry of the
+// mount table is a FUSE file system whose source is a bucket URL.
+func isBucketMount(source, target, fsType string) bool {
+	return strings.HasPrefix(fsType, "fuse") &&
+		strings.HasPrefix(source, s3.DeviceScheme)
+}
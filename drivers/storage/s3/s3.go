@@ -0,0 +1,82 @@
+package s3
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
+
+const (
+	// Name is the name of the storage driver
+	Name = "s3"
+
+	// DeviceScheme is the prefix of the scheme of the URLs with which
+	// instances mount the volumes with a FUSE helper.
+	DeviceScheme = "s3+"
+)
+
+func init() {
+	registerConfig()
+}
+
+func registerConfig() {
+	r := apiconfig.NewRegistration("S3")
+	r.Key(gofig.String, "", "https://s3.amazonaws.com", "", "s3.endpoint")
+	r.Key(gofig.String, "", "us-east-1", "", "s3.region")
+	r.Key(gofig.String, "", "", "", "s3.accessKey")
+	r.Key(gofig.SecureString, "", "", "", "s3.secretKey")
+	r.Key(gofig.String, "", "", "", "s3.bucketPrefix")
+	r.Key(gofig.String, "", "libstorage-snapshots", "", "s3.snapshotBucket")
+	r.Key(gofig.Bool, "", false, "", "s3.sharedMounts")
+	apiconfig.Register(r)
+}
+
+// Endpoint returns the URL of the S3 API.
+func Endpoint(config gofig.Config) string {
+	return config.GetString("s3.endpoint")
+}
+
+// Region returns the region in which the buckets are created and with which
+// requests are signed.
+func Region(config gofig.Config) string {
+	return config.GetString("s3.region")
+}
+
+// AccessKey returns the access key ID with which requests are signed.
+func AccessKey(config gofig.Config) string {
+	return config.GetString("s3.accessKey")
+}
+
+// SecretKey returns the secret access key with which requests are signed.
+func SecretKey(config gofig.Config) string {
+	return config.GetString("s3.secretKey")
+}
+
+// BucketPrefix returns the prefix of the names of the buckets that are
+// volumes. A volume's name is the name of its bucket without the prefix.
+func BucketPrefix(config gofig.Config) string {
+	return config.GetString("s3.bucketPrefix")
+}
+
+// SnapshotBucket returns the name of the bucket in which the snapshots are
+// kept.
+func SnapshotBucket(config gofig.Config) string {
+	return config.GetString("s3.snapshotBucket")
+}
+
+// SharedMounts returns a flag indicating whether or not a volume can be
+// attached to more than one instance at a time.
+func SharedMounts(config gofig.Config) bool {
+	return config.GetBool("s3.sharedMounts")
+}
+
+// DeviceName returns the URL with which an instance mounts a bucket. It is
+// the bucket's path-style URL at an endpoint with the device scheme prefixed
+// to the endpoint's scheme, for example s3+https://s3.amazonaws.com/bucket.
+func DeviceName(endpoint *url.URL, bucket string) string {
+	return fmt.Sprintf(
+		"%s%s://%s/%s", DeviceScheme, endpoint.Scheme, endpoint.Host, bucket)
+}
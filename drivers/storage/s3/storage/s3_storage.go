@@ -0,0 +1,664 @@
+package storage
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/registry"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+	"github.com/emccode/libstorage/drivers/storage/s3"
+	s3client "github.com/emccode/libstorage/drivers/storage/s3/client"
+)
+
+// driver is the storage driver for an S3-compatible object store. A volume
+// is a versioned bucket whose tags are the volume's size, which is a quota
+// that is checked only when the volume is shrunk, its type, its fields, and
+// the instances to which it is attached. A snapshot is a copy of the current
+// versions of a bucket's objects under a prefix in the snapshot bucket.
+// Instances mount the buckets with a FUSE helper, so attaching a volume only
+// records the instance's attachment.
+type driver struct {
+	sync.Mutex
+	config       gofig.Config
+	client       *s3client.Client
+	prefix       string
+	snapBucket   string
+	sharedMounts bool
+}
+
+func init() {
+	registry.RegisterStorageDriver(s3.Name, newDriver)
+}
+
+func newDriver() types.StorageDriver {
+	return &driver{}
+}
+
+// Name returns the name of the driver
+func (d *driver) Name() string {
+	return s3.Name
+}
+
+// Init initializes the driver.
+func (d *driver) Init(ctx types.Context, config gofig.Config) error {
+	d.config = config
+	d.prefix = s3.BucketPrefix(config)
+	d.snapBucket = s3.SnapshotBucket(config)
+	d.sharedMounts = s3.SharedMounts(config)
+
+	fields := log.Fields{
+		"endpoint":       s3.Endpoint(config),
+		"region":         s3.Region(config),
+		"accessKey":      s3.AccessKey(config),
+		"bucketPrefix":   d.prefix,
+		"snapshotBucket": d.snapBucket,
+		"sharedMounts":   d.sharedMounts,
+	}
+
+	if s3.AccessKey(config) == "" || s3.SecretKey(config) == "" {
+		return goof.WithFields(fields, "s3 access key and secret key required")
+	}
+	if err := validateBucketName(d.snapBucket); err != nil {
+		return goof.WithFieldsE(fields, "invalid s3 snapshot bucket", err)
+	}
+
+	client, err := s3client.New(
+		s3.Endpoint(config),
+		s3.Region(config),
+		ebsclient.StaticCredentials(
+			s3.AccessKey(config), s3.SecretKey(config), ""))
+	if err != nil {
+		return err
+	}
+	d.client = client
+
+	ctx.WithFields(fields).Info("storage driver initialized")
+	return nil
+}
+
+// Type returns the type of storage a driver provides
+func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	return types.Object, nil
+}
+
+// NextDeviceInfo returns the information about the driver's next available
+// device workflow.
+func (d *driver) NextDeviceInfo(
+	ctx types.Context) (*types.NextDeviceInfo, error) {
+	return &types.NextDeviceInfo{Ignore: true}, nil
+}
+
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots:   true,
+		Copy:        true,
+		Resize:      true,
+		MultiAttach: d.sharedMounts,
+	}, nil
+}
+
+// InstanceInspect returns an instance.
+func (d *driver) InstanceInspect(
+	ctx types.Context,
+	opts types.Store) (*types.Instance, error) {
+
+	iid := context.MustInstanceID(ctx)
+	if iid.ID != "" {
+		return &types.Instance{InstanceID: iid}, nil
+	}
+
+	var hostname string
+	if err := iid.UnmarshalMetadata(&hostname); err != nil {
+		return nil, err
+	}
+
+	return &types.Instance{
+		Name: hostname,
+		InstanceID: &types.InstanceID{
+			ID:     hostname,
+			Driver: iid.Driver,
+		},
+	}, nil
+}
+
+// Volumes returns all volumes or a filtered list of volumes.
+func (d *driver) Volumes(
+	ctx types.Context,
+	opts *types.VolumesOpts) ([]*types.Volume, error) {
+
+	buckets, err := d.client.Buckets()
+	if err != nil {
+		return nil, err
+	}
+
+	attachments := opts != nil && opts.Attachments
+
+	vols := []*types.Volume{}
+	for _, b := range buckets {
+		if !d.isVolume(b.Name) {
+			continue
+		}
+		tags, err := d.client.BucketTags(b.Name)
+		if err != nil {
+			// the bucket was removed after the buckets were listed
+			if s3client.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		var attached []string
+		if attachments {
+			if attached, err = d.attachedInstances(b.Name); err != nil {
+				return nil, err
+			}
+		}
+		vol := d.toVolume(b.Name, tags, attached)
+		if opts != nil && opts.Filter != nil {
+			ok, err := filters.Match(opts.Filter, vol)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		vols = append(vols, vol)
+	}
+
+	return utils.SortVolumeByID(vols), nil
+}
+
+// VolumeInspect inspects a single volume.
+func (d *driver) VolumeInspect(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeInspectOpts) (*types.Volume, error) {
+
+	tags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	var attached []string
+	if opts != nil && opts.Attachments {
+		if attached, err = d.attachedInstances(volumeID); err != nil {
+			return nil, err
+		}
+	}
+	return d.toVolume(volumeID, tags, attached), nil
+}
+
+// VolumeCreate creates a new volume.
+func (d *driver) VolumeCreate(
+	ctx types.Context,
+	name string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	bucket := d.prefix + name
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	tags := newTags(opts.Opts)
+	if opts.Size != nil {
+		tags[sizeTag] = strconv.FormatInt(*opts.Size, 10)
+	}
+	if opts.Type != nil {
+		tags[typeTag] = *opts.Type
+	}
+
+	if err := d.createBucket(bucket, tags); err != nil {
+		return nil, err
+	}
+
+	return d.toVolume(bucket, tags, nil), nil
+}
+
+// VolumeCreateFromSnapshot creates a new volume from an existing snapshot.
+func (d *driver) VolumeCreateFromSnapshot(
+	ctx types.Context,
+	snapshotID, volumeName string,
+	opts *types.VolumeCreateOpts) (*types.Volume, error) {
+
+	bucket := d.prefix + volumeName
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	snap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := newTags(opts.Opts)
+	tags[sizeTag] = strconv.FormatInt(snap.VolumeSize, 10)
+	if opts.Size != nil {
+		tags[sizeTag] = strconv.FormatInt(*opts.Size, 10)
+	}
+	if opts.Type != nil {
+		tags[typeTag] = *opts.Type
+	}
+
+	if err := d.createBucket(bucket, tags); err != nil {
+		return nil, err
+	}
+
+	prefix := snapshotPrefix(snap.ID)
+	objs, _, err := d.client.Objects(d.snapBucket, prefix, "")
+	if err == nil {
+		for _, o := range objs {
+			key := o.Key[len(prefix):]
+			if err = d.client.CopyObject(
+				bucket, key, d.snapBucket, o.Key, ""); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		d.removeBucket(bucket)
+		return nil, err
+	}
+
+	return d.toVolume(bucket, tags, nil), nil
+}
+
+// VolumeCopy copies an existing volume.
+func (d *driver) VolumeCopy(
+	ctx types.Context,
+	volumeID, volumeName string,
+	opts types.Store) (*types.Volume, error) {
+
+	bucket := d.prefix + volumeName
+	if err := validateBucketName(bucket); err != nil {
+		return nil, err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	ogTags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := newTags(opts)
+	for k, v := range ogTags {
+		switch {
+		case strings.HasPrefix(k, tagPrefix):
+			tags[k] = v
+		default:
+			if _, ok := tags[k]; !ok {
+				tags[k] = v
+			}
+		}
+	}
+
+	if err := d.createBucket(bucket, tags); err != nil {
+		return nil, err
+	}
+
+	if err := d.copyObjects(volumeID, bucket, ""); err != nil {
+		d.removeBucket(bucket)
+		return nil, err
+	}
+
+	return d.toVolume(bucket, tags, nil), nil
+}
+
+// VolumeResize changes the size of a volume. A volume cannot be shrunk to a
+// size that is smaller than the size of its objects.
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	tags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Size < volumeSize(tags) {
+		objs, _, err := d.client.Objects(volumeID, "", "")
+		if err != nil {
+			return nil, err
+		}
+		used := int64(0)
+		for _, o := range objs {
+			used += o.Size
+		}
+		if used > opts.Size*gib {
+			return nil, goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"size":     opts.Size,
+				"used":     used,
+			}, "volume's objects exceed size")
+		}
+	}
+
+	tags[sizeTag] = strconv.FormatInt(opts.Size, 10)
+	if err := d.client.PutBucketTags(volumeID, tags); err != nil {
+		return nil, err
+	}
+
+	return d.toVolume(volumeID, tags, nil), nil
+}
+
+// VolumeSnapshot snapshots a volume by copying the current versions of its
+// objects.
+func (d *driver) VolumeSnapshot(
+	ctx types.Context,
+	volumeID, snapshotName string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	tags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := newSnapshot(volumeID, volumeSize(tags), snapshotName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.createSnapshotBucket(); err != nil {
+		return nil, err
+	}
+
+	if err := d.copyObjects(
+		volumeID, d.snapBucket, snapshotPrefix(snap.ID)); err != nil {
+		d.removeSnapshotObjects(snap.ID)
+		return nil, err
+	}
+
+	if err := d.writeSnapshot(snap); err != nil {
+		d.removeSnapshotObjects(snap.ID)
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// VolumeRemove removes a volume and every version of its objects.
+func (d *driver) VolumeRemove(
+	ctx types.Context,
+	volumeID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getVolume(volumeID); err != nil {
+		return err
+	}
+
+	if err := d.removeBucket(volumeID); err != nil {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error removing volume", err)
+	}
+	if err := d.setAttachedInstances(volumeID, nil); err != nil {
+		return goof.WithFieldE(
+			"volumeID", volumeID, "error removing attachment record", err)
+	}
+	return nil
+}
+
+// VolumeAttach records the attachment of a volume to an instance. The
+// attachment's device name is the URL of the volume's bucket, which the
+// instance mounts with a FUSE helper. The volume does not appear as a local
+// device until it is mounted, so the attach token is empty.
+func (d *driver) VolumeAttach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeAttachOpts) (*types.Volume, string, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	tags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	iid := inst.InstanceID
+
+	attached, err := d.attachedInstances(volumeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ids := []string{}
+	for _, id := range attached {
+		if id == iid.ID {
+			if opts.Force {
+				continue
+			}
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID":   volumeID,
+				"instanceID": iid.ID,
+			}, "volume already attached to instance")
+		}
+		if !d.sharedMounts && !opts.Force {
+			return nil, "", goof.WithFields(goof.Fields{
+				"volumeID":   volumeID,
+				"instanceID": id,
+			}, "volume already attached to another instance")
+		}
+		if d.sharedMounts {
+			ids = append(ids, id)
+		}
+	}
+
+	ids = append(ids, iid.ID)
+	if err := d.setAttachedInstances(volumeID, ids); err != nil {
+		return nil, "", err
+	}
+
+	return d.toVolume(volumeID, tags, ids), "", nil
+}
+
+// VolumeDetach removes the attachment of a volume to an instance.
+func (d *driver) VolumeDetach(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeDetachOpts) (*types.Volume, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	tags, err := d.getVolume(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	inst, err := d.InstanceInspect(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	attached, err := d.attachedInstances(volumeID)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	for _, id := range attached {
+		if id != inst.InstanceID.ID {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) != len(attached) {
+		if err := d.setAttachedInstances(volumeID, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.toVolume(volumeID, tags, nil), nil
+}
+
+// Snapshots returns all snapshots or a filtered list of snapshots.
+func (d *driver) Snapshots(
+	ctx types.Context,
+	opts types.Store) ([]*types.Snapshot, error) {
+
+	filter, err := filters.FromStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	snaps, err := d.readSnapshots()
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		return snaps, nil
+	}
+
+	matched := []*types.Snapshot{}
+	for _, s := range snaps {
+		ok, err := filters.Match(filter, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matched = append(matched, s)
+		}
+	}
+	return matched, nil
+}
+
+// SnapshotInspect inspects a single snapshot.
+func (d *driver) SnapshotInspect(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	return d.getSnapshot(snapshotID)
+}
+
+// SnapshotCopy copies an existing snapshot. The copy is kept in the snapshot
+// bucket, so the destination ID is ignored.
+func (d *driver) SnapshotCopy(
+	ctx types.Context,
+	snapshotID, snapshotName, destinationID string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	d.Lock()
+	defer d.Unlock()
+
+	ogSnap, err := d.getSnapshot(snapshotID)
+	if err != nil {
+		return nil, err
+	}
+
+	snap, err := newSnapshot(
+		ogSnap.VolumeID, ogSnap.VolumeSize, snapshotName, opts)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range ogSnap.Fields {
+		if _, ok := snap.Fields[k]; !ok {
+			snap.Fields[k] = v
+		}
+	}
+
+	ogPrefix := snapshotPrefix(ogSnap.ID)
+	objs, _, err := d.client.Objects(d.snapBucket, ogPrefix, "")
+	if err == nil {
+		for _, o := range objs {
+			key := snapshotPrefix(snap.ID) + o.Key[len(ogPrefix):]
+			if err = d.client.CopyObject(
+				d.snapBucket, key, d.snapBucket, o.Key, ""); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil {
+		err = d.writeSnapshot(snap)
+	}
+	if err != nil {
+		d.removeSnapshotObjects(snap.ID)
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+// SnapshotRemove removes a snapshot and its objects.
+func (d *driver) SnapshotRemove(
+	ctx types.Context,
+	snapshotID string,
+	opts types.Store) error {
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, err := d.getSnapshot(snapshotID); err != nil {
+		return err
+	}
+
+	if err := d.removeSnapshotObjects(snapshotID); err != nil {
+		return goof.WithFieldE(
+			"snapshotID", snapshotID, "error removing snapshot", err)
+	}
+	return d.client.DeleteObject(
+		d.snapBucket, snapshotRecordKey(snapshotID), "")
+}
+
+func newSnapshot(
+	volumeID string,
+	volumeSize int64,
+	name string,
+	opts types.Store) (*types.Snapshot, error) {
+
+	uuid, err := types.NewUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &types.Snapshot{
+		ID:         uuid.String(),
+		Name:       name,
+		VolumeID:   volumeID,
+		VolumeSize: volumeSize,
+		StartTime:  time.Now().Unix(),
+		Status:     "available",
+		Fields:     map[string]string{},
+	}
+	if opts != nil {
+		if customFields := opts.GetStore("opts"); customFields != nil {
+			for _, k := range customFields.Keys() {
+				snap.Fields[k] = customFields.GetString(k)
+			}
+		}
+	}
+	return snap, nil
+}
@@ -0,0 +1,317 @@
+package storage
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/akutz/goof"
+
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/drivers/storage/s3"
+	s3client "github.com/emccode/libstorage/drivers/storage/s3/client"
+)
+
+const (
+	// tagPrefix is the prefix of the keys of the bucket tags that the driver
+	// uses. The other tags of a bucket are its volume's fields.
+	tagPrefix = "libstorage."
+
+	sizeTag = tagPrefix + "size"
+	typeTag = tagPrefix + "type"
+
+	// snapshotRecordExt is the extension of the keys of the snapshot records
+	// in the snapshot bucket.
+	snapshotRecordExt = ".json"
+
+	// attachmentsPrefix is the prefix of the keys of the attachment records
+	// in the snapshot bucket. The records are objects rather than bucket
+	// tags since the value of a tag is limited to 256 characters.
+	attachmentsPrefix = "attachments/"
+
+	gib = int64(1024 * 1024 * 1024)
+)
+
+// bucketNameRX matches the names of buckets that can be addressed by path.
+var bucketNameRX = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+func validateBucketName(name string) error {
+	if !bucketNameRX.MatchString(name) || strings.Contains(name, "..") {
+		return goof.WithField("name", name, "invalid bucket name")
+	}
+	return nil
+}
+
+// isVolume returns a flag indicating whether or not a bucket is a volume.
+func (d *driver) isVolume(bucket string) bool {
+	return bucket != d.snapBucket &&
+		strings.HasPrefix(bucket, d.prefix) &&
+		bucketNameRX.MatchString(bucket)
+}
+
+// getVolume returns the tags of a volume's bucket, or a not found error if
+// the bucket does not exist.
+func (d *driver) getVolume(volumeID string) (map[string]string, error) {
+	if !d.isVolume(volumeID) {
+		return nil, utils.NewNotFoundError(volumeID)
+	}
+	tags, err := d.client.BucketTags(volumeID)
+	if err != nil {
+		if s3client.IsNotFound(err) {
+			return nil, utils.NewNotFoundError(volumeID)
+		}
+		return nil, err
+	}
+	return tags, nil
+}
+
+// createBucket creates a versioned bucket with tags.
+func (d *driver) createBucket(bucket string, tags map[string]string) error {
+	if err := d.client.CreateBucket(bucket); err != nil {
+		return goof.WithFieldE("bucket", bucket, "error creating bucket", err)
+	}
+
+	err := d.client.EnableVersioning(bucket)
+	if err == nil {
+		err = d.client.PutBucketTags(bucket, tags)
+	}
+	if err != nil {
+		d.client.DeleteBucket(bucket)
+		return goof.WithFieldE(
+			"bucket", bucket, "error initializing bucket", err)
+	}
+	return nil
+}
+
+// removeBucket removes every version of a bucket's objects and then the
+// bucket.
+func (d *driver) removeBucket(bucket string) error {
+	vers, err := d.client.ObjectVersions(bucket, "")
+	if err != nil {
+		return err
+	}
+	for _, v := range vers {
+		if err := d.client.DeleteObject(
+			bucket, v.Key, v.VersionID); err != nil {
+			return err
+		}
+	}
+	return d.client.DeleteBucket(bucket)
+}
+
+// copyObjects copies the current versions of a bucket's objects to another
+// bucket with a prefix added to their keys.
+func (d *driver) copyObjects(src, dst, prefix string) error {
+	vers, err := d.client.ObjectVersions(src, "")
+	if err != nil {
+		return err
+	}
+	for _, v := range vers {
+		if !v.IsLatest || v.DeleteMarker {
+			continue
+		}
+		if err := d.client.CopyObject(
+			dst, prefix+v.Key, src, v.Key, v.VersionID); err != nil {
+			return goof.WithFieldsE(goof.Fields{
+				"bucket": src,
+				"key":    v.Key,
+			}, "error copying object", err)
+		}
+	}
+	return nil
+}
+
+// createSnapshotBucket creates the snapshot bucket if it does not exist.
+func (d *driver) createSnapshotBucket() error {
+	err := d.client.CreateBucket(d.snapBucket)
+	if err == nil ||
+		s3client.IsCode(err, "BucketAlreadyOwnedByYou") {
+		return nil
+	}
+	return goof.WithFieldE(
+		"bucket", d.snapBucket, "error creating snapshot bucket", err)
+}
+
+func snapshotPrefix(snapshotID string) string {
+	return snapshotID + "/"
+}
+
+func snapshotRecordKey(snapshotID string) string {
+	return snapshotID + snapshotRecordExt
+}
+
+func (d *driver) getSnapshot(snapshotID string) (*types.Snapshot, error) {
+	if snapshotID == "" || strings.Contains(snapshotID, "/") {
+		return nil, utils.NewNotFoundError(snapshotID)
+	}
+	buf, err := d.client.GetObject(d.snapBucket, snapshotRecordKey(snapshotID))
+	if err != nil {
+		if s3client.IsNotFound(err) {
+			return nil, utils.NewNotFoundError(snapshotID)
+		}
+		return nil, err
+	}
+	snap := &types.Snapshot{}
+	if err := json.Unmarshal(buf, snap); err != nil {
+		return nil, goof.WithFieldE(
+			"snapshotID", snapshotID, "error decoding snapshot record", err)
+	}
+	return snap, nil
+}
+
+// readSnapshots returns the snapshots whose records are in the snapshot
+// bucket, which has no snapshots if it does not exist.
+func (d *driver) readSnapshots() ([]*types.Snapshot, error) {
+	objs, _, err := d.client.Objects(d.snapBucket, "", "/")
+	if err != nil {
+		if s3client.IsNotFound(err) {
+			return []*types.Snapshot{}, nil
+		}
+		return nil, err
+	}
+	snaps := []*types.Snapshot{}
+	for _, o := range objs {
+		if !strings.HasSuffix(o.Key, snapshotRecordExt) {
+			continue
+		}
+		snap, err := d.getSnapshot(
+			strings.TrimSuffix(o.Key, snapshotRecordExt))
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	return snaps, nil
+}
+
+func (d *driver) writeSnapshot(snap *types.Snapshot) error {
+	buf, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return d.client.PutObject(
+		d.snapBucket, snapshotRecordKey(snap.ID), "application/json", buf)
+}
+
+// removeSnapshotObjects removes the copies of the objects of a snapshot.
+func (d *driver) removeSnapshotObjects(snapshotID string) error {
+	objs, _, err := d.client.Objects(
+		d.snapBucket, snapshotPrefix(snapshotID), "")
+	if err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err := d.client.DeleteObject(d.snapBucket, o.Key, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *driver) deviceName(bucket string) string {
+	return s3.DeviceName(d.client.Endpoint(), bucket)
+}
+
+// toVolume returns the volume of a bucket. The volume has an attachment for
+// each of the IDs of the attached instances.
+func (d *driver) toVolume(
+	bucket string,
+	tags map[string]string,
+	attached []string) *types.Volume {
+
+	vol := &types.Volume{
+		ID:               bucket,
+		Name:             strings.TrimPrefix(bucket, d.prefix),
+		Type:             tags[typeTag],
+		Size:             volumeSize(tags),
+		AvailabilityZone: d.client.Region(),
+		Status:           "available",
+		Fields:           map[string]string{},
+	}
+	for k, v := range tags {
+		if !strings.HasPrefix(k, tagPrefix) {
+			vol.Fields[k] = v
+		}
+	}
+	for _, id := range attached {
+		vol.Attachments = append(vol.Attachments, &types.VolumeAttachment{
+			VolumeID: bucket,
+			InstanceID: &types.InstanceID{
+				ID:     id,
+				Driver: s3.Name,
+			},
+			DeviceName: d.deviceName(bucket),
+			Status:     "attached",
+		})
+	}
+	return vol
+}
+
+// newTags returns the tags of a new volume's bucket, which are the volume's
+// custom fields and a size of zero.
+func newTags(opts types.Store) map[string]string {
+	tags := map[string]string{sizeTag: "0"}
+	if opts != nil {
+		if customFields := opts.GetStore("opts"); customFields != nil {
+			for _, k := range customFields.Keys() {
+				if !strings.HasPrefix(k, tagPrefix) {
+					tags[k] = customFields.GetString(k)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// volumeSize returns the size of a volume in GiB. A bucket that the driver
+// did not create has no size.
+func volumeSize(tags map[string]string) int64 {
+	size, _ := strconv.ParseInt(tags[sizeTag], 10, 64)
+	return size
+}
+
+func attachmentsKey(volumeID string) string {
+	return attachmentsPrefix + volumeID + ".json"
+}
+
+// attachedInstances returns the IDs of the instances to which a volume is
+// attached. A volume without an attachment record is not attached.
+func (d *driver) attachedInstances(volumeID string) ([]string, error) {
+	buf, err := d.client.GetObject(d.snapBucket, attachmentsKey(volumeID))
+	if err != nil {
+		if s3client.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ids := []string{}
+	if err := json.Unmarshal(buf, &ids); err != nil {
+		return nil, goof.WithFieldE(
+			"volumeID", volumeID, "error decoding attachment record", err)
+	}
+	return ids, nil
+}
+
+// setAttachedInstances sets the IDs of the instances to which a volume is
+// attached. The attachment record is removed when there are no IDs.
+func (d *driver) setAttachedInstances(volumeID string, ids []string) error {
+	if len(ids) == 0 {
+		err := d.client.DeleteObject(
+			d.snapBucket, attachmentsKey(volumeID), "")
+		if err != nil && !s3client.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+	if err := d.createSnapshotBucket(); err != nil {
+		return err
+	}
+	buf, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	return d.client.PutObject(
+		d.snapBucket, attachmentsKey(volumeID), "application/json", buf)
+}
@@ -0,0 +1,2 @@
+S3_COVERPKG := $(ROOT_IMPORT_PATH)/drivers/storage/s3
+TEST_COVERPKG_./drivers/storage/s3/tests := $(S3_COVERPKG),$(S3_COVERPKG)/executor
@@ -0,0 +1,671 @@
+package s3
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ebsclient "github.com/emccode/libstorage/drivers/storage/ebs/client"
+)
+
+const (
+	// testPageSize is the number of entries in a page of a listing, which is
+	// small so that the driver pages through the listings.
+	testPageSize = 2
+)
+
+// s3Server is a stand-in for an S3-compatible API that addresses buckets by
+// path. It checks that requests are signed with the configured credentials
+// and that the signed payload hashes and the content MD5s are correct.
+type s3Server struct {
+	sync.Mutex
+	*httptest.Server
+
+	region    string
+	accessKey string
+	secretKey string
+	buckets   map[string]*s3Bucket
+	lastID    int
+}
+
+type s3Bucket struct {
+	created    time.Time
+	versioning bool
+	tags       map[string]string
+	objects    map[string][]*s3Version
+}
+
+type s3Version struct {
+	id           string
+	data         []byte
+	modified     time.Time
+	deleteMarker bool
+}
+
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Status  int      `xml:"-"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+func newS3Server(region, accessKey, secretKey string) *s3Server {
+	s := &s3Server{
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		buckets:   map[string]*s3Bucket{},
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// putObject creates a version of an object as if another client had.
+func (s *s3Server) putObject(bucket, key string, data []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.put(s.buckets[bucket], key, data)
+}
+
+// getObject returns the data of the current version of an object, or nil if
+// the object does not exist.
+func (s *s3Server) getObject(bucket, key string) []byte {
+	s.Lock()
+	defer s.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil
+	}
+	v := latest(b.objects[key])
+	if v == nil {
+		return nil
+	}
+	return v.data
+}
+
+// bucketNames returns the names of the buckets.
+func (s *s3Server) bucketNames() []string {
+	s.Lock()
+	defer s.Unlock()
+	names := []string{}
+	for n := range s.buckets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *s3Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if e := s.verify(r, body); e != nil {
+		writeError(w, e)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	var (
+		res interface{}
+		e   *s3Error
+	)
+	switch {
+	case parts[0] == "":
+		res, e = s.listBuckets()
+	case len(parts) == 1:
+		res, e = s.doBucket(r, parts[0], body)
+	default:
+		var data []byte
+		data, e = s.doObject(r, parts[0], parts[1], body)
+		if e == nil {
+			w.Write(data)
+			return
+		}
+	}
+	if e != nil {
+		writeError(w, e)
+		return
+	}
+	if res != nil {
+		buf, err := xml.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write(buf)
+	}
+}
+
+// verify checks the signature, the payload hash, and the content MD5 of a
+// request.
+func (s *s3Server) verify(r *http.Request, body []byte) *s3Error {
+	denied := func(msg string) *s3Error {
+		return &s3Error{
+			Status: http.StatusForbidden, Code: "AccessDenied", Message: msg}
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.Contains(auth, "Credential="+s.accessKey+"/") {
+		return denied("unknown access key")
+	}
+	if !strings.Contains(auth, "/"+s.region+"/s3/aws4_request") {
+		return denied("invalid credential scope")
+	}
+
+	sum := sha256.Sum256(body)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		return &s3Error{
+			Status:  http.StatusBadRequest,
+			Code:    "XAmzContentSHA256Mismatch",
+			Message: "payload hash does not match",
+		}
+	}
+	if m := r.Header.Get("Content-MD5"); m != "" {
+		sum := md5.Sum(body)
+		if m != base64.StdEncoding.EncodeToString(sum[:]) {
+			return &s3Error{
+				Status:  http.StatusBadRequest,
+				Code:    "BadDigest",
+				Message: "content md5 does not match",
+			}
+		}
+	}
+
+	t, err := time.Parse("20060102T150405Z", r.Header.Get("X-Amz-Date"))
+	if err != nil {
+		return denied(err.Error())
+	}
+
+	req, err := http.NewRequest(
+		r.Method, "http://"+r.Host+r.URL.RequestURI(), nil)
+	if err != nil {
+		return denied(err.Error())
+	}
+	for k, v := range r.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || strings.HasPrefix(lk, "x-amz-") {
+			req.Header[k] = v
+		}
+	}
+	ebsclient.Sign(req, body, &ebsclient.Credentials{
+		AccessKeyID:     s.accessKey,
+		SecretAccessKey: s.secretKey,
+	}, s.region, "s3", t)
+	if req.Header.Get("Authorization") != auth {
+		return denied("signature does not match")
+	}
+	return nil
+}
+
+type listBucketsResult struct {
+	XMLName xml.Name       `xml:"ListAllMyBucketsResult"`
+	Buckets []bucketResult `xml:"Buckets>Bucket"`
+}
+
+type bucketResult struct {
+	Name         string    `xml:"Name"`
+	CreationDate time.Time `xml:"CreationDate"`
+}
+
+func (s *s3Server) listBuckets() (interface{}, *s3Error) {
+	res := &listBucketsResult{Buckets: []bucketResult{}}
+	for _, n := range sortedKeys(s.buckets) {
+		res.Buckets = append(res.Buckets, bucketResult{
+			Name:         n,
+			CreationDate: s.buckets[n].created,
+		})
+	}
+	return res, nil
+}
+
+type createBucketConfiguration struct {
+	LocationConstraint string `xml:"LocationConstraint"`
+}
+
+type versioningConfiguration struct {
+	Status string `xml:"Status"`
+}
+
+type tagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"TagSet>Tag"`
+}
+
+func (s *s3Server) doBucket(
+	r *http.Request, name string, body []byte) (interface{}, *s3Error) {
+
+	q := r.URL.Query()
+	b, ok := s.buckets[name]
+
+	if r.Method == "PUT" && len(q) == 0 {
+		if ok {
+			return nil, &s3Error{
+				Status:  http.StatusConflict,
+				Code:    "BucketAlreadyOwnedByYou",
+				Message: "bucket exists",
+			}
+		}
+		cfg := &createBucketConfiguration{}
+		if len(body) > 0 {
+			if err := xml.Unmarshal(body, cfg); err != nil {
+				return nil, malformed(err)
+			}
+		}
+		if cfg.LocationConstraint == "" {
+			cfg.LocationConstraint = "us-east-1"
+		}
+		if cfg.LocationConstraint != s.region {
+			return nil, &s3Error{
+				Status:  http.StatusBadRequest,
+				Code:    "IllegalLocationConstraintException",
+				Message: "invalid location constraint",
+			}
+		}
+		s.buckets[name] = &s3Bucket{
+			created: time.Now().UTC(),
+			objects: map[string][]*s3Version{},
+		}
+		return nil, nil
+	}
+
+	if !ok {
+		return nil, &s3Error{
+			Status:  http.StatusNotFound,
+			Code:    "NoSuchBucket",
+			Message: "bucket does not exist",
+		}
+	}
+
+	switch {
+	case r.Method == "DELETE" && len(q) == 0:
+		if len(b.objects) > 0 {
+			return nil, &s3Error{
+				Status:  http.StatusConflict,
+				Code:    "BucketNotEmpty",
+				Message: "bucket is not empty",
+			}
+		}
+		delete(s.buckets, name)
+		return nil, nil
+
+	case r.Method == "PUT" && hasParam(q, "versioning"):
+		cfg := &versioningConfiguration{}
+		if err := xml.Unmarshal(body, cfg); err != nil {
+			return nil, malformed(err)
+		}
+		b.versioning = cfg.Status == "Enabled"
+		return nil, nil
+
+	case r.Method == "PUT" && hasParam(q, "tagging"):
+		if r.Header.Get("Content-MD5") == "" {
+			return nil, &s3Error{
+				Status:  http.StatusBadRequest,
+				Code:    "InvalidRequest",
+				Message: "content md5 required",
+			}
+		}
+		t := &tagging{}
+		if err := xml.Unmarshal(body, t); err != nil {
+			return nil, malformed(err)
+		}
+		b.tags = map[string]string{}
+		for _, tag := range t.TagSet {
+			b.tags[tag.Key] = tag.Value
+		}
+		return nil, nil
+
+	case r.Method == "GET" && hasParam(q, "tagging"):
+		if b.tags == nil {
+			return nil, &s3Error{
+				Status:  http.StatusNotFound,
+				Code:    "NoSuchTagSet",
+				Message: "bucket has no tags",
+			}
+		}
+		res := &tagging{}
+		for _, k := range sortedKeys(b.tags) {
+			res.TagSet = append(res.TagSet, struct {
+				Key   string `xml:"Key"`
+				Value string `xml:"Value"`
+			}{k, b.tags[k]})
+		}
+		return res, nil
+
+	case r.Method == "GET" && hasParam(q, "versions"):
+		return s.listVersions(b, q), nil
+
+	case r.Method == "GET" && q.Get("list-type") == "2":
+		return s.listObjects(b, q), nil
+	}
+
+	return nil, &s3Error{
+		Status:  http.StatusNotImplemented,
+		Code:    "NotImplemented",
+		Message: r.Method + " " + r.URL.RequestURI(),
+	}
+}
+
+type listObjectsResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Contents              []objectResult `xml:"Contents"`
+	CommonPrefixes        []string       `xml:"CommonPrefixes>Prefix"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+}
+
+type objectResult struct {
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// listObjects lists the current objects of a bucket. The continuation token
+// is the index of the next entry.
+func (s *s3Server) listObjects(b *s3Bucket, q url.Values) interface{} {
+	prefix, delim := q.Get("prefix"), q.Get("delimiter")
+
+	type entry struct {
+		key    string
+		prefix bool
+		v      *s3Version
+	}
+	entries := []entry{}
+	seen := map[string]bool{}
+	for _, k := range sortedKeys(b.objects) {
+		v := latest(b.objects[k])
+		if v == nil || !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if delim != "" {
+			if i := strings.Index(k[len(prefix):], delim); i >= 0 {
+				p := k[:len(prefix)+i+len(delim)]
+				if !seen[p] {
+					seen[p] = true
+					entries = append(entries, entry{key: p, prefix: true})
+				}
+				continue
+			}
+		}
+		entries = append(entries, entry{key: k, v: v})
+	}
+
+	start, _ := strconv.Atoi(q.Get("continuation-token"))
+	res := &listObjectsResult{}
+	for i := start; i < len(entries); i++ {
+		if i == start+testPageSize {
+			res.IsTruncated = true
+			res.NextContinuationToken = strconv.Itoa(i)
+			break
+		}
+		e := entries[i]
+		if e.prefix {
+			res.CommonPrefixes = append(res.CommonPrefixes, e.key)
+			continue
+		}
+		res.Contents = append(res.Contents, objectResult{
+			Key:          e.key,
+			Size:         int64(len(e.v.data)),
+			LastModified: e.v.modified,
+		})
+	}
+	return res
+}
+
+type listVersionsResult struct {
+	XMLName             xml.Name        `xml:"ListVersionsResult"`
+	Versions            []versionResult `xml:"Version"`
+	DeleteMarkers       []versionResult `xml:"DeleteMarker"`
+	IsTruncated         bool            `xml:"IsTruncated"`
+	NextKeyMarker       string          `xml:"NextKeyMarker,omitempty"`
+	NextVersionIDMarker string          `xml:"NextVersionIdMarker,omitempty"`
+}
+
+type versionResult struct {
+	Key          string    `xml:"Key"`
+	VersionID    string    `xml:"VersionId"`
+	IsLatest     bool      `xml:"IsLatest"`
+	Size         int64     `xml:"Size"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+// listVersions lists the versions of a bucket's objects by key and then from
+// the newest version to the oldest.
+func (s *s3Server) listVersions(b *s3Bucket, q url.Values) interface{} {
+	prefix := q.Get("prefix")
+	keyMarker, verMarker := q.Get("key-marker"), q.Get("version-id-marker")
+
+	res := &listVersionsResult{}
+	started := keyMarker == ""
+	n := 0
+	for _, k := range sortedKeys(b.objects) {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		vers := b.objects[k]
+		for i := len(vers) - 1; i >= 0; i-- {
+			v := vers[i]
+			if !started {
+				started = k == keyMarker && v.id == verMarker
+				continue
+			}
+			if n == testPageSize {
+				res.IsTruncated = true
+				return res
+			}
+			vr := versionResult{
+				Key:          k,
+				VersionID:    v.id,
+				IsLatest:     i == len(vers)-1,
+				Size:         int64(len(v.data)),
+				LastModified: v.modified,
+			}
+			if v.deleteMarker {
+				res.DeleteMarkers = append(res.DeleteMarkers, vr)
+			} else {
+				res.Versions = append(res.Versions, vr)
+			}
+			res.NextKeyMarker, res.NextVersionIDMarker = k, v.id
+			n++
+		}
+	}
+	res.NextKeyMarker, res.NextVersionIDMarker = "", ""
+	return res
+}
+
+type copyObjectResult struct {
+	XMLName      xml.Name  `xml:"CopyObjectResult"`
+	LastModified time.Time `xml:"LastModified"`
+}
+
+func (s *s3Server) doObject(
+	r *http.Request, bucket, key string, body []byte) ([]byte, *s3Error) {
+
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, &s3Error{
+			Status:  http.StatusNotFound,
+			Code:    "NoSuchBucket",
+			Message: "bucket does not exist",
+		}
+	}
+	noSuchKey := &s3Error{
+		Status:  http.StatusNotFound,
+		Code:    "NoSuchKey",
+		Message: "object does not exist",
+	}
+
+	switch r.Method {
+	case "GET":
+		v := latest(b.objects[key])
+		if v == nil {
+			return nil, noSuchKey
+		}
+		return v.data, nil
+
+	case "PUT":
+		src := r.Header.Get("X-Amz-Copy-Source")
+		if src == "" {
+			s.put(b, key, body)
+			return nil, nil
+		}
+
+		// the copy source is an escaped path and an optional version ID
+		var srcVer string
+		if i := strings.Index(src, "?versionId="); i >= 0 {
+			srcVer, _ = url.QueryUnescape(src[i+11:])
+			src = src[:i]
+		}
+		src, err := url.QueryUnescape(src)
+		if err != nil {
+			return nil, malformed(err)
+		}
+		parts := strings.SplitN(strings.TrimPrefix(src, "/"), "/", 2)
+		sb, ok := s.buckets[parts[0]]
+		if !ok || len(parts) != 2 {
+			return nil, noSuchKey
+		}
+		var v *s3Version
+		if srcVer == "" {
+			v = latest(sb.objects[parts[1]])
+		} else {
+			for _, sv := range sb.objects[parts[1]] {
+				if sv.id == srcVer && !sv.deleteMarker {
+					v = sv
+				}
+			}
+		}
+		if v == nil {
+			return nil, noSuchKey
+		}
+		s.put(b, key, v.data)
+		buf, _ := xml.Marshal(&copyObjectResult{LastModified: time.Now()})
+		return buf, nil
+
+	case "DELETE":
+		vers := b.objects[key]
+		if verID := r.URL.Query().Get("versionId"); verID != "" {
+			kept := []*s3Version{}
+			for _, v := range vers {
+				if v.id != verID {
+					kept = append(kept, v)
+				}
+			}
+			vers = kept
+		} else if b.versioning {
+			vers = append(vers, &s3Version{
+				id:           s.newVersionID(),
+				modified:     time.Now().UTC(),
+				deleteMarker: true,
+			})
+		} else {
+			vers = nil
+		}
+		if len(vers) == 0 {
+			delete(b.objects, key)
+		} else {
+			b.objects[key] = vers
+		}
+		return nil, nil
+	}
+
+	return nil, &s3Error{
+		Status:  http.StatusNotImplemented,
+		Code:    "NotImplemented",
+		Message: r.Method + " " + r.URL.RequestURI(),
+	}
+}
+
+// put creates a version of an object, which replaces the object if the
+// bucket is not versioned.
+func (s *s3Server) put(b *s3Bucket, key string, data []byte) {
+	v := &s3Version{
+		id:       "null",
+		data:     append([]byte{}, data...),
+		modified: time.Now().UTC(),
+	}
+	if !b.versioning {
+		b.objects[key] = []*s3Version{v}
+		return
+	}
+	v.id = s.newVersionID()
+	b.objects[key] = append(b.objects[key], v)
+}
+
+func (s *s3Server) newVersionID() string {
+	s.lastID++
+	return fmt.Sprintf("v%08d", s.lastID)
+}
+
+// latest returns the current version of an object, or nil if the object
+// does not exist or is deleted.
+func latest(vers []*s3Version) *s3Version {
+	if len(vers) == 0 || vers[len(vers)-1].deleteMarker {
+		return nil
+	}
+	return vers[len(vers)-1]
+}
+
+func hasParam(q url.Values, name string) bool {
+	_, ok := q[name]
+	return ok
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch tm := m.(type) {
+	case map[string]*s3Bucket:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case map[string][]*s3Version:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	case map[string]string:
+		for k := range tm {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func malformed(err error) *s3Error {
+	return &s3Error{
+		Status:  http.StatusBadRequest,
+		Code:    "MalformedXML",
+		Message: err.Error(),
+	}
+}
+
+func writeError(w http.ResponseWriter, e *s3Error) {
+	buf := &bytes.Buffer{}
+	buf.WriteString(xml.Header)
+	xml.NewEncoder(buf).Encode(e)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(e.Status)
+	w.Write(buf.Bytes())
+}
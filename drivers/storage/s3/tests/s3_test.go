@@ -0,0 +1,396 @@
+package s3
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/akutz/gofig"
+	"github.com/akutz/goof"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/emccode/libstorage/api/context"
+	"github.com/emccode/libstorage/api/server"
+	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
+	"github.com/emccode/libstorage/api/types"
+	"github.com/emccode/libstorage/api/utils"
+
+	// load the driver
+	"github.com/emccode/libstorage/drivers/storage/s3"
+	_ "github.com/emccode/libstorage/drivers/storage/s3/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/s3/storage"
+)
+
+const (
+	testRegion     = "eu-west-1"
+	testAccessKey  = "access-key"
+	testSecretKey  = "secret-key"
+	testPrefix     = "ls-"
+	testSnapBucket = "ls-snapshots"
+)
+
+var (
+	testDirs     []string
+	testDirsLock = &sync.Mutex{}
+)
+
+func TestMain(m *testing.M) {
+	server.CloseOnAbort()
+	ec := m.Run()
+	for _, d := range testDirs {
+		os.RemoveAll(d)
+	}
+	os.Exit(ec)
+}
+
+func newTestDir(t *testing.T) string {
+	d, err := ioutil.TempDir("", "libstorage-s3")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	testDirsLock.Lock()
+	defer testDirsLock.Unlock()
+	testDirs = append(testDirs, d)
+	return d
+}
+
+// newTestConfig returns a new S3 stand-in and the configuration of a driver
+// that uses it. The configuration's FUSE helper records its arguments in a
+// file in the directory whose path is returned.
+func newTestConfig(t *testing.T) (*s3Server, []byte, string) {
+	d := newTestDir(t)
+	helper := path.Join(d, "mount.s3")
+	if !assert.NoError(t, ioutil.WriteFile(helper, []byte(fmt.Sprintf(
+		"#!/bin/sh\necho \"$@\" > %s\n", path.Join(d, "args"))),
+		0755)) {
+		t.FailNow()
+	}
+
+	s := newS3Server(testRegion, testAccessKey, testSecretKey)
+	return s, []byte(fmt.Sprintf(`
+s3:
+  endpoint:       %s
+  region:         %s
+  accessKey:      %s
+  secretKey:      %s
+  bucketPrefix:   %s
+  snapshotBucket: %s
+linux:
+  fuse:
+    helper:  %s
+    options: allow_other
+`, s.URL, testRegion, testAccessKey, testSecretKey, testPrefix,
+		testSnapBucket, helper)), d
+}
+
+func TestServices(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().Services(nil)
+		assert.NoError(t, err)
+		if assert.Contains(t, reply, s3.Name) {
+			assert.Equal(t, types.Object, reply[s3.Name].Driver.Type)
+			assert.True(t, reply[s3.Name].Driver.NextDevice.Ignore)
+		}
+	}
+	apitests.Run(t, s3.Name, tc, tf)
+}
+
+func TestInstanceID(t *testing.T) {
+	hostname, err := utils.HostName()
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, s3.Name)
+		iid, err := client.Executor().InstanceID(ctx, utils.NewStore())
+		assert.NoError(t, err)
+		if assert.NotNil(t, iid) {
+			assert.Equal(t, hostname, iid.ID)
+		}
+
+		ld, err := client.Executor().LocalDevices(
+			ctx, &types.LocalDevicesOpts{
+				ScanType: types.DeviceScanQuick,
+				Opts:     utils.NewStore(),
+			})
+		if assert.NoError(t, err) {
+			assert.Equal(t, s3.Name, ld.Driver)
+		}
+	}
+	apitests.Run(t, s3.Name, tc, tf)
+}
+
+func TestVolumes(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, s3.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, testPrefix+"vol1", vol.ID)
+		assert.Equal(t, "vol1", vol.Name)
+
+		// the buckets without the prefix and the snapshot bucket are not
+		// volumes
+		for _, name := range []string{"other", "vol2"} {
+			_, err := client.API().VolumeCreate(
+				nil, s3.Name, &types.VolumeCreateRequest{Name: name})
+			assert.NoError(t, err)
+		}
+		_, err = client.API().VolumeSnapshot(
+			nil, s3.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		assert.NoError(t, err)
+		assert.Contains(t, s.bucketNames(), testSnapBucket)
+
+		vols, err := client.API().VolumesByService(nil, s3.Name, false)
+		if assert.NoError(t, err) {
+			assert.Len(t, vols, 3)
+			assert.NotContains(t, vols, testSnapBucket)
+		}
+
+		_, err = client.API().VolumeInspect(nil, s3.Name, testSnapBucket, false)
+		assert.Error(t, err)
+		_, err = client.API().VolumeInspect(nil, s3.Name, "vol1", false)
+		assert.Error(t, err)
+
+		// bucket names are lower case
+		_, err = client.API().VolumeCreate(
+			nil, s3.Name, &types.VolumeCreateRequest{Name: "Vol3"})
+		assert.Error(t, err)
+	}
+	apitests.Run(t, s3.Name, tc, tf)
+}
+
+func TestVolumeLifecycle(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		size := int64(2)
+		volType := "standard"
+		vol, err := client.API().VolumeCreate(
+			nil, s3.Name, &types.VolumeCreateRequest{
+				Name: "vol1",
+				Size: &size,
+				Type: &volType,
+				Opts: map[string]interface{}{"owner": "libstorage"},
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, size, vol.Size)
+		assert.Equal(t, volType, vol.Type)
+		assert.Equal(t, testRegion, vol.AvailabilityZone)
+		assert.Equal(t, "libstorage", vol.Fields["owner"])
+
+		_, err = client.API().VolumeCreate(
+			nil, s3.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		assert.Error(t, err)
+
+		// enough objects that the listings are paged
+		for _, k := range []string{"a", "b/c", "b/d", "e f"} {
+			s.putObject(vol.ID, k, []byte("v1 "+k))
+		}
+
+		vol, err = client.API().VolumeInspect(nil, s3.Name, vol.ID, false)
+		if assert.NoError(t, err) {
+			assert.Equal(t, size, vol.Size)
+			assert.Equal(t, "libstorage", vol.Fields["owner"])
+		}
+
+		vol, err = client.API().VolumeResize(
+			nil, s3.Name, vol.ID, &types.VolumeResizeRequest{Size: 4})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), vol.Size)
+		}
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, s3.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snap1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "snap1", snap.Name)
+		assert.Equal(t, vol.ID, snap.VolumeID)
+		assert.Equal(t, int64(4), snap.VolumeSize)
+		assert.Equal(t, []byte("v1 e f"),
+			s.getObject(testSnapBucket, snap.ID+"/e f"))
+
+		// the snapshot is not changed by changes to the volume
+		s.putObject(vol.ID, "a", []byte("v2 a"))
+
+		snapCopy, err := client.API().SnapshotCopy(
+			nil, s3.Name, snap.ID,
+			&types.SnapshotCopyRequest{SnapshotName: "snap2"})
+		if assert.NoError(t, err) {
+			assert.NotEqual(t, snap.ID, snapCopy.ID)
+			assert.Equal(t, vol.ID, snapCopy.VolumeID)
+			assert.Equal(t, []byte("v1 b/c"),
+				s.getObject(testSnapBucket, snapCopy.ID+"/b/c"))
+		}
+
+		snaps, err := client.API().SnapshotsByService(nil, s3.Name)
+		if assert.NoError(t, err) {
+			assert.Len(t, snaps, 2)
+		}
+
+		vol2, err := client.API().VolumeCreateFromSnapshot(
+			nil, s3.Name, snap.ID,
+			&types.VolumeCreateRequest{Name: "vol2"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), vol2.Size)
+			assert.Equal(t, []byte("v1 a"), s.getObject(vol2.ID, "a"))
+			assert.Equal(t, []byte("v1 e f"), s.getObject(vol2.ID, "e f"))
+		}
+
+		vol3, err := client.API().VolumeCopy(
+			nil, s3.Name, vol.ID,
+			&types.VolumeCopyRequest{VolumeName: "vol3"})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(4), vol3.Size)
+			assert.Equal(t, volType, vol3.Type)
+			assert.Equal(t, "libstorage", vol3.Fields["owner"])
+			assert.Equal(t, []byte("v2 a"), s.getObject(vol3.ID, "a"))
+		}
+
+		// a volume cannot be shrunk to less than the size of its objects
+		s.putObject(vol.ID, "big", make([]byte, 1024))
+		_, err = client.API().VolumeResize(
+			nil, s3.Name, vol.ID, &types.VolumeResizeRequest{Size: 0})
+		assert.Error(t, err)
+		vol, err = client.API().VolumeResize(
+			nil, s3.Name, vol.ID, &types.VolumeResizeRequest{Size: 1})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(1), vol.Size)
+		}
+
+		assert.NoError(t, client.API().SnapshotRemove(nil, s3.Name, snap.ID))
+		assert.Error(t, client.API().SnapshotRemove(nil, s3.Name, snap.ID))
+		assert.Nil(t, s.getObject(testSnapBucket, snap.ID+"/a"))
+
+		for _, v := range []*types.Volume{vol, vol2, vol3} {
+			if v != nil {
+				assert.NoError(t, client.API().VolumeRemove(nil, s3.Name, v.ID))
+			}
+		}
+		assert.NotContains(t, s.bucketNames(), vol.ID)
+		_, err = client.API().VolumeInspect(nil, s3.Name, vol.ID, false)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, s3.Name, tc, tf)
+}
+
+func TestVolumeAttach(t *testing.T) {
+	s, tc, d := newTestConfig(t)
+	defer s.Close()
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, s3.Name, &types.VolumeCreateRequest{Name: "vol1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		vol, token, err := client.API().VolumeAttach(
+			nil, s3.Name, vol.ID, &types.VolumeAttachRequest{})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Empty(t, token)
+
+		u, _ := url.Parse(s.URL)
+		dev := fmt.Sprintf("s3+http://%s/%s", u.Host, vol.ID)
+		if assert.Len(t, vol.Attachments, 1) {
+			assert.Equal(t, dev, vol.Attachments[0].DeviceName)
+		}
+
+		_, _, err = client.API().VolumeAttach(
+			nil, s3.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.Error(t, err)
+
+		// the device is mounted with the FUSE helper
+		if runtime.GOOS == "linux" {
+			ctx := context.Background()
+			mp := path.Join(d, "mnt")
+			assert.NoError(t, client.OS().Format(
+				ctx, dev, &types.DeviceFormatOpts{NewFSType: "ext4"}))
+			assert.NoError(t, client.OS().Mount(
+				ctx, dev, mp, &types.DeviceMountOpts{}))
+			args, err := ioutil.ReadFile(path.Join(d, "args"))
+			if assert.NoError(t, err) {
+				assert.Equal(t,
+					fmt.Sprintf("%s %s -o fsname=%s,allow_other", dev, mp, dev),
+					strings.TrimSpace(string(args)))
+			}
+		}
+
+		vol, err = client.API().VolumeDetach(
+			nil, s3.Name, vol.ID, &types.VolumeDetachRequest{})
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 0)
+		}
+		vol, err = client.API().VolumeInspect(nil, s3.Name, vol.ID, true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 0)
+		}
+
+		vol, _, err = client.API().VolumeAttach(
+			nil, s3.Name, vol.ID, &types.VolumeAttachRequest{})
+		assert.NoError(t, err)
+		vol, _, err = client.API().VolumeAttach(
+			nil, s3.Name, vol.ID, &types.VolumeAttachRequest{Force: true})
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, 1)
+		}
+
+		// the attachments are recorded in an object rather than a bucket
+		// tag, so there is no limit on the length of the instance IDs
+		key := "attachments/" + vol.ID + ".json"
+		ids := []string{}
+		for i := 0; i < 10; i++ {
+			ids = append(ids, fmt.Sprintf("instance-%040d", i))
+		}
+		buf, _ := json.Marshal(ids)
+		s.putObject(testSnapBucket, key, buf)
+		vol, err = client.API().VolumeInspect(nil, s3.Name, vol.ID, true)
+		if assert.NoError(t, err) {
+			assert.Len(t, vol.Attachments, len(ids))
+		}
+
+		// without shared mounts the volume cannot be attached to more than
+		// one instance
+		_, _, err = client.API().VolumeAttach(
+			nil, s3.Name, vol.ID, &types.VolumeAttachRequest{})
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 501, httpErr.Status())
+		}
+
+		assert.NoError(t, client.API().VolumeRemove(nil, s3.Name, vol.ID))
+		assert.Nil(t, s.getObject(testSnapBucket, key))
+	}
+	apitests.Run(t, s3.Name, tc, tf)
+}
+
+func TestConformance(t *testing.T) {
+	s, tc, _ := newTestConfig(t)
+	defer s.Close()
+	rep := (&conformance.Suite{
+		Driver: s3.Name,
+		Config: tc,
+	}).Run(t)
+	assert.Empty(t, rep.Unsupported)
+}
@@ -3,6 +3,8 @@ package scaleio
 import (
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const (
@@ -28,13 +30,13 @@ func init() {
 }
 
 func registerConfig() {
-	r := gofig.NewRegistration("ScaleIO")
+	r := apiconfig.NewRegistration("ScaleIO")
 	r.Key(gofig.String, "", "", "", "scaleio.endpoint")
 	r.Key(gofig.Bool, "", false, "", "scaleio.insecure")
 	r.Key(gofig.Bool, "", false, "", "scaleio.useCerts")
 	r.Key(gofig.String, "", "", "", "scaleio.userID")
 	r.Key(gofig.String, "", "", "", "scaleio.userName")
-	r.Key(gofig.String, "", "", "", "scaleio.password")
+	r.Key(gofig.SecureString, "", "", "", "scaleio.password")
 	r.Key(gofig.String, "", "", "", "scaleio.systemID")
 	r.Key(gofig.String, "", "", "", "scaleio.systemName")
 	r.Key(gofig.String, "", "", "", "scaleio.protectionDomainID")
@@ -43,5 +45,7 @@ func registerConfig() {
 	r.Key(gofig.String, "", "", "", "scaleio.storagePoolName")
 	r.Key(gofig.String, "", "", "", "scaleio.thinOrThick")
 	r.Key(gofig.String, "", "", "", "scaleio.version")
-	gofig.Register(r)
+	r.Constrain("scaleio.thinOrThick",
+		apiconfig.OneOf("ThinProvisioned", "ThickProvisioned"))
+	apiconfig.Register(r)
 }
@@ -122,6 +122,15 @@ func (d *driver) NextDeviceInfo(
 	return nil, nil
 }
 
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		IOPS: true,
+	}, nil
+}
+
 func (d *driver) InstanceInspect(
 	ctx types.Context,
 	opts types.Store) (*types.Instance, error) {
@@ -368,7 +377,7 @@ func (d *driver) VolumeCreate(ctx types.Context, volumeName string,
 		volume.IOPS = *opts.IOPS
 	}
 
-	vol, err := d.createVolume(ctx, volumeName, volume)
+	vol, err := d.createVolume(ctx, volumeName, volume, opts.Opts)
 	if err != nil {
 		return nil, err
 	}
@@ -649,7 +658,7 @@ func (d *driver) getVolume(
 }
 
 func (d *driver) createVolume(ctx types.Context, volumeName string,
-	vol *types.Volume) (*siotypes.VolumeResp, error) {
+	vol *types.Volume, opts types.Store) (*siotypes.VolumeResp, error) {
 
 	volumeName = shrink(volumeName)
 
@@ -665,15 +674,17 @@ func (d *driver) createVolume(ctx types.Context, volumeName string,
 	volumeParam := &siotypes.VolumeParam{
 		Name:           volumeName,
 		VolumeSizeInKb: strconv.Itoa(int(vol.Size) * 1024 * 1024),
-		VolumeType:     d.thinOrThick(),
+		VolumeType:     d.volumeThinOrThick(opts),
 	}
 
-	if vol.Type == "" {
-		vol.Type = d.storagePool.StoragePool.Name
-		fields["volumeType"] = vol.Type
+	storagePool, err := d.volumeStoragePool(opts, vol.Type)
+	if err != nil {
+		return nil, goof.WithFieldsE(fields, "error finding storage pool", err)
 	}
+	vol.Type = storagePool.StoragePool.Name
+	fields["volumeType"] = vol.Type
 
-	volumeResp, err := d.client.CreateVolume(volumeParam, vol.Type)
+	volumeResp, err := storagePool.CreateVolume(volumeParam)
 	if err != nil {
 		return nil, goof.WithFieldsE(fields, "error creating volume", err)
 	}
@@ -788,6 +799,85 @@ func (d *driver) thinOrThick() string {
 	return thinOrThick
 }
 
+// volumeThinOrThick returns the provisioning of a new volume. The
+// thinOrThick option of a request, such as one that a volume profile sets,
+// overrides the configured provisioning.
+func (d *driver) volumeThinOrThick(opts types.Store) string {
+	if opts != nil {
+		if custom := opts.GetStore("opts"); custom != nil {
+			switch v := custom.GetString("thinOrThick"); v {
+			case "ThinProvisioned", "ThickProvisioned":
+				return v
+			}
+		}
+	}
+	return d.thinOrThick()
+}
+
+// volumeStoragePool returns the storage pool of a new volume. The
+// protectionDomainID or protectionDomainName option of a request, such as
+// one that a volume profile sets, selects the protection domain of the pool.
+// The storagePoolID or storagePoolName option, or else the volume's type,
+// selects the pool. The configured storage pool is used if a request selects
+// neither.
+func (d *driver) volumeStoragePool(
+	opts types.Store, volumeType string) (*sio.StoragePool, error) {
+
+	var pdID, pdName, spID, spName string
+	if opts != nil {
+		if custom := opts.GetStore("opts"); custom != nil {
+			pdID = custom.GetString("protectionDomainID")
+			pdName = custom.GetString("protectionDomainName")
+			spID = custom.GetString("storagePoolID")
+			spName = custom.GetString("storagePoolName")
+		}
+	}
+	if spID == "" && spName == "" {
+		spName = volumeType
+	}
+
+	storagePool := sio.NewStoragePool(d.client)
+
+	if pdID == "" && pdName == "" {
+		if spID == "" && spName == "" {
+			return d.storagePool, nil
+		}
+		sp, err := d.client.FindStoragePool(spID, spName, "")
+		if err != nil {
+			return nil, goof.WithFieldsE(goof.Fields{
+				"storagePoolId":   spID,
+				"storagePoolName": spName,
+			}, "error finding storage pool", err)
+		}
+		storagePool.StoragePool = sp
+		return storagePool, nil
+	}
+
+	pd, err := d.system.FindProtectionDomain(pdID, pdName, "")
+	if err != nil {
+		return nil, goof.WithFieldsE(goof.Fields{
+			"domainId":   pdID,
+			"domainName": pdName,
+		}, "error finding protection domain", err)
+	}
+	protectionDomain := sio.NewProtectionDomain(d.client)
+	protectionDomain.ProtectionDomain = pd
+
+	if spID == "" && spName == "" {
+		spName = d.storagePool.StoragePool.Name
+	}
+	sp, err := protectionDomain.FindStoragePool(spID, spName, "")
+	if err != nil {
+		return nil, goof.WithFieldsE(goof.Fields{
+			"domainId":        pd.ID,
+			"storagePoolId":   spID,
+			"storagePoolName": spName,
+		}, "error finding storage pool", err)
+	}
+	storagePool.StoragePool = sp
+	return storagePool, nil
+}
+
 func (d *driver) version() string {
 	return d.config.GetString("scaleio.version")
 }
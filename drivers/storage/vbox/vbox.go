@@ -1,6 +1,10 @@
 package vbox
 
-import "github.com/akutz/gofig"
+import (
+	"github.com/akutz/gofig"
+
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
+)
 
 const (
 	// Name is the provider's name.
@@ -13,9 +17,9 @@ func init() {
 
 func registerConfig() {
 
-	r := gofig.NewRegistration("VirtualBox")
+	r := apiconfig.NewRegistration("VirtualBox")
 	r.Key(gofig.String, "", "", "", "virtualbox.username")
-	r.Key(gofig.String, "", "", "", "virtualbox.password")
+	r.Key(gofig.SecureString, "", "", "", "virtualbox.password")
 	r.Key(gofig.String, "", "http://10.0.2.2:18083", "", "virtualbox.endpoint")
 	r.Key(gofig.String, "", "", "", "virtualbox.volumePath")
 	r.Key(gofig.String, "", "", "", "virtualbox.localMachineNameOrId")
@@ -24,5 +28,5 @@ func registerConfig() {
 	r.Key(gofig.String, "", "/dev/disk/by-id", "", "virtualbox.diskIDPath")
 	r.Key(gofig.String,
 		"", "/sys/class/scsi_host/", "", "virtualbox.scsiHostPath")
-	gofig.Register(r)
+	apiconfig.Register(r)
 }
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"sync"
 
 	log "github.com/Sirupsen/logrus"
@@ -27,6 +29,8 @@ type driver struct {
 	config      gofig.Config
 	rootDir     string
 	devFilePath string
+	volPath     string
+	blockMode   bool
 }
 
 func init() {
@@ -46,6 +50,8 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 
 	d.rootDir = vfs.RootDir(config)
 	d.devFilePath = vfs.DeviceFilePath(config)
+	d.volPath = vfs.VolumesDirPath(config)
+	d.blockMode = vfs.BlockMode(config)
 	if !gotil.FileExists(d.devFilePath) {
 		err := ioutil.WriteFile(d.devFilePath, initialDeviceFile, 0644)
 		if err != nil {
@@ -138,6 +144,10 @@ func (d *driver) LocalDevices(
 		"dev.path": d.devFilePath,
 	}).Debug("config info")
 
+	if d.blockMode {
+		return d.loopDevices()
+	}
+
 	var devFileRWL *sync.RWMutex
 	func() {
 		devFileLocksRWL.RLock()
@@ -182,6 +192,27 @@ func (d *driver) LocalDevices(
 	return &types.LocalDevices{Driver: vfs.Name, DeviceMap: localDevs}, nil
 }
 
+// loopDevices returns a map of the IDs of the volumes whose image files are
+// attached to loop devices and the paths to the loop devices.
+func (d *driver) loopDevices() (*types.LocalDevices, error) {
+	devs, err := vfs.LoopDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	volPath := vfs.RealPath(d.volPath)
+	localDevs := map[string]string{}
+
+	for dev, img := range devs {
+		if filepath.Dir(img) != volPath || filepath.Ext(img) != vfs.ImageExt {
+			continue
+		}
+		localDevs[strings.TrimSuffix(filepath.Base(img), vfs.ImageExt)] = dev
+	}
+
+	return &types.LocalDevices{Driver: vfs.Name, DeviceMap: localDevs}, nil
+}
+
 var initialDeviceFile = []byte(`/dev/xvda
 /dev/xvdb
 /dev/xvdc
@@ -3,8 +3,10 @@ package storage
 import (
 	"fmt"
 	"os"
+	"sort"
 	"time"
 
+	log "github.com/Sirupsen/logrus"
 	"github.com/akutz/gofig"
 	"github.com/akutz/goof"
 	"github.com/akutz/gotil"
@@ -12,6 +14,7 @@ import (
 	"github.com/emccode/libstorage/api/registry"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/filters"
 	"github.com/emccode/libstorage/drivers/storage/vfs"
 )
 
@@ -26,6 +29,8 @@ type driver struct {
 
 	volPath  string
 	snapPath string
+
+	blockMode bool
 }
 
 func init() {
@@ -46,8 +51,12 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 
 	d.volPath = vfs.VolumesDirPath(config)
 	d.snapPath = vfs.SnapshotsDirPath(config)
+	d.blockMode = vfs.BlockMode(config)
 
-	ctx.WithField("vfs.root.path", vfs.RootDir(config)).Info("vfs.root")
+	ctx.WithFields(log.Fields{
+		"vfs.root.path": vfs.RootDir(config),
+		"vfs.blockMode": d.blockMode,
+	}).Info("vfs.root")
 
 	os.MkdirAll(d.volPath, 0755)
 	os.MkdirAll(d.snapPath, 0755)
@@ -71,6 +80,9 @@ func (d *driver) Init(ctx types.Context, config gofig.Config) error {
 }
 
 func (d *driver) Type(ctx types.Context) (types.StorageType, error) {
+	if d.blockMode {
+		return types.Block, nil
+	}
 	return types.Object, nil
 }
 
@@ -81,6 +93,19 @@ func (d *driver) NextDeviceInfo(
 	}, nil
 }
 
+// Capabilities returns the operations and volume options that the driver
+// supports.
+func (d *driver) Capabilities(
+	ctx types.Context) (*types.Capabilities, error) {
+	return &types.Capabilities{
+		Snapshots:   true,
+		Copy:        true,
+		Resize:      true,
+		MultiAttach: true,
+		IOPS:        true,
+	}, nil
+}
+
 func (d *driver) InstanceInspect(
 	ctx types.Context,
 	opts types.Store) (*types.Instance, error) {
@@ -127,10 +152,59 @@ func (d *driver) Volumes(
 		if err != nil {
 			return nil, err
 		}
+		if opts != nil && opts.Filter != nil {
+			ok, err := filters.Match(opts.Filter, v)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
 		volumes = append(volumes, v)
 	}
 
-	return utils.SortVolumeByID(volumes), nil
+	volumes = utils.SortVolumeByID(volumes)
+	if opts != nil && opts.Page != nil {
+		volumes = pageVolumes(volumes, opts.Page)
+	}
+	return volumes, nil
+}
+
+// pageVolumes returns the page of volumes, which are sorted by ID, if the page
+// is sorted by ID as well. The driver's continuation token is the ID of the
+// last volume of the page. The volumes are returned for the server to page if
+// the page is sorted by other fields.
+func pageVolumes(volumes []*types.Volume, page *types.Page) []*types.Volume {
+	switch len(page.Sort) {
+	case 0:
+	case 1:
+		if page.Sort[0].Field != "id" || page.Sort[0].Descending {
+			return volumes
+		}
+	default:
+		return volumes
+	}
+
+	start := page.Offset
+	if page.Token != "" {
+		start = sort.Search(len(volumes), func(i int) bool {
+			return volumes[i].ID > page.Token
+		})
+	}
+	if start > len(volumes) {
+		start = len(volumes)
+	}
+	end := len(volumes)
+	if page.Limit > 0 && start+page.Limit < end {
+		end = start + page.Limit
+	}
+
+	page.Paged = true
+	if end < len(volumes) {
+		page.NextToken = volumes[end-1].ID
+	}
+	return volumes[start:end]
 }
 
 func (d *driver) VolumeInspect(
@@ -173,6 +247,13 @@ func (d *driver) VolumeCreate(
 		}
 	}
 
+	if d.blockMode {
+		v.Size = blockSize(v.Size)
+		if err := vfs.CreateImage(d.volImagePath(v.ID), v.Size); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := d.writeVolume(v); err != nil {
 		return nil, err
 	}
@@ -223,6 +304,11 @@ func (d *driver) VolumeCreateFromSnapshot(
 		}
 	}
 
+	if err := d.copyImage(
+		d.snapImagePath(snap.ID), d.volImagePath(v.ID), v.Size); err != nil {
+		return nil, err
+	}
+
 	if err := d.writeVolume(v); err != nil {
 		return nil, err
 	}
@@ -256,6 +342,13 @@ func (d *driver) VolumeCopy(
 		}
 	}
 
+	if err := d.copyImage(
+		d.volImagePath(ogVol.ID),
+		d.volImagePath(newVol.ID),
+		newVol.Size); err != nil {
+		return nil, err
+	}
+
 	if err := d.writeVolume(newVol); err != nil {
 		return nil, err
 	}
@@ -263,6 +356,37 @@ func (d *driver) VolumeCopy(
 	return newVol, nil
 }
 
+func (d *driver) VolumeResize(
+	ctx types.Context,
+	volumeID string,
+	opts *types.VolumeResizeOpts) (*types.Volume, error) {
+
+	v, err := d.getVolumeByID(volumeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if d.blockMode {
+		if opts.Size < v.Size {
+			return nil, goof.WithFields(goof.Fields{
+				"volumeID": volumeID,
+				"size":     v.Size,
+				"newSize":  opts.Size,
+			}, "cannot shrink volume in block mode")
+		}
+		if err := d.resizeImage(v.ID, opts.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	v.Size = opts.Size
+	if err := d.writeVolume(v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
 func (d *driver) VolumeSnapshot(
 	ctx types.Context,
 	volumeID, snapshotName string,
@@ -289,6 +413,11 @@ func (d *driver) VolumeSnapshot(
 		}
 	}
 
+	if err := d.copyImage(
+		d.volImagePath(v.ID), d.snapImagePath(s.ID), v.Size); err != nil {
+		return nil, err
+	}
+
 	if err := d.writeSnapshot(s); err != nil {
 		return nil, err
 	}
@@ -305,6 +434,9 @@ func (d *driver) VolumeRemove(
 	if !gotil.FileExists(volJSONPath) {
 		return utils.NewNotFoundError(volumeID)
 	}
+	if err := d.removeImage(d.volImagePath(volumeID)); err != nil {
+		return err
+	}
 	os.Remove(volJSONPath)
 	return nil
 }
@@ -324,6 +456,14 @@ func (d *driver) VolumeAttach(
 		nextDevice = *opts.NextDevice
 	}
 
+	token := "1234"
+	if d.blockMode {
+		if nextDevice, err = d.attachImage(vol.ID, vol.Size); err != nil {
+			return nil, "", err
+		}
+		token = vol.ID
+	}
+
 	att := &types.VolumeAttachment{
 		VolumeID:   vol.ID,
 		InstanceID: context.MustInstanceID(ctx),
@@ -338,7 +478,7 @@ func (d *driver) VolumeAttach(
 
 	vol.Attachments = []*types.VolumeAttachment{att}
 
-	return vol, "1234", nil
+	return vol, token, nil
 }
 
 func (d *driver) VolumeDetach(
@@ -351,6 +491,12 @@ func (d *driver) VolumeDetach(
 		return nil, err
 	}
 
+	if d.blockMode {
+		if err := d.detachImage(vol.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	iid := context.MustInstanceID(ctx)
 
 	y := -1
@@ -376,6 +522,11 @@ func (d *driver) Snapshots(
 	ctx types.Context,
 	opts types.Store) ([]*types.Snapshot, error) {
 
+	filter, err := filters.FromStore(opts)
+	if err != nil {
+		return nil, err
+	}
+
 	snapJSONPaths, err := d.getSnapJSONs()
 	if err != nil {
 		return nil, err
@@ -388,6 +539,15 @@ func (d *driver) Snapshots(
 		if err != nil {
 			return nil, err
 		}
+		if filter != nil {
+			ok, err := filters.Match(filter, s)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
 		snapshots = append(snapshots, s)
 	}
 
@@ -432,6 +592,13 @@ func (d *driver) SnapshotCopy(
 		}
 	}
 
+	if err := d.copyImage(
+		d.snapImagePath(ogSnap.ID),
+		d.snapImagePath(newSnap.ID),
+		newSnap.VolumeSize); err != nil {
+		return nil, err
+	}
+
 	if err := d.writeSnapshot(newSnap); err != nil {
 		return nil, err
 	}
@@ -448,6 +615,9 @@ func (d *driver) SnapshotRemove(
 	if !gotil.FileExists(snapJSONPath) {
 		return utils.NewNotFoundError(snapshotID)
 	}
+	if err := d.removeImage(d.snapImagePath(snapshotID)); err != nil {
+		return err
+	}
 	os.Remove(snapJSONPath)
 	return nil
 }
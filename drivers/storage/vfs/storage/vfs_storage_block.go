@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"github.com/akutz/gotil"
+	"github.com/emccode/libstorage/drivers/storage/vfs"
+)
+
+const (
+	// defaultBlockSize is the size, in GiB, of a volume that is created in
+	// block mode without a size.
+	defaultBlockSize = 1
+)
+
+func (d *driver) volImagePath(volumeID string) string {
+	return vfs.VolumeImagePath(d.config, volumeID)
+}
+
+func (d *driver) snapImagePath(snapshotID string) string {
+	return vfs.SnapshotImagePath(d.config, snapshotID)
+}
+
+// copyImage copies the image file of a volume or snapshot in block mode. A
+// volume or snapshot created before block mode was enabled has no image
+// file, in which case an empty image file is created instead.
+func (d *driver) copyImage(src, dst string, size int64) error {
+	if !d.blockMode {
+		return nil
+	}
+	if !gotil.FileExists(src) {
+		return vfs.CreateImage(dst, blockSize(size))
+	}
+	return vfs.CopyImage(src, dst, size)
+}
+
+// removeImage removes the image file of a volume or snapshot in block mode.
+func (d *driver) removeImage(path string) error {
+	if !d.blockMode {
+		return nil
+	}
+	return vfs.RemoveImage(path)
+}
+
+// attachImage attaches a volume's image file to a loop device and returns
+// the path to the loop device. A volume that is already attached keeps its
+// loop device.
+func (d *driver) attachImage(volumeID string, size int64) (string, error) {
+	img := d.volImagePath(volumeID)
+	if err := vfs.CreateImage(img, blockSize(size)); err != nil {
+		return "", err
+	}
+	dev, err := vfs.LoopDevice(img)
+	if err != nil {
+		return "", err
+	}
+	if dev != "" {
+		return dev, nil
+	}
+	return vfs.AttachLoop(img)
+}
+
+// resizeImage grows a volume's image file and the loop device to which it
+// is attached.
+func (d *driver) resizeImage(volumeID string, size int64) error {
+	img := d.volImagePath(volumeID)
+	if err := vfs.CreateImage(img, size); err != nil {
+		return err
+	}
+	dev, err := vfs.LoopDevice(img)
+	if err != nil || dev == "" {
+		return err
+	}
+	return vfs.ResizeLoop(dev)
+}
+
+// detachImage detaches a volume's image file from its loop device.
+func (d *driver) detachImage(volumeID string) error {
+	dev, err := vfs.LoopDevice(d.volImagePath(volumeID))
+	if err != nil || dev == "" {
+		return err
+	}
+	return vfs.DetachLoop(dev)
+}
+
+func blockSize(size int64) int64 {
+	if size == 0 {
+		return defaultBlockSize
+	}
+	return size
+}
@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,11 +21,15 @@ import (
 	"github.com/akutz/gotil"
 	"github.com/stretchr/testify/assert"
 
+	apiclient "github.com/emccode/libstorage/api/client"
 	"github.com/emccode/libstorage/api/context"
 	"github.com/emccode/libstorage/api/server"
 	apitests "github.com/emccode/libstorage/api/tests"
+	"github.com/emccode/libstorage/api/tests/conformance"
 	"github.com/emccode/libstorage/api/types"
 	"github.com/emccode/libstorage/api/utils"
+	"github.com/emccode/libstorage/api/utils/paging"
+	"github.com/emccode/libstorage/client"
 
 	// load the vfs driver packages
 
@@ -83,6 +89,13 @@ func TestServiceInpspect(t *testing.T) {
 		assert.Equal(t, vfs.Name, reply.Name)
 		assert.Equal(t, vfs.Name, reply.Driver.Name)
 		assert.True(t, reply.Driver.NextDevice.Ignore)
+		if assert.NotNil(t, reply.Driver.Capabilities) {
+			assert.True(t, reply.Driver.Capabilities.Snapshots)
+			assert.True(t, reply.Driver.Capabilities.Copy)
+			assert.True(t, reply.Driver.Capabilities.Resize)
+			assert.True(t, reply.Driver.Capabilities.MultiAttach)
+			assert.True(t, reply.Driver.Capabilities.IOPS)
+		}
 	}
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
@@ -258,6 +271,238 @@ func TestSnapshotsByService(t *testing.T) {
 	apitests.Run(t, vfs.Name, tc, tf)
 }
 
+func TestVolumesByServicePage(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		page := &types.PageRequest{Limit: 2, Sort: "-name"}
+		reply, next, err := client.API().VolumesByServicePage(
+			nil, "vfs", false, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 2)
+		assert.Contains(t, reply, "vfs-002")
+		assert.Contains(t, reply, "vfs-001")
+		assert.NotEmpty(t, next)
+
+		page.Token = next
+		reply, next, err = client.API().VolumesByServicePage(
+			nil, "vfs", false, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 1)
+		assert.Contains(t, reply, "vfs-000")
+		assert.Empty(t, next)
+
+		_, _, err = client.API().VolumesByServicePage(
+			nil, "vfs", false, &types.PageRequest{Sort: "bogus"})
+		assert.Error(t, err)
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumesByServicePageDriverToken(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		page := &types.PageRequest{Limit: 2, Sort: "id"}
+		reply, next, err := client.API().VolumesByServicePage(
+			nil, "vfs", false, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 2)
+		assert.Contains(t, reply, "vfs-000")
+		assert.Contains(t, reply, "vfs-001")
+
+		// the driver pages volumes sorted by ID and returns its own token
+		offset, driverToken, err := paging.DecodeToken(next)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, offset)
+		assert.Equal(t, "vfs-001", driverToken)
+
+		page.Token = next
+		reply, next, err = client.API().VolumesByServicePage(
+			nil, "vfs", false, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 1)
+		assert.Contains(t, reply, "vfs-002")
+		assert.Empty(t, next)
+
+		// a driver token cannot continue a page that the server sorts
+		page.Sort = "-name"
+		_, _, err = client.API().VolumesByServicePage(
+			nil, "vfs", false, page)
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 400, httpErr.Status())
+		}
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumeIterator(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		it := apiclient.NewVolumeIterator(
+			nil, client.API(), "", false,
+			&types.PageRequest{Limit: 1, Sort: "-id"})
+		ids := []string{}
+		for it.Next() {
+			assert.Equal(t, "vfs", it.Service())
+			ids = append(ids, it.Volume().ID)
+		}
+		assert.NoError(t, it.Err())
+		assert.Equal(t, []string{"vfs-002", "vfs-001", "vfs-000"}, ids)
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestSnapshotIterator(t *testing.T) {
+	tc, _, _, snaps := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		it := apiclient.NewSnapshotIterator(
+			nil, client.API(), "vfs", &types.PageRequest{Limit: 4, Sort: "id"})
+		ids := []string{}
+		for it.Next() {
+			ids = append(ids, it.Snapshot().ID)
+		}
+		assert.NoError(t, it.Err())
+		assert.Len(t, ids, len(snaps))
+		assert.Equal(t, "vfs-000-000", ids[0])
+		assert.Equal(t, "vfs-002-002", ids[len(ids)-1])
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumesWithFilter(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(
+			context.FilterKey, "(&(attachments=*)(!(name=*001)))")
+		reply, err := client.API().VolumesByService(ctx, "vfs", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply, 1)
+		assert.Contains(t, reply, "vfs-000")
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestSnapshotsWithFilter(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(
+			context.FilterKey, "(|(volumeID=vfs-001)(id=vfs-002-000))")
+		reply, err := client.API().Snapshots(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Len(t, reply["vfs"], 4)
+		assert.Contains(t, reply["vfs"], "vfs-001-002")
+		assert.Contains(t, reply["vfs"], "vfs-002-000")
+
+		ctx = context.Background().WithValue(context.FilterKey, "name=bad")
+		_, err = client.API().SnapshotsByService(ctx, "vfs")
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 400, httpErr.Status())
+		}
+
+		_, err = client.API().Tasks(ctx)
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 400, httpErr.Status())
+		}
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumeInspectByName(t *testing.T) {
+	tc, _, vols, _ := newTestConfigAll(t)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().VolumeInspectByName(
+			nil, "vfs", "volume 001", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.EqualValues(t, vols[reply.ID], reply)
+		assert.Equal(t, "vfs-001", reply.ID)
+
+		_, err = client.API().VolumeInspectByName(
+			nil, "vfs", "Volume 009", false)
+		assert.Error(t, err)
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+const uniqueNamesYAML = `
+libstorage:
+  server:
+    volumes:
+      uniqueNames: true
+`
+
+func TestVolumeCreateUniqueNames(t *testing.T) {
+	tc, _, _, _ := newTestConfigAll(t)
+	tc = append(tc, []byte(uniqueNamesYAML)...)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		_, err := client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{Name: "VOLUME 000"})
+		if assert.Error(t, err) {
+			httpErr, ok := err.(goof.HTTPError)
+			if assert.True(t, ok) {
+				assert.Equal(t, 409, httpErr.Status())
+			}
+		}
+
+		reply, err := client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{Name: "Volume 003"})
+		assert.NoError(t, err)
+		assert.NotNil(t, reply)
+
+		_, err = client.API().VolumeCopy(
+			nil, vfs.Name, "vfs-000",
+			&types.VolumeCopyRequest{VolumeName: "volume 001"})
+		if assert.Error(t, err) {
+			httpErr, ok := err.(goof.HTTPError)
+			if assert.True(t, ok) {
+				assert.Equal(t, 409, httpErr.Status())
+			}
+		}
+
+		_, err = client.API().VolumeCreateFromSnapshot(
+			nil, vfs.Name, "vfs-000-002",
+			&types.VolumeCreateRequest{Name: "Volume 002"})
+		if assert.Error(t, err) {
+			httpErr, ok := err.(goof.HTTPError)
+			if assert.True(t, ok) {
+				assert.Equal(t, 409, httpErr.Status())
+			}
+		}
+
+		var (
+			wg      sync.WaitGroup
+			created int32
+		)
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.API().VolumeCreate(
+					nil, vfs.Name,
+					&types.VolumeCreateRequest{Name: "Volume 004"})
+				if err == nil {
+					atomic.AddInt32(&created, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.EqualValues(t, 1, created)
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
 func TestVolumeCreate(t *testing.T) {
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
 		volumeName := "Volume 003"
@@ -300,6 +545,118 @@ func TestVolumeCreate(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+const profilesConfigYAML = `
+libstorage:
+  server:
+    profiles:
+      gold:
+        type: ssd
+        iops: 1000
+        size: 8
+        opts:
+          tier: gold
+`
+
+func newProfilesTestConfig(t *testing.T) []byte {
+	return append(newTestConfig(t), []byte(profilesConfigYAML)...)
+}
+
+func TestServiceProfiles(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().ServiceProfiles(nil, vfs.Name)
+		assert.NoError(t, err)
+		assert.Len(t, reply, 1)
+		if p, ok := reply["gold"]; assert.True(t, ok) {
+			assert.Equal(t, "gold", p.Name)
+			assert.Equal(t, "ssd", p.Type)
+			assert.Equal(t, int64(1000), p.IOPS)
+			assert.Equal(t, int64(8), p.Size)
+			assert.EqualValues(t, "gold", p.Opts["tier"])
+		}
+	}
+	apitests.Run(t, vfs.Name, newProfilesTestConfig(t), tf)
+}
+
+const serviceProfilesConfigYAML = `
+libstorage:
+  server:
+    profiles:
+      gold:
+        type: ssd
+        iops: 1000
+        size: 8
+        opts:
+          tier: gold
+    services:
+      vfs:
+        profiles:
+          gold:
+            type: hdd
+            opts:
+              owner: root
+          silver:
+            size: 4
+`
+
+func TestServiceProfilesMergedByName(t *testing.T) {
+	tc := append(
+		newTestConfig(t), []byte(serviceProfilesConfigYAML)...)
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().ServiceProfiles(nil, vfs.Name)
+		assert.NoError(t, err)
+		assert.Len(t, reply, 2)
+		if p, ok := reply["gold"]; assert.True(t, ok) {
+			assert.Equal(t, "hdd", p.Type)
+			assert.Equal(t, int64(1000), p.IOPS)
+			assert.Equal(t, int64(8), p.Size)
+			assert.EqualValues(t, "gold", p.Opts["tier"])
+			assert.EqualValues(t, "root", p.Opts["owner"])
+		}
+		if p, ok := reply["silver"]; assert.True(t, ok) {
+			assert.Equal(t, "silver", p.Name)
+			assert.Equal(t, int64(4), p.Size)
+			assert.Empty(t, p.Type)
+		}
+	}
+	apitests.Run(t, vfs.Name, tc, tf)
+}
+
+func TestVolumeCreateWithProfile(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		profile := "Gold"
+		iops := int64(500)
+		reply, err := client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{
+				Name:    "Volume 004",
+				IOPS:    &iops,
+				Profile: &profile,
+				Opts:    map[string]interface{}{"owner": "root"},
+			})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, "ssd", reply.Type)
+		assert.Equal(t, iops, reply.IOPS)
+		assert.Equal(t, int64(8), reply.Size)
+		assert.Equal(t, "gold", reply.Fields["tier"])
+		assert.Equal(t, "root", reply.Fields["owner"])
+
+		profile = "bronze"
+		_, err = client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{
+				Name:    "Volume 005",
+				Profile: &profile,
+			})
+		if httpErr, ok := err.(goof.HTTPError); assert.True(t, ok) {
+			assert.Equal(t, 400, httpErr.Status())
+			assert.Equal(t,
+				"service vfs has no profile bronze", httpErr.Error())
+		}
+	}
+	apitests.Run(t, vfs.Name, newProfilesTestConfig(t), tf)
+}
+
 func TestVolumeCopy(t *testing.T) {
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
 		request := &types.VolumeCopyRequest{
@@ -328,6 +685,30 @@ func TestVolumeCopy(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+func TestVolumeResize(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		reply, err := client.API().VolumeResize(
+			nil, vfs.Name, "vfs-000", &types.VolumeResizeRequest{Size: 20480})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.Equal(t, "vfs-000", reply.ID)
+		assert.Equal(t, int64(20480), reply.Size)
+
+		reply, err = client.API().VolumeInspect(nil, vfs.Name, "vfs-000", false)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(20480), reply.Size)
+
+		_, err = client.API().VolumeResize(
+			nil, vfs.Name, "vfs-999", &types.VolumeResizeRequest{Size: 1})
+		if assert.Error(t, err) {
+			assert.Equal(t, 404, err.(goof.HTTPError).Status())
+		}
+	}
+	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
+}
+
 func TestVolumeRemove(t *testing.T) {
 
 	tf1 := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -390,6 +771,86 @@ func TestVolumeSnapshot(t *testing.T) {
 	apitests.Run(t, vfs.Name, newTestConfig(t), tf)
 }
 
+const auditConfigYAML = `
+libstorage:
+  server:
+    audit:
+      enabled: true
+      file: %s
+`
+
+// findAuditRecord returns the first audit record in a file with the given
+// route, volume ID and snapshot ID. The file is read until the record is
+// found or a second has passed, since records are written once a request's
+// response is sent.
+func findAuditRecord(
+	file, route, volumeID, snapshotID string) *types.AuditRecord {
+
+	for i := 0; i < 10; i++ {
+		buf, _ := ioutil.ReadFile(file)
+		scn := bufio.NewScanner(bytes.NewReader(buf))
+		for scn.Scan() {
+			rec := &types.AuditRecord{}
+			if err := json.Unmarshal(scn.Bytes(), rec); err != nil {
+				continue
+			}
+			if rec.Route == route &&
+				rec.VolumeID == volumeID &&
+				rec.SnapshotID == snapshotID {
+				return rec
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
+
+func TestAuditCreatedResources(t *testing.T) {
+	d, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+	auditFile := path.Join(d, "audit.log")
+	config := append(
+		newTestConfig(t), []byte(fmt.Sprintf(auditConfigYAML, auditFile))...)
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		vol, err := client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{Name: "Volume 006"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		rec := findAuditRecord(auditFile, "volumeCreate", vol.ID, "")
+		if assert.NotNil(t, rec) {
+			assert.Equal(t, types.AuditOutcomeSuccess, rec.Outcome)
+			assert.Equal(t, 201, rec.StatusCode)
+		}
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, vfs.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snapshot3"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.NotNil(t, findAuditRecord(
+			auditFile, "volumeSnapshot", vol.ID, snap.ID))
+
+		copy, err := client.API().SnapshotCopy(
+			nil, vfs.Name, snap.ID,
+			&types.SnapshotCopyRequest{SnapshotName: "snapshot4"})
+		assert.NoError(t, err)
+		if err != nil {
+			t.FailNow()
+		}
+		assert.NotNil(t, findAuditRecord(
+			auditFile, "snapshotCopy", "", copy.ID))
+	}
+	apitests.Run(t, vfs.Name, config, tf)
+}
+
 func TestVolumeCreateFromSnapshot(t *testing.T) {
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
 
@@ -507,6 +968,138 @@ func TestVolumeDetachWithControllerClient(t *testing.T) {
 		t, types.ControllerClient, vfs.Name, newTestConfig(t), tf)
 }
 
+const blockModeYAML = `
+  blockMode: true
+`
+
+func newBlockModeTestConfig(t *testing.T) []byte {
+	return append(newTestConfig(t), []byte(blockModeYAML)...)
+}
+
+func TestVolumeCreateBlockMode(t *testing.T) {
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		svc, err := client.API().ServiceInspect(nil, vfs.Name)
+		assert.NoError(t, err)
+		assert.Equal(t, types.Block, svc.Driver.Type)
+
+		size := int64(1)
+		vol, err := client.API().VolumeCreate(
+			nil, vfs.Name, &types.VolumeCreateRequest{
+				Name: "Volume 003",
+				Size: &size,
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		volImg := vfs.VolumeImagePath(config, vol.ID)
+		assertImage(t, volImg, size)
+
+		data := []byte("libstorage")
+		f, err := os.OpenFile(volImg, os.O_WRONLY, 0600)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = f.WriteAt(data, 1<<20)
+		f.Close()
+		assert.NoError(t, err)
+
+		snap, err := client.API().VolumeSnapshot(
+			nil, vfs.Name, vol.ID,
+			&types.VolumeSnapshotRequest{SnapshotName: "snapshot1"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		snapImg := vfs.SnapshotImagePath(config, snap.ID)
+		assertImage(t, snapImg, size)
+		assertImageData(t, snapImg, data, 1<<20)
+
+		newSize := int64(2)
+		newVol, err := client.API().VolumeCreateFromSnapshot(
+			nil, vfs.Name, snap.ID, &types.VolumeCreateRequest{
+				Name: "Volume 004",
+				Size: &newSize,
+			})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		newVolImg := vfs.VolumeImagePath(config, newVol.ID)
+		assertImage(t, newVolImg, newSize)
+		assertImageData(t, newVolImg, data, 1<<20)
+
+		copyVol, err := client.API().VolumeCopy(
+			nil, vfs.Name, vol.ID,
+			&types.VolumeCopyRequest{VolumeName: "Copy of Volume 003"})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		copyVolImg := vfs.VolumeImagePath(config, copyVol.ID)
+		assertImage(t, copyVolImg, size)
+		assertImageData(t, copyVolImg, data, 1<<20)
+
+		resized, err := client.API().VolumeResize(
+			nil, vfs.Name, vol.ID, &types.VolumeResizeRequest{Size: 3})
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(3), resized.Size)
+		}
+		assertImage(t, volImg, 3)
+		assertImageData(t, volImg, data, 1<<20)
+
+		_, err = client.API().VolumeResize(
+			nil, vfs.Name, vol.ID, &types.VolumeResizeRequest{Size: 1})
+		assert.Error(t, err)
+
+		assert.NoError(t, client.API().SnapshotRemove(nil, vfs.Name, snap.ID))
+		assert.False(t, gotil.FileExists(snapImg))
+		assert.NoError(t, client.API().VolumeRemove(nil, vfs.Name, vol.ID))
+		assert.False(t, gotil.FileExists(volImg))
+	}
+	apitests.Run(t, vfs.Name, newBlockModeTestConfig(t), tf)
+}
+
+func TestVolumeAttachBlockMode(t *testing.T) {
+	if os.Geteuid() != 0 || !gotil.FileExists("/dev/loop-control") {
+		t.Skip("block mode requires root and loop devices")
+	}
+	if _, err := exec.LookPath("losetup"); err != nil {
+		t.Skip("block mode requires losetup")
+	}
+
+	tf := func(config gofig.Config, client types.Client, t *testing.T) {
+		ctx := context.Background().WithValue(context.ServiceKey, vfs.Name)
+		localDevices := func() map[string]string {
+			ld, err := client.Executor().LocalDevices(
+				ctx, &types.LocalDevicesOpts{
+					ScanType: types.DeviceScanQuick,
+					Opts:     utils.NewStore(),
+				})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			return ld.DeviceMap
+		}
+
+		reply, attTokn, err := client.API().VolumeAttach(
+			nil, vfs.Name, "vfs-002", &types.VolumeAttachRequest{})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "vfs-002", attTokn)
+		if assert.Len(t, reply.Attachments, 1) {
+			dev := reply.Attachments[0].DeviceName
+			assert.True(t, strings.HasPrefix(dev, "/dev/loop"), dev)
+			assert.Equal(t, dev, localDevices()["vfs-002"])
+		}
+
+		_, err = client.API().VolumeDetach(
+			nil, vfs.Name, "vfs-002", &types.VolumeDetachRequest{})
+		assert.NoError(t, err)
+		assert.NotContains(t, localDevices(), "vfs-002")
+
+		assert.NoError(t, client.API().VolumeRemove(nil, vfs.Name, "vfs-002"))
+	}
+	apitests.Run(t, vfs.Name, newBlockModeTestConfig(t), tf)
+}
+
 func TestVolumeDetachAllForService(t *testing.T) {
 	tc, _, vols, _ := newTestConfigAll(t)
 	tf := func(config gofig.Config, client types.Client, t *testing.T) {
@@ -693,6 +1286,88 @@ func TestLocalDevices(t *testing.T) {
 		}).Test)
 }
 
+func TestReload(t *testing.T) {
+
+	sock := utils.GetTempSockFile()
+	newConfig := func(services ...string) gofig.Config {
+		buf := &bytes.Buffer{}
+		fmt.Fprintf(buf, reloadConfigYAML, sock)
+		for _, s := range services {
+			fmt.Fprintf(buf, "      %s:\n        driver: %s\n", s, vfs.Name)
+		}
+		config := gofig.New()
+		assert.NoError(t, config.ReadConfig(buf))
+		assert.NoError(t, config.ReadConfig(
+			bytes.NewReader(newTestConfig(t))))
+		return config
+	}
+
+	config := newConfig(vfs.Name)
+	s, errs, err := server.Serve(nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		s.Close()
+		<-errs
+	}()
+
+	c, err := client.New(nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertServices := func(names ...string) {
+		reply, err := c.API().Services(nil)
+		assert.NoError(t, err)
+		assert.Len(t, reply, len(names))
+		for _, n := range names {
+			assert.Contains(t, reply, n)
+		}
+	}
+	assertServices(vfs.Name)
+
+	server.SetConfigLoader(func() (gofig.Config, error) {
+		return newConfig(vfs.Name, "vfs2"), nil
+	})
+	assert.NoError(t, s.Reload())
+	assertServices(vfs.Name, "vfs2")
+
+	server.SetConfigLoader(func() (gofig.Config, error) {
+		return newConfig("vfs2"), nil
+	})
+	assert.NoError(t, s.Reload())
+	assertServices("vfs2")
+
+	// a service that fails to initialize leaves the services unchanged
+	server.SetConfigLoader(func() (gofig.Config, error) {
+		config := newConfig("vfs2")
+		config.Set("libstorage.server.services.bad.driver", "invalid")
+		return config, nil
+	})
+	assert.Error(t, s.Reload())
+	assertServices("vfs2")
+}
+
+func TestConformance(t *testing.T) {
+	rep := (&conformance.Suite{
+		Driver: vfs.Name,
+		Config: newTestConfig(t),
+	}).Run(t)
+	assert.Empty(t, rep.Unsupported)
+}
+
+const reloadConfigYAML = `libstorage:
+  host: unix://%[1]s
+  client:
+    type: controller
+  server:
+    endpoints:
+      localhost:
+        address: unix://%[1]s
+    services:
+`
+
 func removeTestDirs() {
 	testDirsLock.RLock()
 	defer testDirsLock.RUnlock()
@@ -722,6 +1397,25 @@ func assertSnapDir(
 	assert.Equal(t, exists, gotil.FileExists(snapDir))
 }
 
+func assertImage(t *testing.T, img string, size int64) {
+	info, err := os.Stat(img)
+	if assert.NoError(t, err) {
+		assert.Equal(t, size<<30, info.Size())
+	}
+}
+
+func assertImageData(t *testing.T, img string, data []byte, off int64) {
+	f, err := os.Open(img)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	buf := make([]byte, len(data))
+	_, err = f.ReadAt(buf, off)
+	assert.NoError(t, err)
+	assert.Equal(t, data, buf)
+}
+
 var (
 	testDirs     []string
 	testDirsLock = &sync.RWMutex{}
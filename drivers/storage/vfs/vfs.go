@@ -5,11 +5,16 @@ import (
 
 	"github.com/akutz/gofig"
 	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const (
 	// Name is the name of the driver.
 	Name = "vfs"
+
+	// ImageExt is the file extension of the image files that back the VFS
+	// volumes and snapshots in block mode.
+	ImageExt = ".img"
 )
 
 func init() {
@@ -18,9 +23,10 @@ func init() {
 
 func registerConfig() {
 	defaultRootDir := types.Lib.Join("vfs")
-	r := gofig.NewRegistration("VFS")
+	r := apiconfig.NewRegistration("VFS")
 	r.Key(gofig.String, "", defaultRootDir, "", "vfs.root")
-	gofig.Register(r)
+	r.Key(gofig.Bool, "", false, "", "vfs.blockMode")
+	apiconfig.Register(r)
 }
 
 // RootDir returns the path to the VFS root directory.
@@ -28,6 +34,12 @@ func RootDir(config gofig.Config) string {
 	return config.GetString("vfs.root")
 }
 
+// BlockMode returns a flag indicating whether or not the VFS volumes are
+// backed by image files that are attached to loop devices.
+func BlockMode(config gofig.Config) bool {
+	return config.GetBool("vfs.blockMode")
+}
+
 // DeviceFilePath returns the path to the VFS devices file.
 func DeviceFilePath(config gofig.Config) string {
 	return path.Join(RootDir(config), "dev")
@@ -42,3 +54,13 @@ func VolumesDirPath(config gofig.Config) string {
 func SnapshotsDirPath(config gofig.Config) string {
 	return path.Join(RootDir(config), "snap")
 }
+
+// VolumeImagePath returns the path to the image file of a VFS volume.
+func VolumeImagePath(config gofig.Config, volumeID string) string {
+	return path.Join(VolumesDirPath(config), volumeID+ImageExt)
+}
+
+// SnapshotImagePath returns the path to the image file of a VFS snapshot.
+func SnapshotImagePath(config gofig.Config, snapshotID string) string {
+	return path.Join(SnapshotsDirPath(config), snapshotID+ImageExt)
+}
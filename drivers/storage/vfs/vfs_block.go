@@ -0,0 +1,160 @@
+package vfs
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// gibibyte is the number of bytes in a GiB, the unit of a volume's size.
+	gibibyte = 1 << 30
+
+	// copyBlockSize is the size of the blocks that a sparse copy reads and
+	// writes.
+	copyBlockSize = 64 * 1024
+)
+
+// CreateImage creates a sparse image file with a size of the specified
+// number of GiB. An existing image file that is smaller than the size is
+// grown.
+func CreateImage(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return goof.WithFieldE("path", path, "error creating image", err)
+	}
+	defer f.Close()
+	return growImage(f, size)
+}
+
+// CopyImage copies an image file and grows the copy to a size of the
+// specified number of GiB. The copy is a reflink of the source if the file
+// system supports reflinks. Otherwise the copy is a sparse copy that skips
+// the blocks of the source that hold only zeroes.
+func CopyImage(src, dst string, size int64) error {
+	s, err := os.Open(src)
+	if err != nil {
+		return goof.WithFieldE("path", src, "error opening image", err)
+	}
+	defer s.Close()
+
+	d, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return goof.WithFieldE("path", dst, "error creating image", err)
+	}
+	defer d.Close()
+
+	if err := reflink(s, d); err != nil {
+		if err := sparseCopy(s, d); err != nil {
+			return goof.WithFieldsE(goof.Fields{
+				"src": src,
+				"dst": dst,
+			}, "error copying image", err)
+		}
+	}
+
+	return growImage(d, size)
+}
+
+// RemoveImage detaches an image file from the loop devices to which it is
+// attached and removes the image file. Removing an image file that does not
+// exist is not an error.
+func RemoveImage(path string) error {
+	devs, err := LoopDevices()
+	if err != nil {
+		return err
+	}
+	rp := RealPath(path)
+	for dev, img := range devs {
+		if img == rp {
+			if err := DetachLoop(dev); err != nil {
+				return err
+			}
+		}
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return goof.WithFieldE("path", path, "error removing image", err)
+	}
+	return nil
+}
+
+// LoopDevice returns the loop device to which an image file is attached, or
+// an empty string if the image file is not attached.
+func LoopDevice(path string) (string, error) {
+	devs, err := LoopDevices()
+	if err != nil {
+		return "", err
+	}
+	rp := RealPath(path)
+	for dev, img := range devs {
+		if img == rp {
+			return dev, nil
+		}
+	}
+	return "", nil
+}
+
+// RealPath returns the absolute path to a file with its symbolic links
+// resolved, which is how the kernel reports the image file of a loop device.
+// The path is returned as is if it cannot be resolved.
+func RealPath(p string) string {
+	if ap, err := filepath.Abs(p); err == nil {
+		p = ap
+	}
+	if rp, err := filepath.EvalSymlinks(p); err == nil {
+		return rp
+	}
+	dir, file := filepath.Split(p)
+	if rd, err := filepath.EvalSymlinks(dir); err == nil {
+		p = filepath.Join(rd, file)
+	}
+	return p
+}
+
+func growImage(f *os.File, size int64) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if n := size * gibibyte; n > info.Size() {
+		return f.Truncate(n)
+	}
+	return nil
+}
+
+// sparseCopy copies src to dst one block at a time and seeks past the blocks
+// that hold only zeroes so that they remain holes in dst.
+func sparseCopy(src, dst *os.File) error {
+	if _, err := src.Seek(0, os.SEEK_SET); err != nil {
+		return err
+	}
+
+	var (
+		buf  = make([]byte, copyBlockSize)
+		zero = make([]byte, copyBlockSize)
+		off  int64
+	)
+
+	for {
+		n, err := io.ReadFull(src, buf)
+		if n > 0 {
+			if !bytes.Equal(buf[:n], zero[:n]) {
+				if _, err := dst.WriteAt(buf[:n], off); err != nil {
+					return err
+				}
+			}
+			off += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return dst.Truncate(off)
+}
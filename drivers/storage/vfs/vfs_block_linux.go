@@ -0,0 +1,80 @@
+// +build linux
+
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/akutz/goof"
+)
+
+const (
+	// ficlone is the ioctl that makes a file a reflink of another file.
+	ficlone = 0x40049409
+
+	sysBlockPath = "/sys/block"
+)
+
+// AttachLoop attaches an image file to the first free loop device and
+// returns the path to the loop device.
+func AttachLoop(img string) (string, error) {
+	out, err := exec.Command("losetup", "--find", "--show", img).Output()
+	if err != nil {
+		return "", goof.WithFieldE("path", img, "error attaching image", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// DetachLoop detaches a loop device from its image file.
+func DetachLoop(dev string) error {
+	if err := exec.Command("losetup", "--detach", dev).Run(); err != nil {
+		return goof.WithFieldE("device", dev, "error detaching image", err)
+	}
+	return nil
+}
+
+// ResizeLoop updates the size of a loop device to the size of its image
+// file.
+func ResizeLoop(dev string) error {
+	if err := exec.Command("losetup", "--set-capacity", dev).Run(); err != nil {
+		return goof.WithFieldE("device", dev, "error resizing loop device", err)
+	}
+	return nil
+}
+
+// LoopDevices returns a map of the paths to the attached loop devices and
+// the paths to their image files.
+func LoopDevices() (map[string]string, error) {
+	devs := map[string]string{}
+	backingFiles, err := filepath.Glob(
+		path.Join(sysBlockPath, "loop*", "loop", "backing_file"))
+	if err != nil {
+		return nil, err
+	}
+	for _, bf := range backingFiles {
+		buf, err := ioutil.ReadFile(bf)
+		if err != nil {
+			// the loop device was detached after the glob was expanded
+			continue
+		}
+		img := strings.TrimSuffix(strings.TrimSpace(string(buf)), " (deleted)")
+		dev := path.Base(path.Dir(path.Dir(bf)))
+		devs[path.Join("/dev", dev)] = img
+	}
+	return devs, nil
+}
+
+func reflink(src, dst *os.File) error {
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL, dst.Fd(), ficlone, src.Fd())
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
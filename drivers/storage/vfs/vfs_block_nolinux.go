@@ -0,0 +1,38 @@
+// +build !linux
+
+package vfs
+
+import (
+	"os"
+
+	"github.com/akutz/goof"
+)
+
+var errBlockModeUnsupported = goof.New("vfs block mode requires linux")
+
+// AttachLoop attaches an image file to the first free loop device and
+// returns the path to the loop device.
+func AttachLoop(img string) (string, error) {
+	return "", errBlockModeUnsupported
+}
+
+// DetachLoop detaches a loop device from its image file.
+func DetachLoop(dev string) error {
+	return errBlockModeUnsupported
+}
+
+// ResizeLoop updates the size of a loop device to the size of its image
+// file.
+func ResizeLoop(dev string) error {
+	return errBlockModeUnsupported
+}
+
+// LoopDevices returns a map of the paths to the attached loop devices and
+// the paths to their image files.
+func LoopDevices() (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func reflink(src, dst *os.File) error {
+	return errBlockModeUnsupported
+}
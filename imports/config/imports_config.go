@@ -8,6 +8,7 @@ import (
 	"github.com/akutz/gofig"
 
 	"github.com/emccode/libstorage/api/types"
+	apiconfig "github.com/emccode/libstorage/api/utils/config"
 )
 
 const (
@@ -27,7 +28,7 @@ func init() {
 	}
 	log.SetLevel(logLevel)
 
-	r := gofig.NewRegistration("libStorage")
+	r := apiconfig.NewRegistration("libStorage")
 
 	rk := func(
 		keyType gofig.KeyType,
@@ -81,6 +82,70 @@ func init() {
 	rk(gofig.Bool, false, "", types.ConfigEmbedded)
 	rk(gofig.String, "1m", "", types.ConfigServerTasksExeTimeout)
 	rk(gofig.String, "0s", "", types.ConfigServerTasksLogTimeout)
+	rk(gofig.String, "0s", "", types.ConfigServerCacheVolumes)
+	rk(gofig.String, "0s", "", types.ConfigServerCacheSnapshots)
+	rk(gofig.Bool, false, "", types.ConfigServerVolumesUniqueNames)
+	rk(gofig.String, "", "", types.ConfigTracingFile)
+	rk(gofig.Bool, false, "", types.ConfigServerAuditEnabled)
+	rk(gofig.String, "file", "", types.ConfigServerAuditType)
+	rk(gofig.String, "", "", types.ConfigServerAuditFile)
+	rk(gofig.Int, 100, "", types.ConfigServerAuditMaxSize)
+	rk(gofig.Int, 5, "", types.ConfigServerAuditMaxBackups)
+	rk(gofig.String, "", "", types.ConfigServerAuditSyslogNetwork)
+	rk(gofig.String, "", "", types.ConfigServerAuditSyslogAddress)
+	rk(gofig.String, "libstorage", "", types.ConfigServerAuditSyslogTag)
+	rk(gofig.String, "1m", "", types.ConfigServerReloadDrainTimeout)
+	rk(gofig.String, "", "", types.ConfigServerName)
+	rk(gofig.SecureString, "", "", types.ConfigServerAdminToken)
+	rk(gofig.String, "", "", types.ConfigServerAdminTokenFile)
+	rk(gofig.Bool, false, "", types.ConfigServerStateEnabled)
+	rk(gofig.String, "", "", types.ConfigServerStateDir)
+	rk(gofig.String, "", "", types.ConfigSecretsKeystorePath)
+	rk(gofig.String, "", "", types.ConfigSecretsKeystoreKeyFile)
 
-	gofig.Register(r)
+	// keys that are read from a config but that have no default values
+	r.Declare(gofig.String, types.ConfigRoot+".driver")
+	r.Declare(gofig.Bool, types.ConfigTLS)
+	r.Declare(gofig.Bool, types.ConfigTLSDisabled)
+	r.Declare(gofig.String, types.ConfigTLSServerName)
+	r.Declare(gofig.Bool, types.ConfigTLSClientCertRequired)
+	r.Declare(gofig.String, types.ConfigTLSTrustedCertsFile)
+	r.Declare(gofig.String, types.ConfigTLSCertFile)
+	r.Declare(gofig.String, types.ConfigTLSKeyFile)
+	r.Declare(gofig.Bool, types.ConfigSchemaResponseValidationEnabled)
+	r.Declare(gofig.Bool, types.ConfigIgVolOpsCreateImplicit)
+	r.Declare(gofig.String, types.ConfigIgVolOpsCreateDefaultFsType)
+	r.Declare(gofig.String, types.ConfigIgVolOpsCreateDefaultType)
+	r.Declare(gofig.String, types.ConfigIgVolOpsCreateDefaultIOPS,
+		apiconfig.NonNegative)
+	r.Declare(gofig.String, types.ConfigIgVolOpsCreateDefaultSize,
+		apiconfig.NonNegative)
+	r.Declare(gofig.String, types.ConfigIgVolOpsCreateDefaultAZ)
+	r.Declare(gofig.String, types.ConfigIgVolOpsMountPath)
+	r.Declare(gofig.String, types.ConfigIgVolOpsMountRootPath)
+
+	r.Constrain(types.ConfigHost, apiconfig.Address)
+	r.Constrain(types.ConfigServerAutoEndpointMode,
+		apiconfig.OneOf(types.TCPEndpoint.String(), types.UnixEndpoint.String()))
+	r.Constrain(types.ConfigClientType, apiconfig.OneOf(
+		types.IntegrationClient.String(), types.ControllerClient.String()))
+	r.Constrain(types.ConfigLogLevel, apiconfig.OneOf(
+		"panic", "fatal", "error", "warn", "warning", "info", "debug"))
+	r.Constrain(types.ConfigHTTPWriteTimeout, apiconfig.NonNegative)
+	r.Constrain(types.ConfigHTTPReadTimeout, apiconfig.NonNegative)
+	r.Constrain(types.ConfigClientCacheInstanceID, apiconfig.Duration)
+	r.Constrain(types.ConfigDeviceAttachTimeout, apiconfig.Duration)
+	r.Constrain(types.ConfigDeviceScanType, apiconfig.OneOf("0", "1"))
+	r.Constrain(types.ConfigServerTasksExeTimeout, apiconfig.Duration)
+	r.Constrain(types.ConfigServerTasksLogTimeout, apiconfig.Duration)
+	r.Constrain(types.ConfigServerCacheVolumes, apiconfig.Duration)
+	r.Constrain(types.ConfigServerCacheSnapshots, apiconfig.Duration)
+	r.Constrain(types.ConfigServerAuditType, apiconfig.OneOf("file", "syslog"))
+	r.Constrain(types.ConfigServerAuditMaxSize, apiconfig.NonNegative)
+	r.Constrain(types.ConfigServerAuditMaxBackups, apiconfig.NonNegative)
+	r.Constrain(types.ConfigServerAuditSyslogNetwork,
+		apiconfig.OneOf("tcp", "tcp4", "tcp6", "udp", "udp4", "udp6", "unix"))
+	r.Constrain(types.ConfigServerReloadDrainTimeout, apiconfig.Duration)
+
+	apiconfig.Register(r)
 }
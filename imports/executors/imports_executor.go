@@ -2,11 +2,16 @@ package executors
 
 import (
 	// load the storage executors
+	_ "github.com/emccode/libstorage/drivers/storage/cinder/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/ebs/executor"
 	//_ "github.com/emccode/libstorage/drivers/storage/ec2/executor"
 	//_ "github.com/emccode/libstorage/drivers/storage/gce/executor"
 	_ "github.com/emccode/libstorage/drivers/storage/isilon/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/lvm/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/nfs/executor"
 	//_ "github.com/emccode/libstorage/drivers/storage/openstack/executor"
 	//_ "github.com/emccode/libstorage/drivers/storage/rackspace/executor"
+	_ "github.com/emccode/libstorage/drivers/storage/s3/executor"
 	_ "github.com/emccode/libstorage/drivers/storage/scaleio/executor"
 	_ "github.com/emccode/libstorage/drivers/storage/vbox/executor"
 	_ "github.com/emccode/libstorage/drivers/storage/vfs/executor"
@@ -0,0 +1,8 @@
+// +build mock
+
+package executors
+
+import (
+	// load the mock storage executor
+	_ "github.com/emccode/libstorage/drivers/storage/mock/executor"
+)
@@ -2,7 +2,12 @@ package remote
 
 import (
 	// import to load
+	_ "github.com/emccode/libstorage/drivers/storage/cinder/storage"
+	_ "github.com/emccode/libstorage/drivers/storage/ebs/storage"
 	_ "github.com/emccode/libstorage/drivers/storage/isilon/storage"
+	_ "github.com/emccode/libstorage/drivers/storage/lvm/storage"
+	_ "github.com/emccode/libstorage/drivers/storage/nfs/storage"
+	_ "github.com/emccode/libstorage/drivers/storage/s3/storage"
 	_ "github.com/emccode/libstorage/drivers/storage/scaleio/storage"
 	_ "github.com/emccode/libstorage/drivers/storage/vbox/storage"
 	_ "github.com/emccode/libstorage/drivers/storage/vfs/storage"
@@ -2,8 +2,11 @@ package routers
 
 import (
 	// imports to load routers
+	_ "github.com/emccode/libstorage/api/server/router/admin"
+	_ "github.com/emccode/libstorage/api/server/router/audit"
 	_ "github.com/emccode/libstorage/api/server/router/executor"
 	_ "github.com/emccode/libstorage/api/server/router/help"
+	_ "github.com/emccode/libstorage/api/server/router/metrics"
 	_ "github.com/emccode/libstorage/api/server/router/root"
 	_ "github.com/emccode/libstorage/api/server/router/service"
 	_ "github.com/emccode/libstorage/api/server/router/snapshot"